@@ -560,6 +560,24 @@ func TestDefaultFunctionParsing(t *testing.T) {
 	}
 }
 
+func TestStateMutabilityParsing(t *testing.T) {
+	const definition = `[
+	{ "type" : "function", "name" : "balance", "stateMutability" : "view" },
+	{ "type" : "function", "name" : "hash", "stateMutability" : "pure" },
+	{ "type" : "function", "name" : "send", "stateMutability" : "nonpayable" },
+	{ "type" : "function", "name" : "deposit", "stateMutability" : "payable" }
+]`
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{"balance": true, "hash": true, "send": false, "deposit": false} {
+		if have := abi.Methods[name].Const; have != want {
+			t.Errorf("method %s: constant mismatch: have %v, want %v", name, have, want)
+		}
+	}
+}
+
 func TestBareEvents(t *testing.T) {
 	const definition = `[
 	{ "type" : "event", "name" : "balance" },
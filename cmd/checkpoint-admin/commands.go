@@ -0,0 +1,231 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/console"
+	"github.com/ethereum/go-ethereum/contracts/checkpointoracle"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var commandDeploy = cli.Command{
+	Name:  "deploy",
+	Usage: "deploy a new checkpoint oracle",
+	Description: `
+Deploy a new checkpoint oracle contract with the given admins, accepting
+checkpoints once the threshold of them voted for the same one.
+`,
+	Flags: []cli.Flag{
+		rpcFlag,
+		keyfileFlag,
+		passphraseFlag,
+		cli.StringFlag{
+			Name:  "admins",
+			Usage: "comma separated addresses of the oracle admins",
+		},
+		cli.Uint64Flag{
+			Name:  "threshold",
+			Value: 1,
+			Usage: "number of admin votes needed to accept a checkpoint",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		var admins []common.Address
+		for _, admin := range strings.Split(ctx.String("admins"), ",") {
+			if !common.IsHexAddress(admin) {
+				utils.Fatalf("Invalid admin address %q", admin)
+			}
+			admins = append(admins, common.HexToAddress(admin))
+		}
+		client := newClient(ctx)
+		addr, tx, _, err := checkpointoracle.DeployCheckpointOracle(newTransactor(ctx), client, admins, ctx.Uint64("threshold"))
+		if err != nil {
+			utils.Fatalf("Failed to deploy checkpoint oracle: %v", err)
+		}
+		fmt.Println("Transaction:", tx.Hash().Hex())
+		fmt.Println("Waiting for the deployment to be mined...")
+		if _, err := bind.WaitDeployed(context.Background(), client, tx); err != nil {
+			utils.Fatalf("Failed to deploy checkpoint oracle: %v", err)
+		}
+		fmt.Println("Oracle:", addr.Hex())
+		return nil
+	},
+}
+
+var commandStatus = cli.Command{
+	Name:  "status",
+	Usage: "show the latest checkpoint accepted by an oracle",
+	Flags: []cli.Flag{
+		rpcFlag,
+		oracleFlag,
+	},
+	Action: func(ctx *cli.Context) error {
+		oracle := newOracle(ctx, newClient(ctx))
+
+		threshold, err := oracle.Threshold(nil)
+		if err != nil {
+			utils.Fatalf("Failed to retrieve oracle threshold: %v", err)
+		}
+		fmt.Println("Threshold:", threshold)
+
+		cp, err := oracle.LatestCheckpoint(nil)
+		if err != nil {
+			utils.Fatalf("Failed to retrieve latest checkpoint: %v", err)
+		}
+		if cp == nil {
+			fmt.Println("No checkpoint accepted yet")
+			return nil
+		}
+		printCheckpoint(cp)
+		return nil
+	},
+}
+
+var commandPublish = cli.Command{
+	Name:  "publish",
+	Usage: "vote a checkpoint of a LES server into an oracle",
+	Description: `
+Retrieve a checkpoint from a LES server and vote for it as an oracle admin. By
+default the latest checkpoint of the server is voted for, a specific section
+can be chosen with --index.
+
+The LES server needs to expose the les API, which is only available on the IPC
+endpoint by default.
+`,
+	Flags: []cli.Flag{
+		rpcFlag,
+		oracleFlag,
+		keyfileFlag,
+		passphraseFlag,
+		cli.StringFlag{
+			Name:  "server",
+			Usage: "RPC endpoint of the LES server to retrieve the checkpoint from (default = --rpc)",
+		},
+		cli.Int64Flag{
+			Name:  "index",
+			Value: -1,
+			Usage: "section index of the checkpoint to vote for (default = latest)",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		endpoint := ctx.String("server")
+		if endpoint == "" {
+			endpoint = ctx.String(rpcFlag.Name)
+		}
+		server, err := rpc.Dial(endpoint)
+		if err != nil {
+			utils.Fatalf("Failed to connect to LES server: %v", err)
+		}
+		cp := new(checkpointoracle.Checkpoint)
+		if index := ctx.Int64("index"); index < 0 {
+			err = server.Call(cp, "les_latestCheckpoint")
+		} else {
+			err = server.Call(cp, "les_getCheckpoint", index)
+		}
+		if err != nil {
+			utils.Fatalf("Failed to retrieve checkpoint: %v", err)
+		}
+		printCheckpoint(cp)
+
+		client := newClient(ctx)
+		tx, err := newOracle(ctx, client).Vote(newTransactor(ctx), cp)
+		if err != nil {
+			utils.Fatalf("Failed to vote for checkpoint: %v", err)
+		}
+		fmt.Println("Transaction:", tx.Hash().Hex())
+		fmt.Println("Waiting for the vote to be mined...")
+		if _, err := bind.WaitMined(context.Background(), client, tx); err != nil {
+			utils.Fatalf("Failed to vote for checkpoint: %v", err)
+		}
+		return nil
+	},
+}
+
+// newClient connects to the node given by the --rpc flag.
+func newClient(ctx *cli.Context) *ethclient.Client {
+	client, err := ethclient.Dial(ctx.String(rpcFlag.Name))
+	if err != nil {
+		utils.Fatalf("Failed to connect to node: %v", err)
+	}
+	return client
+}
+
+// newOracle binds the oracle given by the --oracle flag.
+func newOracle(ctx *cli.Context, client *ethclient.Client) *checkpointoracle.CheckpointOracle {
+	addr := ctx.String(oracleFlag.Name)
+	if !common.IsHexAddress(addr) {
+		utils.Fatalf("Invalid oracle address %q", addr)
+	}
+	oracle, err := checkpointoracle.NewCheckpointOracle(common.HexToAddress(addr), client)
+	if err != nil {
+		utils.Fatalf("Failed to bind checkpoint oracle: %v", err)
+	}
+	return oracle
+}
+
+// newTransactor decrypts the keyfile given by the --keyfile flag and creates a
+// transactor signing with it.
+func newTransactor(ctx *cli.Context) *bind.TransactOpts {
+	keyjson, err := ioutil.ReadFile(ctx.String(keyfileFlag.Name))
+	if err != nil {
+		utils.Fatalf("Failed to read the keyfile: %v", err)
+	}
+	key, err := keystore.DecryptKey(keyjson, getPassPhrase(ctx))
+	if err != nil {
+		utils.Fatalf("Error decrypting key: %v", err)
+	}
+	return bind.NewKeyedTransactor(key.PrivateKey)
+}
+
+// getPassPhrase obtains a passphrase given by the user. It first checks the
+// --passwordfile command line flag and ultimately prompts the user for a
+// passphrase.
+func getPassPhrase(ctx *cli.Context) string {
+	if file := ctx.String(passphraseFlag.Name); file != "" {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			utils.Fatalf("Failed to read passphrase file '%s': %v", file, err)
+		}
+		return strings.TrimRight(string(content), "\r\n")
+	}
+	passphrase, err := console.Stdin.PromptPassword("Passphrase: ")
+	if err != nil {
+		utils.Fatalf("Failed to read passphrase: %v", err)
+	}
+	return passphrase
+}
+
+// printCheckpoint prints the fields of a checkpoint along with the hash admins
+// vote on.
+func printCheckpoint(cp *checkpointoracle.Checkpoint) {
+	fmt.Println("Section:       ", cp.SectionIdx)
+	fmt.Println("Section head:  ", cp.SectionHead.Hex())
+	fmt.Println("CHT root:      ", cp.CHTRoot.Hex())
+	fmt.Println("BloomTrie root:", cp.BloomTrieRoot.Hex())
+	fmt.Println("Hash:          ", cp.Hash().Hex())
+}
@@ -0,0 +1,161 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/tests"
+
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+var blockTestCommand = cli.Command{
+	Action:    blockTestCmd,
+	Name:      "blocktest",
+	Usage:     "executes the given blockchain tests",
+	ArgsUsage: "<file>",
+}
+
+type BlocktestResult struct {
+	Name  string `json:"name"`
+	Pass  bool   `json:"pass"`
+	Fork  string `json:"fork"`
+	Error string `json:"error,omitempty"`
+}
+
+// blockTracer is a struct logger collecting the logs of every block executing
+// any code separately, so the trace of a particular block can be retrieved.
+type blockTracer struct {
+	*vm.StructLogger
+
+	config *vm.LogConfig
+	number *big.Int
+	traces map[uint64]*vm.StructLogger
+}
+
+func newBlockTracer(config *vm.LogConfig) *blockTracer {
+	return &blockTracer{
+		StructLogger: vm.NewStructLogger(config),
+		config:       config,
+		traces:       make(map[uint64]*vm.StructLogger),
+	}
+}
+
+// CaptureState starts a new trace whenever execution moves on to a new block,
+// and logs the state of the EVM into the current one. Blocks executed multiple
+// times (e.g. during reorgs) only retain their last trace.
+func (t *blockTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.number == nil || t.number.Cmp(env.BlockNumber) != 0 {
+		t.StructLogger = vm.NewStructLogger(t.config)
+		t.number = new(big.Int).Set(env.BlockNumber)
+		t.traces[t.number.Uint64()] = t.StructLogger
+	}
+	return t.StructLogger.CaptureState(env, pc, op, gas, cost, memory, stack, contract, depth, err)
+}
+
+// trace returns the logs collected while executing the block with the given
+// number, or nil if it didn't execute any code.
+func (t *blockTracer) trace(number *big.Int) []vm.StructLog {
+	if logger := t.traces[number.Uint64()]; logger != nil {
+		return logger.StructLogs()
+	}
+	return nil
+}
+
+func blockTestCmd(ctx *cli.Context) error {
+	if len(ctx.Args().First()) == 0 {
+		return errors.New("path-to-test argument required")
+	}
+	// Configure the go-ethereum logger
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(ctx.GlobalInt(VerbosityFlag.Name)))
+	log.Root().SetHandler(glogger)
+
+	// Load the test content from the input file
+	src, err := ioutil.ReadFile(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	var blockTests map[string]tests.BlockTest
+	if err = json.Unmarshal(src, &blockTests); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(blockTests))
+	for name := range blockTests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Iterate over all the tests, run them and aggregate the results
+	tracing := ctx.GlobalBool(DebugFlag.Name) || ctx.GlobalBool(MachineFlag.Name)
+	traced := false
+
+	results := make([]BlocktestResult, 0, len(blockTests))
+	failed := 0
+	for _, name := range names {
+		test := blockTests[name]
+
+		result := BlocktestResult{Name: name, Fork: test.Network(), Pass: true}
+		if err := test.Run(vm.Config{}, false); err != nil {
+			result.Pass, result.Error = false, err.Error()
+			failed++
+
+			// Rerun the first test failing on a block, tracing that block
+			if blockErr, ok := err.(tests.BlockError); ok && tracing && !traced {
+				traced = true
+
+				tracer := newBlockTracer(&vm.LogConfig{
+					DisableMemory: ctx.GlobalBool(DisableMemoryFlag.Name),
+					DisableStack:  ctx.GlobalBool(DisableStackFlag.Name),
+				})
+				test.Run(vm.Config{Debug: true, Tracer: tracer}, false)
+				dumpBlockTrace(ctx, name, blockErr.Number, tracer.trace(blockErr.Number))
+			}
+		}
+		results = append(results, result)
+	}
+	out, _ := json.MarshalIndent(results, "", "  ")
+	fmt.Println(string(out))
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tests failed", failed, len(results))
+	}
+	return nil
+}
+
+// dumpBlockTrace writes the traced execution of a failing block to the standard
+// error, either human or machine readable.
+func dumpBlockTrace(ctx *cli.Context, name string, number *big.Int, logs []vm.StructLog) {
+	if ctx.GlobalBool(MachineFlag.Name) {
+		for _, log := range logs {
+			blob, _ := json.Marshal(log)
+			fmt.Fprintln(os.Stderr, string(blob))
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "#### TRACE: %s, block %v ####\n", name, number)
+	vm.WriteTrace(os.Stderr, logs)
+}
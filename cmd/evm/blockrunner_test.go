@@ -0,0 +1,70 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Tests that blockchain tests report their results, exit with a failure status
+// if any of them fails, and trace the block a failing test failed on.
+//
+// The tests share a chain of two blocks: the first one calls a contract storing
+// a value, the second one is a plain transfer with an invalid state root.
+func TestBlockTest(t *testing.T) {
+	tests := []struct {
+		test   string
+		status int
+		trace  string // Header of the expected block trace, empty if none
+		sstore bool   // Whether the trace contains the contract execution
+	}{
+		// The second block is expected to be rejected, all good
+		{test: "valid", status: 0},
+
+		// The second block is expected to be imported, trace it (not the first
+		// block, which is the last one executing any code)
+		{test: "invalid_block", status: 1, trace: "#### TRACE: invalidBlock, block 2 ####"},
+
+		// The post state of the head block mismatches, trace the head block even
+		// though an invalid block was executed on top afterwards
+		{test: "post_state", status: 1, trace: "#### TRACE: postState, block 1 ####", sstore: true},
+	}
+	for _, tt := range tests {
+		evm := runEvm(t, "--debug", "blocktest", filepath.Join("testdata", "blocktest", tt.test+".json"))
+		evm.Expect(readTestdata(t, "blocktest", "exp_"+tt.test+".json"))
+		evm.ExpectExit()
+
+		if status := evm.ExitStatus(); status != tt.status {
+			t.Errorf("%s: exit status mismatch: have %d, want %d", tt.test, status, tt.status)
+		}
+		stderr := evm.StderrText()
+		if tt.trace == "" {
+			if strings.Contains(stderr, "#### TRACE") {
+				t.Errorf("%s: unexpected trace:\n%s", tt.test, stderr)
+			}
+			continue
+		}
+		if !strings.Contains(stderr, tt.trace) {
+			t.Errorf("%s: trace header missing, want %q:\n%s", tt.test, tt.trace, stderr)
+		}
+		if sstore := strings.Contains(stderr, "SSTORE"); sstore != tt.sstore {
+			t.Errorf("%s: contract execution trace mismatch: have %v, want %v", tt.test, sstore, tt.sstore)
+		}
+	}
+}
@@ -0,0 +1,501 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/compiler"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+const debuggerHelp = `Commands:
+  step, s                    execute the next instruction, entering calls
+  next, n                    execute the next instruction, stepping over calls
+  out, o                     run until the current call frame returns
+  continue, c                run until the next breakpoint
+  break pc <pc> [address]    pause before executing the instruction at <pc>
+  break op <opcode>          pause before executing any <opcode> instruction
+  break sstore [slot]        pause before any storage write (to [slot])
+  break line <file>:<line>   pause before executing code of a source line
+  breakpoints, b             list the active breakpoints
+  delete <id>                remove a breakpoint
+  stack                      print the stack, top first
+  memory [offset [length]]   print the memory contents
+  storage [slot]             print the storage slots written, or a given one
+  returndata                 print the return data of the last call
+  where, w                   print the current execution position
+  backtrace, bt              print the Solidity call stack
+  quit, q                    abort the execution
+An empty line repeats the last stepping command.
+`
+
+// debugMode is the execution mode of the debugger between two pauses.
+type debugMode int
+
+const (
+	modeStep     debugMode = iota // Pause before the next instruction
+	modeNext                      // Pause before the next instruction in the same or a parent frame
+	modeOut                       // Pause before the next instruction in a parent frame
+	modeContinue                  // Pause only on breakpoints
+)
+
+// breakpoint is a condition pausing the execution when met.
+type breakpoint struct {
+	id int
+
+	pc   *uint64         // Program counter to stop at
+	addr *common.Address // Contract the program counter is restricted to
+	op   *vm.OpCode      // Opcode to stop at
+	slot *common.Hash    // Storage slot whose writes to stop at
+	file string          // Source file to stop in
+	line int             // Source line to stop at
+}
+
+// String implements fmt.Stringer, describing the condition of the breakpoint.
+func (b *breakpoint) String() string {
+	switch {
+	case b.pc != nil && b.addr != nil:
+		return fmt.Sprintf("pc %d in %x", *b.pc, *b.addr)
+	case b.pc != nil:
+		return fmt.Sprintf("pc %d", *b.pc)
+	case b.op != nil:
+		return fmt.Sprintf("op %v", *b.op)
+	case b.slot != nil:
+		return fmt.Sprintf("sstore %x", *b.slot)
+	case b.file != "":
+		return fmt.Sprintf("line %s:%d", b.file, b.line)
+	default:
+		return "sstore"
+	}
+}
+
+// matches checks whether the breakpoint is hit by the instruction about to be
+// executed, with line being the source location if it starts a new line.
+func (b *breakpoint) matches(pc uint64, op vm.OpCode, stack *vm.Stack, contract *vm.Contract, line *compiler.SourceLocation) bool {
+	switch {
+	case b.pc != nil:
+		return *b.pc == pc && (b.addr == nil || *b.addr == contract.Address())
+	case b.op != nil:
+		return *b.op == op
+	case b.file != "":
+		return line != nil && line.Line == b.line && (line.File == b.file || strings.HasSuffix(line.File, "/"+b.file))
+	default:
+		if op != vm.SSTORE || len(stack.Data()) < 1 {
+			return false
+		}
+		return b.slot == nil || *b.slot == common.BigToHash(stack.Back(0))
+	}
+}
+
+// Debugger is an EVM tracer pausing the execution on breakpoints or while
+// stepping through the code, reading commands to inspect the state of the
+// machine and to resume execution from an input stream.
+type Debugger struct {
+	in  *bufio.Scanner
+	out io.Writer
+
+	mode     debugMode
+	depth    int    // Call depth the last stepping command was issued at
+	last     string // Last stepping command, repeated on empty input
+	detached bool   // Whether the input ran out, execution runs to completion
+
+	breakpoints []*breakpoint
+	nextID      int
+
+	writes map[common.Address][]common.Hash // Storage slots written, in order
+	ended  bool
+
+	sources  *compiler.SourceTracker  // Source tracker to resolve instructions to sources with (optional)
+	location *compiler.SourceLocation // Source location of the current instruction
+}
+
+// NewDebugger creates an interactive debugger reading commands from the input
+// and writing its output to the given writer. Execution pauses before the first
+// instruction. If a source tracker is given, the execution can also be followed
+// at the Solidity level.
+func NewDebugger(in io.Reader, out io.Writer, sources *compiler.SourceTracker) *Debugger {
+	return &Debugger{
+		in:      bufio.NewScanner(in),
+		out:     out,
+		mode:    modeStep,
+		nextID:  1,
+		writes:  make(map[common.Address][]common.Hash),
+		sources: sources,
+	}
+}
+
+// CaptureStart implements vm.Tracer, announcing the start of the execution.
+func (d *Debugger) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	kind := "call"
+	if create {
+		kind = "create"
+	}
+	fmt.Fprintf(d.out, "Starting %s from %x to %x, gas %d, value %v, input 0x%x\n", kind, from, to, gas, value, input)
+	fmt.Fprintln(d.out, "Type 'help' for the list of commands.")
+	return nil
+}
+
+// CaptureState implements vm.Tracer, pausing the execution and processing user
+// commands if a breakpoint is hit or the code is being stepped through.
+func (d *Debugger) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if err != nil {
+		fmt.Fprintf(d.out, "Error at pc %d (%v) in %x: %v\n", pc, op, contract.Address(), err)
+		return nil
+	}
+	if d.detached {
+		return nil
+	}
+	// Resolve the source location, line breakpoints only hit when entering a line
+	var line *compiler.SourceLocation
+	if d.sources != nil {
+		prev := d.location
+		d.location = d.sources.Step(contract.Code, pc, depth)
+		if d.location != nil && (prev == nil || prev.File != d.location.File || prev.Line != d.location.Line) {
+			line = d.location
+		}
+	}
+	// Track the storage writes to be able to list them on request
+	if op == vm.SSTORE && len(stack.Data()) > 0 {
+		d.trackWrite(contract.Address(), common.BigToHash(stack.Back(0)))
+	}
+	// Decide whether to pause before executing the instruction
+	pause := false
+	switch d.mode {
+	case modeStep:
+		pause = true
+	case modeNext:
+		pause = depth <= d.depth
+	case modeOut:
+		pause = depth < d.depth
+	}
+	for _, b := range d.breakpoints {
+		if b.matches(pc, op, stack, contract, line) {
+			fmt.Fprintf(d.out, "Breakpoint %d hit: %v\n", b.id, b)
+			pause = true
+			break
+		}
+	}
+	if !pause {
+		return nil
+	}
+	d.where(pc, op, gas, cost, contract, depth)
+	d.prompt(env, pc, op, gas, cost, memory, stack, contract, depth)
+	return nil
+}
+
+// CaptureFault implements vm.Tracer, reporting the error execution stopped on.
+func (d *Debugger) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	fmt.Fprintf(d.out, "Fault at pc %d (%v) in %x: %v\n", pc, op, contract.Address(), err)
+	return nil
+}
+
+// CaptureEnd implements vm.Tracer, reporting the outcome of the execution.
+func (d *Debugger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
+	if d.ended {
+		return nil
+	}
+	d.ended = true
+
+	fmt.Fprintf(d.out, "Execution finished in %v, gas used %d, output 0x%x\n", t, gasUsed, output)
+	if err != nil {
+		fmt.Fprintf(d.out, "Execution failed: %v\n", err)
+	}
+	return nil
+}
+
+// trackWrite records a storage slot being written by a contract.
+func (d *Debugger) trackWrite(addr common.Address, slot common.Hash) {
+	for _, known := range d.writes[addr] {
+		if known == slot {
+			return
+		}
+	}
+	d.writes[addr] = append(d.writes[addr], slot)
+}
+
+// where prints the instruction the execution is paused before.
+func (d *Debugger) where(pc uint64, op vm.OpCode, gas, cost uint64, contract *vm.Contract, depth int) {
+	fmt.Fprintf(d.out, "[depth %d] %x pc %-5d %-14v gas %d cost %d\n", depth, contract.Address(), pc, op, gas, cost)
+	if d.location != nil {
+		fmt.Fprintf(d.out, "    at %v\n", d.location)
+	}
+}
+
+// prompt reads and executes user commands until one resumes execution.
+func (d *Debugger) prompt(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int) {
+	for {
+		fmt.Fprint(d.out, "> ")
+		if !d.in.Scan() {
+			// Input exhausted, run to completion without pausing anymore
+			fmt.Fprintln(d.out)
+			d.detached = true
+			return
+		}
+		fields := strings.Fields(d.in.Text())
+		if len(fields) == 0 {
+			if d.last == "" {
+				continue
+			}
+			fields = []string{d.last}
+		}
+		switch cmd, args := fields[0], fields[1:]; cmd {
+		case "step", "s":
+			d.resume(cmd, modeStep, depth)
+			return
+
+		case "next", "n":
+			d.resume(cmd, modeNext, depth)
+			return
+
+		case "out", "o":
+			d.resume(cmd, modeOut, depth)
+			return
+
+		case "continue", "c":
+			d.resume(cmd, modeContinue, depth)
+			return
+
+		case "quit", "q":
+			fmt.Fprintln(d.out, "Aborting execution")
+			d.detached = true
+			env.Cancel()
+			return
+
+		case "break":
+			if b, err := parseBreakpoint(args); err != nil {
+				fmt.Fprintln(d.out, "Invalid breakpoint:", err)
+			} else {
+				b.id, d.nextID = d.nextID, d.nextID+1
+				d.breakpoints = append(d.breakpoints, b)
+				fmt.Fprintf(d.out, "Breakpoint %d set: %v\n", b.id, b)
+			}
+
+		case "breakpoints", "b":
+			if len(d.breakpoints) == 0 {
+				fmt.Fprintln(d.out, "No breakpoints")
+			}
+			for _, b := range d.breakpoints {
+				fmt.Fprintf(d.out, "%3d: %v\n", b.id, b)
+			}
+
+		case "delete":
+			d.deleteBreakpoint(args)
+
+		case "stack":
+			data := stack.Data()
+			if len(data) == 0 {
+				fmt.Fprintln(d.out, "Stack is empty")
+			}
+			for i := len(data) - 1; i >= 0; i-- {
+				fmt.Fprintf(d.out, "%04d: %x\n", len(data)-1-i, common.BigToHash(data[i]))
+			}
+
+		case "memory":
+			d.printMemory(memory, args)
+
+		case "storage":
+			d.printStorage(env, contract.Address(), args)
+
+		case "returndata":
+			fmt.Fprintf(d.out, "0x%x\n", env.Interpreter().ReturnData())
+
+		case "where", "w":
+			d.where(pc, op, gas, cost, contract, depth)
+
+		case "backtrace", "bt":
+			if d.sources == nil {
+				fmt.Fprintln(d.out, "No sources loaded, use --sources")
+			} else {
+				writeStackTrace(d.out, d.sources.StackTrace())
+			}
+
+		case "help", "h":
+			fmt.Fprint(d.out, debuggerHelp)
+
+		default:
+			fmt.Fprintf(d.out, "Unknown command %q, type 'help' for the list of commands\n", cmd)
+		}
+	}
+}
+
+// resume sets the execution mode until the next pause.
+func (d *Debugger) resume(cmd string, mode debugMode, depth int) {
+	d.last, d.mode, d.depth = cmd, mode, depth
+}
+
+// parseBreakpoint parses the arguments of a break command.
+func parseBreakpoint(args []string) (*breakpoint, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("missing breakpoint kind (pc, op, sstore or line)")
+	}
+	switch args[0] {
+	case "pc":
+		if len(args) < 2 || len(args) > 3 {
+			return nil, fmt.Errorf("usage: break pc <pc> [address]")
+		}
+		pc, err := strconv.ParseUint(args[1], 0, 64)
+		if err != nil {
+			return nil, err
+		}
+		b := &breakpoint{pc: &pc}
+		if len(args) == 3 {
+			if !common.IsHexAddress(args[2]) {
+				return nil, fmt.Errorf("invalid address %q", args[2])
+			}
+			addr := common.HexToAddress(args[2])
+			b.addr = &addr
+		}
+		return b, nil
+
+	case "op":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("usage: break op <opcode>")
+		}
+		name := strings.ToUpper(args[1])
+		op := vm.StringToOp(name)
+		if op.String() != name {
+			return nil, fmt.Errorf("unknown opcode %q", args[1])
+		}
+		return &breakpoint{op: &op}, nil
+
+	case "sstore":
+		if len(args) > 2 {
+			return nil, fmt.Errorf("usage: break sstore [slot]")
+		}
+		b := new(breakpoint)
+		if len(args) == 2 {
+			slot, err := parseSlot(args[1])
+			if err != nil {
+				return nil, err
+			}
+			b.slot = &slot
+		}
+		return b, nil
+
+	case "line":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("usage: break line <file>:<line>")
+		}
+		sep := strings.LastIndex(args[1], ":")
+		if sep <= 0 {
+			return nil, fmt.Errorf("usage: break line <file>:<line>")
+		}
+		line, err := strconv.Atoi(args[1][sep+1:])
+		if err != nil {
+			return nil, err
+		}
+		return &breakpoint{file: args[1][:sep], line: line}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown breakpoint kind %q", args[0])
+	}
+}
+
+// parseSlot parses a storage slot given either as a decimal or hex number.
+func parseSlot(s string) (common.Hash, error) {
+	slot, ok := new(big.Int).SetString(s, 0)
+	if !ok || slot.Sign() < 0 || slot.BitLen() > 256 {
+		return common.Hash{}, fmt.Errorf("invalid storage slot %q", s)
+	}
+	return common.BigToHash(slot), nil
+}
+
+// deleteBreakpoint removes the breakpoint with the given id.
+func (d *Debugger) deleteBreakpoint(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(d.out, "Usage: delete <id>")
+		return
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		fmt.Fprintln(d.out, "Invalid breakpoint id:", err)
+		return
+	}
+	for i, b := range d.breakpoints {
+		if b.id == id {
+			d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+			fmt.Fprintf(d.out, "Breakpoint %d deleted\n", id)
+			return
+		}
+	}
+	fmt.Fprintf(d.out, "No breakpoint %d\n", id)
+}
+
+// printMemory dumps a range of the memory, 32 bytes per line.
+func (d *Debugger) printMemory(memory *vm.Memory, args []string) {
+	data := memory.Data()
+
+	offset, length := uint64(0), uint64(len(data))
+	if len(args) > 0 {
+		var err error
+		if offset, err = strconv.ParseUint(args[0], 0, 64); err != nil {
+			fmt.Fprintln(d.out, "Invalid offset:", err)
+			return
+		}
+		length = 32
+	}
+	if len(args) > 1 {
+		var err error
+		if length, err = strconv.ParseUint(args[1], 0, 64); err != nil {
+			fmt.Fprintln(d.out, "Invalid length:", err)
+			return
+		}
+	}
+	if offset >= uint64(len(data)) {
+		fmt.Fprintf(d.out, "Memory size is %d bytes\n", len(data))
+		return
+	}
+	if end := uint64(len(data)); offset+length > end || offset+length < offset {
+		length = end - offset
+	}
+	for i := offset; i < offset+length; i += 32 {
+		end := i + 32
+		if end > offset+length {
+			end = offset + length
+		}
+		fmt.Fprintf(d.out, "%04x: %x\n", i, data[i:end])
+	}
+}
+
+// printStorage prints either the given storage slot of the current contract,
+// or all the slots it wrote to during execution.
+func (d *Debugger) printStorage(env *vm.EVM, addr common.Address, args []string) {
+	if len(args) > 0 {
+		slot, err := parseSlot(args[0])
+		if err != nil {
+			fmt.Fprintln(d.out, err)
+			return
+		}
+		fmt.Fprintf(d.out, "%x: %x\n", slot, env.StateDB.GetState(addr, slot))
+		return
+	}
+	if len(d.writes[addr]) == 0 {
+		fmt.Fprintln(d.out, "No storage slots written")
+		return
+	}
+	for _, slot := range d.writes[addr] {
+		fmt.Fprintf(d.out, "%x: %x\n", slot, env.StateDB.GetState(addr, slot))
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+var (
+	// debuggerCaller calls debuggerCallee, then stores 1 into slot 0.
+	debuggerCaller = common.FromHex(
+		"6000600060006000600060ee5af1" + // pc 0-13: CALL(gas, 0xee, 0, 0, 0, 0, 0)
+			"50" + // pc 14: POP
+			"6001600055" + // pc 15-19: SSTORE(0, 1)
+			"00", // pc 20: STOP
+	)
+	// debuggerCallee stores 0x2a into memory and 7 into slot 3.
+	debuggerCallee = common.FromHex(
+		"602a600052" + // pc 0-4: MSTORE(0, 0x2a)
+			"6007600355" + // pc 5-9: SSTORE(3, 7)
+			"00", // pc 10: STOP
+	)
+	debuggerCalleeAddr = common.HexToAddress("0xee")
+
+	// debuggerPause matches the position the debugger reports when pausing.
+	debuggerPause = regexp.MustCompile(`\[depth (\d+)\] [0-9a-f]{40} pc (\d+) `)
+)
+
+// runDebugger executes the test contracts with a debugger reading the given
+// script, returning the debugger output, the positions it paused at (as depth
+// and pc pairs) and the value stored by the caller.
+func runDebugger(t *testing.T, script string) (string, []string, common.Hash) {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	statedb.SetCode(debuggerCalleeAddr, debuggerCallee)
+
+	out := new(bytes.Buffer)
+	debugger := NewDebugger(strings.NewReader(script), out, nil)
+
+	_, statedb, err := runtime.Execute(debuggerCaller, nil, &runtime.Config{
+		GasLimit:  1000000,
+		State:     statedb,
+		EVMConfig: vm.Config{Debug: true, Tracer: debugger},
+	})
+	if err != nil {
+		t.Fatalf("failed to execute code: %v", err)
+	}
+	var pauses []string
+	for _, match := range debuggerPause.FindAllStringSubmatch(out.String(), -1) {
+		pauses = append(pauses, match[1]+":"+match[2])
+	}
+	return out.String(), pauses, statedb.GetState(common.StringToAddress("contract"), common.Hash{})
+}
+
+// Tests that the debugger steps through the code, pauses on breakpoints and
+// processes the commands scripted in its input.
+func TestDebugger(t *testing.T) {
+	var (
+		stored = common.BigToHash(common.Big1)
+		callee = fmt.Sprintf("%x", debuggerCalleeAddr)
+	)
+	tests := []struct {
+		script   string
+		pauses   []string
+		contains []string
+		stored   common.Hash
+	}{
+		// Running out of input runs the execution to completion
+		{
+			script: "",
+			pauses: []string{"1:0"},
+			stored: stored,
+		},
+		// Stepping, with an empty line repeating the last command
+		{
+			script: "s\n\ns\n",
+			pauses: []string{"1:0", "1:2", "1:4", "1:6"},
+			stored: stored,
+		},
+		// Stepping into a call, over its instructions and out of it
+		{
+			script:   "break pc 13\nc\ns\nn\no\n",
+			pauses:   []string{"1:0", "1:13", "2:0", "2:2", "1:14"},
+			contains: []string{"Breakpoint 1 set: pc 13\n", "Breakpoint 1 hit: pc 13\n"},
+			stored:   stored,
+		},
+		// Stepping over a call
+		{
+			script: "break pc 13\nc\nn\n",
+			pauses: []string{"1:0", "1:13", "1:14"},
+			stored: stored,
+		},
+		// Program counter breakpoints restricted to a contract
+		{
+			script:   "break pc 0 0x" + callee + "\nc\n",
+			pauses:   []string{"1:0", "2:0"},
+			contains: []string{"Breakpoint 1 set: pc 0 in " + callee + "\n"},
+			stored:   stored,
+		},
+		// Opcode and storage write breakpoints
+		{
+			script: "break op sstore\nc\nc\n",
+			pauses: []string{"1:0", "2:9", "1:19"},
+			stored: stored,
+		},
+		{
+			script:   "break sstore 0\nc\n",
+			pauses:   []string{"1:0", "1:19"},
+			contains: []string{"Breakpoint 1 hit: sstore 0000000000000000000000000000000000000000000000000000000000000000\n"},
+			stored:   stored,
+		},
+		// Listing and deleting breakpoints
+		{
+			script: "b\nbreak op sstore\nbreak pc 13\nb\ndelete 1\ndelete 1\nc\n",
+			pauses: []string{"1:0", "1:13"},
+			contains: []string{
+				"No breakpoints\n",
+				"  1: op SSTORE\n  2: pc 13\n",
+				"Breakpoint 1 deleted\n",
+				"No breakpoint 1\n",
+			},
+			stored: stored,
+		},
+		// Inspecting the stack, memory and storage
+		{
+			script: "break pc 9 0x" + callee + "\nc\nstack\nn\nmemory 0\nstorage\nstorage 4\n",
+			pauses: []string{"1:0", "2:9", "2:10"},
+			contains: []string{
+				"0000: 0000000000000000000000000000000000000000000000000000000000000003\n0001: 0000000000000000000000000000000000000000000000000000000000000007\n",
+				"0000: 000000000000000000000000000000000000000000000000000000000000002a\n",
+				"0000000000000000000000000000000000000000000000000000000000000003: 0000000000000000000000000000000000000000000000000000000000000007\n",
+				"0000000000000000000000000000000000000000000000000000000000000004: 0000000000000000000000000000000000000000000000000000000000000000\n",
+			},
+			stored: stored,
+		},
+		// Invalid commands are reported without resuming the execution
+		{
+			script: "break\nbreak foo\nbreak pc x\nbreak op foo\nbreak sstore -1\ndelete\nbogus\n",
+			pauses: []string{"1:0"},
+			contains: []string{
+				"Invalid breakpoint: missing breakpoint kind",
+				"Invalid breakpoint: unknown breakpoint kind \"foo\"\n",
+				"Invalid breakpoint: strconv.ParseUint",
+				"Invalid breakpoint: unknown opcode \"foo\"\n",
+				"Invalid breakpoint: invalid storage slot \"-1\"\n",
+				"Usage: delete <id>\n",
+				"Unknown command \"bogus\", type 'help' for the list of commands\n",
+			},
+			stored: stored,
+		},
+		// Quitting aborts the execution
+		{
+			script:   "break pc 13\nc\nq\n",
+			pauses:   []string{"1:0", "1:13"},
+			contains: []string{"Aborting execution\n"},
+			stored:   common.Hash{},
+		},
+	}
+	for i, tt := range tests {
+		out, pauses, stored := runDebugger(t, tt.script)
+		if !reflect.DeepEqual(pauses, tt.pauses) {
+			t.Errorf("test %d: pauses mismatch: have %v, want %v\n%s", i, pauses, tt.pauses, out)
+		}
+		for _, want := range tt.contains {
+			if !strings.Contains(out, want) {
+				t.Errorf("test %d: output missing %q:\n%s", i, want, out)
+			}
+		}
+		if stored != tt.stored {
+			t.Errorf("test %d: stored value mismatch: have %x, want %x", i, stored, tt.stored)
+		}
+		if !strings.Contains(out, "Execution finished") {
+			t.Errorf("test %d: execution end not reported:\n%s", i, out)
+		}
+	}
+}
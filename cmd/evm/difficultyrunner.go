@@ -0,0 +1,110 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+var DifficultyForkFlag = cli.StringFlag{
+	Name:  "difficulty.fork",
+	Usage: "Name of the chain configuration or ruleset to calculate the difficulties with",
+	Value: "Byzantium",
+}
+
+var difficultyCommand = cli.Command{
+	Action:    difficultyCmd,
+	Name:      "difficulty",
+	Usage:     "executes the given difficulty tests",
+	ArgsUsage: "<file>",
+	Flags: []cli.Flag{
+		DifficultyForkFlag,
+	},
+}
+
+type DifficultytestResult struct {
+	Name  string `json:"name"`
+	Pass  bool   `json:"pass"`
+	Fork  string `json:"fork"`
+	Error string `json:"error,omitempty"`
+}
+
+// difficultyConfigs are the chain configurations difficulty tests can run with
+// apart from the test forks, which activate all their rules from genesis.
+var difficultyConfigs = map[string]*params.ChainConfig{
+	"MainNetwork": params.MainnetChainConfig,
+	"Ropsten":     params.TestnetChainConfig,
+	"Rinkeby":     params.RinkebyChainConfig,
+}
+
+func difficultyCmd(ctx *cli.Context) error {
+	if len(ctx.Args().First()) == 0 {
+		return errors.New("path-to-test argument required")
+	}
+	fork := ctx.String(DifficultyForkFlag.Name)
+
+	config, ok := difficultyConfigs[fork]
+	if !ok {
+		if config, ok = tests.Forks[fork]; !ok {
+			return tests.UnsupportedForkError{Name: fork}
+		}
+	}
+	// Load the test content from the input file
+	src, err := ioutil.ReadFile(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	var difficultyTests map[string]tests.DifficultyTest
+	if err = json.Unmarshal(src, &difficultyTests); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(difficultyTests))
+	for name := range difficultyTests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Iterate over all the tests, run them and aggregate the results
+	results := make([]DifficultytestResult, 0, len(difficultyTests))
+	failed := 0
+	for _, name := range names {
+		test := difficultyTests[name]
+
+		result := DifficultytestResult{Name: name, Fork: fork, Pass: true}
+		if err := test.Run(config); err != nil {
+			result.Pass, result.Error = false, err.Error()
+			failed++
+		}
+		results = append(results, result)
+	}
+	out, _ := json.MarshalIndent(results, "", "  ")
+	fmt.Println(string(out))
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tests failed", failed, len(results))
+	}
+	return nil
+}
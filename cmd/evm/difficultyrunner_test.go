@@ -0,0 +1,45 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// Tests that difficulty tests are run with the chain configuration selected by
+// their own fork flag, and that any failing test is reflected in the exit code.
+func TestDifficulty(t *testing.T) {
+	tests := []struct {
+		args   []string
+		expect string
+		status int
+	}{
+		{args: []string{"--difficulty.fork", "MainNetwork"}, expect: "exp_mainnet.json", status: 0},
+		{args: nil, expect: "exp_byzantium.json", status: 1},
+	}
+	for i, tt := range tests {
+		args := append([]string{"difficulty"}, tt.args...)
+		evm := runEvm(t, append(args, filepath.Join("testdata", "difficulty", "mainnet.json"))...)
+		evm.Expect(readTestdata(t, "difficulty", tt.expect))
+		evm.ExpectExit()
+
+		if status := evm.ExitStatus(); status != tt.status {
+			t.Errorf("test %d: exit status mismatch: have %d, want %d", i, status, tt.status)
+		}
+	}
+}
@@ -114,6 +114,14 @@ var (
 		Name:  "nostack",
 		Usage: "disable stack output",
 	}
+	InteractiveFlag = cli.BoolFlag{
+		Name:  "interactive",
+		Usage: "step through the execution in an interactive debugger",
+	}
+	SourcesFlag = cli.StringFlag{
+		Name:  "sources",
+		Usage: "Solidity file or compiled contracts JSON to map traces, debugger and failures to sources",
+	}
 )
 
 func init() {
@@ -138,12 +146,18 @@ func init() {
 		ReceiverFlag,
 		DisableMemoryFlag,
 		DisableStackFlag,
+		InteractiveFlag,
+		SourcesFlag,
 	}
 	app.Commands = []cli.Command{
 		compileCommand,
 		disasmCommand,
 		runCommand,
+		replayCommand,
+		blockTestCommand,
+		difficultyCommand,
 		stateTestCommand,
+		transitionCommand,
 	}
 }
 
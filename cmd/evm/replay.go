@@ -0,0 +1,191 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+var replayCommand = cli.Command{
+	Action:    replayCmd,
+	Name:      "replay",
+	Usage:     "replays a mined transaction from a geth data directory",
+	ArgsUsage: "<txhash>",
+	Flags: []cli.Flag{
+		utils.DataDirFlag,
+	},
+	Description: `
+The replay command re-executes a transaction already included in the chain of a
+stopped geth node, on top of the state it originally ran on. Together with the
+--interactive flag it allows stepping through the transaction in the debugger,
+while --debug and --json print the trace of the execution. The state of the
+parent block must be available, so it works best on archive nodes.`,
+}
+
+// replayChain is a chain context reading the ancestor headers from the chain
+// database.
+type replayChain struct {
+	db     ethdb.Database
+	engine consensus.Engine
+}
+
+func (c *replayChain) Engine() consensus.Engine { return c.engine }
+func (c *replayChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	return core.GetHeader(c.db, hash, number)
+}
+
+func replayCmd(ctx *cli.Context) error {
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(ctx.GlobalInt(VerbosityFlag.Name)))
+	log.Root().SetHandler(glogger)
+
+	if len(ctx.Args().First()) == 0 {
+		return errors.New("transaction hash argument required")
+	}
+	hash := common.HexToHash(ctx.Args().First())
+
+	// Open the chain database and look up the transaction
+	path := filepath.Join(ctx.String(utils.DataDirFlag.Name), "geth", "chaindata")
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("chain database not found: %v", err)
+	}
+	db, err := ethdb.NewLDBDatabase(path, 16, 16)
+	if err != nil {
+		return fmt.Errorf("failed to open chain database: %v", err)
+	}
+	defer db.Close()
+
+	tx, blockHash, number, index := core.GetTransaction(db, hash)
+	if tx == nil {
+		return fmt.Errorf("transaction %x not found", hash)
+	}
+	block := core.GetBlock(db, blockHash, number)
+	if block == nil {
+		return fmt.Errorf("block #%d [%x…] not found", number, blockHash[:4])
+	}
+	parent := core.GetHeader(db, block.ParentHash(), number-1)
+	if parent == nil {
+		return fmt.Errorf("parent of block #%d [%x…] not found", number, blockHash[:4])
+	}
+	config, err := core.GetChainConfig(db, core.GetCanonicalHash(db, 0))
+	if err != nil {
+		return fmt.Errorf("failed to retrieve chain config: %v", err)
+	}
+	if err := core.CheckChainConfig(config); err != nil {
+		return fmt.Errorf("invalid chain config: %v", err)
+	}
+	statedb, err := state.New(parent.Root, state.NewDatabase(db))
+	if err != nil {
+		return fmt.Errorf("state of block #%d not available: %v", number-1, err)
+	}
+	// Assemble the consensus engine, only used to resolve block authors
+	var engine consensus.Engine
+	if config.Clique != nil {
+		engine = clique.New(config.Clique, db)
+	} else if config.Istanbul != nil {
+		// Replaying only needs to recover the proposer, any key does as identity
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return err
+		}
+		engine = istanbul.New(config.Istanbul, key, db)
+	} else {
+		engine = ethash.NewFaker()
+	}
+	// Re-apply all the transactions preceding the requested one in the block
+	var (
+		chain   = &replayChain{db: db, engine: engine}
+		header  = block.Header()
+		gaspool = new(core.GasPool).AddGas(block.GasLimit())
+		usedGas = new(uint64)
+	)
+	if config.DAOForkSupport && config.DAOForkBlock != nil && config.DAOForkBlock.Cmp(block.Number()) == 0 {
+		misc.ApplyDAOHardFork(statedb)
+	}
+	for i, prev := range block.Transactions()[:index] {
+		statedb.Prepare(prev.Hash(), blockHash, i)
+		if _, _, err := core.ApplyTransaction(config, chain, nil, gaspool, statedb, header, prev, usedGas, vm.Config{}); err != nil {
+			return fmt.Errorf("failed to apply transaction %d [%x…]: %v", i, prev.Hash().Bytes()[:4], err)
+		}
+	}
+	// Execute the requested transaction with the configured tracer
+	logconfig := &vm.LogConfig{
+		DisableMemory: ctx.GlobalBool(DisableMemoryFlag.Name),
+		DisableStack:  ctx.GlobalBool(DisableStackFlag.Name),
+	}
+	var (
+		tracer      vm.Tracer
+		debugLogger *vm.StructLogger
+		srcLogger   *vm.SourceLogger
+		sources     = makeSourceTracker(ctx)
+	)
+	switch {
+	case ctx.GlobalBool(InteractiveFlag.Name):
+		tracer = NewDebugger(os.Stdin, os.Stdout, sources)
+	case ctx.GlobalBool(MachineFlag.Name):
+		tracer = NewJSONLogger(logconfig, os.Stdout)
+	case ctx.GlobalBool(DebugFlag.Name):
+		debugLogger = vm.NewStructLogger(logconfig)
+		tracer = debugLogger
+		if sources != nil {
+			srcLogger = vm.NewSourceLogger(debugLogger, sources)
+			tracer = srcLogger
+		}
+	}
+	statedb.Prepare(tx.Hash(), blockHash, int(index))
+
+	receipt, gas, err := core.ApplyTransaction(config, chain, nil, gaspool, statedb, header, tx, usedGas, vm.Config{Debug: tracer != nil, Tracer: tracer})
+	if err != nil {
+		return fmt.Errorf("failed to apply transaction: %v", err)
+	}
+	if debugLogger != nil {
+		fmt.Fprintln(os.Stderr, "#### TRACE ####")
+		if srcLogger != nil {
+			writeSourceTrace(os.Stderr, srcLogger)
+		} else {
+			vm.WriteTrace(os.Stderr, debugLogger.StructLogs())
+		}
+		fmt.Fprintln(os.Stderr, "#### LOGS ####")
+		vm.WriteLogs(os.Stderr, receipt.Logs)
+	}
+	if srcLogger != nil && receipt.Status == types.ReceiptStatusFailed {
+		fmt.Fprintln(os.Stderr, "#### STACK TRACE ####")
+		writeStackTrace(os.Stderr, srcLogger.StackTrace())
+	}
+	fmt.Fprintf(os.Stderr, "Transaction %x in block #%d, index %d: status %d, gas used %d\n", hash, number, index, receipt.Status, gas)
+	return nil
+}
@@ -66,6 +66,12 @@ func readGenesis(genesisPath string) *core.Genesis {
 	if err := json.NewDecoder(file).Decode(genesis); err != nil {
 		utils.Fatalf("invalid genesis file: %v", err)
 	}
+	// The EVM refuses to run with unsupported EIPs or contracts, report them early
+	if genesis.Config != nil {
+		if err := core.CheckChainConfig(genesis.Config); err != nil {
+			utils.Fatalf("invalid genesis chain config: %v", err)
+		}
+	}
 	return genesis
 }
 
@@ -81,12 +87,19 @@ func runCmd(ctx *cli.Context) error {
 	var (
 		tracer      vm.Tracer
 		debugLogger *vm.StructLogger
+		srcLogger   *vm.SourceLogger
+		sources     = makeSourceTracker(ctx)
 		statedb     *state.StateDB
 		chainConfig *params.ChainConfig
 		sender      = common.StringToAddress("sender")
 		receiver    = common.StringToAddress("receiver")
 	)
-	if ctx.GlobalBool(MachineFlag.Name) {
+	if ctx.GlobalBool(InteractiveFlag.Name) {
+		if ctx.GlobalString(CodeFileFlag.Name) == "-" {
+			utils.Fatalf("The interactive debugger can't be used with code read from stdin")
+		}
+		tracer = NewDebugger(os.Stdin, os.Stdout, sources)
+	} else if ctx.GlobalBool(MachineFlag.Name) {
 		tracer = NewJSONLogger(logconfig, os.Stdout)
 	} else if ctx.GlobalBool(DebugFlag.Name) {
 		debugLogger = vm.NewStructLogger(logconfig)
@@ -94,6 +107,15 @@ func runCmd(ctx *cli.Context) error {
 	} else {
 		debugLogger = vm.NewStructLogger(logconfig)
 	}
+	// Resolve the executed code to its sources if available, the debugger does it on its own
+	if sources != nil && debugLogger != nil {
+		if !ctx.GlobalBool(DebugFlag.Name) {
+			// Only the stack trace of failures is reported, don't accumulate the steps
+			debugLogger = vm.NewStructLogger(&vm.LogConfig{DisableMemory: true, DisableStack: true, DisableStorage: true, Limit: 1})
+		}
+		srcLogger = vm.NewSourceLogger(debugLogger, sources)
+		tracer = srcLogger
+	}
 	if ctx.GlobalString(GenesisFlag.Name) != "" {
 		gen := readGenesis(ctx.GlobalString(GenesisFlag.Name))
 		db, _ := ethdb.NewMemDatabase()
@@ -162,7 +184,7 @@ func runCmd(ctx *cli.Context) error {
 		Value:    utils.GlobalBig(ctx, ValueFlag.Name),
 		EVMConfig: vm.Config{
 			Tracer:             tracer,
-			Debug:              ctx.GlobalBool(DebugFlag.Name) || ctx.GlobalBool(MachineFlag.Name),
+			Debug:              tracer != nil,
 			DisableGasMetering: ctx.GlobalBool(DisableGasMeteringFlag.Name),
 		},
 	}
@@ -215,13 +237,20 @@ func runCmd(ctx *cli.Context) error {
 	}
 
 	if ctx.GlobalBool(DebugFlag.Name) {
-		if debugLogger != nil {
+		if srcLogger != nil {
+			fmt.Fprintln(os.Stderr, "#### TRACE ####")
+			writeSourceTrace(os.Stderr, srcLogger)
+		} else if debugLogger != nil {
 			fmt.Fprintln(os.Stderr, "#### TRACE ####")
 			vm.WriteTrace(os.Stderr, debugLogger.StructLogs())
 		}
 		fmt.Fprintln(os.Stderr, "#### LOGS ####")
 		vm.WriteLogs(os.Stderr, statedb.Logs())
 	}
+	if srcLogger != nil && err != nil {
+		fmt.Fprintln(os.Stderr, "#### STACK TRACE ####")
+		writeStackTrace(os.Stderr, srcLogger.StackTrace())
+	}
 
 	if ctx.GlobalBool(StatDumpFlag.Name) {
 		var mem goruntime.MemStats
@@ -235,7 +264,7 @@ Gas used:           %d
 
 `, execTime, mem.HeapObjects, mem.Alloc, mem.TotalAlloc, mem.NumGC, initialGas-leftOverGas)
 	}
-	if tracer != nil {
+	if tracer != nil && (srcLogger == nil || ctx.GlobalBool(DebugFlag.Name)) {
 		tracer.CaptureEnd(ret, initialGas-leftOverGas, execTime, err)
 	} else {
 		fmt.Printf("0x%x\n", ret)
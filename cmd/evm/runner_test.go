@@ -0,0 +1,72 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Tests that a genesis activating unsupported EIPs or native contracts is
+// reported as an error instead of crashing the EVM.
+func TestRunInvalidGenesis(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "evm-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	tests := []struct {
+		config string
+		err    string
+	}{
+		{`{"eips": {"1": 0}}`, "EIP-1"},
+		{`{"precompiles": {"0x0000000000000000000000000000000000000100": {"name": "unknown", "block": 0}}}`, `unknown contract "unknown"`},
+	}
+	for i, tt := range tests {
+		genesis := filepath.Join(tmp, "genesis.json")
+		if err := ioutil.WriteFile(genesis, []byte(`{"gasLimit": "0x1000000", "difficulty": "0x1", "alloc": {}, "config": `+tt.config+`}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		evm := runEvm(t, "--prestate", genesis, "--code", "00", "run")
+		evm.WaitExit()
+
+		if status := evm.ExitStatus(); status != 1 {
+			t.Errorf("test %d: exit status mismatch: have %d, want %d", i, status, 1)
+		}
+		if stderr := evm.StderrText(); !strings.Contains(stderr, tt.err) || strings.Contains(stderr, "panic") {
+			t.Errorf("test %d: error mismatch: have %q, want %q", i, stderr, tt.err)
+		}
+	}
+}
+
+// Tests that requesting source locations along with machine readable traces is
+// rejected instead of silently dropping the sources.
+func TestRunSourcesJSON(t *testing.T) {
+	evm := runEvm(t, "--json", "--sources", "contracts.json", "--code", "00", "run")
+	evm.WaitExit()
+
+	if status := evm.ExitStatus(); status != 1 {
+		t.Errorf("exit status mismatch: have %d, want %d", status, 1)
+	}
+	if stderr := evm.StderrText(); !strings.Contains(stderr, "can't be combined") {
+		t.Errorf("error mismatch: have %q", stderr)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common/compiler"
+	"github.com/ethereum/go-ethereum/core/vm"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+// makeSourceTracker loads the compiled contracts set by the --sources flag, if
+// any, and creates a tracker resolving executed instructions to their sources.
+// The flag accepts either Solidity files, compiled on the fly with solc, or the
+// JSON output of the compiler package. The machine readable JSON traces have no
+// room for source locations, so the two are mutually exclusive.
+func makeSourceTracker(ctx *cli.Context) *compiler.SourceTracker {
+	path := ctx.GlobalString(SourcesFlag.Name)
+	if path == "" {
+		return nil
+	}
+	if ctx.GlobalBool(MachineFlag.Name) {
+		utils.Fatalf("The --%s flag can't be combined with --%s", SourcesFlag.Name, MachineFlag.Name)
+	}
+	var contracts map[string]*compiler.Contract
+	if strings.HasSuffix(path, ".sol") {
+		var err error
+		if contracts, err = compiler.CompileSolidity("", path); err != nil {
+			utils.Fatalf("Failed to compile sources: %v", err)
+		}
+	} else if err := readJSONFile(path, &contracts); err != nil {
+		utils.Fatalf("Failed to load compiled contracts: %v", err)
+	}
+	return compiler.NewSourceTracker(contracts)
+}
+
+// writeSourceTrace writes the captured steps in a human readable format, along
+// with their source locations.
+func writeSourceTrace(writer io.Writer, logger *vm.SourceLogger) {
+	locations := logger.Locations()
+	for i, log := range logger.StructLogs() {
+		if loc := locations[i]; loc != nil {
+			fmt.Fprintf(writer, "Source: %v\n", loc)
+		}
+		vm.WriteTrace(writer, []vm.StructLog{log})
+	}
+}
+
+// writeStackTrace writes a Solidity level stack trace, innermost call first.
+func writeStackTrace(writer io.Writer, trace []*compiler.SourceLocation) {
+	if len(trace) == 0 {
+		fmt.Fprintln(writer, "No source locations available")
+	}
+	for _, loc := range trace {
+		fmt.Fprintf(writer, "    at %v\n", loc)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Tests that the transition tool executes the transactions of a block, rejects
+// the invalid ones without failing the block, credits the configured mining
+// reward and resolves ancestor hashes from the environment.
+func TestT8n(t *testing.T) {
+	dir := filepath.Join("testdata", "t8n")
+	tests := []struct {
+		reward string
+		expect string
+	}{
+		{reward: "2000000000000000000", expect: "exp.json"},
+		{reward: "-1", expect: "exp_noreward.json"},
+	}
+	for _, tt := range tests {
+		evm := runEvm(t, "t8n",
+			"--input.alloc", filepath.Join(dir, "alloc.json"),
+			"--input.env", filepath.Join(dir, "env.json"),
+			"--input.txs", filepath.Join(dir, "txs.json"),
+			"--output.alloc", "stdout",
+			"--output.result", "stdout",
+			"--state.reward", tt.reward,
+		)
+		evm.Expect(readTestdata(t, "t8n", tt.expect))
+		evm.ExpectExit()
+	}
+}
+
+// Tests that the transition tool accepts its inputs combined from the standard
+// input and produces the same output as when reading them from files.
+func TestT8nStdin(t *testing.T) {
+	input, err := json.Marshal(map[string]json.RawMessage{
+		"alloc": json.RawMessage(readTestdata(t, "t8n", "alloc.json")),
+		"env":   json.RawMessage(readTestdata(t, "t8n", "env.json")),
+		"txs":   json.RawMessage(readTestdata(t, "t8n", "txs.json")),
+	})
+	if err != nil {
+		t.Fatalf("failed to assemble input: %v", err)
+	}
+	evm := runEvm(t, "t8n",
+		"--input.alloc", "stdin",
+		"--input.env", "stdin",
+		"--input.txs", "stdin",
+		"--output.alloc", "stdout",
+		"--output.result", "stdout",
+		"--state.reward", "2000000000000000000",
+	)
+	evm.InputLine(string(input))
+	evm.CloseStdin()
+
+	evm.Expect(readTestdata(t, "t8n", "exp.json"))
+	evm.ExpectExit()
+}
+
+// Tests that outputs not sent to a standard stream are written into their own
+// files instead of being merged.
+func TestT8nOutputFile(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "evm-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	dir := filepath.Join("testdata", "t8n")
+	evm := runEvm(t, "t8n",
+		"--input.alloc", filepath.Join(dir, "alloc.json"),
+		"--input.env", filepath.Join(dir, "env.json"),
+		"--input.txs", filepath.Join(dir, "txs.json"),
+		"--output.alloc", filepath.Join(tmp, "alloc.json"),
+		"--output.result", "stdout",
+		"--state.reward", "2000000000000000000",
+	)
+	var expect struct {
+		Alloc  json.RawMessage `json:"alloc"`
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(readTestdata(t, "t8n", "exp.json")), &expect); err != nil {
+		t.Fatalf("failed to decode expected output: %v", err)
+	}
+	result, _ := json.MarshalIndent(map[string]json.RawMessage{"result": expect.Result}, "", "  ")
+	evm.Expect(string(result) + "\n")
+	evm.ExpectExit()
+
+	blob, err := ioutil.ReadFile(filepath.Join(tmp, "alloc.json"))
+	if err != nil {
+		t.Fatalf("failed to read alloc output: %v", err)
+	}
+	var have, want interface{}
+	json.Unmarshal(blob, &have)
+	json.Unmarshal(expect.Alloc, &want)
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("alloc mismatch:\nhave %s\nwant %s", blob, expect.Alloc)
+	}
+}
@@ -0,0 +1,331 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/tests"
+
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+var (
+	InputAllocFlag = cli.StringFlag{
+		Name:  "input.alloc",
+		Usage: "`stdin` or file name of where to find the prestate alloc to use",
+		Value: "alloc.json",
+	}
+	InputEnvFlag = cli.StringFlag{
+		Name:  "input.env",
+		Usage: "`stdin` or file name of where to find the prestate env to use",
+		Value: "env.json",
+	}
+	InputTxsFlag = cli.StringFlag{
+		Name:  "input.txs",
+		Usage: "`stdin` or file name of where to find the signed transactions to apply",
+		Value: "txs.json",
+	}
+	OutputAllocFlag = cli.StringFlag{
+		Name:  "output.alloc",
+		Usage: "Determines where to put the post-state alloc: `stdout`, `stderr` or a file name",
+		Value: "alloc.json",
+	}
+	OutputResultFlag = cli.StringFlag{
+		Name:  "output.result",
+		Usage: "Determines where to put the result (roots, receipts, rejections): `stdout`, `stderr` or a file name",
+		Value: "result.json",
+	}
+	ForkFlag = cli.StringFlag{
+		Name:  "state.fork",
+		Usage: "Name of the ruleset to use",
+		Value: "Byzantium",
+	}
+	RewardFlag = cli.Int64Flag{
+		Name:  "state.reward",
+		Usage: "Mining reward to credit the coinbase with, negative to disable",
+		Value: 0,
+	}
+)
+
+var transitionCommand = cli.Command{
+	Action:    transitionCmd,
+	Name:      "transition",
+	Aliases:   []string{"t8n"},
+	Usage:     "executes a full state transition",
+	ArgsUsage: " ",
+	Flags: []cli.Flag{
+		InputAllocFlag,
+		InputEnvFlag,
+		InputTxsFlag,
+		OutputAllocFlag,
+		OutputResultFlag,
+		ForkFlag,
+		RewardFlag,
+	},
+}
+
+// transitionEnv is the block environment the transactions are executed in.
+type transitionEnv struct {
+	Coinbase    common.Address                      `json:"currentCoinbase"`
+	Difficulty  *math.HexOrDecimal256               `json:"currentDifficulty"`
+	GasLimit    math.HexOrDecimal64                 `json:"currentGasLimit"`
+	Number      math.HexOrDecimal64                 `json:"currentNumber"`
+	Timestamp   math.HexOrDecimal64                 `json:"currentTimestamp"`
+	BlockHashes map[math.HexOrDecimal64]common.Hash `json:"blockHashes,omitempty"`
+}
+
+// transitionInput is the combined input of the transition tool if read from
+// the standard input.
+type transitionInput struct {
+	Alloc core.GenesisAlloc    `json:"alloc,omitempty"`
+	Env   *transitionEnv       `json:"env,omitempty"`
+	Txs   []*types.Transaction `json:"txs,omitempty"`
+}
+
+// rejectedTx is a transaction that could not be included into the block.
+type rejectedTx struct {
+	Index int    `json:"index"`
+	Err   string `json:"error"`
+}
+
+// transitionResult is the outcome of a state transition, apart from the post
+// state itself.
+type transitionResult struct {
+	StateRoot   common.Hash         `json:"stateRoot"`
+	TxRoot      common.Hash         `json:"txRoot"`
+	ReceiptRoot common.Hash         `json:"receiptRoot"`
+	LogsHash    common.Hash         `json:"logsHash"`
+	Bloom       types.Bloom         `json:"logsBloom"`
+	GasUsed     math.HexOrDecimal64 `json:"gasUsed"`
+	Receipts    types.Receipts      `json:"receipts"`
+	Rejected    []*rejectedTx       `json:"rejected,omitempty"`
+}
+
+// transitionChain is a chain context resolving ancestor hashes from the ones
+// explicitly listed in the environment.
+type transitionChain struct {
+	hashes map[math.HexOrDecimal64]common.Hash
+}
+
+func (c *transitionChain) Engine() consensus.Engine                    { return nil }
+func (c *transitionChain) GetHeader(common.Hash, uint64) *types.Header { return nil }
+func (c *transitionChain) GetHash(number uint64) common.Hash {
+	return c.hashes[math.HexOrDecimal64(number)]
+}
+
+func transitionCmd(ctx *cli.Context) error {
+	config, ok := tests.Forks[ctx.String(ForkFlag.Name)]
+	if !ok {
+		return tests.UnsupportedForkError{Name: ctx.String(ForkFlag.Name)}
+	}
+	if err := core.CheckChainConfig(config); err != nil {
+		return err
+	}
+	// Gather the inputs, either from the standard input or from separate files
+	var (
+		input transitionInput
+		err   error
+	)
+	allocFile, envFile, txsFile := ctx.String(InputAllocFlag.Name), ctx.String(InputEnvFlag.Name), ctx.String(InputTxsFlag.Name)
+	if allocFile == "stdin" || envFile == "stdin" || txsFile == "stdin" {
+		if err := json.NewDecoder(os.Stdin).Decode(&input); err != nil {
+			return fmt.Errorf("failed to decode stdin: %v", err)
+		}
+	}
+	if allocFile != "stdin" {
+		if err = readJSONFile(allocFile, &input.Alloc); err != nil {
+			return err
+		}
+	}
+	if envFile != "stdin" {
+		if err = readJSONFile(envFile, &input.Env); err != nil {
+			return err
+		}
+	}
+	if txsFile != "stdin" {
+		if err = readJSONFile(txsFile, &input.Txs); err != nil {
+			return err
+		}
+	}
+	if input.Env == nil {
+		return errors.New("missing block environment")
+	}
+	if input.Env.Difficulty == nil {
+		return errors.New("missing currentDifficulty in block environment")
+	}
+	// Apply all the transactions to the prestate, collecting the rejected ones
+	db, _ := ethdb.NewMemDatabase()
+	statedb := tests.MakePreState(db, input.Alloc)
+
+	result, err := applyTransactions(config, statedb, input.Env, input.Txs, ctx.Int64(RewardFlag.Name))
+	if err != nil {
+		return err
+	}
+	// Dispatch the results, merging them if they go to the same standard stream
+	alloc := dumpAlloc(statedb)
+
+	outputs := make(map[string]map[string]interface{})
+	for _, output := range []struct {
+		dest  string
+		name  string
+		value interface{}
+	}{
+		{ctx.String(OutputAllocFlag.Name), "alloc", alloc},
+		{ctx.String(OutputResultFlag.Name), "result", result},
+	} {
+		switch output.dest {
+		case "stdout", "stderr":
+			if outputs[output.dest] == nil {
+				outputs[output.dest] = make(map[string]interface{})
+			}
+			outputs[output.dest][output.name] = output.value
+
+		default:
+			blob, err := json.MarshalIndent(output.value, "", "  ")
+			if err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(output.dest, blob, 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %v", output.name, err)
+			}
+		}
+	}
+	for dest, values := range outputs {
+		blob, err := json.MarshalIndent(values, "", "  ")
+		if err != nil {
+			return err
+		}
+		if dest == "stdout" {
+			fmt.Fprintln(os.Stdout, string(blob))
+		} else {
+			fmt.Fprintln(os.Stderr, string(blob))
+		}
+	}
+	return nil
+}
+
+// applyTransactions executes the transactions on top of the prestate in the
+// given block environment, the same way a block would be assembled: invalid
+// transactions are rejected without invalidating the entire block.
+func applyTransactions(config *params.ChainConfig, statedb *state.StateDB, env *transitionEnv, txs []*types.Transaction, reward int64) (*transitionResult, error) {
+	header := &types.Header{
+		Coinbase:   env.Coinbase,
+		Difficulty: (*big.Int)(env.Difficulty),
+		GasLimit:   uint64(env.GasLimit),
+		Number:     new(big.Int).SetUint64(uint64(env.Number)),
+		Time:       new(big.Int).SetUint64(uint64(env.Timestamp)),
+	}
+	if config.DAOForkSupport && config.DAOForkBlock != nil && config.DAOForkBlock.Cmp(header.Number) == 0 {
+		misc.ApplyDAOHardFork(statedb)
+	}
+	var (
+		chain    = &transitionChain{hashes: env.BlockHashes}
+		gaspool  = new(core.GasPool).AddGas(header.GasLimit)
+		included types.Transactions
+		result   = new(transitionResult)
+		logs     []*types.Log
+	)
+	for i, tx := range txs {
+		statedb.Prepare(tx.Hash(), common.Hash{}, len(included))
+
+		snapshot, gas := statedb.Snapshot(), *gaspool
+		receipt, _, err := core.ApplyTransaction(config, chain, &env.Coinbase, gaspool, statedb, header, tx, &header.GasUsed, vm.Config{})
+		if err != nil {
+			statedb.RevertToSnapshot(snapshot)
+			*gaspool = gas
+
+			result.Rejected = append(result.Rejected, &rejectedTx{Index: i, Err: err.Error()})
+			continue
+		}
+		included = append(included, tx)
+		result.Receipts = append(result.Receipts, receipt)
+		logs = append(logs, receipt.Logs...)
+	}
+	if reward >= 0 {
+		statedb.AddBalance(env.Coinbase, big.NewInt(reward))
+	}
+	root, err := statedb.Commit(config.IsEIP158(header.Number))
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit state: %v", err)
+	}
+	result.StateRoot = root
+	result.TxRoot = types.DeriveSha(included)
+	result.ReceiptRoot = types.DeriveSha(result.Receipts)
+	result.Bloom = types.CreateBloom(result.Receipts)
+	result.GasUsed = math.HexOrDecimal64(header.GasUsed)
+
+	blob, _ := rlp.EncodeToBytes(logs)
+	result.LogsHash = crypto.Keccak256Hash(blob)
+
+	if result.Receipts == nil {
+		result.Receipts = types.Receipts{}
+	}
+	return result, nil
+}
+
+// dumpAlloc converts the content of a state database into an alloc usable as
+// the prestate of subsequent transitions.
+func dumpAlloc(statedb *state.StateDB) core.GenesisAlloc {
+	alloc := make(core.GenesisAlloc)
+	for addr, dump := range statedb.RawDump().Accounts {
+		balance, _ := new(big.Int).SetString(dump.Balance, 10)
+		account := core.GenesisAccount{
+			Code:    common.Hex2Bytes(dump.Code),
+			Balance: balance,
+			Nonce:   dump.Nonce,
+		}
+		if len(dump.Storage) > 0 {
+			account.Storage = make(map[common.Hash]common.Hash)
+			for key, value := range dump.Storage {
+				_, content, _, _ := rlp.Split(common.Hex2Bytes(value))
+				account.Storage[common.HexToHash(key)] = common.BytesToHash(content)
+			}
+		}
+		alloc[common.HexToAddress(addr)] = account
+	}
+	return alloc
+}
+
+// readJSONFile decodes the content of a JSON file into the given value.
+func readJSONFile(path string, value interface{}) error {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(blob, value); err != nil {
+		return fmt.Errorf("failed to decode %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	ethashDAGFlag = cli.BoolFlag{
+		Name:  "dag",
+		Usage: "Operate on the mining DAGs too, not just the verification caches",
+	}
+
+	ethashCommand = cli.Command{
+		Name:     "ethash",
+		Usage:    "Manage ethash verification caches and mining DAGs",
+		Category: "MISCELLANEOUS COMMANDS",
+		Description: `
+Manage the ethash caches and DAGs stored in folders shared between multiple
+processes running with --ethash.shared. Nodes sharing a folder never delete
+old epochs, which instead need to be garbage collected with this command.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "generate",
+				Usage:     "Pre-generate ethash caches (and DAGs) of upcoming epochs",
+				ArgsUsage: "<blockNum> [epochs]",
+				Action:    utils.MigrateFlags(ethashGenerate),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.EthashCacheDirFlag,
+					utils.EthashDatasetDirFlag,
+					ethashDAGFlag,
+				},
+				Description: `
+    geth ethash generate [--dag] <blockNum> [epochs]
+
+Generates the ethash verification caches (and mining DAGs if --dag is set) of
+the epoch containing <blockNum> and the following ones, 2 epochs in total by
+default. Files already present are left untouched, and files being generated
+by other processes concurrently are waited for.`,
+			},
+			{
+				Name:      "gc",
+				Usage:     "Delete the ethash caches and DAGs of past epochs",
+				ArgsUsage: "<blockNum> [keep]",
+				Action:    utils.MigrateFlags(ethashGC),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.EthashCacheDirFlag,
+					utils.EthashDatasetDirFlag,
+					ethashDAGFlag,
+				},
+				Description: `
+    geth ethash gc [--dag] <blockNum> [keep]
+
+Deletes the ethash verification caches (and mining DAGs if --dag is set) of
+all the epochs preceding the one containing <blockNum>, apart from the most
+recent [keep] ones, 1 by default.`,
+			},
+		},
+	}
+)
+
+// ethashArgs parses the block number and optional epoch count of the ethash
+// subcommands, and resolves the folders they operate on.
+func ethashArgs(ctx *cli.Context, count int) (uint64, int, string, string) {
+	args := ctx.Args()
+	if len(args) < 1 || len(args) > 2 {
+		utils.Fatalf("This command requires a block number and optionally an epoch count.")
+	}
+	block, err := strconv.ParseUint(args[0], 0, 64)
+	if err != nil {
+		utils.Fatalf("Invalid block number: %v", err)
+	}
+	if len(args) > 1 {
+		if count, err = strconv.Atoi(args[1]); err != nil || count < 0 {
+			utils.Fatalf("Invalid epoch count: %s", args[1])
+		}
+	}
+	stack, cfg := makeConfigNode(ctx)
+	return block, count, stack.ResolvePath(cfg.Eth.Ethash.CacheDir), cfg.Eth.Ethash.DatasetDir
+}
+
+// ethashGenerate pre-generates the ethash caches and optionally DAGs of some
+// upcoming epochs into the configured folders.
+func ethashGenerate(ctx *cli.Context) error {
+	block, epochs, cachedir, dagdir := ethashArgs(ctx, 2)
+
+	ethash.MakeSharedCache(block, epochs, cachedir)
+	if ctx.Bool(ethashDAGFlag.Name) {
+		ethash.MakeSharedDataset(block, epochs, dagdir)
+	}
+	return nil
+}
+
+// ethashGC deletes the ethash caches and optionally DAGs of past epochs from
+// the configured folders.
+func ethashGC(ctx *cli.Context) error {
+	block, keep, cachedir, dagdir := ethashArgs(ctx, 1)
+
+	dirs := []string{cachedir}
+	if ctx.Bool(ethashDAGFlag.Name) {
+		dirs = append(dirs, dagdir)
+	}
+	for _, dir := range dirs {
+		removed, err := ethash.GarbageCollect(dir, block, keep)
+		for _, path := range removed {
+			fmt.Println("Removed", path)
+		}
+		if err != nil {
+			utils.Fatalf("Failed to garbage collect %s: %v", dir, err)
+		}
+	}
+	return nil
+}
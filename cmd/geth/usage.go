@@ -80,6 +80,10 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.IdentityFlag,
 			utils.LightServFlag,
 			utils.LightPeersFlag,
+			utils.LightPinnedServersFlag,
+			utils.LightCheckpointOracleFlag,
+			utils.ULCServersFlag,
+			utils.ULCFractionFlag,
 			utils.LightKDFFlag,
 		},
 	},
@@ -98,6 +102,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.EthashDatasetDirFlag,
 			utils.EthashDatasetsInMemoryFlag,
 			utils.EthashDatasetsOnDiskFlag,
+			utils.EthashSharedFlag,
 		},
 	},
 	//{
@@ -131,6 +136,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.CacheFlag,
 			utils.CacheDatabaseFlag,
 			utils.CacheGCFlag,
+			utils.CacheNoPrefetchFlag,
 			utils.TrieCacheGenFlag,
 		},
 	},
@@ -184,10 +190,14 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.MiningEnabledFlag,
 			utils.MinerThreadsFlag,
+			utils.MinerNotifyFlag,
+			utils.MinerRemoteSignerFlag,
 			utils.EtherbaseFlag,
 			utils.TargetGasLimitFlag,
 			utils.GasPriceFlag,
 			utils.ExtraDataFlag,
+			utils.StratumAddrFlag,
+			utils.StratumDifficultyFlag,
 		},
 	},
 	{
@@ -201,6 +211,8 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "VIRTUAL MACHINE",
 		Flags: []cli.Flag{
 			utils.VMEnableDebugFlag,
+			utils.VMProfileFlag,
+			utils.VMParallelFlag,
 		},
 	},
 	{
@@ -35,6 +35,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -185,6 +186,26 @@ var (
 		Usage: "Maximum number of LES client peers",
 		Value: eth.DefaultConfig.LightPeers,
 	}
+	LightPinnedServersFlag = cli.StringFlag{
+		Name:  "lightpinned",
+		Usage: "Comma separated enode URLs of LES servers to always connect to first (light client mode)",
+		Value: "",
+	}
+	LightCheckpointOracleFlag = cli.StringFlag{
+		Name:  "lightoracle",
+		Usage: "Address of the checkpoint oracle contract to adopt trusted checkpoints from (light client mode)",
+		Value: "",
+	}
+	ULCServersFlag = cli.StringFlag{
+		Name:  "ulc.servers",
+		Usage: "Comma separated enode URLs of trusted LES servers (enables ultra light client mode)",
+		Value: "",
+	}
+	ULCFractionFlag = cli.IntFlag{
+		Name:  "ulc.fraction",
+		Usage: "Minimum percentage of trusted servers that need to announce a head for it to be accepted (ultra light client mode)",
+		Value: eth.DefaultULCMinTrustedFraction,
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -244,6 +265,10 @@ var (
 		Usage: "Number of recent ethash mining DAGs to keep on disk (1+GB each)",
 		Value: eth.DefaultConfig.Ethash.DatasetsOnDisk,
 	}
+	EthashSharedFlag = cli.BoolFlag{
+		Name:  "ethash.shared",
+		Usage: "Share the ethash cache and DAG folders with other processes (disables pruning, see 'geth ethash gc')",
+	}
 	// Transaction pool settings
 	TxPoolNoLocalsFlag = cli.BoolFlag{
 		Name:  "txpool.nolocals",
@@ -310,6 +335,10 @@ var (
 		Usage: "Percentage of cache memory allowance to use for trie pruning",
 		Value: 25,
 	}
+	CacheNoPrefetchFlag = cli.BoolFlag{
+		Name:  "cache.noprefetch",
+		Usage: "Disable heuristic state prefetch during block import (less CPU and disk IO, more time waiting for data)",
+	}
 	TrieCacheGenFlag = cli.IntFlag{
 		Name:  "trie-cache-gens",
 		Usage: "Number of trie node generations to keep in memory",
@@ -325,6 +354,14 @@ var (
 		Usage: "Number of CPU threads to use for mining",
 		Value: runtime.NumCPU(),
 	}
+	MinerNotifyFlag = cli.StringFlag{
+		Name:  "minernotify",
+		Usage: "Comma separated HTTP URL list to notify of new work packages",
+	}
+	MinerRemoteSignerFlag = cli.BoolFlag{
+		Name:  "minerremotesigner",
+		Usage: "Leave the signing of clique blocks to a remote sealer if the etherbase key is not held locally",
+	}
 	TargetGasLimitFlag = cli.Uint64Flag{
 		Name:  "targetgaslimit",
 		Usage: "Target gas limit sets the artificial target gas floor for the blocks to mine",
@@ -344,6 +381,15 @@ var (
 		Name:  "extradata",
 		Usage: "Block extra data set by the miner (default = client version)",
 	}
+	StratumAddrFlag = cli.StringFlag{
+		Name:  "stratum.addr",
+		Usage: "Stratum mining server listening address (disabled if empty)",
+	}
+	StratumDifficultyFlag = cli.Uint64Flag{
+		Name:  "stratum.difficulty",
+		Usage: "Difficulty of the shares handed out to the Stratum miners",
+		Value: eth.DefaultConfig.StratumDifficulty,
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -360,6 +406,14 @@ var (
 		Name:  "vmdebug",
 		Usage: "Record information useful for VM and contract debugging",
 	}
+	VMProfileFlag = cli.BoolFlag{
+		Name:  "vmprofile",
+		Usage: "Record opcode level execution statistics of imported blocks (debug_evmProfile)",
+	}
+	VMParallelFlag = cli.BoolFlag{
+		Name:  "vmparallel",
+		Usage: "Execute the transactions of imported blocks in parallel (experimental)",
+	}
 	// Logging and debug settings
 	EthStatsURLFlag = cli.StringFlag{
 		Name:  "ethstats",
@@ -960,6 +1014,9 @@ func setEthash(ctx *cli.Context, cfg *eth.Config) {
 	if ctx.GlobalIsSet(EthashDatasetsOnDiskFlag.Name) {
 		cfg.Ethash.DatasetsOnDisk = ctx.GlobalInt(EthashDatasetsOnDiskFlag.Name)
 	}
+	if ctx.GlobalIsSet(EthashSharedFlag.Name) {
+		cfg.Ethash.SharedDirs = ctx.GlobalBool(EthashSharedFlag.Name)
+	}
 }
 
 // checkExclusive verifies that only a single isntance of the provided flags was
@@ -1038,6 +1095,24 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(LightPeersFlag.Name) {
 		cfg.LightPeers = ctx.GlobalInt(LightPeersFlag.Name)
 	}
+	if ctx.GlobalIsSet(LightPinnedServersFlag.Name) {
+		cfg.LightPinnedServers = strings.Split(ctx.GlobalString(LightPinnedServersFlag.Name), ",")
+	}
+	if ctx.GlobalIsSet(LightCheckpointOracleFlag.Name) {
+		addr := ctx.GlobalString(LightCheckpointOracleFlag.Name)
+		if !common.IsHexAddress(addr) {
+			Fatalf("Invalid checkpoint oracle address %q", addr)
+		}
+		cfg.LightCheckpointOracle = common.HexToAddress(addr)
+	}
+	if ctx.GlobalIsSet(ULCServersFlag.Name) {
+		cfg.ULC = &eth.ULCConfig{
+			TrustedServers:     strings.Split(ctx.GlobalString(ULCServersFlag.Name), ","),
+			MinTrustedFraction: ctx.GlobalInt(ULCFractionFlag.Name),
+		}
+		// Ultra light clients are always light clients
+		cfg.SyncMode = downloader.LightSync
+	}
 	if ctx.GlobalIsSet(NetworkIdFlag.Name) {
 		cfg.NetworkId = ctx.GlobalUint64(NetworkIdFlag.Name)
 	}
@@ -1055,15 +1130,33 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cfg.TrieCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
 	}
+	if ctx.GlobalIsSet(CacheNoPrefetchFlag.Name) {
+		cfg.NoPrefetch = ctx.GlobalBool(CacheNoPrefetchFlag.Name)
+	}
 	if ctx.GlobalIsSet(MinerThreadsFlag.Name) {
 		cfg.MinerThreads = ctx.GlobalInt(MinerThreadsFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerNotifyFlag.Name) {
+		cfg.MinerNotify = strings.Split(ctx.GlobalString(MinerNotifyFlag.Name), ",")
+	}
+	if ctx.GlobalIsSet(MinerRemoteSignerFlag.Name) {
+		cfg.MinerRemoteSigner = ctx.GlobalBool(MinerRemoteSignerFlag.Name)
+	}
 	if ctx.GlobalIsSet(DocRootFlag.Name) {
 		cfg.DocRoot = ctx.GlobalString(DocRootFlag.Name)
 	}
 	if ctx.GlobalIsSet(ExtraDataFlag.Name) {
 		cfg.ExtraData = []byte(ctx.GlobalString(ExtraDataFlag.Name))
 	}
+	if ctx.GlobalIsSet(StratumAddrFlag.Name) {
+		cfg.StratumAddr = ctx.GlobalString(StratumAddrFlag.Name)
+	}
+	if ctx.GlobalIsSet(StratumDifficultyFlag.Name) {
+		cfg.StratumDifficulty = ctx.GlobalUint64(StratumDifficultyFlag.Name)
+		if cfg.StratumDifficulty == 0 {
+			Fatalf("--%s must be positive", StratumDifficultyFlag.Name)
+		}
+	}
 	if ctx.GlobalIsSet(GasPriceFlag.Name) {
 		cfg.GasPrice = GlobalBig(ctx, GasPriceFlag.Name)
 	}
@@ -1071,6 +1164,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.GlobalBool(VMEnableDebugFlag.Name)
 	}
+	if ctx.GlobalIsSet(VMProfileFlag.Name) {
+		cfg.EnableEVMProfiling = ctx.GlobalBool(VMProfileFlag.Name)
+	}
+	if ctx.GlobalIsSet(VMParallelFlag.Name) {
+		cfg.EnableParallelProcessing = ctx.GlobalBool(VMParallelFlag.Name)
+	}
 
 	// Override any default configs for hard coded networks.
 	switch {
@@ -1225,6 +1324,13 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	var engine consensus.Engine
 	if config.Clique != nil {
 		engine = clique.New(config.Clique, chainDb)
+	} else if config.Istanbul != nil {
+		// Chain commands only verify blocks, any key does as validator identity
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			Fatalf("%v", err)
+		}
+		engine = istanbul.New(config.Istanbul, key, chainDb)
 	} else {
 		engine = ethash.NewFaker()
 		if !ctx.GlobalBool(FakePoWFlag.Name) {
@@ -1235,6 +1341,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 				DatasetDir:     stack.ResolvePath(eth.DefaultConfig.Ethash.DatasetDir),
 				DatasetsInMem:  eth.DefaultConfig.Ethash.DatasetsInMem,
 				DatasetsOnDisk: eth.DefaultConfig.Ethash.DatasetsOnDisk,
+				SharedDirs:     ctx.GlobalBool(EthashSharedFlag.Name),
 			})
 		}
 	}
@@ -1242,9 +1349,11 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
 	}
 	cache := &core.CacheConfig{
-		Disabled:      ctx.GlobalString(GCModeFlag.Name) == "archive",
-		TrieNodeLimit: eth.DefaultConfig.TrieCache,
-		TrieTimeLimit: eth.DefaultConfig.TrieTimeout,
+		Disabled:            ctx.GlobalString(GCModeFlag.Name) == "archive",
+		TrieNodeLimit:       eth.DefaultConfig.TrieCache,
+		TrieTimeLimit:       eth.DefaultConfig.TrieTimeout,
+		TrieCleanLimit:      eth.DefaultConfig.TrieCleanCache,
+		TrieCleanNoPrefetch: ctx.GlobalBool(CacheNoPrefetchFlag.Name),
 	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cache.TrieNodeLimit = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
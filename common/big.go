@@ -27,4 +27,7 @@ var (
 	Big32  = big.NewInt(32)
 	Big256 = big.NewInt(0xff)
 	Big257 = big.NewInt(257)
+
+	// BigWordBits is the bit width of EVM words, note Big256 is 0xff.
+	BigWordBits = big.NewInt(256)
 )
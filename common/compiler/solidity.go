@@ -32,8 +32,9 @@ import (
 var versionRegexp = regexp.MustCompile(`([0-9]+)\.([0-9]+)\.([0-9]+)`)
 
 type Contract struct {
-	Code string       `json:"code"`
-	Info ContractInfo `json:"info"`
+	Code        string       `json:"code"`
+	RuntimeCode string       `json:"runtime-code"`
+	Info        ContractInfo `json:"info"`
 }
 
 type ContractInfo struct {
@@ -46,6 +47,13 @@ type ContractInfo struct {
 	UserDoc         interface{} `json:"userDoc"`
 	DeveloperDoc    interface{} `json:"developerDoc"`
 	Metadata        string      `json:"metadata"`
+
+	// Source level debug information, see https://solidity.readthedocs.io/en/develop/miscellaneous.html#source-mappings
+	SrcMap        string                 `json:"srcMap"`
+	SrcMapRuntime string                 `json:"srcMapRuntime"`
+	SourceList    []string               `json:"sourceList"`
+	Sources       map[string]string      `json:"sources"`
+	AST           map[string]interface{} `json:"ast"`
 }
 
 // Solidity contains information about the solidity compiler.
@@ -57,20 +65,41 @@ type Solidity struct {
 // --combined-output format
 type solcOutput struct {
 	Contracts map[string]struct {
-		Bin, Abi, Devdoc, Userdoc, Metadata string
+		Bin, Metadata string
+
+		// The abi and docs are JSON encoded strings up to solc 0.7 and raw
+		// JSON values from solc 0.8 on, see decodeSolcJSON.
+		Abi, Devdoc, Userdoc json.RawMessage
+
+		BinRuntime    string `json:"bin-runtime"`
+		SrcMap        string `json:"srcmap"`
+		SrcMapRuntime string `json:"srcmap-runtime"`
 	}
-	Version string
+	Sources map[string]struct {
+		AST interface{}
+	}
+	SourceList []string
+	Version    string
 }
 
 func (s *Solidity) makeArgs() []string {
 	p := []string{
 		"--combined-json", "bin,abi,userdoc,devdoc",
-		"--add-std",  // include standard lib contracts
 		"--optimize", // code optimizer switched on
 	}
 	if s.Major > 0 || s.Minor > 4 || s.Patch > 6 {
 		p[1] += ",metadata"
 	}
+	if s.Major > 0 || s.Minor > 3 {
+		p[1] += ",bin-runtime,srcmap,srcmap-runtime,ast"
+	}
+	if s.Major == 0 && s.Minor < 5 {
+		p = append(p, "--add-std") // include standard lib contracts, dropped in solc 0.5
+	} else {
+		// Newer compilers default to the latest fork, pin the code to byzantium
+		// so it runs on every network supported by the node.
+		p = append(p, "--evm-version", "byzantium")
+	}
 	return p
 }
 
@@ -115,7 +144,7 @@ func CompileSolidityString(solc, source string) (map[string]*Contract, error) {
 	args := append(s.makeArgs(), "--")
 	cmd := exec.Command(s.Path, append(args, "-")...)
 	cmd.Stdin = strings.NewReader(source)
-	return s.run(cmd, source)
+	return s.run(cmd, source, map[string]string{"<stdin>": source})
 }
 
 // CompileSolidity compiles all given Solidity source files.
@@ -123,7 +152,7 @@ func CompileSolidity(solc string, sourcefiles ...string) (map[string]*Contract,
 	if len(sourcefiles) == 0 {
 		return nil, errors.New("solc: no source files")
 	}
-	source, err := slurpFiles(sourcefiles)
+	source, sources, err := slurpFiles(sourcefiles)
 	if err != nil {
 		return nil, err
 	}
@@ -133,10 +162,10 @@ func CompileSolidity(solc string, sourcefiles ...string) (map[string]*Contract,
 	}
 	args := append(s.makeArgs(), "--")
 	cmd := exec.Command(s.Path, append(args, sourcefiles...)...)
-	return s.run(cmd, source)
+	return s.run(cmd, source, sources)
 }
 
-func (s *Solidity) run(cmd *exec.Cmd, source string) (map[string]*Contract, error) {
+func (s *Solidity) run(cmd *exec.Cmd, source string, sources map[string]string) (map[string]*Contract, error) {
 	var stderr, stdout bytes.Buffer
 	cmd.Stderr = &stderr
 	cmd.Stdout = &stdout
@@ -149,23 +178,31 @@ func (s *Solidity) run(cmd *exec.Cmd, source string) (map[string]*Contract, erro
 	}
 
 	// Compilation succeeded, assemble and return the contracts.
+	var ast map[string]interface{}
+	if len(output.Sources) > 0 {
+		ast = make(map[string]interface{})
+		for name, source := range output.Sources {
+			ast[name] = source.AST
+		}
+	}
 	contracts := make(map[string]*Contract)
 	for name, info := range output.Contracts {
 		// Parse the individual compilation results.
 		var abi interface{}
-		if err := json.Unmarshal([]byte(info.Abi), &abi); err != nil {
+		if err := decodeSolcJSON(info.Abi, &abi); err != nil {
 			return nil, fmt.Errorf("solc: error reading abi definition (%v)", err)
 		}
 		var userdoc interface{}
-		if err := json.Unmarshal([]byte(info.Userdoc), &userdoc); err != nil {
+		if err := decodeSolcJSON(info.Userdoc, &userdoc); err != nil {
 			return nil, fmt.Errorf("solc: error reading user doc: %v", err)
 		}
 		var devdoc interface{}
-		if err := json.Unmarshal([]byte(info.Devdoc), &devdoc); err != nil {
+		if err := decodeSolcJSON(info.Devdoc, &devdoc); err != nil {
 			return nil, fmt.Errorf("solc: error reading dev doc: %v", err)
 		}
 		contracts[name] = &Contract{
-			Code: "0x" + info.Bin,
+			Code:        "0x" + info.Bin,
+			RuntimeCode: "0x" + info.BinRuntime,
 			Info: ContractInfo{
 				Source:          source,
 				Language:        "Solidity",
@@ -176,20 +213,41 @@ func (s *Solidity) run(cmd *exec.Cmd, source string) (map[string]*Contract, erro
 				UserDoc:         userdoc,
 				DeveloperDoc:    devdoc,
 				Metadata:        info.Metadata,
+				SrcMap:          info.SrcMap,
+				SrcMapRuntime:   info.SrcMapRuntime,
+				SourceList:      output.SourceList,
+				Sources:         sources,
+				AST:             ast,
 			},
 		}
 	}
 	return contracts, nil
 }
 
-func slurpFiles(files []string) (string, error) {
-	var concat bytes.Buffer
+// decodeSolcJSON unmarshals a combined-json field of the solc output, which
+// older compilers emit as a JSON encoded string instead of a plain JSON value.
+func decodeSolcJSON(field json.RawMessage, v interface{}) error {
+	var encoded string
+	if err := json.Unmarshal(field, &encoded); err == nil {
+		field = json.RawMessage(encoded)
+	}
+	return json.Unmarshal(field, v)
+}
+
+// slurpFiles reads the given source files, returning both their concatenated
+// and individual contents.
+func slurpFiles(files []string) (string, map[string]string, error) {
+	var (
+		concat   bytes.Buffer
+		contents = make(map[string]string)
+	)
 	for _, file := range files {
 		content, err := ioutil.ReadFile(file)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
 		concat.Write(content)
+		contents[file] = string(content)
 	}
-	return concat.String(), nil
+	return concat.String(), contents, nil
 }
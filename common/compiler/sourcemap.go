@@ -0,0 +1,358 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Jump types of instructions in source maps.
+const (
+	JumpRegular byte = '-' // Regular jump within a function, or no jump at all
+	JumpInto    byte = 'i' // Jump into a function
+	JumpOut     byte = 'o' // Jump returning from a function
+)
+
+// libraryPlaceholder matches the placeholders of unlinked library addresses.
+var libraryPlaceholder = regexp.MustCompile("__.{36}__")
+
+// SourceLocation is a position in the Solidity sources of a contract.
+type SourceLocation struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Function string `json:"function,omitempty"`
+}
+
+// String implements fmt.Stringer.
+func (l *SourceLocation) String() string {
+	if l.Function == "" {
+		return fmt.Sprintf("%s:%d:%d", l.File, l.Line, l.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d (%s)", l.File, l.Line, l.Column, l.Function)
+}
+
+// sourceRange is a single decompressed entry of a source map.
+type sourceRange struct {
+	start  int
+	length int
+	file   int
+	jump   byte
+}
+
+// sourceFunction is a function definition found in a source file's AST.
+type sourceFunction struct {
+	start  int
+	length int
+	name   string
+}
+
+// sourceFile is a source file a contract was compiled from.
+type sourceFile struct {
+	name      string
+	lines     []int            // Offsets of the line beginnings
+	functions []sourceFunction // Function definitions, ordered by position
+}
+
+// SourceMap maps the program counters of a contract's bytecode to locations in
+// its Solidity sources.
+type SourceMap struct {
+	ranges []sourceRange  // Source ranges of the instructions
+	pcs    map[uint64]int // Instruction indexes by program counter
+	files  []*sourceFile  // Source files by index in the source list
+}
+
+// NewSourceMap creates the source map of a compiled contract, either of its
+// creation or its runtime code.
+func NewSourceMap(contract *Contract, runtime bool) (*SourceMap, error) {
+	code, srcmap := contract.Code, contract.Info.SrcMap
+	if runtime {
+		code, srcmap = contract.RuntimeCode, contract.Info.SrcMapRuntime
+	}
+	if srcmap == "" {
+		return nil, errors.New("no source map available")
+	}
+	bytecode, err := decodeCode(code)
+	if err != nil {
+		return nil, err
+	}
+	ranges, err := parseSourceMap(srcmap)
+	if err != nil {
+		return nil, err
+	}
+	m := &SourceMap{
+		ranges: ranges,
+		pcs:    make(map[uint64]int),
+		files:  make([]*sourceFile, len(contract.Info.SourceList)),
+	}
+	for pc, index := 0, 0; pc < len(bytecode); pc, index = pc+1, index+1 {
+		m.pcs[uint64(pc)] = index
+		if op := bytecode[pc]; op >= 0x60 && op <= 0x7f { // PUSH1 - PUSH32
+			pc += int(op - 0x5f)
+		}
+	}
+	for i, name := range contract.Info.SourceList {
+		file := &sourceFile{name: name}
+		if source, ok := contract.Info.Sources[name]; ok {
+			file.lines = []int{0}
+			for offset, char := range source {
+				if char == '\n' {
+					file.lines = append(file.lines, offset+1)
+				}
+			}
+		}
+		if ast, ok := contract.Info.AST[name]; ok {
+			collectFunctions(ast, "", &file.functions)
+			sort.Sort(functionsByPosition(file.functions))
+		}
+		m.files[i] = file
+	}
+	return m, nil
+}
+
+// decodeCode decodes hex encoded bytecode, zeroing out the placeholders of any
+// unlinked libraries.
+func decodeCode(code string) ([]byte, error) {
+	code = strings.TrimPrefix(code, "0x")
+	code = libraryPlaceholder.ReplaceAllString(code, strings.Repeat("0", 40))
+	return hex.DecodeString(code)
+}
+
+// parseSourceMap decompresses a source map in the format emitted by solc, where
+// every instruction is described by a "start:length:file:jump" entry, with any
+// field identical to the previous entry's omitted.
+func parseSourceMap(srcmap string) ([]sourceRange, error) {
+	var (
+		ranges []sourceRange
+		last   = sourceRange{jump: JumpRegular}
+	)
+	for i, entry := range strings.Split(srcmap, ";") {
+		for j, field := range strings.Split(entry, ":") {
+			if field == "" {
+				continue
+			}
+			var err error
+			switch j {
+			case 0:
+				last.start, err = strconv.Atoi(field)
+			case 1:
+				last.length, err = strconv.Atoi(field)
+			case 2:
+				last.file, err = strconv.Atoi(field)
+			case 3:
+				if field != "-" && field != "i" && field != "o" {
+					err = fmt.Errorf("unknown jump type %q", field)
+				}
+				last.jump = field[0]
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid source map entry %d: %v", i, err)
+			}
+		}
+		ranges = append(ranges, last)
+	}
+	return ranges, nil
+}
+
+// collectFunctions gathers the function and modifier definitions from either a
+// legacy or a compact solc AST, naming them after their enclosing contracts.
+func collectFunctions(node interface{}, contract string, functions *[]sourceFunction) {
+	obj, ok := node.(map[string]interface{})
+	if !ok {
+		return
+	}
+	// Legacy ASTs store the node type in "name" and its name in the attributes,
+	// compact ones the node type in "nodeType" and its name in "name"
+	kind, _ := obj["nodeType"].(string)
+	name, _ := obj["name"].(string)
+	attrs := obj
+	if kind == "" {
+		kind, name = name, ""
+		if attrs, ok = obj["attributes"].(map[string]interface{}); ok {
+			name, _ = attrs["name"].(string)
+		}
+	}
+	switch kind {
+	case "ContractDefinition":
+		contract = name
+
+	case "FunctionDefinition", "ModifierDefinition":
+		if fnkind, _ := attrs["kind"].(string); fnkind == "constructor" || fnkind == "fallback" {
+			name = fnkind
+		} else if isConstructor, _ := attrs["isConstructor"].(bool); isConstructor {
+			name = "constructor"
+		} else if name == "" {
+			name = "fallback"
+		}
+		if src, ok := obj["src"].(string); ok {
+			parts := strings.Split(src, ":")
+			if len(parts) >= 2 {
+				start, err1 := strconv.Atoi(parts[0])
+				length, err2 := strconv.Atoi(parts[1])
+				if err1 == nil && err2 == nil {
+					if contract != "" {
+						name = contract + "." + name
+					}
+					*functions = append(*functions, sourceFunction{start: start, length: length, name: name})
+				}
+			}
+		}
+	}
+	for _, key := range []string{"children", "nodes"} {
+		if children, ok := obj[key].([]interface{}); ok {
+			for _, child := range children {
+				collectFunctions(child, contract, functions)
+			}
+		}
+	}
+}
+
+type functionsByPosition []sourceFunction
+
+func (s functionsByPosition) Len() int           { return len(s) }
+func (s functionsByPosition) Less(i, j int) bool { return s[i].start < s[j].start }
+func (s functionsByPosition) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// instruction returns the source range of the instruction at the given program
+// counter, if it is mapped to any.
+func (m *SourceMap) instruction(pc uint64) (*sourceRange, bool) {
+	index, ok := m.pcs[pc]
+	if !ok || index >= len(m.ranges) {
+		return nil, false
+	}
+	return &m.ranges[index], true
+}
+
+// Locate returns the source location of the instruction at the given program
+// counter, or nil if it doesn't correspond to any source code, e.g. because it
+// was generated by the compiler.
+func (m *SourceMap) Locate(pc uint64) *SourceLocation {
+	r, ok := m.instruction(pc)
+	if !ok || r.file < 0 || r.file >= len(m.files) {
+		return nil
+	}
+	file := m.files[r.file]
+	loc := &SourceLocation{File: file.name}
+	if len(file.lines) > 0 {
+		line := sort.Search(len(file.lines), func(i int) bool { return file.lines[i] > r.start })
+		loc.Line, loc.Column = line, r.start-file.lines[line-1]+1
+	}
+	// Find the innermost function definition enclosing the range
+	for _, fn := range file.functions {
+		if fn.start > r.start {
+			break
+		}
+		if fn.start+fn.length >= r.start+r.length {
+			loc.Function = fn.name
+		}
+	}
+	return loc
+}
+
+// Jump returns the jump type of the instruction at the given program counter.
+func (m *SourceMap) Jump(pc uint64) byte {
+	if r, ok := m.instruction(pc); ok {
+		return r.jump
+	}
+	return JumpRegular
+}
+
+// SourceTracker follows an EVM execution through the source maps of a set of
+// contracts, maintaining the Solidity level call stack of the execution.
+// Contracts are identified by their code, both for creation and message calls.
+type SourceTracker struct {
+	maps   map[string]*SourceMap // Source maps by contract code
+	frames [][]*SourceLocation   // Internal function call stacks by call depth
+}
+
+// NewSourceTracker creates a tracker following the execution of the given
+// compiled contracts, as returned by the Solidity compiler.
+func NewSourceTracker(contracts map[string]*Contract) *SourceTracker {
+	t := &SourceTracker{maps: make(map[string]*SourceMap)}
+	for _, contract := range contracts {
+		for _, runtime := range []bool{false, true} {
+			code := contract.Code
+			if runtime {
+				code = contract.RuntimeCode
+			}
+			srcmap, err := NewSourceMap(contract, runtime)
+			if err != nil {
+				continue
+			}
+			bytecode, _ := decodeCode(code)
+			t.maps[string(bytecode)] = srcmap
+		}
+	}
+	return t
+}
+
+// Step advances the tracker to the instruction about to be executed at the given
+// (1 based) call depth, returning its source location, if known.
+func (t *SourceTracker) Step(code []byte, pc uint64, depth int) *SourceLocation {
+	// Enter or leave the call frames to reach the current depth
+	for len(t.frames) < depth {
+		t.frames = append(t.frames, []*SourceLocation{nil})
+	}
+	t.frames = t.frames[:depth]
+	if depth == 0 {
+		return nil
+	}
+	frame := t.frames[depth-1]
+
+	srcmap, ok := t.maps[string(code)]
+	if !ok {
+		frame[len(frame)-1] = nil
+		return nil
+	}
+	loc := srcmap.Locate(pc)
+	if loc != nil {
+		frame[len(frame)-1] = loc
+	}
+	// Track the internal function calls, which are plain jumps in the bytecode
+	switch srcmap.Jump(pc) {
+	case JumpInto:
+		frame = append(frame, nil)
+	case JumpOut:
+		if len(frame) > 1 {
+			frame = frame[:len(frame)-1]
+		}
+	}
+	t.frames[depth-1] = frame
+	return loc
+}
+
+// StackTrace returns the Solidity level call stack of the last instruction the
+// tracker was stepped to, innermost call first. Frames of unknown code are
+// omitted.
+func (t *SourceTracker) StackTrace() []*SourceLocation {
+	var trace []*SourceLocation
+	for i := len(t.frames) - 1; i >= 0; i-- {
+		frame := t.frames[i]
+		for j := len(frame) - 1; j >= 0; j-- {
+			if frame[j] != nil {
+				trace = append(trace, frame[j])
+			}
+		}
+	}
+	return trace
+}
@@ -0,0 +1,204 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sourceMapSource = `contract Test {
+    function f() {
+        g();
+    }
+    function g() {
+        revert();
+    }
+}
+`
+
+// src returns the solc source range of a snippet of the test source.
+func src(snippet string) string {
+	return fmt.Sprintf("%d:%d:0", strings.Index(sourceMapSource, snippet), len(snippet))
+}
+
+// sourceMapContract assembles a compiled contract from the test source, with
+// either a legacy or compact AST. Its runtime code is:
+//
+//   0: PUSH1 0x05  (f: g())
+//   2: JUMP        (f: g(), into g)
+//   3: STOP        (compiler generated)
+//   4: INVALID     (compiler generated)
+//   5: JUMPDEST    (g)
+//   6: PUSH1 0x00  (g: revert())
+//   8: DUP1        (g: revert())
+//   9: REVERT      (g: revert())
+func sourceMapContract(compact bool) *Contract {
+	var (
+		contract = sourceMapSource[:len(sourceMapSource)-1]
+		f        = "function f() {\n        g();\n    }"
+		g        = "function g() {\n        revert();\n    }"
+		ast      string
+	)
+	if compact {
+		ast = fmt.Sprintf(`{"nodeType": "SourceUnit", "src": "0:%d:0", "nodes": [
+			{"nodeType": "ContractDefinition", "name": "Test", "src": "%s", "nodes": [
+				{"nodeType": "FunctionDefinition", "name": "f", "kind": "function", "src": "%s"},
+				{"nodeType": "FunctionDefinition", "name": "g", "kind": "function", "src": "%s"}
+			]}
+		]}`, len(sourceMapSource), src(contract), src(f), src(g))
+	} else {
+		ast = fmt.Sprintf(`{"name": "SourceUnit", "src": "0:%d:0", "children": [
+			{"name": "ContractDefinition", "attributes": {"name": "Test"}, "src": "%s", "children": [
+				{"name": "FunctionDefinition", "attributes": {"name": "f", "isConstructor": false}, "src": "%s"},
+				{"name": "FunctionDefinition", "attributes": {"name": "g", "isConstructor": false}, "src": "%s"}
+			]}
+		]}`, len(sourceMapSource), src(contract), src(f), src(g))
+	}
+	var tree interface{}
+	if err := json.Unmarshal([]byte(ast), &tree); err != nil {
+		panic(err)
+	}
+	srcmap := strings.Join([]string{
+		src("g()") + ":-",
+		":::i",
+		"0:0:-1:-",
+		"",
+		src(g),
+		src("revert()"),
+		"",
+		"",
+	}, ";")
+
+	return &Contract{
+		Code:        "0x00",
+		RuntimeCode: "0x60055600fe5b600080fd",
+		Info: ContractInfo{
+			SrcMap:        "0:0:-1:-",
+			SrcMapRuntime: srcmap,
+			SourceList:    []string{"test.sol"},
+			Sources:       map[string]string{"test.sol": sourceMapSource},
+			AST:           map[string]interface{}{"test.sol": tree},
+		},
+	}
+}
+
+// Tests that program counters are correctly resolved to source locations.
+func TestSourceMapLocate(t *testing.T) {
+	for _, compact := range []bool{false, true} {
+		srcmap, err := NewSourceMap(sourceMapContract(compact), true)
+		if err != nil {
+			t.Fatalf("compact %v: failed to create source map: %v", compact, err)
+		}
+		tests := []struct {
+			pc   uint64
+			loc  *SourceLocation
+			jump byte
+		}{
+			{0, &SourceLocation{"test.sol", 3, 9, "Test.f"}, JumpRegular},
+			{2, &SourceLocation{"test.sol", 3, 9, "Test.f"}, JumpInto},
+			{3, nil, JumpRegular},
+			{4, nil, JumpRegular},
+			{5, &SourceLocation{"test.sol", 5, 5, "Test.g"}, JumpRegular},
+			{6, &SourceLocation{"test.sol", 6, 9, "Test.g"}, JumpRegular},
+			{9, &SourceLocation{"test.sol", 6, 9, "Test.g"}, JumpRegular},
+			{1, nil, JumpRegular},   // Push data
+			{100, nil, JumpRegular}, // Out of bounds
+		}
+		for i, tt := range tests {
+			if loc := srcmap.Locate(tt.pc); !reflect.DeepEqual(loc, tt.loc) {
+				t.Errorf("compact %v, test %d: location mismatch: have %v, want %v", compact, i, loc, tt.loc)
+			}
+			if jump := srcmap.Jump(tt.pc); jump != tt.jump {
+				t.Errorf("compact %v, test %d: jump type mismatch: have %c, want %c", compact, i, jump, tt.jump)
+			}
+		}
+	}
+}
+
+// Tests that the tracker maintains the Solidity call stack across internal and
+// external calls.
+func TestSourceTrackerStackTrace(t *testing.T) {
+	contract := sourceMapContract(true)
+	tracker := NewSourceTracker(map[string]*Contract{"test.sol:Test": contract})
+
+	code, _ := decodeCode(contract.RuntimeCode)
+	unknown := []byte{0x00}
+
+	// Execute f, which calls into g, which calls into an unknown contract
+	for _, pc := range []uint64{0, 2, 5, 6, 8} {
+		tracker.Step(code, pc, 1)
+	}
+	tracker.Step(unknown, 0, 2)
+
+	want := []string{"test.sol:6:9 (Test.g)", "test.sol:3:9 (Test.f)"}
+	if trace := stackTraceStrings(tracker.StackTrace()); !reflect.DeepEqual(trace, want) {
+		t.Fatalf("stack trace mismatch: have %v, want %v", trace, want)
+	}
+	// Return from the external call and revert
+	if loc := tracker.Step(code, 9, 1); loc == nil || loc.Function != "Test.g" {
+		t.Fatalf("revert location mismatch: have %v", loc)
+	}
+	if trace := stackTraceStrings(tracker.StackTrace()); !reflect.DeepEqual(trace, want) {
+		t.Fatalf("stack trace mismatch: have %v, want %v", trace, want)
+	}
+}
+
+func stackTraceStrings(trace []*SourceLocation) []string {
+	var strs []string
+	for _, loc := range trace {
+		strs = append(strs, loc.String())
+	}
+	return strs
+}
+
+// Tests that compressed source maps are correctly expanded.
+func TestParseSourceMap(t *testing.T) {
+	ranges, err := parseSourceMap("1:2:0:-;:5;;7::1:i;::-1:o")
+	if err != nil {
+		t.Fatalf("failed to parse source map: %v", err)
+	}
+	want := []sourceRange{
+		{1, 2, 0, JumpRegular},
+		{1, 5, 0, JumpRegular},
+		{1, 5, 0, JumpRegular},
+		{7, 5, 1, JumpInto},
+		{7, 5, -1, JumpOut},
+	}
+	if !reflect.DeepEqual(ranges, want) {
+		t.Fatalf("source map mismatch: have %v, want %v", ranges, want)
+	}
+	if _, err := parseSourceMap("1:2:0:x"); err == nil {
+		t.Fatalf("invalid jump type accepted")
+	}
+}
+
+// Tests that bytecode with unlinked library placeholders can be decoded.
+func TestDecodeUnlinkedCode(t *testing.T) {
+	placeholder := "__test.sol:Lib" + strings.Repeat("_", 26)
+
+	code, err := decodeCode("0x73" + placeholder + "6000")
+	if err != nil {
+		t.Fatalf("failed to decode unlinked code: %v", err)
+	}
+	if len(code) != 23 || code[0] != 0x73 || code[21] != 0x60 {
+		t.Fatalf("decoded code mismatch: %x", code)
+	}
+}
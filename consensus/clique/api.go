@@ -17,6 +17,8 @@
 package clique
 
 import (
+	"fmt"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -117,3 +119,71 @@ func (api *API) Discard(address common.Address) {
 
 	delete(api.clique.proposals, address)
 }
+
+// ConfigProposals returns the current governance proposals the node tries to
+// uphold and vote on.
+func (api *API) ConfigProposals() map[string]uint64 {
+	api.clique.lock.RLock()
+	defer api.clique.lock.RUnlock()
+
+	proposals := make(map[string]uint64)
+	for param, value := range api.clique.configProposals {
+		proposals[param.String()] = value
+	}
+	return proposals
+}
+
+// ProposeConfig injects a new governance proposal to change one of the consensus
+// parameters (period, epoch or maxSigners) that the signer will attempt to push
+// through. Passed changes take effect from the next checkpoint block.
+func (api *API) ProposeConfig(name string, value uint64) error {
+	param, err := parseConfigParam(name)
+	if err != nil {
+		return err
+	}
+	if !validConfigValue(param, value) {
+		return fmt.Errorf("invalid %s value %d", param, value)
+	}
+	api.clique.lock.Lock()
+	defer api.clique.lock.Unlock()
+
+	api.clique.configProposals[param] = value
+	return nil
+}
+
+// DiscardConfig drops a currently running governance proposal, stopping the
+// signer from casting further votes on the parameter.
+func (api *API) DiscardConfig(name string) error {
+	param, err := parseConfigParam(name)
+	if err != nil {
+		return err
+	}
+	api.clique.lock.Lock()
+	defer api.clique.lock.Unlock()
+
+	delete(api.clique.configProposals, param)
+	return nil
+}
+
+// GetSignerStatus retrieves the liveness of the authorized signers over a window
+// of blocks (default 1024) ending with the specified block: the number of blocks
+// each sealed in-turn and out-of-turn, the last one sealed and the in-turn slots
+// missed.
+func (api *API) GetSignerStatus(number *rpc.BlockNumber, window *uint64) (map[common.Address]*SignerStatus, error) {
+	// Retrieve the requested block number (or current if none requested)
+	var header *types.Header
+	if number == nil || *number == rpc.LatestBlockNumber {
+		header = api.chain.CurrentHeader()
+	} else {
+		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	// Ensure we have an actually valid block and return the liveness up to it
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	var blocks uint64
+	if window != nil {
+		blocks = *window
+	}
+	return api.clique.signerStatus(api.chain, header, blocks)
+}
@@ -105,6 +105,14 @@ var (
 	// ones).
 	errInvalidCheckpointSigners = errors.New("invalid signer list on checkpoint block")
 
+	// errInvalidConfigVote is returned if a non-checkpoint block contains a
+	// governance vote on an unknown parameter or with an unacceptable value.
+	errInvalidConfigVote = errors.New("invalid governance vote")
+
+	// errInvalidCheckpointConfig is returned if a checkpoint block doesn't record
+	// exactly the consensus parameter changes scheduled by governance votes.
+	errInvalidCheckpointConfig = errors.New("invalid config record on checkpoint block")
+
 	// errInvalidMixDigest is returned if a block's mix digest is non-zero.
 	errInvalidMixDigest = errors.New("non-zero mix digest")
 
@@ -201,7 +209,8 @@ type Clique struct {
 	recents    *lru.ARCCache // Snapshots for recent block to speed up reorgs
 	signatures *lru.ARCCache // Signatures of recent blocks to speed up mining
 
-	proposals map[common.Address]bool // Current list of proposals we are pushing
+	proposals       map[common.Address]bool // Current list of proposals we are pushing
+	configProposals map[ConfigParam]uint64  // Current list of governance proposals we are pushing
 
 	signer common.Address // Ethereum address of the signing key
 	signFn SignerFn       // Signer function to authorize hashes with
@@ -226,6 +235,8 @@ func New(config *params.CliqueConfig, db ethdb.Database) *Clique {
 		recents:    recents,
 		signatures: signatures,
 		proposals:  make(map[common.Address]bool),
+
+		configProposals: make(map[ConfigParam]uint64),
 	}
 }
 
@@ -275,18 +286,10 @@ func (c *Clique) verifyHeader(chain consensus.ChainReader, header *types.Header,
 	if header.Time.Cmp(big.NewInt(time.Now().Unix())) > 0 {
 		return consensus.ErrFutureBlock
 	}
-	// Checkpoint blocks need to enforce zero beneficiary
-	checkpoint := (number % c.config.Epoch) == 0
-	if checkpoint && header.Coinbase != (common.Address{}) {
-		return errInvalidCheckpointBeneficiary
-	}
-	// Nonces must be 0x00..0 or 0xff..f, zeroes enforced on checkpoints
+	// Nonces must be 0x00..0 or 0xff..f
 	if !bytes.Equal(header.Nonce[:], nonceAuthVote) && !bytes.Equal(header.Nonce[:], nonceDropVote) {
 		return errInvalidVote
 	}
-	if checkpoint && !bytes.Equal(header.Nonce[:], nonceDropVote) {
-		return errInvalidCheckpointVote
-	}
 	// Check that the extra-data contains both the vanity and signature
 	if len(header.Extra) < extraVanity {
 		return errMissingVanity
@@ -294,13 +297,11 @@ func (c *Clique) verifyHeader(chain consensus.ChainReader, header *types.Header,
 	if len(header.Extra) < extraVanity+extraSeal {
 		return errMissingSignature
 	}
-	// Ensure that the extra-data contains a signer list on checkpoint, but none otherwise
-	signersBytes := len(header.Extra) - extraVanity - extraSeal
-	if !checkpoint && signersBytes != 0 {
-		return errExtraSigners
-	}
-	if checkpoint && signersBytes%common.AddressLength != 0 {
-		return errInvalidCheckpointSigners
+	// Checkpoints depend on the epoch in force, only known upfront for genesis
+	if number == 0 {
+		if err := verifyVotingFields(header, true); err != nil {
+			return err
+		}
 	}
 	// Ensure that the mix digest is zero as we don't have fork protection currently
 	if header.MixDigest != (common.Hash{}) {
@@ -324,6 +325,43 @@ func (c *Clique) verifyHeader(chain consensus.ChainReader, header *types.Header,
 	return c.verifyCascadingFields(chain, header, parents)
 }
 
+// verifyVotingFields verifies the header fields whose meaning depends on whether
+// the block is a checkpoint or not: the beneficiary, the nonce and the payload
+// of the extra-data between the vanity and the seal.
+func verifyVotingFields(header *types.Header, checkpoint bool) error {
+	payload := header.Extra[extraVanity : len(header.Extra)-extraSeal]
+	if !checkpoint {
+		// Ensure that the extra-data contains at most a governance vote
+		switch len(payload) {
+		case 0:
+			return nil
+		case extraConfigVote:
+			_, _, err := decodeConfigVote(payload)
+			return err
+		default:
+			return errExtraSigners
+		}
+	}
+	// Checkpoint blocks need to enforce zero beneficiary and nonce
+	if header.Coinbase != (common.Address{}) {
+		return errInvalidCheckpointBeneficiary
+	}
+	if !bytes.Equal(header.Nonce[:], nonceDropVote) {
+		return errInvalidCheckpointVote
+	}
+	// Ensure that the extra-data contains a signer list and an optional config record
+	_, record, err := splitCheckpointExtra(payload)
+	if err != nil {
+		return err
+	}
+	if record != nil {
+		if _, _, _, err := decodeConfigRecord(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // verifyCascadingFields verifies all the header fields that are not standalone,
 // rather depend on a batch of previous headers. The caller may optionally pass
 // in a batch of parents (ascending order) to avoid looking those up from the
@@ -344,24 +382,31 @@ func (c *Clique) verifyCascadingFields(chain consensus.ChainReader, header *type
 	if parent == nil || parent.Number.Uint64() != number-1 || parent.Hash() != header.ParentHash {
 		return consensus.ErrUnknownAncestor
 	}
-	if parent.Time.Uint64()+c.config.Period > header.Time.Uint64() {
-		return ErrInvalidTimestamp
-	}
 	// Retrieve the snapshot needed to verify this header and cache it
 	snap, err := c.snapshot(chain, number-1, header.ParentHash, parents)
 	if err != nil {
 		return err
 	}
-	// If the block is a checkpoint block, verify the signer list
-	if number%c.config.Epoch == 0 {
+	checkpoint := snap.checkpoint(number)
+	if err := verifyVotingFields(header, checkpoint); err != nil {
+		return err
+	}
+	if parent.Time.Uint64()+snap.Period > header.Time.Uint64() {
+		return ErrInvalidTimestamp
+	}
+	// If the block is a checkpoint block, verify the signer list and config record
+	if checkpoint {
 		signers := make([]byte, len(snap.Signers)*common.AddressLength)
 		for i, signer := range snap.signers() {
 			copy(signers[i*common.AddressLength:], signer[:])
 		}
-		extraSuffix := len(header.Extra) - extraSeal
-		if !bytes.Equal(header.Extra[extraVanity:extraSuffix], signers) {
+		have, record, _ := splitCheckpointExtra(header.Extra[extraVanity : len(header.Extra)-extraSeal])
+		if !bytes.Equal(have, signers) {
 			return errInvalidCheckpointSigners
 		}
+		if !bytes.Equal(record, snap.record()) {
+			return errInvalidCheckpointConfig
+		}
 	}
 	// All basic checks passed, verify the seal and return
 	return c.verifySeal(chain, header, parents)
@@ -394,11 +439,16 @@ func (c *Clique) snapshot(chain consensus.ChainReader, number uint64, hash commo
 			if err := c.VerifyHeader(chain, genesis, false); err != nil {
 				return nil, err
 			}
-			signers := make([]common.Address, (len(genesis.Extra)-extraVanity-extraSeal)/common.AddressLength)
+			payload, record, _ := splitCheckpointExtra(genesis.Extra[extraVanity : len(genesis.Extra)-extraSeal])
+
+			signers := make([]common.Address, len(payload)/common.AddressLength)
 			for i := 0; i < len(signers); i++ {
-				copy(signers[i][:], genesis.Extra[extraVanity+i*common.AddressLength:])
+				copy(signers[i][:], payload[i*common.AddressLength:])
 			}
 			snap = newSnapshot(c.config, c.signatures, 0, genesis.Hash(), signers)
+			if record != nil {
+				snap.Period, snap.Epoch, snap.MaxSigners, _ = decodeConfigRecord(record)
+			}
 			if err := snap.store(c.db); err != nil {
 				return nil, err
 			}
@@ -515,7 +565,9 @@ func (c *Clique) Prepare(chain consensus.ChainReader, header *types.Header) erro
 	if err != nil {
 		return err
 	}
-	if number%c.config.Epoch != 0 {
+	checkpoint := snap.checkpoint(number)
+	var vote []byte
+	if !checkpoint {
 		c.lock.RLock()
 
 		// Gather all the proposals that make sense voting on
@@ -534,6 +586,18 @@ func (c *Clique) Prepare(chain consensus.ChainReader, header *types.Header) erro
 				copy(header.Nonce[:], nonceDropVote)
 			}
 		}
+		// Gather all the governance proposals that make sense voting on
+		changes := make([]ConfigParam, 0, len(c.configProposals))
+		for param, value := range c.configProposals {
+			if snap.validConfigVote(param, value) {
+				changes = append(changes, param)
+			}
+		}
+		// If there's pending governance proposals, cast a vote on them
+		if len(changes) > 0 {
+			param := changes[rand.Intn(len(changes))]
+			vote = encodeConfigVote(param, c.configProposals[param])
+		}
 		c.lock.RUnlock()
 	}
 	// Set the correct difficulty
@@ -545,10 +609,13 @@ func (c *Clique) Prepare(chain consensus.ChainReader, header *types.Header) erro
 	}
 	header.Extra = header.Extra[:extraVanity]
 
-	if number%c.config.Epoch == 0 {
+	if checkpoint {
 		for _, signer := range snap.signers() {
 			header.Extra = append(header.Extra, signer[:]...)
 		}
+		header.Extra = append(header.Extra, snap.record()...)
+	} else {
+		header.Extra = append(header.Extra, vote...)
 	}
 	header.Extra = append(header.Extra, make([]byte, extraSeal)...)
 
@@ -560,7 +627,7 @@ func (c *Clique) Prepare(chain consensus.ChainReader, header *types.Header) erro
 	if parent == nil {
 		return consensus.ErrUnknownAncestor
 	}
-	header.Time = new(big.Int).Add(parent.Time, new(big.Int).SetUint64(c.config.Period))
+	header.Time = new(big.Int).Add(parent.Time, new(big.Int).SetUint64(snap.Period))
 	if header.Time.Int64() < time.Now().Unix() {
 		header.Time = big.NewInt(time.Now().Unix())
 	}
@@ -579,7 +646,7 @@ func (c *Clique) Finalize(chain consensus.ChainReader, header *types.Header, sta
 }
 
 // Authorize injects a private key into the consensus engine to mint new blocks
-// with.
+// with. A nil signing function leaves the signing to a remote sealer.
 func (c *Clique) Authorize(signer common.Address, signFn SignerFn) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
@@ -598,10 +665,6 @@ func (c *Clique) Seal(chain consensus.ChainReader, block *types.Block, stop <-ch
 	if number == 0 {
 		return nil, errUnknownBlock
 	}
-	// For 0-period chains, refuse to seal empty blocks (no reward but would spin sealing)
-	if c.config.Period == 0 && len(block.Transactions()) == 0 {
-		return nil, errWaitTransactions
-	}
 	// Don't hold the signer fields for the entire sealing procedure
 	c.lock.RLock()
 	signer, signFn := c.signer, c.signFn
@@ -612,6 +675,10 @@ func (c *Clique) Seal(chain consensus.ChainReader, block *types.Block, stop <-ch
 	if err != nil {
 		return nil, err
 	}
+	// For 0-period chains, refuse to seal empty blocks (no reward but would spin sealing)
+	if snap.Period == 0 && len(block.Transactions()) == 0 {
+		return nil, errWaitTransactions
+	}
 	if _, authorized := snap.Signers[signer]; !authorized {
 		return nil, errUnauthorized
 	}
@@ -626,6 +693,10 @@ func (c *Clique) Seal(chain consensus.ChainReader, block *types.Block, stop <-ch
 			}
 		}
 	}
+	// If the signing key is held remotely, leave the sealing to the remote sealer
+	if signFn == nil {
+		return nil, nil
+	}
 	// Sweet, the protocol permits us to sign the block, wait for our time
 	delay := time.Unix(header.Time.Int64(), 0).Sub(time.Now()) // nolint: gosimple
 	if header.Difficulty.Cmp(diffNoTurn) == 0 {
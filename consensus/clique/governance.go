@@ -0,0 +1,164 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package clique
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	extraConfigVote   = 9  // Extra-data bytes of a governance vote: parameter byte and 8 byte value
+	extraConfigRecord = 24 // Extra-data bytes of a checkpoint config record: period, epoch and max signers
+)
+
+// ConfigParam identifies a consensus parameter that the signers can vote to
+// change through governance votes.
+type ConfigParam uint8
+
+const (
+	ParamPeriod     ConfigParam = iota + 1 // Minimum difference between two consecutive block's timestamps
+	ParamEpoch                             // Number of blocks after which to checkpoint and reset the pending votes
+	ParamMaxSigners                        // Maximum number of authorized signers (0 = unlimited)
+)
+
+// configParamNames maps the governance parameters to their user facing names.
+var configParamNames = map[ConfigParam]string{
+	ParamPeriod:     "period",
+	ParamEpoch:      "epoch",
+	ParamMaxSigners: "maxSigners",
+}
+
+// String implements the stringer interface, returning the user facing name of
+// the parameter.
+func (p ConfigParam) String() string {
+	if name, ok := configParamNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(p))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p ConfigParam) MarshalText() ([]byte, error) {
+	if _, ok := configParamNames[p]; !ok {
+		return nil, fmt.Errorf("unknown clique parameter %d", uint8(p))
+	}
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *ConfigParam) UnmarshalText(input []byte) error {
+	param, err := parseConfigParam(string(input))
+	if err != nil {
+		return err
+	}
+	*p = param
+	return nil
+}
+
+// parseConfigParam converts a user facing parameter name into its identifier.
+func parseConfigParam(name string) (ConfigParam, error) {
+	for param, known := range configParamNames {
+		if known == name {
+			return param, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown clique parameter %q", name)
+}
+
+// validConfigValue returns whether a value is acceptable for the given parameter.
+func validConfigValue(param ConfigParam, value uint64) bool {
+	switch param {
+	case ParamPeriod, ParamMaxSigners:
+		return true
+	case ParamEpoch:
+		return value > 0
+	default:
+		return false
+	}
+}
+
+// ConfigVote represents a single vote that an authorized signer made to change
+// one of the consensus parameters. Pending votes are discarded at checkpoints.
+type ConfigVote struct {
+	Signer common.Address `json:"signer"` // Authorized signer that cast this vote
+	Param  ConfigParam    `json:"param"`  // Consensus parameter being voted on
+	Value  uint64         `json:"value"`  // New value proposed for the parameter
+}
+
+// encodeConfigVote serializes a governance vote into its extra-data form.
+func encodeConfigVote(param ConfigParam, value uint64) []byte {
+	blob := make([]byte, extraConfigVote)
+	blob[0] = byte(param)
+	binary.BigEndian.PutUint64(blob[1:], value)
+	return blob
+}
+
+// decodeConfigVote parses a governance vote from its extra-data form.
+func decodeConfigVote(blob []byte) (ConfigParam, uint64, error) {
+	if len(blob) != extraConfigVote {
+		return 0, 0, errInvalidConfigVote
+	}
+	param, value := ConfigParam(blob[0]), binary.BigEndian.Uint64(blob[1:])
+	if !validConfigValue(param, value) {
+		return 0, 0, errInvalidConfigVote
+	}
+	return param, value, nil
+}
+
+// encodeConfigRecord serializes the consensus parameters into the form they are
+// recorded in within checkpoint extra-data.
+func encodeConfigRecord(period, epoch, maxSigners uint64) []byte {
+	blob := make([]byte, extraConfigRecord)
+	binary.BigEndian.PutUint64(blob[0:], period)
+	binary.BigEndian.PutUint64(blob[8:], epoch)
+	binary.BigEndian.PutUint64(blob[16:], maxSigners)
+	return blob
+}
+
+// decodeConfigRecord parses the consensus parameters from a checkpoint record.
+func decodeConfigRecord(blob []byte) (period, epoch, maxSigners uint64, err error) {
+	if len(blob) != extraConfigRecord {
+		return 0, 0, 0, errInvalidCheckpointConfig
+	}
+	period = binary.BigEndian.Uint64(blob[0:])
+	epoch = binary.BigEndian.Uint64(blob[8:])
+	maxSigners = binary.BigEndian.Uint64(blob[16:])
+
+	if !validConfigValue(ParamEpoch, epoch) {
+		return 0, 0, 0, errInvalidCheckpointConfig
+	}
+	return period, epoch, maxSigners, nil
+}
+
+// splitCheckpointExtra splits the payload of a checkpoint header's extra-data
+// (i.e. without vanity and seal) into the signer list and the optional config
+// record. As the record is not a multiple of the address length, its presence
+// can be detected unambiguously.
+func splitCheckpointExtra(payload []byte) (signers []byte, record []byte, err error) {
+	switch {
+	case len(payload)%common.AddressLength == 0:
+		return payload, nil, nil
+	case len(payload) >= extraConfigRecord && (len(payload)-extraConfigRecord)%common.AddressLength == 0:
+		split := len(payload) - extraConfigRecord
+		return payload[:split], payload[split:], nil
+	default:
+		return nil, nil, errInvalidCheckpointSigners
+	}
+}
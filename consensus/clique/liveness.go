@@ -0,0 +1,178 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package clique
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	signerStatusWindow    = 1024  // Default number of blocks to report signer liveness over
+	maxSignerStatusWindow = 65536 // Maximum number of blocks to report signer liveness over
+)
+
+// SignerStatus is the liveness report of a single signer over a window of blocks.
+type SignerStatus struct {
+	Sealed      uint64  `json:"sealed"`      // Number of blocks sealed within the window
+	InTurn      uint64  `json:"inTurn"`      // Number of sealed blocks that were in-turn
+	OutOfTurn   uint64  `json:"outOfTurn"`   // Number of sealed blocks that were out-of-turn
+	InTurnRatio float64 `json:"inTurnRatio"` // Ratio of in-turn blocks among the sealed ones
+	LastSealed  uint64  `json:"lastSealed"`  // Number of the last block sealed (0 if unknown)
+	Missed      uint64  `json:"missed"`      // Number of in-turn slots sealed by someone else
+}
+
+// recentlySigned returns whether the signer is among the recent ones that are
+// not permitted to seal the given block.
+func (s *Snapshot) recentlySigned(number uint64, signer common.Address) bool {
+	for seen, recent := range s.Recents {
+		if recent == signer {
+			if limit := uint64(len(s.Signers)/2 + 1); number < limit || seen > number-limit {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inturnSigner returns the signer whose turn it is to seal the given block.
+func (s *Snapshot) inturnSigner(number uint64) common.Address {
+	signers := s.signers()
+	if len(signers) == 0 {
+		return common.Address{}
+	}
+	return signers[number%uint64(len(signers))]
+}
+
+// signerStatus computes the liveness of the signers over the window of blocks
+// ending with head. A block with an out-of-turn difficulty counts as a missed
+// slot for the in-turn signer, unless the recents list forbade it to seal.
+func (c *Clique) signerStatus(chain consensus.ChainReader, head *types.Header, window uint64) (map[common.Address]*SignerStatus, error) {
+	if window == 0 {
+		window = signerStatusWindow
+	}
+	if window > maxSignerStatusWindow {
+		return nil, fmt.Errorf("window too large: have %d, max %d", window, maxSignerStatusWindow)
+	}
+	if number := head.Number.Uint64(); window > number {
+		window = number
+	}
+	// Gather the headers within the window in ascending order
+	headers := make([]*types.Header, window)
+	for i, header := int(window)-1, head; i >= 0; i-- {
+		headers[i] = header
+		if i > 0 {
+			if header = chain.GetHeader(header.ParentHash, header.Number.Uint64()-1); header == nil {
+				return nil, consensus.ErrUnknownAncestor
+			}
+		}
+	}
+	// Retrieve the snapshot preceding the window and seed the last sealed blocks
+	parentHash := head.Hash()
+	if window > 0 {
+		parentHash = headers[0].ParentHash
+	}
+	snap, err := c.snapshot(chain, head.Number.Uint64()-window, parentHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	status := make(map[common.Address]*SignerStatus)
+	for signer := range snap.Signers {
+		status[signer] = new(SignerStatus)
+	}
+	for number, signer := range snap.Recents {
+		if stat, ok := status[signer]; ok && number > stat.LastSealed {
+			stat.LastSealed = number
+		}
+	}
+	// Replay the headers one by one, crediting the sealers and blaming the absents
+	for _, header := range headers {
+		number := header.Number.Uint64()
+
+		signer, err := ecrecover(header, c.signatures)
+		if err != nil {
+			return nil, err
+		}
+		stat, ok := status[signer]
+		if !ok {
+			stat = new(SignerStatus)
+			status[signer] = stat
+		}
+		stat.Sealed++
+		stat.LastSealed = number
+
+		if header.Difficulty.Cmp(diffInTurn) == 0 {
+			stat.InTurn++
+		} else {
+			stat.OutOfTurn++
+			if inturn := snap.inturnSigner(number); inturn != signer && !snap.recentlySigned(number, inturn) {
+				if absent, ok := status[inturn]; ok {
+					absent.Missed++
+				}
+			}
+		}
+		if snap, err = snap.apply([]*types.Header{header}); err != nil {
+			return nil, err
+		}
+	}
+	for _, stat := range status {
+		if stat.Sealed > 0 {
+			stat.InTurnRatio = float64(stat.InTurn) / float64(stat.Sealed)
+		}
+	}
+	return status, nil
+}
+
+// MarkInserted feeds the liveness of the signers into the metrics system for a
+// block inserted into the local chain. Contrary to seal verification, which may
+// run multiple times for the same block (e.g. for its header and body), this is
+// meant to be called exactly once per block.
+func (c *Clique) MarkInserted(chain consensus.ChainReader, header *types.Header) {
+	number := header.Number.Uint64()
+	if !metrics.Enabled || number == 0 {
+		return
+	}
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return
+	}
+	signer, err := ecrecover(header, c.signatures)
+	if err != nil {
+		return
+	}
+	markSignerMetrics(snap, number, signer, snap.inturn(number, signer))
+}
+
+// markSignerMetrics feeds the liveness of the signers into the metrics system,
+// recording the sealer of an inserted block and the in-turn signer it replaced.
+func markSignerMetrics(snap *Snapshot, number uint64, signer common.Address, inturn bool) {
+	if !metrics.Enabled {
+		return
+	}
+	if inturn {
+		metrics.NewMeter(fmt.Sprintf("clique/signers/%x/inturn", signer)).Mark(1)
+		return
+	}
+	metrics.NewMeter(fmt.Sprintf("clique/signers/%x/outofturn", signer)).Mark(1)
+	if absent := snap.inturnSigner(number); absent != signer && !snap.recentlySigned(number, absent) {
+		metrics.NewMeter(fmt.Sprintf("clique/signers/%x/missed", absent)).Mark(1)
+	}
+}
@@ -54,6 +54,12 @@ type Snapshot struct {
 	Recents map[uint64]common.Address   `json:"recents"` // Set of recent signers for spam protections
 	Votes   []*Vote                     `json:"votes"`   // List of votes cast in chronological order
 	Tally   map[common.Address]Tally    `json:"tally"`   // Current vote tally to avoid recalculating
+
+	Period      uint64                 `json:"period"`      // Minimum block period currently in force
+	Epoch       uint64                 `json:"epoch"`       // Checkpoint interval currently in force
+	MaxSigners  uint64                 `json:"maxSigners"`  // Maximum number of signers currently in force (0 = unlimited)
+	ConfigVotes []*ConfigVote          `json:"configVotes"` // List of governance votes cast in chronological order
+	Scheduled   map[ConfigParam]uint64 `json:"scheduled"`   // Parameter changes passed, taking effect at the next checkpoint
 }
 
 // newSnapshot creates a new snapshot with the specified startup parameters. This
@@ -68,6 +74,11 @@ func newSnapshot(config *params.CliqueConfig, sigcache *lru.ARCCache, number uin
 		Signers:  make(map[common.Address]struct{}),
 		Recents:  make(map[uint64]common.Address),
 		Tally:    make(map[common.Address]Tally),
+
+		Period:     config.Period,
+		Epoch:      config.Epoch,
+		MaxSigners: config.MaxSigners,
+		Scheduled:  make(map[ConfigParam]uint64),
 	}
 	for _, signer := range signers {
 		snap.Signers[signer] = struct{}{}
@@ -88,6 +99,14 @@ func loadSnapshot(config *params.CliqueConfig, sigcache *lru.ARCCache, db ethdb.
 	snap.config = config
 	snap.sigcache = sigcache
 
+	// Snapshots stored before governance votes existed lack the parameters in
+	// force, which at that point could only have been the configured ones
+	if snap.Epoch == 0 {
+		snap.Period, snap.Epoch, snap.MaxSigners = config.Period, config.Epoch, config.MaxSigners
+	}
+	if snap.Scheduled == nil {
+		snap.Scheduled = make(map[ConfigParam]uint64)
+	}
 	return snap, nil
 }
 
@@ -111,6 +130,12 @@ func (s *Snapshot) copy() *Snapshot {
 		Recents:  make(map[uint64]common.Address),
 		Votes:    make([]*Vote, len(s.Votes)),
 		Tally:    make(map[common.Address]Tally),
+
+		Period:      s.Period,
+		Epoch:       s.Epoch,
+		MaxSigners:  s.MaxSigners,
+		ConfigVotes: make([]*ConfigVote, len(s.ConfigVotes)),
+		Scheduled:   make(map[ConfigParam]uint64),
 	}
 	for signer := range s.Signers {
 		cpy.Signers[signer] = struct{}{}
@@ -123,14 +148,75 @@ func (s *Snapshot) copy() *Snapshot {
 	}
 	copy(cpy.Votes, s.Votes)
 
+	for param, value := range s.Scheduled {
+		cpy.Scheduled[param] = value
+	}
+	copy(cpy.ConfigVotes, s.ConfigVotes)
+
 	return cpy
 }
 
+// checkpoint returns whether the given block number is a checkpoint according
+// to the epoch length in force.
+func (s *Snapshot) checkpoint(number uint64) bool {
+	return number%s.Epoch == 0
+}
+
 // validVote returns whether it makes sense to cast the specified vote in the
-// given snapshot context (e.g. don't try to add an already authorized signer).
+// given snapshot context (e.g. don't try to add an already authorized signer,
+// or one beyond the maximum signer count).
 func (s *Snapshot) validVote(address common.Address, authorize bool) bool {
 	_, signer := s.Signers[address]
-	return (signer && !authorize) || (!signer && authorize)
+	return (signer && !authorize) || (!signer && authorize && !s.full())
+}
+
+// full returns whether the signer list reached the maximum signer count.
+func (s *Snapshot) full() bool {
+	return s.MaxSigners != 0 && uint64(len(s.Signers)) >= s.MaxSigners
+}
+
+// configValue returns the value of a consensus parameter currently in force.
+func (s *Snapshot) configValue(param ConfigParam) uint64 {
+	switch param {
+	case ParamPeriod:
+		return s.Period
+	case ParamEpoch:
+		return s.Epoch
+	default:
+		return s.MaxSigners
+	}
+}
+
+// validConfigVote returns whether it makes sense to cast the specified governance
+// vote in the given snapshot context (e.g. don't try to set the current value).
+func (s *Snapshot) validConfigVote(param ConfigParam, value uint64) bool {
+	if !validConfigValue(param, value) {
+		return false
+	}
+	if scheduled, ok := s.Scheduled[param]; ok {
+		return scheduled != value
+	}
+	return s.configValue(param) != value
+}
+
+// record returns the config record the next checkpoint header must contain in
+// its extra-data, or nil if no parameter changes are scheduled.
+func (s *Snapshot) record() []byte {
+	if len(s.Scheduled) == 0 {
+		return nil
+	}
+	period, epoch, maxSigners := s.Period, s.Epoch, s.MaxSigners
+	for param, value := range s.Scheduled {
+		switch param {
+		case ParamPeriod:
+			period = value
+		case ParamEpoch:
+			epoch = value
+		case ParamMaxSigners:
+			maxSigners = value
+		}
+	}
+	return encodeConfigRecord(period, epoch, maxSigners)
 }
 
 // cast adds a new vote into the tally.
@@ -192,9 +278,17 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 	for _, header := range headers {
 		// Remove any votes on checkpoint blocks
 		number := header.Number.Uint64()
-		if number%s.config.Epoch == 0 {
+		if snap.checkpoint(number) {
 			snap.Votes = nil
 			snap.Tally = make(map[common.Address]Tally)
+			snap.ConfigVotes = nil
+
+			// Parameter changes scheduled by governance take effect from the
+			// checkpoint onwards (the checkpoint header itself records them)
+			if record := snap.record(); record != nil {
+				snap.Period, snap.Epoch, snap.MaxSigners, _ = decodeConfigRecord(record)
+				snap.Scheduled = make(map[ConfigParam]uint64)
+			}
 		}
 		// Delete the oldest signer from the recent list to allow it signing again
 		if limit := uint64(len(snap.Signers)/2 + 1); number >= limit {
@@ -247,7 +341,11 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 		// If the vote passed, update the list of signers
 		if tally := snap.Tally[header.Coinbase]; tally.Votes > len(snap.Signers)/2 {
 			if tally.Authorize {
-				snap.Signers[header.Coinbase] = struct{}{}
+				// Other authorizations might have filled the signer list since
+				// this vote was cast, in which case the proposal is dropped
+				if !snap.full() {
+					snap.Signers[header.Coinbase] = struct{}{}
+				}
 			} else {
 				delete(snap.Signers, header.Coinbase)
 
@@ -267,6 +365,12 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 						i--
 					}
 				}
+				for i := 0; i < len(snap.ConfigVotes); i++ {
+					if snap.ConfigVotes[i].Signer == header.Coinbase {
+						snap.ConfigVotes = append(snap.ConfigVotes[:i], snap.ConfigVotes[i+1:]...)
+						i--
+					}
+				}
 			}
 			// Discard any previous votes around the just changed account
 			for i := 0; i < len(snap.Votes); i++ {
@@ -277,6 +381,14 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 			}
 			delete(snap.Tally, header.Coinbase)
 		}
+		// Tally up any governance vote carried in the extra-data
+		if payload := header.Extra[extraVanity : len(header.Extra)-extraSeal]; !snap.checkpoint(number) && len(payload) > 0 {
+			param, value, err := decodeConfigVote(payload)
+			if err != nil {
+				return nil, err
+			}
+			snap.castConfig(signer, param, value)
+		}
 	}
 	snap.Number += uint64(len(headers))
 	snap.Hash = headers[len(headers)-1].Hash()
@@ -284,6 +396,43 @@ func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
 	return snap, nil
 }
 
+// castConfig adds a new governance vote, replacing any previous one by the same
+// signer on the same parameter, and schedules the change once it reaches a
+// majority of the signers.
+func (s *Snapshot) castConfig(signer common.Address, param ConfigParam, value uint64) {
+	// Discard any previous vote from the signer on the same parameter
+	for i, vote := range s.ConfigVotes {
+		if vote.Signer == signer && vote.Param == param {
+			s.ConfigVotes = append(s.ConfigVotes[:i], s.ConfigVotes[i+1:]...)
+			break // only one vote allowed
+		}
+	}
+	if !s.validConfigVote(param, value) {
+		return
+	}
+	s.ConfigVotes = append(s.ConfigVotes, &ConfigVote{
+		Signer: signer,
+		Param:  param,
+		Value:  value,
+	})
+	// If the vote passed, schedule the change and discard the votes around it
+	votes := 0
+	for _, vote := range s.ConfigVotes {
+		if vote.Param == param && vote.Value == value {
+			votes++
+		}
+	}
+	if votes > len(s.Signers)/2 {
+		s.Scheduled[param] = value
+		for i := 0; i < len(s.ConfigVotes); i++ {
+			if s.ConfigVotes[i].Param == param {
+				s.ConfigVotes = append(s.ConfigVotes[:i], s.ConfigVotes[i+1:]...)
+				i--
+			}
+		}
+	}
+}
+
 // signers retrieves the list of authorized signers in ascending order.
 func (s *Snapshot) signers() []common.Address {
 	signers := make([]common.Address, 0, len(s.Signers))
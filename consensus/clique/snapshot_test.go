@@ -19,7 +19,9 @@ package clique
 import (
 	"bytes"
 	"crypto/ecdsa"
+	"fmt"
 	"math/big"
+	"reflect"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -27,6 +29,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/params"
 )
 
@@ -403,3 +406,357 @@ func TestVoting(t *testing.T) {
 		}
 	}
 }
+
+type testerConfigVote struct {
+	signer string
+	param  ConfigParam
+	value  uint64
+	voted  string
+	auth   bool
+}
+
+// Tests that governance votes on the consensus parameters are tallied correctly
+// and only take effect from the checkpoint following their passing.
+func TestConfigVoting(t *testing.T) {
+	tests := []struct {
+		period      uint64
+		epoch       uint64
+		maxSigners  uint64
+		signers     []string
+		votes       []testerConfigVote
+		results     []string
+		period2     uint64
+		epoch2      uint64
+		maxSigners2 uint64
+		scheduled   map[ConfigParam]uint64
+	}{
+		{
+			// Majority votes on a new period, scheduled but not applied before the checkpoint
+			period:  15,
+			epoch:   100,
+			signers: []string{"A", "B", "C"},
+			votes: []testerConfigVote{
+				{signer: "A", param: ParamPeriod, value: 5},
+				{signer: "B", param: ParamPeriod, value: 5},
+			},
+			results:   []string{"A", "B", "C"},
+			period2:   15,
+			epoch2:    100,
+			scheduled: map[ConfigParam]uint64{ParamPeriod: 5},
+		}, {
+			// Majority votes on a new period, applied at the checkpoint
+			period:  15,
+			epoch:   6,
+			signers: []string{"A", "B", "C"},
+			votes: []testerConfigVote{
+				{signer: "A", param: ParamPeriod, value: 5},
+				{signer: "B", param: ParamPeriod, value: 5},
+				{signer: "C"},
+				{signer: "A"},
+				{signer: "B"},
+				{signer: "C"},
+			},
+			results:   []string{"A", "B", "C"},
+			period2:   5,
+			epoch2:    6,
+			scheduled: map[ConfigParam]uint64{},
+		}, {
+			// Minority vote on a new period, dropped at the checkpoint
+			period:  15,
+			epoch:   4,
+			signers: []string{"A", "B", "C"},
+			votes: []testerConfigVote{
+				{signer: "A", param: ParamPeriod, value: 5},
+				{signer: "B"},
+				{signer: "C"},
+				{signer: "A"},
+				{signer: "B", param: ParamPeriod, value: 5},
+			},
+			results:   []string{"A", "B", "C"},
+			period2:   15,
+			epoch2:    4,
+			scheduled: map[ConfigParam]uint64{},
+		}, {
+			// Changed vote from a signer replaces its previous one
+			period:  15,
+			epoch:   100,
+			signers: []string{"A", "B", "C"},
+			votes: []testerConfigVote{
+				{signer: "A", param: ParamPeriod, value: 5},
+				{signer: "B"},
+				{signer: "A", param: ParamPeriod, value: 7},
+				{signer: "B", param: ParamPeriod, value: 5},
+			},
+			results:   []string{"A", "B", "C"},
+			period2:   15,
+			epoch2:    100,
+			scheduled: map[ConfigParam]uint64{},
+		}, {
+			// New epoch length applied at the checkpoint, moving the next one
+			period:  15,
+			epoch:   4,
+			signers: []string{"A", "B"},
+			votes: []testerConfigVote{
+				{signer: "A", param: ParamEpoch, value: 3},
+				{signer: "B", param: ParamEpoch, value: 3},
+				{signer: "A"},
+				{signer: "B"},
+				{signer: "A", voted: "C", auth: true},
+				{signer: "B"}, // checkpoint under the new epoch, resets the vote on C
+				{signer: "A"},
+				{signer: "B", voted: "C", auth: true},
+			},
+			results:   []string{"A", "B"},
+			period2:   15,
+			epoch2:    3,
+			scheduled: map[ConfigParam]uint64{},
+		}, {
+			// Full signer list rejects further authorizations
+			period:     15,
+			epoch:      100,
+			maxSigners: 2,
+			signers:    []string{"A", "B"},
+			votes: []testerConfigVote{
+				{signer: "A", voted: "C", auth: true},
+				{signer: "B", voted: "C", auth: true},
+			},
+			results:     []string{"A", "B"},
+			period2:     15,
+			epoch2:      100,
+			maxSigners2: 2,
+			scheduled:   map[ConfigParam]uint64{},
+		}, {
+			// Raised signer cap applied at the checkpoint allows further authorizations
+			period:     15,
+			epoch:      3,
+			maxSigners: 2,
+			signers:    []string{"A", "B"},
+			votes: []testerConfigVote{
+				{signer: "A", param: ParamMaxSigners, value: 3},
+				{signer: "B", param: ParamMaxSigners, value: 3},
+				{signer: "A"},
+				{signer: "B", voted: "C", auth: true},
+				{signer: "A", voted: "C", auth: true},
+			},
+			results:     []string{"A", "B", "C"},
+			period2:     15,
+			epoch2:      3,
+			maxSigners2: 3,
+			scheduled:   map[ConfigParam]uint64{},
+		},
+	}
+	for i, tt := range tests {
+		// Create the account pool and the genesis block with the initial set of signers
+		accounts := newTesterAccountPool()
+
+		signers := make([]common.Address, len(tt.signers))
+		for j, signer := range tt.signers {
+			signers[j] = accounts.address(signer)
+		}
+		genesis := &core.Genesis{
+			ExtraData: make([]byte, extraVanity+common.AddressLength*len(signers)+extraSeal),
+		}
+		for j, signer := range signers {
+			copy(genesis.ExtraData[extraVanity+j*common.AddressLength:], signer[:])
+		}
+		db, _ := ethdb.NewMemDatabase()
+		genesis.Commit(db)
+
+		// Assemble a chain of headers from the cast votes
+		headers := make([]*types.Header, len(tt.votes))
+		for j, vote := range tt.votes {
+			headers[j] = &types.Header{
+				Number: big.NewInt(int64(j) + 1),
+				Time:   big.NewInt(int64(j) * int64(tt.period)),
+				Extra:  make([]byte, extraVanity),
+			}
+			if vote.voted != "" {
+				headers[j].Coinbase = accounts.address(vote.voted)
+			}
+			if vote.param != 0 {
+				headers[j].Extra = append(headers[j].Extra, encodeConfigVote(vote.param, vote.value)...)
+			}
+			headers[j].Extra = append(headers[j].Extra, make([]byte, extraSeal)...)
+			if j > 0 {
+				headers[j].ParentHash = headers[j-1].Hash()
+			}
+			if vote.auth {
+				copy(headers[j].Nonce[:], nonceAuthVote)
+			}
+			accounts.sign(headers[j], vote.signer)
+		}
+		// Pass all the headers through clique and ensure tallying succeeds
+		head := headers[len(headers)-1]
+
+		config := &params.CliqueConfig{Period: tt.period, Epoch: tt.epoch, MaxSigners: tt.maxSigners}
+		snap, err := New(config, db).snapshot(&testerChainReader{db: db}, head.Number.Uint64(), head.Hash(), headers)
+		if err != nil {
+			t.Errorf("test %d: failed to create voting snapshot: %v", i, err)
+			continue
+		}
+		if snap.Period != tt.period2 || snap.Epoch != tt.epoch2 || snap.MaxSigners != tt.maxSigners2 {
+			t.Errorf("test %d: parameter mismatch: have period %d epoch %d max %d, want period %d epoch %d max %d",
+				i, snap.Period, snap.Epoch, snap.MaxSigners, tt.period2, tt.epoch2, tt.maxSigners2)
+		}
+		if !reflect.DeepEqual(snap.Scheduled, tt.scheduled) {
+			t.Errorf("test %d: scheduled changes mismatch: have %v, want %v", i, snap.Scheduled, tt.scheduled)
+		}
+		if len(snap.Signers) != len(tt.results) {
+			t.Errorf("test %d: signer count mismatch: have %d, want %d", i, len(snap.Signers), len(tt.results))
+		}
+		for _, signer := range tt.results {
+			if _, ok := snap.Signers[accounts.address(signer)]; !ok {
+				t.Errorf("test %d: signer %s missing", i, signer)
+			}
+		}
+	}
+}
+
+// Tests that checkpoint extra-data is split into the signer list and the config
+// record unambiguously.
+func TestSplitCheckpointExtra(t *testing.T) {
+	record := encodeConfigRecord(5, 100, 7)
+	for n := 0; n < 4; n++ {
+		signers := make([]byte, n*common.AddressLength)
+
+		have, rec, err := splitCheckpointExtra(signers)
+		if err != nil || len(have) != len(signers) || rec != nil {
+			t.Errorf("%d signers, no record: have %d signer bytes, record %x, err %v", n, len(have), rec, err)
+		}
+		have, rec, err = splitCheckpointExtra(append(signers, record...))
+		if err != nil || len(have) != len(signers) || !bytes.Equal(rec, record) {
+			t.Errorf("%d signers, with record: have %d signer bytes, record %x, err %v", n, len(have), rec, err)
+		}
+	}
+	if _, _, err := splitCheckpointExtra(make([]byte, common.AddressLength+1)); err != errInvalidCheckpointSigners {
+		t.Errorf("malformed payload: have error %v, want %v", err, errInvalidCheckpointSigners)
+	}
+	period, epoch, maxSigners, err := decodeConfigRecord(record)
+	if err != nil || period != 5 || epoch != 100 || maxSigners != 7 {
+		t.Errorf("record roundtrip: have %d/%d/%d, err %v", period, epoch, maxSigners, err)
+	}
+	if _, _, _, err := decodeConfigRecord(encodeConfigRecord(5, 0, 0)); err != errInvalidCheckpointConfig {
+		t.Errorf("zero epoch record: have error %v, want %v", err, errInvalidCheckpointConfig)
+	}
+}
+
+// testerHeaderReader extends testerChainReader with a set of non-canonical
+// headers retrievable by hash.
+type testerHeaderReader struct {
+	testerChainReader
+	headers map[common.Hash]*types.Header
+}
+
+func (r *testerHeaderReader) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header, ok := r.headers[hash]; ok {
+		return header
+	}
+	if number == 0 {
+		return r.GetHeaderByNumber(0)
+	}
+	return nil
+}
+
+// Tests that signer liveness is credited to the sealers and missed in-turn slots
+// blamed on absent signers, unless the recents list forbade them to seal. The
+// liveness metrics should only be updated on block insertion, not when blocks
+// are (possibly repeatedly) verified.
+func TestSignerStatus(t *testing.T) {
+	accounts := newTesterAccountPool()
+
+	signers := []common.Address{accounts.address("A"), accounts.address("B"), accounts.address("C")}
+	genesis := &core.Genesis{
+		ExtraData: make([]byte, extraVanity+common.AddressLength*len(signers)+extraSeal),
+	}
+	for j, signer := range signers {
+		copy(genesis.ExtraData[extraVanity+j*common.AddressLength:], signer[:])
+	}
+	db, _ := ethdb.NewMemDatabase()
+	block := genesis.MustCommit(db)
+
+	engine := New(&params.CliqueConfig{Epoch: 30000}, db)
+	reader := &testerHeaderReader{testerChainReader{db: db}, make(map[common.Hash]*types.Header)}
+
+	snap, err := engine.snapshot(reader, 0, block.Hash(), nil)
+	if err != nil {
+		t.Fatalf("failed to create genesis snapshot: %v", err)
+	}
+	sorted := snap.signers()
+
+	// Block 1 is sealed in-turn, block 2 out-of-turn while its in-turn signer is
+	// absent, block 3 out-of-turn as its in-turn signer has just sealed block 2
+	names := make(map[common.Address]string)
+	for _, name := range []string{"A", "B", "C"} {
+		names[accounts.address(name)] = name
+	}
+	sealers := []common.Address{sorted[1], sorted[0], sorted[2]}
+	difficulties := []*big.Int{diffInTurn, diffNoTurn, diffNoTurn}
+
+	var (
+		parent  = block.Header()
+		headers []*types.Header
+	)
+	for i, sealer := range sealers {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     big.NewInt(int64(i) + 1),
+			Time:       big.NewInt(int64(i) + 1),
+			Difficulty: difficulties[i],
+			Extra:      make([]byte, extraVanity+extraSeal),
+		}
+		accounts.sign(header, names[sealer])
+		reader.headers[header.Hash()] = header
+		headers = append(headers, header)
+		parent = header
+	}
+	status, err := engine.signerStatus(reader, parent, 0)
+	if err != nil {
+		t.Fatalf("failed to compute signer status: %v", err)
+	}
+	want := map[common.Address]SignerStatus{
+		sorted[0]: {Sealed: 1, OutOfTurn: 1, LastSealed: 2},
+		sorted[1]: {Sealed: 1, InTurn: 1, InTurnRatio: 1, LastSealed: 1},
+		sorted[2]: {Sealed: 1, OutOfTurn: 1, LastSealed: 3, Missed: 1},
+	}
+	if len(status) != len(want) {
+		t.Fatalf("signer count mismatch: have %d, want %d", len(status), len(want))
+	}
+	for signer, stat := range want {
+		if have := status[signer]; have == nil || *have != stat {
+			t.Errorf("signer %s: status mismatch: have %+v, want %+v", names[signer], have, stat)
+		}
+	}
+	// Verify all the blocks repeatedly, which should not touch the metrics
+	defer func(enabled bool) { metrics.Enabled = enabled }(metrics.Enabled)
+	metrics.Enabled = true
+
+	meter := func(signer common.Address, kind string) int64 {
+		return metrics.NewMeter(fmt.Sprintf("clique/signers/%x/%s", signer, kind)).Count()
+	}
+	for i := 0; i < 2; i++ {
+		for j, header := range headers {
+			if err := engine.VerifySeal(reader, header); err != nil {
+				t.Fatalf("block %d: failed to verify seal: %v", j+1, err)
+			}
+		}
+	}
+	for _, signer := range sorted {
+		for _, kind := range []string{"inturn", "outofturn", "missed"} {
+			if count := meter(signer, kind); count != 0 {
+				t.Errorf("signer %s: %s meter updated on verification: have %d, want 0", names[signer], kind, count)
+			}
+		}
+	}
+	// Insert the blocks, which should update the metrics once per block
+	for _, header := range headers {
+		engine.MarkInserted(reader, header)
+	}
+	for signer, stat := range want {
+		counts := map[string]uint64{"inturn": stat.InTurn, "outofturn": stat.OutOfTurn, "missed": stat.Missed}
+		for kind, count := range counts {
+			if have := meter(signer, kind); have != int64(count) {
+				t.Errorf("signer %s: %s meter mismatch: have %d, want %d", names[signer], kind, have, count)
+			}
+		}
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
 	"math/big"
@@ -104,3 +105,21 @@ type PoW interface {
 	// Hashrate returns the current mining hashrate of a PoW consensus engine.
 	Hashrate() float64
 }
+
+// Handler is a consensus engine that needs to exchange messages with the other
+// participants of the consensus over its own devp2p sub-protocol.
+type Handler interface {
+	Engine
+
+	// Protocols returns the devp2p sub-protocols the consensus engine runs.
+	Protocols() []p2p.Protocol
+
+	// Start begins processing consensus messages on top of the given chain. The
+	// verify callback is used to fully validate proposals (body and state
+	// transition) before agreeing on them, the insert callback to import blocks
+	// finalized by the consensus that were not sealed locally.
+	Start(chain ChainReader, verify func(*types.Block) error, insert func(*types.Block) error) error
+
+	// Stop terminates the consensus message processing.
+	Stop() error
+}
@@ -47,7 +47,7 @@ var (
 	maxUint256 = new(big.Int).Exp(big.NewInt(2), big.NewInt(256), big.NewInt(0))
 
 	// sharedEthash is a full instance that can be shared between multiple users.
-	sharedEthash = New(Config{"", 3, 0, "", 1, 0, ModeNormal})
+	sharedEthash = New(Config{"", 3, 0, "", 1, 0, false, ModeNormal})
 
 	// algorithmRevision is the data structure version used for file naming.
 	algorithmRevision = 23
@@ -212,7 +212,7 @@ func newCache(epoch uint64) interface{} {
 }
 
 // generate ensures that the cache content is generated before use.
-func (c *cache) generate(dir string, limit int, test bool) {
+func (c *cache) generate(dir string, limit int, shared, test bool) {
 	c.once.Do(func() {
 		size := cacheSize(c.epoch*epochLength + 1)
 		seed := seedHash(c.epoch*epochLength + 1)
@@ -246,6 +246,20 @@ func (c *cache) generate(dir string, limit int, test bool) {
 		}
 		logger.Debug("Failed to load old ethash cache", "err", err)
 
+		// If the folder is shared, wait for anyone else generating the same cache
+		if shared {
+			release, err := lockFile(path)
+			if err != nil {
+				logger.Warn("Failed to lock shared ethash cache", "err", err)
+			} else {
+				defer release.Release()
+
+				if c.dump, c.mmap, c.cache, err = memoryMap(path); err == nil {
+					logger.Debug("Loaded shared ethash cache from disk")
+					return
+				}
+			}
+		}
 		// No previous cache available, create a new cache file to fill
 		c.dump, c.mmap, c.cache, err = memoryMapAndGenerate(path, size, func(buffer []uint32) { generateCache(buffer, c.epoch, seed) })
 		if err != nil {
@@ -254,7 +268,11 @@ func (c *cache) generate(dir string, limit int, test bool) {
 			c.cache = make([]uint32, size/4)
 			generateCache(c.cache, c.epoch, seed)
 		}
-		// Iterate over all previous instances and delete old ones
+		// Iterate over all previous instances and delete old ones, unless they are
+		// shared with other processes which might still use them
+		if shared {
+			return
+		}
 		for ep := int(c.epoch) - limit; ep >= 0; ep-- {
 			seed := seedHash(uint64(ep)*epochLength + 1)
 			path := filepath.Join(dir, fmt.Sprintf("cache-R%d-%x%s", algorithmRevision, seed[:8], endian))
@@ -288,7 +306,7 @@ func newDataset(epoch uint64) interface{} {
 }
 
 // generate ensures that the dataset content is generated before use.
-func (d *dataset) generate(dir string, limit int, test bool) {
+func (d *dataset) generate(dir string, limit int, shared, test bool) {
 	d.once.Do(func() {
 		csize := cacheSize(d.epoch*epochLength + 1)
 		dsize := datasetSize(d.epoch*epochLength + 1)
@@ -326,6 +344,20 @@ func (d *dataset) generate(dir string, limit int, test bool) {
 		}
 		logger.Debug("Failed to load old ethash dataset", "err", err)
 
+		// If the folder is shared, wait for anyone else generating the same dataset
+		if shared {
+			release, err := lockFile(path)
+			if err != nil {
+				logger.Warn("Failed to lock shared ethash dataset", "err", err)
+			} else {
+				defer release.Release()
+
+				if d.dump, d.mmap, d.dataset, err = memoryMap(path); err == nil {
+					logger.Debug("Loaded shared ethash dataset from disk")
+					return
+				}
+			}
+		}
 		// No previous dataset available, create a new dataset file to fill
 		cache := make([]uint32, csize/4)
 		generateCache(cache, d.epoch, seed)
@@ -337,7 +369,11 @@ func (d *dataset) generate(dir string, limit int, test bool) {
 			d.dataset = make([]uint32, dsize/2)
 			generateDataset(d.dataset, d.epoch, cache)
 		}
-		// Iterate over all previous instances and delete old ones
+		// Iterate over all previous instances and delete old ones, unless they are
+		// shared with other processes which might still use them
+		if shared {
+			return
+		}
 		for ep := int(d.epoch) - limit; ep >= 0; ep-- {
 			seed := seedHash(uint64(ep)*epochLength + 1)
 			path := filepath.Join(dir, fmt.Sprintf("full-R%d-%x%s", algorithmRevision, seed[:8], endian))
@@ -358,13 +394,35 @@ func (d *dataset) finalizer() {
 // MakeCache generates a new ethash cache and optionally stores it to disk.
 func MakeCache(block uint64, dir string) {
 	c := cache{epoch: block / epochLength}
-	c.generate(dir, math.MaxInt32, false)
+	c.generate(dir, math.MaxInt32, false, false)
 }
 
 // MakeDataset generates a new ethash dataset and optionally stores it to disk.
 func MakeDataset(block uint64, dir string) {
 	d := dataset{epoch: block / epochLength}
-	d.generate(dir, math.MaxInt32, false)
+	d.generate(dir, math.MaxInt32, false, false)
+}
+
+// MakeSharedCache generates the ethash caches of the given block's epoch and
+// the ones following it into a folder shared with other processes, waiting for
+// any of them generating the same caches concurrently.
+func MakeSharedCache(block uint64, epochs int, dir string) {
+	for i := 0; i < epochs; i++ {
+		c := cache{epoch: block/epochLength + uint64(i)}
+		c.generate(dir, math.MaxInt32, true, false)
+		c.finalizer()
+	}
+}
+
+// MakeSharedDataset generates the ethash datasets of the given block's epoch and
+// the ones following it into a folder shared with other processes, waiting for
+// any of them generating the same datasets concurrently.
+func MakeSharedDataset(block uint64, epochs int, dir string) {
+	for i := 0; i < epochs; i++ {
+		d := dataset{epoch: block/epochLength + uint64(i)}
+		d.generate(dir, math.MaxInt32, true, false)
+		d.finalizer()
+	}
 }
 
 // Mode defines the type and amount of PoW verification an ethash engine makes.
@@ -386,6 +444,7 @@ type Config struct {
 	DatasetDir     string
 	DatasetsInMem  int
 	DatasetsOnDisk int
+	SharedDirs     bool
 	PowMode        Mode
 }
 
@@ -423,6 +482,9 @@ func New(config Config) *Ethash {
 	if config.DatasetDir != "" && config.DatasetsOnDisk > 0 {
 		log.Info("Disk storage enabled for ethash DAGs", "dir", config.DatasetDir, "count", config.DatasetsOnDisk)
 	}
+	if config.SharedDirs {
+		log.Info("Sharing ethash storage with other processes", "caches", config.CacheDir, "dags", config.DatasetDir)
+	}
 	return &Ethash{
 		config:   config,
 		caches:   newlru("cache", config.CachesInMem, newCache),
@@ -498,12 +560,12 @@ func (ethash *Ethash) cache(block uint64) *cache {
 	current := currentI.(*cache)
 
 	// Wait for generation finish.
-	current.generate(ethash.config.CacheDir, ethash.config.CachesOnDisk, ethash.config.PowMode == ModeTest)
+	current.generate(ethash.config.CacheDir, ethash.config.CachesOnDisk, ethash.config.SharedDirs, ethash.config.PowMode == ModeTest)
 
 	// If we need a new future cache, now's a good time to regenerate it.
 	if futureI != nil {
 		future := futureI.(*cache)
-		go future.generate(ethash.config.CacheDir, ethash.config.CachesOnDisk, ethash.config.PowMode == ModeTest)
+		go future.generate(ethash.config.CacheDir, ethash.config.CachesOnDisk, ethash.config.SharedDirs, ethash.config.PowMode == ModeTest)
 	}
 	return current
 }
@@ -517,12 +579,12 @@ func (ethash *Ethash) dataset(block uint64) *dataset {
 	current := currentI.(*dataset)
 
 	// Wait for generation finish.
-	current.generate(ethash.config.DatasetDir, ethash.config.DatasetsOnDisk, ethash.config.PowMode == ModeTest)
+	current.generate(ethash.config.DatasetDir, ethash.config.DatasetsOnDisk, ethash.config.SharedDirs, ethash.config.PowMode == ModeTest)
 
 	// If we need a new future dataset, now's a good time to regenerate it.
 	if futureI != nil {
 		future := futureI.(*dataset)
-		go future.generate(ethash.config.DatasetDir, ethash.config.DatasetsOnDisk, ethash.config.PowMode == ModeTest)
+		go future.generate(ethash.config.DatasetDir, ethash.config.DatasetsOnDisk, ethash.config.SharedDirs, ethash.config.PowMode == ModeTest)
 	}
 
 	return current
@@ -21,6 +21,7 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 
@@ -77,3 +78,49 @@ func verifyTest(wg *sync.WaitGroup, e *Ethash, workerIndex, epochs int) {
 		e.VerifySeal(nil, head)
 	}
 }
+
+// Tests that multiple ethash instances sharing the same cache folder reuse each
+// other's files without deleting any, leaving that up to garbage collection.
+func TestSharedCacheDir(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ethash-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			e := New(Config{CachesInMem: 1, CachesOnDisk: 1, CacheDir: tmpdir, SharedDirs: true, PowMode: ModeTest})
+			for epoch := 0; epoch < 4; epoch++ {
+				e.VerifySeal(nil, &types.Header{Number: big.NewInt(int64(epoch * epochLength)), Difficulty: big.NewInt(100)})
+			}
+		}()
+	}
+	wg.Wait()
+
+	caches, _ := filepath.Glob(filepath.Join(tmpdir, "cache-*[^k]"))
+	if len(caches) != 5 { // 4 epochs and the future one
+		t.Fatalf("cache file count mismatch: have %d, want %d: %v", len(caches), 5, caches)
+	}
+	// Garbage collect all but the current and previous epochs
+	removed, err := GarbageCollect(tmpdir, 3*epochLength, 1)
+	if err != nil {
+		t.Fatalf("failed to garbage collect caches: %v", err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("removed file count mismatch: have %d, want %d: %v", len(removed), 2, removed)
+	}
+	caches, _ = filepath.Glob(filepath.Join(tmpdir, "cache-*[^k]"))
+	if len(caches) != 3 {
+		t.Errorf("cache file count mismatch: have %d, want %d: %v", len(caches), 3, caches)
+	}
+	// Lock files must be kept, others might be waiting on them
+	locks, _ := filepath.Glob(filepath.Join(tmpdir, "cache-*.lock"))
+	if len(locks) != 5 {
+		t.Errorf("lock file count mismatch: have %d, want %d: %v", len(locks), 5, locks)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/prometheus/prometheus/util/flock"
+)
+
+// lockRetryInterval is the time to wait between attempts to acquire the lock of
+// a shared cache or dataset file held by another process.
+const lockRetryInterval = 100 * time.Millisecond
+
+// lockFile acquires the inter-process lock guarding a shared ethash cache or
+// dataset file, waiting for any other process holding it to finish. An error is
+// only returned if the lock file cannot be created at all (e.g. the folder is
+// read only), in which case there's no point in waiting.
+func lockFile(path string) (flock.Releaser, error) {
+	start, logged := time.Now(), time.Now()
+	for {
+		release, _, err := flock.New(path + ".lock")
+		if err == nil {
+			return release, nil
+		}
+		if _, ok := err.(*os.PathError); ok {
+			return nil, err
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Waiting for shared ethash file", "path", path, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// GarbageCollect deletes all the caches and datasets from a shared folder that
+// belong to epochs preceding the given block's, apart from the most recent keep
+// ones, returning the removed files. Files are only removed while holding their
+// locks, so nobody can be halfway through generating them. The zero sized lock
+// files themselves are kept: unlinking them would let a process waiting on the
+// old one and another creating a new one both hold the lock at the same time.
+func GarbageCollect(dir string, block uint64, keep int) ([]string, error) {
+	var endian string
+	if !isLittleEndian() {
+		endian = ".be"
+	}
+	var removed []string
+	for epoch := int(block/epochLength) - keep - 1; epoch >= 0; epoch-- {
+		seed := seedHash(uint64(epoch)*epochLength + 1)
+
+		for _, kind := range []string{"cache", "full"} {
+			path := filepath.Join(dir, fmt.Sprintf("%s-R%d-%x%s", kind, algorithmRevision, seed[:8], endian))
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				continue
+			}
+			release, err := lockFile(path)
+			if err != nil {
+				return removed, err
+			}
+			err = os.Remove(path)
+			release.Release()
+			if err != nil {
+				return removed, err
+			}
+			removed = append(removed, path)
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,588 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package istanbul
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	maxBacklog      = 1024 // Maximum number of future consensus messages to keep around
+	maxTimeoutShift = 8    // Maximum number of times the round timeout is doubled
+)
+
+// phase is the progress of the local validator within a round.
+type phase uint8
+
+const (
+	stateAcceptRequest phase = iota // Waiting for the proposal of the round
+	statePreprepared                // Proposal accepted, gathering prepares
+	statePrepared                   // Quorum prepared (proposal locked), gathering commits
+	stateCommitted                  // Quorum committed, waiting for the finalized block
+)
+
+// roundState is the consensus data gathered within the current round.
+type roundState struct {
+	view     View
+	snap     *Snapshot                   // Validator set deciding on the sequence
+	parent   *types.Header               // Header the sequence is building on top of
+	proposal *types.Block                // Proposal accepted in this round
+	prepares map[common.Address]struct{} // Validators that prepared the proposal
+	commits  map[common.Address][]byte   // Commit seals of the validators
+}
+
+// stateMachine is the Istanbul consensus state machine, running the pre-prepare, prepare
+// and commit phases of every round and changing rounds if the validators fail to
+// agree on a proposal in time. All the state is confined to a single goroutine
+// fed by the request, message and timeout channels.
+type stateMachine struct {
+	backend *Istanbul
+	chain   consensus.ChainReader
+
+	current      *roundState
+	state        phase
+	locked       *types.Block                           // Proposal prepared by a quorum, to be re-proposed on round changes
+	pending      *types.Block                           // Local proposal waiting for our turn
+	target       uint64                                 // Round we're asking the validators to change to
+	waiting      bool                                   // Whether we're waiting for a round change
+	roundChanges map[uint64]map[common.Address]struct{} // Validators asking for a round change, per round
+	backlog      []*message                             // Consensus messages from future rounds
+	timer        *time.Timer                            // Timer to trigger a round change
+
+	requests chan *types.Block
+	messages chan *message
+	timeouts chan View
+	quit     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// newStateMachine creates a consensus state machine on top of the given chain.
+func newStateMachine(backend *Istanbul, chain consensus.ChainReader) *stateMachine {
+	return &stateMachine{
+		backend:  backend,
+		chain:    chain,
+		requests: make(chan *types.Block),
+		messages: make(chan *message, 256),
+		timeouts: make(chan View),
+		quit:     make(chan struct{}),
+	}
+}
+
+// start launches the consensus processing goroutine.
+func (c *stateMachine) start() {
+	c.wg.Add(1)
+	go c.loop()
+}
+
+// stop terminates the consensus processing and waits for it to return.
+func (c *stateMachine) stop() {
+	close(c.quit)
+	c.wg.Wait()
+}
+
+// request hands a local proposal to the consensus.
+func (c *stateMachine) request(block *types.Block) {
+	select {
+	case c.requests <- block:
+	case <-c.quit:
+	}
+}
+
+// message hands an authenticated consensus message to the consensus.
+func (c *stateMachine) message(msg *message) {
+	select {
+	case c.messages <- msg:
+	case <-c.quit:
+	}
+}
+
+// loop is the consensus processing goroutine.
+func (c *stateMachine) loop() {
+	defer c.wg.Done()
+	defer func() {
+		if c.timer != nil {
+			c.timer.Stop()
+		}
+	}()
+	c.startSequence(c.chain.CurrentHeader())
+
+	for {
+		select {
+		case block := <-c.requests:
+			c.handleRequest(block)
+		case msg := <-c.messages:
+			c.handleMessage(msg)
+		case view := <-c.timeouts:
+			c.handleTimeout(view)
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// startSequence starts agreeing on the block following the given parent.
+func (c *stateMachine) startSequence(parent *types.Header) {
+	snap, err := c.backend.snapshot(c.chain, parent.Number.Uint64(), parent.Hash(), []*types.Header{parent})
+	if err != nil {
+		log.Error("Failed to retrieve validator snapshot", "number", parent.Number, "hash", parent.Hash(), "err", err)
+		return
+	}
+	c.current = &roundState{
+		view:   View{Sequence: parent.Number.Uint64() + 1},
+		snap:   snap,
+		parent: parent,
+	}
+	c.locked = nil
+	if c.pending != nil && c.pending.ParentHash() != parent.Hash() {
+		c.pending = nil
+	}
+	c.roundChanges = make(map[uint64]map[common.Address]struct{})
+	c.target = 0
+
+	log.Debug("Starting new consensus sequence", "view", c.current.view, "validators", len(snap.Validators))
+	c.startRound(0)
+}
+
+// startRound resets the consensus data of the current sequence and starts the
+// given round of agreement.
+func (c *stateMachine) startRound(round uint64) {
+	c.current.view.Round = round
+	c.current.proposal = nil
+	c.current.prepares = make(map[common.Address]struct{})
+	c.current.commits = make(map[common.Address][]byte)
+
+	for r := range c.roundChanges {
+		if r <= round {
+			delete(c.roundChanges, r)
+		}
+	}
+	if c.target < round {
+		c.target = round
+	}
+	c.state = stateAcceptRequest
+	c.waiting = false
+	c.resetTimer(round)
+
+	c.propose()
+	c.processBacklog()
+}
+
+// resetTimer arms the round change timer, doubling the timeout with every round.
+func (c *stateMachine) resetTimer(round uint64) {
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	shift := round
+	if shift > maxTimeoutShift {
+		shift = maxTimeoutShift
+	}
+	timeout := time.Duration(c.backend.config.RequestTimeout) * time.Millisecond << shift
+
+	view := c.current.view
+	c.timer = time.AfterFunc(timeout, func() {
+		select {
+		case c.timeouts <- view:
+		case <-c.quit:
+		}
+	})
+}
+
+// isValidator returns whether the local node is a validator of the sequence.
+func (c *stateMachine) isValidator() bool {
+	return c.current.snap.isValidator(c.backend.address)
+}
+
+// isProposer returns whether the local node proposes in the current round.
+func (c *stateMachine) isProposer() bool {
+	return c.current.snap.proposer(c.current.view.Sequence, c.current.view.Round) == c.backend.address
+}
+
+// propose sends the locked or the pending local proposal to the validators if
+// it's our turn to propose.
+func (c *stateMachine) propose() {
+	if c.state != stateAcceptRequest || c.waiting || !c.isProposer() {
+		return
+	}
+	proposal := c.locked
+	if proposal == nil {
+		proposal = c.pending
+	}
+	if proposal == nil {
+		return
+	}
+	c.broadcast(msgPreprepare, &preprepare{View: c.current.view, Proposal: proposal})
+}
+
+// broadcast signs a consensus message, sends it to the network and processes
+// it locally.
+func (c *stateMachine) broadcast(code uint64, payload interface{}) {
+	msg, err := newMessage(code, payload, c.backend.key)
+	if err != nil {
+		log.Error("Failed to create consensus message", "code", code, "err", err)
+		return
+	}
+	c.send(msg)
+}
+
+// send relays a signed consensus message to the network and processes it locally.
+func (c *stateMachine) send(msg *message) {
+	if !c.isValidator() {
+		return
+	}
+	blob, err := rlp.EncodeToBytes(msg)
+	if err != nil {
+		log.Error("Failed to encode consensus message", "code", msg.Code, "err", err)
+		return
+	}
+	hash := crypto.Keccak256Hash(blob)
+	c.backend.network.known.Add(hash, struct{}{})
+	c.backend.network.gossip(hash, blob)
+
+	c.handleMessage(msg)
+}
+
+// catchUp restarts the consensus on top of the local chain head if it moved
+// past the sequence being agreed upon (e.g. blocks imported via sync).
+func (c *stateMachine) catchUp() bool {
+	head := c.chain.CurrentHeader()
+	if c.current == nil || head.Number.Uint64() >= c.current.view.Sequence {
+		c.startSequence(head)
+		return true
+	}
+	return false
+}
+
+// handleRequest processes a local proposal, proposing it right away if it's our
+// turn or keeping it around for a later round.
+func (c *stateMachine) handleRequest(block *types.Block) {
+	if c.current == nil || block.NumberU64() > c.current.view.Sequence {
+		c.catchUp()
+	}
+	if c.current == nil || block.ParentHash() != c.current.parent.Hash() {
+		log.Debug("Ignoring stale proposal request", "number", block.Number(), "hash", block.Hash())
+		return
+	}
+	c.pending = block
+	c.propose()
+}
+
+// handleTimeout processes the expiry of the round timer, asking the validators
+// to move on to the next round.
+func (c *stateMachine) handleTimeout(view View) {
+	if c.current == nil {
+		c.catchUp()
+		return
+	}
+	if view.Sequence != c.current.view.Sequence || c.catchUp() {
+		return
+	}
+	c.target++
+	c.waiting = true
+	c.resetTimer(c.target)
+
+	log.Debug("Round timed out, requesting round change", "view", c.current.view, "round", c.target)
+	c.broadcast(msgRoundChange, &subject{View: View{Sequence: c.current.view.Sequence, Round: c.target}})
+}
+
+// handleMessage dispatches a consensus message to its phase handler, deferring
+// messages from future rounds until we get there.
+func (c *stateMachine) handleMessage(msg *message) {
+	if c.current == nil {
+		return
+	}
+	var view View
+	switch msg.Code {
+	case msgPreprepare:
+		pre, err := msg.decodePreprepare()
+		if err != nil {
+			log.Debug("Invalid preprepare message", "err", err)
+			return
+		}
+		view = pre.View
+	case msgFinal:
+		fin, err := msg.decodeFinal()
+		if err != nil {
+			log.Debug("Invalid final message", "err", err)
+			return
+		}
+		view = fin.View
+	case msgPrepare, msgCommit, msgRoundChange:
+		sub, err := msg.decodeSubject()
+		if err != nil {
+			log.Debug("Invalid consensus message", "code", msg.Code, "err", err)
+			return
+		}
+		view = sub.View
+	default:
+		log.Debug("Unknown consensus message", "code", msg.Code)
+		return
+	}
+	// Drop messages from the past and defer the ones from the future
+	if view.Sequence < c.current.view.Sequence {
+		return
+	}
+	if view.Sequence > c.current.view.Sequence {
+		c.deferMessage(msg)
+		c.catchUp()
+		return
+	}
+	if !c.current.snap.isValidator(msg.Address) {
+		log.Debug("Consensus message from non-validator", "address", msg.Address)
+		return
+	}
+	switch msg.Code {
+	case msgRoundChange:
+		c.handleRoundChange(msg, view)
+		return
+	case msgFinal:
+		fin, _ := msg.decodeFinal()
+		c.handleFinal(msg, fin)
+		return
+	}
+	switch {
+	case view.Round < c.current.view.Round:
+		return
+	case view.Round > c.current.view.Round:
+		c.deferMessage(msg)
+		return
+	}
+	switch msg.Code {
+	case msgPreprepare:
+		pre, _ := msg.decodePreprepare()
+		c.handlePreprepare(msg, pre)
+	case msgPrepare:
+		sub, _ := msg.decodeSubject()
+		c.handlePrepare(msg, sub)
+	case msgCommit:
+		sub, _ := msg.decodeSubject()
+		c.handleCommit(msg, sub)
+	}
+}
+
+// deferMessage stores a consensus message to be processed once the round it
+// belongs to is reached.
+func (c *stateMachine) deferMessage(msg *message) {
+	if len(c.backlog) >= maxBacklog {
+		c.backlog = c.backlog[1:]
+	}
+	c.backlog = append(c.backlog, msg)
+}
+
+// processBacklog reprocesses the deferred consensus messages.
+func (c *stateMachine) processBacklog() {
+	backlog := c.backlog
+	c.backlog = nil
+
+	for _, msg := range backlog {
+		c.handleMessage(msg)
+	}
+}
+
+// handlePreprepare processes the proposal of the round, preparing it if valid.
+func (c *stateMachine) handlePreprepare(msg *message, pre *preprepare) {
+	if c.state != stateAcceptRequest {
+		return
+	}
+	if msg.Address != c.current.snap.proposer(pre.View.Sequence, pre.View.Round) {
+		log.Debug("Preprepare from non-proposer", "view", pre.View, "address", msg.Address)
+		return
+	}
+	proposal := pre.Proposal
+	if c.locked != nil && proposal.Hash() != c.locked.Hash() {
+		log.Debug("Preprepare conflicts with locked proposal", "view", pre.View, "hash", proposal.Hash(), "locked", c.locked.Hash())
+		return
+	}
+	// Ensure the proposal builds on top of the sequence and is valid
+	header := proposal.Header()
+	if header.Number.Uint64() != c.current.view.Sequence || header.ParentHash != c.current.parent.Hash() {
+		log.Debug("Preprepare for different block", "view", pre.View, "number", header.Number, "parent", header.ParentHash)
+		return
+	}
+	if err := c.backend.verifyHeader(c.chain, header, []*types.Header{c.current.parent}, false); err != nil {
+		log.Debug("Invalid proposal", "view", pre.View, "hash", proposal.Hash(), "err", err)
+		return
+	}
+	// A valid header says nothing about the transactions and the state root, so
+	// execute the proposal before agreeing on it
+	if err := c.backend.verify(proposal); err != nil {
+		log.Debug("Invalid proposal body", "view", pre.View, "hash", proposal.Hash(), "err", err)
+		return
+	}
+	c.current.proposal = proposal
+	c.state = statePreprepared
+
+	// If we already prepared this proposal in an earlier round, commit right away
+	if c.locked != nil {
+		c.state = statePrepared
+		c.sendCommit()
+	} else {
+		c.broadcast(msgPrepare, &subject{View: c.current.view, Digest: proposal.Hash()})
+	}
+	c.processBacklog()
+}
+
+// handlePrepare processes a validator preparing the proposal of the round,
+// locking and committing to it once a quorum prepared.
+func (c *stateMachine) handlePrepare(msg *message, sub *subject) {
+	if c.state < statePreprepared {
+		c.deferMessage(msg)
+		return
+	}
+	if sub.Digest != c.current.proposal.Hash() {
+		log.Debug("Prepare for different proposal", "view", sub.View, "digest", sub.Digest)
+		return
+	}
+	c.current.prepares[msg.Address] = struct{}{}
+
+	if c.state == statePreprepared && len(c.current.prepares) >= c.current.snap.quorum() {
+		c.locked = c.current.proposal
+		c.state = statePrepared
+		c.sendCommit()
+	}
+}
+
+// sendCommit broadcasts our commit seal for the proposal of the round.
+func (c *stateMachine) sendCommit() {
+	seal, err := crypto.Sign(commitHash(c.current.proposal.Hash()), c.backend.key)
+	if err != nil {
+		log.Error("Failed to sign commit seal", "err", err)
+		return
+	}
+	msg, err := newMessage(msgCommit, &subject{View: c.current.view, Digest: c.current.proposal.Hash()}, c.backend.key)
+	if err != nil {
+		log.Error("Failed to create commit message", "err", err)
+		return
+	}
+	msg.CommittedSeal = seal
+	if err := msg.sign(c.backend.key); err != nil {
+		log.Error("Failed to sign commit message", "err", err)
+		return
+	}
+	c.send(msg)
+}
+
+// handleCommit processes a validator committing to the proposal of the round,
+// finalizing it once a quorum committed.
+func (c *stateMachine) handleCommit(msg *message, sub *subject) {
+	if c.state < statePreprepared {
+		c.deferMessage(msg)
+		return
+	}
+	if c.state == stateCommitted {
+		return
+	}
+	proposal := c.current.proposal
+	if sub.Digest != proposal.Hash() {
+		log.Debug("Commit for different proposal", "view", sub.View, "digest", sub.Digest)
+		return
+	}
+	// Ensure the commit seal is authentic
+	pubkey, err := crypto.SigToPub(commitHash(sub.Digest), msg.CommittedSeal)
+	if err != nil || crypto.PubkeyToAddress(*pubkey) != msg.Address {
+		log.Debug("Invalid commit seal", "view", sub.View, "address", msg.Address)
+		return
+	}
+	c.current.commits[msg.Address] = msg.CommittedSeal
+	if len(c.current.commits) < c.current.snap.quorum() {
+		return
+	}
+	// Quorum reached, the proposal is final. The validators may have gathered
+	// different commit seals, so only the proposer of the round assembles the
+	// block to import, everyone else waits for it (or a round change).
+	c.state = stateCommitted
+	log.Debug("Committed proposal", "view", c.current.view, "hash", proposal.Hash(), "seals", len(c.current.commits))
+
+	if !c.isProposer() {
+		return
+	}
+	seals := make([][]byte, 0, len(c.current.commits))
+	for _, validator := range c.current.snap.Validators {
+		if seal, ok := c.current.commits[validator]; ok {
+			seals = append(seals, seal)
+		}
+	}
+	block, err := c.backend.finalize(proposal, seals)
+	if err != nil {
+		log.Error("Failed to finalize proposal", "view", c.current.view, "hash", proposal.Hash(), "err", err)
+		return
+	}
+	c.broadcast(msgFinal, &final{View: c.current.view, Block: block})
+}
+
+// handleFinal processes the block finalized by the proposer of a round, importing
+// it and moving on to the next sequence. The block is accepted from any round of
+// the sequence, as its commit seals prove the agreement on their own.
+func (c *stateMachine) handleFinal(msg *message, fin *final) {
+	if msg.Address != c.current.snap.proposer(fin.View.Sequence, fin.View.Round) {
+		log.Debug("Final block from non-proposer", "view", fin.View, "address", msg.Address)
+		return
+	}
+	block := fin.Block
+	header := block.Header()
+	if header.Number.Uint64() != c.current.view.Sequence || header.ParentHash != c.current.parent.Hash() {
+		log.Debug("Final block for different sequence", "view", fin.View, "number", header.Number, "parent", header.ParentHash)
+		return
+	}
+	if err := c.backend.verifyHeader(c.chain, header, []*types.Header{c.current.parent}, true); err != nil {
+		log.Debug("Invalid final block", "view", fin.View, "hash", block.Hash(), "err", err)
+		return
+	}
+	log.Debug("Finalized block", "view", fin.View, "hash", block.Hash())
+	if err := c.backend.commit(block); err != nil {
+		// Stay on the current parent, the block will either arrive via sync and
+		// trigger a catch up, or the validators will change round.
+		log.Warn("Failed to import finalized block", "number", block.Number(), "hash", block.Hash(), "err", err)
+		return
+	}
+	c.startSequence(header)
+}
+
+// handleRoundChange processes a validator asking to move on to a later round,
+// joining in once enough validators ask for it and starting the round when a
+// quorum agrees.
+func (c *stateMachine) handleRoundChange(msg *message, view View) {
+	if view.Round <= c.current.view.Round {
+		return
+	}
+	if c.roundChanges[view.Round] == nil {
+		c.roundChanges[view.Round] = make(map[common.Address]struct{})
+	}
+	c.roundChanges[view.Round][msg.Address] = struct{}{}
+	votes := len(c.roundChanges[view.Round])
+
+	// If F+1 validators want to change round, at least one is honest, join them
+	if votes > c.current.snap.faulty() && view.Round > c.target {
+		c.target = view.Round
+		c.waiting = true
+		c.resetTimer(c.target)
+		c.broadcast(msgRoundChange, &subject{View: View{Sequence: c.current.view.Sequence, Round: c.target}})
+		return
+	}
+	// If a quorum wants to change round, do it
+	if votes >= c.current.snap.quorum() {
+		log.Debug("Changing consensus round", "view", c.current.view, "round", view.Round)
+		c.startRound(view.Round)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package istanbul
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	// istanbulDigest represents a hash of "Istanbul practical byzantine fault tolerance"
+	// to identify whether the block is from Istanbul consensus engine
+	istanbulDigest = common.HexToHash("0x63746963616c2062797a616e74696e65206661756c7420746f6c6572616e6365")
+
+	extraVanity = 32 // Fixed number of extra-data bytes reserved for validator vanity
+)
+
+// istanbulExtra is the consensus specific data embedded into the extra-data of
+// Istanbul headers after the vanity prefix.
+type istanbulExtra struct {
+	Validators    []common.Address // Validator set in force when the block was proposed
+	Candidate     common.Address   // Account being voted on to change its validator status (zero = no vote)
+	Authorize     bool             // Whether to authorize or deauthorize the voted account
+	Seal          []byte           // Signature of the proposer over the header
+	CommittedSeal [][]byte         // Signatures of the validators committing to the header
+}
+
+// extractExtra extracts all values of the istanbulExtra from the header. It
+// returns an error if the extra-data is shorter than the vanity prefix or the
+// remainder can't be decoded.
+func extractExtra(h *types.Header) (*istanbulExtra, error) {
+	if len(h.Extra) < extraVanity {
+		return nil, errInvalidExtraDataFormat
+	}
+	extra := new(istanbulExtra)
+	if err := rlp.DecodeBytes(h.Extra[extraVanity:], extra); err != nil {
+		return nil, err
+	}
+	return extra, nil
+}
+
+// filteredHeader returns a copy of the header with the committed seals and
+// optionally the proposer seal removed from the extra-data, or nil if the
+// extra-data can't be decoded/encoded by rlp.
+func filteredHeader(h *types.Header, keepSeal bool) *types.Header {
+	header := types.CopyHeader(h)
+	extra, err := extractExtra(header)
+	if err != nil {
+		return nil
+	}
+	if !keepSeal {
+		extra.Seal = []byte{}
+	}
+	extra.CommittedSeal = [][]byte{}
+
+	payload, err := rlp.EncodeToBytes(extra)
+	if err != nil {
+		return nil
+	}
+	header.Extra = append(header.Extra[:extraVanity], payload...)
+
+	return header
+}
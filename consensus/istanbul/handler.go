@@ -0,0 +1,205 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package istanbul
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	protocolName    = "istanbul" // Name of the consensus devp2p sub-protocol
+	protocolVersion = 1          // Version of the consensus devp2p sub-protocol
+	protocolLength  = 1          // Number of message codes used by the sub-protocol
+
+	consensusMsg   = 0x00             // Message code of the consensus messages
+	maxMessageSize = 10 * 1024 * 1024 // Maximum cap on the size of a consensus message
+
+	knownMessages     = 4096 // Number of recent consensus messages to remember globally
+	peerKnownMessages = 1024 // Number of recent consensus messages to remember per peer
+	peerQueueSize     = 256  // Number of consensus messages to queue up for sending per peer
+)
+
+// peer is a remote node running the consensus sub-protocol.
+type peer struct {
+	*p2p.Peer
+	rw    p2p.MsgReadWriter
+	known *lru.ARCCache // Hashes of the consensus messages known to the peer
+	queue chan []byte   // Consensus messages waiting to be sent to the peer
+}
+
+// broadcast sends the queued consensus messages to the peer until the protocol
+// handler terminates.
+func (p *peer) broadcast(quit chan struct{}) {
+	for {
+		select {
+		case payload := <-p.queue:
+			if err := p2p.Send(p.rw, consensusMsg, payload); err != nil {
+				p.Log().Debug("Failed to send consensus message", "err", err)
+				return
+			}
+		case <-quit:
+			return
+		}
+	}
+}
+
+// network gossips the consensus messages among the connected peers.
+type network struct {
+	peers map[discover.NodeID]*peer
+	known *lru.ARCCache // Hashes of the consensus messages already processed
+	lock  sync.RWMutex  // Protects the peer set
+}
+
+// newNetwork creates an empty consensus message gossiping network.
+func newNetwork() *network {
+	known, _ := lru.NewARC(knownMessages)
+	return &network{
+		peers: make(map[discover.NodeID]*peer),
+		known: known,
+	}
+}
+
+// gossip queues a consensus message for sending to all the peers not yet known
+// to have it. Peers not keeping up with the network get the message dropped.
+func (n *network) gossip(hash common.Hash, payload []byte) {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	for _, p := range n.peers {
+		if p.known.Contains(hash) {
+			continue
+		}
+		select {
+		case p.queue <- payload:
+			p.known.Add(hash, struct{}{})
+		default:
+			p.Log().Debug("Dropping consensus message, send queue full", "hash", hash)
+		}
+	}
+}
+
+// Protocols implements consensus.Handler, returning the devp2p sub-protocol the
+// validators exchange the consensus messages on.
+func (c *Istanbul) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{{
+		Name:    protocolName,
+		Version: protocolVersion,
+		Length:  protocolLength,
+		Run:     c.runPeer,
+	}}
+}
+
+// runPeer is the sub-protocol handler of a single remote peer, verifying the
+// consensus messages received, feeding them to the consensus and relaying them
+// to the rest of the network.
+func (c *Istanbul) runPeer(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+	known, _ := lru.NewARC(peerKnownMessages)
+	remote := &peer{Peer: p, rw: rw, known: known, queue: make(chan []byte, peerQueueSize)}
+
+	quit := make(chan struct{})
+	go remote.broadcast(quit)
+
+	c.network.lock.Lock()
+	c.network.peers[p.ID()] = remote
+	c.network.lock.Unlock()
+
+	defer func() {
+		c.network.lock.Lock()
+		delete(c.network.peers, p.ID())
+		c.network.lock.Unlock()
+
+		close(quit)
+	}()
+	p.Log().Debug("Istanbul peer connected")
+
+	for {
+		msg, err := rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		if msg.Size > maxMessageSize {
+			msg.Discard()
+			return fmt.Errorf("message too large: %v > %v", msg.Size, maxMessageSize)
+		}
+		if msg.Code != consensusMsg {
+			msg.Discard()
+			return fmt.Errorf("invalid message code: %v", msg.Code)
+		}
+		var payload []byte
+		if err := msg.Decode(&payload); err != nil {
+			return err
+		}
+		// Skip the message if it was processed already
+		hash := crypto.Keccak256Hash(payload)
+		remote.known.Add(hash, struct{}{})
+
+		if c.network.known.Contains(hash) {
+			continue
+		}
+		// Ensure the message is authentic before processing and relaying it. The
+		// message is only marked known once accepted, as a validator set check
+		// failing while the local chain is behind may pass on a later delivery.
+		message, err := decodeMessage(payload)
+		if err != nil {
+			log.Debug("Dropping invalid consensus message", "peer", p.ID(), "err", err)
+			continue
+		}
+		if !c.isValidator(message.Address) {
+			log.Debug("Dropping consensus message of non-validator", "peer", p.ID(), "sender", message.Address)
+			continue
+		}
+		c.network.known.Add(hash, struct{}{})
+
+		c.running.RLock()
+		core := c.core
+		c.running.RUnlock()
+
+		if core != nil {
+			core.message(message)
+		}
+
+		c.network.gossip(hash, payload)
+	}
+}
+
+// isValidator returns whether the given address is part of the validator set at
+// the current head of the chain. Without a running consensus there is no chain
+// to check against, so no sender is accepted.
+func (c *Istanbul) isValidator(address common.Address) bool {
+	c.running.RLock()
+	chain := c.chain
+	c.running.RUnlock()
+
+	if chain == nil {
+		return false
+	}
+	head := chain.CurrentHeader()
+	snap, err := c.snapshot(chain, head.Number.Uint64(), head.Hash(), nil)
+	if err != nil {
+		log.Debug("Failed to retrieve validator snapshot", "number", head.Number, "hash", head.Hash(), "err", err)
+		return false
+	}
+	return snap.isValidator(address)
+}
@@ -0,0 +1,182 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package istanbul
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// newRelayTester creates a consensus engine on top of a chain of validators A
+// and B, connected to a peer sending consensus messages and another one they
+// are relayed to.
+func newRelayTester(t *testing.T, accounts *testerAccountPool) (engine *Istanbul, chain *core.BlockChain, sender, target p2p.MsgReadWriter, close func()) {
+	db, _ := ethdb.NewMemDatabase()
+	genesis := testerGenesis(accounts.addresses([]string{"A", "B"}))
+	genesis.MustCommit(db)
+
+	engine = New(&params.IstanbulConfig{}, accounts.key("A"), db)
+	chain, err := core.NewBlockChain(db, nil, genesis.Config, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	senderLocal, senderRemote := p2p.MsgPipe()
+	targetLocal, targetRemote := p2p.MsgPipe()
+
+	go engine.runPeer(p2p.NewPeer(discover.NodeID{1}, "sender", nil), senderLocal)
+	go engine.runPeer(p2p.NewPeer(discover.NodeID{2}, "target", nil), targetLocal)
+
+	close = func() {
+		senderLocal.Close()
+		targetLocal.Close()
+		chain.Stop()
+	}
+	for i := 0; ; i++ {
+		engine.network.lock.RLock()
+		peers := len(engine.network.peers)
+		engine.network.lock.RUnlock()
+		if peers == 2 {
+			break
+		}
+		if i == 100 {
+			close()
+			t.Fatalf("peers not connected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return engine, chain, senderRemote, targetRemote, close
+}
+
+// readRelayed reads the next consensus message relayed to a peer.
+func readRelayed(t *testing.T, target p2p.MsgReadWriter) []byte {
+	msg, err := target.ReadMsg()
+	if err != nil {
+		t.Fatalf("failed to read relayed message: %v", err)
+	}
+	var relayed []byte
+	if err := msg.Decode(&relayed); err != nil {
+		t.Fatalf("failed to decode relayed message: %v", err)
+	}
+	return relayed
+}
+
+// Tests that only the consensus messages of validators are relayed to the rest
+// of the network.
+func TestRelayValidatorMessages(t *testing.T) {
+	accounts := newTesterAccountPool()
+
+	engine, chain, sender, target, close := newRelayTester(t, accounts)
+	defer close()
+	engine.chain = chain
+
+	// Send a message of an outsider followed by one of a validator
+	var payloads [][]byte
+	for i, account := range []string{"C", "B"} {
+		msg, err := newMessage(msgRoundChange, &subject{View: View{Sequence: 1, Round: uint64(i)}}, accounts.key(account))
+		if err != nil {
+			t.Fatalf("failed to create message: %v", err)
+		}
+		payload, _ := rlp.EncodeToBytes(msg)
+		payloads = append(payloads, payload)
+
+		if err := p2p.Send(sender, consensusMsg, payload); err != nil {
+			t.Fatalf("failed to send message: %v", err)
+		}
+	}
+	// Only the validator's message must be relayed
+	if relayed := readRelayed(t, target); !bytes.Equal(relayed, payloads[1]) {
+		t.Errorf("relayed message mismatch: have %x, want %x", relayed, payloads[1])
+	}
+}
+
+// Tests that a consensus message rejected while the validator set can't be
+// checked is not remembered as processed, but relayed once delivered again.
+func TestRelayRejectedMessageLater(t *testing.T) {
+	accounts := newTesterAccountPool()
+
+	engine, chain, sender, target, close := newRelayTester(t, accounts)
+	defer close()
+
+	msg, err := newMessage(msgRoundChange, &subject{View: View{Sequence: 1}}, accounts.key("B"))
+	if err != nil {
+		t.Fatalf("failed to create message: %v", err)
+	}
+	payload, _ := rlp.EncodeToBytes(msg)
+
+	// Without a chain the sender can't be verified, so the message is dropped
+	if err := p2p.Send(sender, consensusMsg, payload); err != nil {
+		t.Fatalf("failed to send message: %v", err)
+	}
+	engine.network.lock.RLock()
+	remote := engine.network.peers[discover.NodeID{1}]
+	engine.network.lock.RUnlock()
+
+	for i := 0; remote.known.Len() == 0; i++ {
+		if i == 100 {
+			t.Fatalf("message not received")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if engine.network.known.Len() != 0 {
+		t.Fatalf("rejected message marked as processed")
+	}
+	// Once the sender can be verified, the same message must be relayed
+	engine.running.Lock()
+	engine.chain = chain
+	engine.running.Unlock()
+
+	if err := p2p.Send(sender, consensusMsg, payload); err != nil {
+		t.Fatalf("failed to send message: %v", err)
+	}
+	if relayed := readRelayed(t, target); !bytes.Equal(relayed, payload) {
+		t.Errorf("relayed message mismatch: have %x, want %x", relayed, payload)
+	}
+}
+
+// Tests that gossiping doesn't block on peers not keeping up with the network,
+// but drops the messages exceeding their send queues.
+func TestGossipQueueLimit(t *testing.T) {
+	known, _ := lru.NewARC(peerKnownMessages)
+	slow := &peer{
+		Peer:  p2p.NewPeer(discover.NodeID{1}, "slow", nil),
+		known: known,
+		queue: make(chan []byte, 1),
+	}
+	network := newNetwork()
+	network.peers[slow.ID()] = slow
+
+	network.gossip(common.Hash{1}, []byte{1})
+	network.gossip(common.Hash{2}, []byte{2})
+
+	if len(slow.queue) != 1 {
+		t.Errorf("queued message count mismatch: have %d, want %d", len(slow.queue), 1)
+	}
+	if !slow.known.Contains(common.Hash{1}) || slow.known.Contains(common.Hash{2}) {
+		t.Errorf("known messages mismatch: have %v, want only the queued one", slow.known.Keys())
+	}
+}
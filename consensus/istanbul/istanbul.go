@@ -0,0 +1,734 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package istanbul implements the Istanbul byzantine fault tolerant consensus
+// engine, providing instant finality through PBFT style rounds.
+//
+// Every block is proposed by a validator chosen round robin, and is final as
+// soon as two thirds of the validator set committed to it: the commit seals are
+// embedded into the header's extra-data, next to the validator set and the
+// proposer's seal. Consensus messages are exchanged over a dedicated devp2p
+// sub-protocol.
+package istanbul
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	checkpointInterval = 1024 // Number of blocks after which to save the validator snapshot to the database
+	inmemorySnapshots  = 128  // Number of recent validator snapshots to keep in memory
+	inmemorySignatures = 4096 // Number of recent block signatures to keep in memory
+)
+
+// Istanbul protocol constants.
+var (
+	epochLength    = uint64(30000) // Default number of blocks after which to checkpoint and reset the pending votes
+	blockPeriod    = uint64(1)     // Default minimum difference between two consecutive block's timestamps
+	requestTimeout = uint64(10000) // Default milliseconds to wait for a round to complete
+
+	defaultDifficulty = big.NewInt(1)            // Difficulty of all blocks, as there are no forks to choose from
+	uncleHash         = types.CalcUncleHash(nil) // Always Keccak256(RLP([])) as uncles are meaningless outside of PoW.
+)
+
+// Various error messages to mark blocks invalid. These should be private to
+// prevent engine specific errors from being referenced in the remainder of the
+// codebase, inherently breaking if the engine is swapped out. Please put common
+// error types into the consensus package.
+var (
+	// errUnknownBlock is returned when the list of validators is requested for a
+	// block that is not part of the local blockchain.
+	errUnknownBlock = errors.New("unknown block")
+
+	// errInvalidExtraDataFormat is returned when the extra-data of a header can't
+	// be decoded into the Istanbul fields.
+	errInvalidExtraDataFormat = errors.New("invalid extra data format")
+
+	// errInvalidMixDigest is returned if a block's mix digest is not the Istanbul digest.
+	errInvalidMixDigest = errors.New("invalid Istanbul mix digest")
+
+	// errInvalidNonce is returned if a block's nonce is non-zero.
+	errInvalidNonce = errors.New("non-zero nonce")
+
+	// errInvalidUncleHash is returned if a block contains an non-empty uncle list.
+	errInvalidUncleHash = errors.New("non empty uncle hash")
+
+	// errInvalidDifficulty is returned if the difficulty of a block is not 1.
+	errInvalidDifficulty = errors.New("invalid difficulty")
+
+	// errInvalidTimestamp is returned if the timestamp of a block is lower than
+	// the previous block's timestamp + the minimum block period.
+	errInvalidTimestamp = errors.New("invalid timestamp")
+
+	// errInvalidValidators is returned if a header doesn't embed the validator set
+	// in force at its parent.
+	errInvalidValidators = errors.New("invalid validator set")
+
+	// errInvalidCheckpointVote is returned if a checkpoint block contains a vote.
+	errInvalidCheckpointVote = errors.New("vote in checkpoint block")
+
+	// errInvalidVotingChain is returned if an authorization list is attempted to
+	// be modified via out-of-range or non-contiguous headers.
+	errInvalidVotingChain = errors.New("invalid voting chain")
+
+	// errUnauthorized is returned if a header is proposed by a non-validator.
+	errUnauthorized = errors.New("unauthorized")
+
+	// errInvalidCommittedSeals is returned if a header doesn't contain the commit
+	// seals of a quorum of distinct validators.
+	errInvalidCommittedSeals = errors.New("invalid committed seals")
+
+	// errInvalidSignature is returned if a consensus message is not signed by the
+	// validator it claims to originate from.
+	errInvalidSignature = errors.New("invalid message signature")
+
+	// errInvalidMessage is returned if a consensus message can't be decoded.
+	errInvalidMessage = errors.New("invalid message")
+
+	// errStopped is returned if a block is attempted to be sealed while the
+	// consensus message processing is not running.
+	errStopped = errors.New("consensus not running")
+)
+
+// sigHash returns the hash which is used as input for the proposer seal. It is
+// the hash of the entire header apart from the seals in the extra-data.
+func sigHash(header *types.Header) (hash common.Hash, err error) {
+	filtered := filteredHeader(header, false)
+	if filtered == nil {
+		return common.Hash{}, errInvalidExtraDataFormat
+	}
+	blob, err := rlp.EncodeToBytes(filtered)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(blob), nil
+}
+
+// sealHash returns the hash identifying the proposal a header was finalized from,
+// which the validators commit to. It is the hash of the entire header apart from
+// the committed seals, which are only gathered after the agreement.
+func sealHash(header *types.Header) (hash common.Hash, err error) {
+	filtered := filteredHeader(header, true)
+	if filtered == nil {
+		return common.Hash{}, errInvalidExtraDataFormat
+	}
+	blob, err := rlp.EncodeToBytes(filtered)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(blob), nil
+}
+
+// ecrecover extracts the Ethereum account address of the proposer of a header.
+func ecrecover(header *types.Header, sigcache *lru.ARCCache) (common.Address, error) {
+	// If the signature's already cached, return that
+	hash := header.Hash()
+	if address, known := sigcache.Get(hash); known {
+		return address.(common.Address), nil
+	}
+	// Retrieve the signature from the header extra-data
+	extra, err := extractExtra(header)
+	if err != nil {
+		return common.Address{}, errInvalidExtraDataFormat
+	}
+	sighash, err := sigHash(header)
+	if err != nil {
+		return common.Address{}, err
+	}
+	pubkey, err := crypto.SigToPub(sighash.Bytes(), extra.Seal)
+	if err != nil {
+		return common.Address{}, err
+	}
+	signer := crypto.PubkeyToAddress(*pubkey)
+
+	sigcache.Add(hash, signer)
+	return signer, nil
+}
+
+// Istanbul is the byzantine fault tolerant consensus engine.
+type Istanbul struct {
+	config *params.IstanbulConfig // Consensus engine configuration parameters
+	db     ethdb.Database         // Database to store and retrieve snapshot checkpoints
+
+	key     *ecdsa.PrivateKey // Validator key to seal blocks and sign consensus messages with
+	address common.Address    // Ethereum address of the validator key
+
+	recents    *lru.ARCCache // Snapshots for recent block to speed up reorgs
+	signatures *lru.ARCCache // Signatures of recent blocks to speed up mining
+
+	proposals map[common.Address]bool // Current list of proposals we are pushing
+	lock      sync.RWMutex            // Protects the proposals
+
+	core    *stateMachine            // Consensus state machine, nil if not running
+	chain   consensus.ChainReader    // Chain the consensus is running on top of
+	verify  func(*types.Block) error // Callback to fully validate proposals before agreeing on them
+	insert  func(*types.Block) error // Callback to import finalized blocks sealed by others
+	sealing common.Hash              // Hash of the proposal being sealed locally
+	sealed  chan *types.Block        // Channel to deliver the finalized local proposal on
+	network *network                 // Consensus message gossiping among the peers
+	running sync.RWMutex             // Protects the consensus running fields
+}
+
+// New creates an Istanbul byzantine fault tolerant consensus engine, using the
+// given key as the validator identity.
+func New(config *params.IstanbulConfig, key *ecdsa.PrivateKey, db ethdb.Database) *Istanbul {
+	// Set any missing consensus parameters to their defaults
+	conf := *config
+	if conf.Epoch == 0 {
+		conf.Epoch = epochLength
+	}
+	if conf.RequestTimeout == 0 {
+		conf.RequestTimeout = requestTimeout
+	}
+	// Allocate the snapshot caches and create the engine
+	recents, _ := lru.NewARC(inmemorySnapshots)
+	signatures, _ := lru.NewARC(inmemorySignatures)
+
+	return &Istanbul{
+		config:     &conf,
+		db:         db,
+		key:        key,
+		address:    crypto.PubkeyToAddress(key.PublicKey),
+		recents:    recents,
+		signatures: signatures,
+		proposals:  make(map[common.Address]bool),
+		network:    newNetwork(),
+	}
+}
+
+// Author implements consensus.Engine, returning the Ethereum address recovered
+// from the proposer seal in the header's extra-data section.
+func (c *Istanbul) Author(header *types.Header) (common.Address, error) {
+	return ecrecover(header, c.signatures)
+}
+
+// VerifyHeader checks whether a header conforms to the consensus rules.
+func (c *Istanbul) VerifyHeader(chain consensus.ChainReader, header *types.Header, seal bool) error {
+	return c.verifyHeader(chain, header, nil, true)
+}
+
+// VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers. The
+// method returns a quit channel to abort the operations and a results channel to
+// retrieve the async verifications (the order is that of the input slice).
+func (c *Istanbul) VerifyHeaders(chain consensus.ChainReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
+	abort := make(chan struct{})
+	results := make(chan error, len(headers))
+
+	go func() {
+		for i, header := range headers {
+			err := c.verifyHeader(chain, header, headers[:i], true)
+
+			select {
+			case <-abort:
+				return
+			case results <- err:
+			}
+		}
+	}()
+	return abort, results
+}
+
+// verifyHeader checks whether a header conforms to the consensus rules. The
+// caller may optionally pass in a batch of parents (ascending order) to avoid
+// looking those up from the database. Proposals are verified without requiring
+// the committed seals, which are only gathered after the agreement.
+func (c *Istanbul) verifyHeader(chain consensus.ChainReader, header *types.Header, parents []*types.Header, committed bool) error {
+	if header.Number == nil {
+		return errUnknownBlock
+	}
+	// Don't waste time checking blocks from the future
+	if header.Time.Cmp(big.NewInt(time.Now().Unix())) > 0 {
+		return consensus.ErrFutureBlock
+	}
+	// Ensure that the extra-data contains the Istanbul fields
+	extra, err := extractExtra(header)
+	if err != nil {
+		return errInvalidExtraDataFormat
+	}
+	// Votes are carried in the extra-data, the nonce is unused
+	if header.Nonce != (types.BlockNonce{}) {
+		return errInvalidNonce
+	}
+	if header.Number.Uint64()%c.config.Epoch == 0 && extra.Candidate != (common.Address{}) {
+		return errInvalidCheckpointVote
+	}
+	// Ensure that the mix digest marks the header as an Istanbul one
+	if header.MixDigest != istanbulDigest {
+		return errInvalidMixDigest
+	}
+	// Ensure that the block doesn't contain any uncles which are meaningless in BFT
+	if header.UncleHash != uncleHash {
+		return errInvalidUncleHash
+	}
+	// Ensure that the block's difficulty is meaningful
+	if header.Difficulty == nil || header.Difficulty.Cmp(defaultDifficulty) != 0 {
+		return errInvalidDifficulty
+	}
+	// If all checks passed, validate any special fields for hard forks
+	if err := misc.VerifyForkHashes(chain.Config(), header, false); err != nil {
+		return err
+	}
+	// All basic checks passed, verify cascading fields
+	return c.verifyCascadingFields(chain, header, parents, committed)
+}
+
+// verifyCascadingFields verifies all the header fields that are not standalone,
+// rather depend on a batch of previous headers. The caller may optionally pass
+// in a batch of parents (ascending order) to avoid looking those up from the
+// database. This is useful for concurrently verifying a batch of new headers.
+func (c *Istanbul) verifyCascadingFields(chain consensus.ChainReader, header *types.Header, parents []*types.Header, committed bool) error {
+	// The genesis block is the always valid dead-end
+	number := header.Number.Uint64()
+	if number == 0 {
+		return nil
+	}
+	// Ensure that the block's timestamp isn't too close to it's parent
+	var parent *types.Header
+	if len(parents) > 0 {
+		parent = parents[len(parents)-1]
+	} else {
+		parent = chain.GetHeader(header.ParentHash, number-1)
+	}
+	if parent == nil || parent.Number.Uint64() != number-1 || parent.Hash() != header.ParentHash {
+		return consensus.ErrUnknownAncestor
+	}
+	if parent.Time.Uint64()+c.config.BlockPeriod > header.Time.Uint64() {
+		return errInvalidTimestamp
+	}
+	// Retrieve the snapshot needed to verify this header and cache it
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, parents)
+	if err != nil {
+		return err
+	}
+	// Ensure the header embeds the validator set in force
+	extra, err := extractExtra(header)
+	if err != nil {
+		return errInvalidExtraDataFormat
+	}
+	if len(extra.Validators) != len(snap.Validators) {
+		return errInvalidValidators
+	}
+	for i, validator := range snap.Validators {
+		if extra.Validators[i] != validator {
+			return errInvalidValidators
+		}
+	}
+	// Ensure the block was proposed by a validator
+	proposer, err := ecrecover(header, c.signatures)
+	if err != nil {
+		return err
+	}
+	if !snap.isValidator(proposer) {
+		return errUnauthorized
+	}
+	// All basic checks passed, verify the committed seals if finalized
+	if !committed {
+		return nil
+	}
+	return c.verifyCommittedSeals(header, snap)
+}
+
+// verifyCommittedSeals checks whether the header contains the commit seals of
+// a quorum of distinct validators.
+func (c *Istanbul) verifyCommittedSeals(header *types.Header, snap *Snapshot) error {
+	extra, err := extractExtra(header)
+	if err != nil {
+		return errInvalidExtraDataFormat
+	}
+	proposal, err := sealHash(header)
+	if err != nil {
+		return err
+	}
+	hash := commitHash(proposal)
+
+	committers := make(map[common.Address]struct{})
+	for _, seal := range extra.CommittedSeal {
+		pubkey, err := crypto.SigToPub(hash, seal)
+		if err != nil {
+			return errInvalidCommittedSeals
+		}
+		committer := crypto.PubkeyToAddress(*pubkey)
+		if !snap.isValidator(committer) {
+			return errInvalidCommittedSeals
+		}
+		if _, ok := committers[committer]; ok {
+			return errInvalidCommittedSeals
+		}
+		committers[committer] = struct{}{}
+	}
+	if len(committers) < snap.quorum() {
+		return errInvalidCommittedSeals
+	}
+	return nil
+}
+
+// snapshot retrieves the validator snapshot at a given point in time.
+func (c *Istanbul) snapshot(chain consensus.ChainReader, number uint64, hash common.Hash, parents []*types.Header) (*Snapshot, error) {
+	// Search for a snapshot in memory or on disk for checkpoints
+	var (
+		headers []*types.Header
+		snap    *Snapshot
+	)
+	for snap == nil {
+		// If an in-memory snapshot was found, use that
+		if s, ok := c.recents.Get(hash); ok {
+			snap = s.(*Snapshot)
+			break
+		}
+		// If an on-disk checkpoint snapshot can be found, use that
+		if number%checkpointInterval == 0 {
+			if s, err := loadSnapshot(c.config.Epoch, c.signatures, c.db, hash); err == nil {
+				log.Trace("Loaded validator snapshot form disk", "number", number, "hash", hash)
+				snap = s
+				break
+			}
+		}
+		// If we're at block zero, make a snapshot
+		if number == 0 {
+			genesis := chain.GetHeaderByNumber(0)
+			if err := c.VerifyHeader(chain, genesis, false); err != nil {
+				return nil, err
+			}
+			extra, err := extractExtra(genesis)
+			if err != nil {
+				return nil, errInvalidExtraDataFormat
+			}
+			snap = newSnapshot(c.config.Epoch, c.signatures, 0, genesis.Hash(), extra.Validators)
+			if err := snap.store(c.db); err != nil {
+				return nil, err
+			}
+			log.Trace("Stored genesis validator snapshot to disk")
+			break
+		}
+		// No snapshot for this header, gather the header and move backward
+		var header *types.Header
+		if len(parents) > 0 {
+			// If we have explicit parents, pick from there (enforced)
+			header = parents[len(parents)-1]
+			if header.Hash() != hash || header.Number.Uint64() != number {
+				return nil, consensus.ErrUnknownAncestor
+			}
+			parents = parents[:len(parents)-1]
+		} else {
+			// No explicit parents (or no more left), reach out to the database
+			header = chain.GetHeader(hash, number)
+			if header == nil {
+				return nil, consensus.ErrUnknownAncestor
+			}
+		}
+		headers = append(headers, header)
+		number, hash = number-1, header.ParentHash
+	}
+	// Previous snapshot found, apply any pending headers on top of it
+	for i := 0; i < len(headers)/2; i++ {
+		headers[i], headers[len(headers)-1-i] = headers[len(headers)-1-i], headers[i]
+	}
+	snap, err := snap.apply(headers)
+	if err != nil {
+		return nil, err
+	}
+	c.recents.Add(snap.Hash, snap)
+
+	// If we've generated a new checkpoint snapshot, save to disk
+	if snap.Number%checkpointInterval == 0 && len(headers) > 0 {
+		if err = snap.store(c.db); err != nil {
+			return nil, err
+		}
+		log.Trace("Stored validator snapshot to disk", "number", snap.Number, "hash", snap.Hash)
+	}
+	return snap, err
+}
+
+// VerifyUncles implements consensus.Engine, always returning an error for any
+// uncles as this consensus mechanism doesn't permit uncles.
+func (c *Istanbul) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
+	if len(block.Uncles()) > 0 {
+		return errors.New("uncles not allowed")
+	}
+	return nil
+}
+
+// VerifySeal implements consensus.Engine, checking whether the proposer seal and
+// the committed seals contained in the header satisfy the consensus protocol
+// requirements.
+func (c *Istanbul) VerifySeal(chain consensus.ChainReader, header *types.Header) error {
+	// Verifying the genesis block is not supported
+	number := header.Number.Uint64()
+	if number == 0 {
+		return errUnknownBlock
+	}
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return err
+	}
+	proposer, err := ecrecover(header, c.signatures)
+	if err != nil {
+		return err
+	}
+	if !snap.isValidator(proposer) {
+		return errUnauthorized
+	}
+	return c.verifyCommittedSeals(header, snap)
+}
+
+// Prepare implements consensus.Engine, preparing all the consensus fields of the
+// header for running the transactions on top.
+func (c *Istanbul) Prepare(chain consensus.ChainReader, header *types.Header) error {
+	header.Nonce = types.BlockNonce{}
+	header.MixDigest = istanbulDigest
+	header.Difficulty = new(big.Int).Set(defaultDifficulty)
+
+	number := header.Number.Uint64()
+	// Assemble the validator snapshot to check which votes make sense
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return err
+	}
+	extra := &istanbulExtra{Validators: snap.Validators}
+	if number%c.config.Epoch != 0 {
+		c.lock.RLock()
+
+		// Gather all the proposals that make sense voting on
+		addresses := make([]common.Address, 0, len(c.proposals))
+		for address, authorize := range c.proposals {
+			if snap.validVote(address, authorize) {
+				addresses = append(addresses, address)
+			}
+		}
+		// If there's pending proposals, cast a vote on them
+		if len(addresses) > 0 {
+			extra.Candidate = addresses[rand.Intn(len(addresses))]
+			extra.Authorize = c.proposals[extra.Candidate]
+		}
+		c.lock.RUnlock()
+	}
+	// Ensure the extra data has all it's components
+	if len(header.Extra) < extraVanity {
+		header.Extra = append(header.Extra, bytes.Repeat([]byte{0x00}, extraVanity-len(header.Extra))...)
+	}
+	payload, err := rlp.EncodeToBytes(extra)
+	if err != nil {
+		return err
+	}
+	header.Extra = append(header.Extra[:extraVanity], payload...)
+
+	// Ensure the timestamp has the correct delay
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	header.Time = new(big.Int).Add(parent.Time, new(big.Int).SetUint64(c.config.BlockPeriod))
+	if header.Time.Int64() < time.Now().Unix() {
+		header.Time = big.NewInt(time.Now().Unix())
+	}
+	return nil
+}
+
+// Finalize implements consensus.Engine, ensuring no uncles are set, nor block
+// rewards given, and returns the final block.
+func (c *Istanbul) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
+	// No block rewards in BFT, so the state remains as is and uncles are dropped
+	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
+	header.UncleHash = types.CalcUncleHash(nil)
+
+	// Assemble and return the final block for sealing
+	return types.NewBlock(header, txs, nil, receipts), nil
+}
+
+// Seal implements consensus.Engine, signing the block as its proposer and
+// running it through the consensus rounds. The method returns the block with
+// the committed seals of the validators once agreed upon, or nil if the chain
+// moved on with another proposal in the meantime.
+func (c *Istanbul) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan struct{}) (*types.Block, error) {
+	header := block.Header()
+
+	// Sealing the genesis block is not supported
+	number := header.Number.Uint64()
+	if number == 0 {
+		return nil, errUnknownBlock
+	}
+	// Bail out if we're unauthorized to propose a block
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !snap.isValidator(c.address) {
+		return nil, errUnauthorized
+	}
+	// Sign the header as its proposer
+	if err := c.signProposal(header); err != nil {
+		return nil, err
+	}
+	block = block.WithSeal(header)
+
+	// Wait until the block is due before putting it up for agreement
+	delay := time.Unix(header.Time.Int64(), 0).Sub(time.Now()) // nolint: gosimple
+	select {
+	case <-stop:
+		return nil, nil
+	case <-time.After(delay):
+	}
+	c.running.Lock()
+	if c.core == nil {
+		c.running.Unlock()
+		return nil, errStopped
+	}
+	core, sealed := c.core, make(chan *types.Block, 1)
+	c.sealing, c.sealed = block.Hash(), sealed // No committed seals yet, the hash is the seal hash
+	c.running.Unlock()
+
+	defer func() {
+		c.running.Lock()
+		if c.sealed == sealed {
+			c.sealing, c.sealed = common.Hash{}, nil
+		}
+		c.running.Unlock()
+	}()
+	core.request(block)
+
+	select {
+	case result := <-sealed:
+		return result, nil
+	case <-stop:
+		return nil, nil
+	}
+}
+
+// signProposal embeds the proposer seal into the header's extra-data.
+func (c *Istanbul) signProposal(header *types.Header) error {
+	extra, err := extractExtra(header)
+	if err != nil {
+		return errInvalidExtraDataFormat
+	}
+	sighash, err := sigHash(header)
+	if err != nil {
+		return err
+	}
+	if extra.Seal, err = crypto.Sign(sighash.Bytes(), c.key); err != nil {
+		return err
+	}
+	payload, err := rlp.EncodeToBytes(extra)
+	if err != nil {
+		return err
+	}
+	header.Extra = append(header.Extra[:extraVanity], payload...)
+	return nil
+}
+
+// finalize assembles the finalized block of a proposal by embedding the commit
+// seals of the validators into its header's extra-data.
+func (c *Istanbul) finalize(proposal *types.Block, seals [][]byte) (*types.Block, error) {
+	header := proposal.Header()
+
+	extra, err := extractExtra(header)
+	if err != nil {
+		return nil, errInvalidExtraDataFormat
+	}
+	extra.CommittedSeal = seals
+
+	payload, err := rlp.EncodeToBytes(extra)
+	if err != nil {
+		return nil, err
+	}
+	header.Extra = append(header.Extra[:extraVanity], payload...)
+	return proposal.WithSeal(header), nil
+}
+
+// commit is invoked by the consensus once a proposal was finalized, handing the
+// block either to the local sealer waiting for it or importing it into the chain.
+func (c *Istanbul) commit(block *types.Block) error {
+	hash, err := sealHash(block.Header())
+	if err != nil {
+		return err
+	}
+	c.running.RLock()
+	sealing, sealed, insert := c.sealing, c.sealed, c.insert
+	c.running.RUnlock()
+
+	if sealed != nil && sealing == hash {
+		sealed <- block
+		return nil
+	}
+	if insert == nil {
+		return nil
+	}
+	return insert(block)
+}
+
+// CalcDifficulty is the difficulty adjustment algorithm, always returning 1 as
+// blocks are final and there are no forks to choose from.
+func (c *Istanbul) CalcDifficulty(chain consensus.ChainReader, time uint64, parent *types.Header) *big.Int {
+	return new(big.Int).Set(defaultDifficulty)
+}
+
+// Start implements consensus.Handler, starting the consensus state machine on
+// top of the given chain.
+func (c *Istanbul) Start(chain consensus.ChainReader, verify func(*types.Block) error, insert func(*types.Block) error) error {
+	c.running.Lock()
+	defer c.running.Unlock()
+
+	if c.core != nil {
+		return nil
+	}
+	c.chain, c.verify, c.insert = chain, verify, insert
+	c.core = newStateMachine(c, chain)
+	c.core.start()
+
+	return nil
+}
+
+// Stop implements consensus.Handler, terminating the consensus state machine.
+func (c *Istanbul) Stop() error {
+	c.running.Lock()
+	core := c.core
+	c.core = nil
+	c.running.Unlock()
+
+	if core != nil {
+		core.stop()
+	}
+	return nil
+}
+
+// APIs implements consensus.Engine, returning the user facing RPC API to allow
+// controlling the validator voting.
+func (c *Istanbul) APIs(chain consensus.ChainReader) []rpc.API {
+	return []rpc.API{{
+		Namespace: "istanbul",
+		Version:   "1.0",
+		Service:   &API{chain: chain, istanbul: c},
+		Public:    false,
+	}}
+}
@@ -0,0 +1,389 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package istanbul
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/p2p/simulations"
+	"github.com/ethereum/go-ethereum/p2p/simulations/adapters"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// testerValidator is a simulated node service running the consensus on its own
+// chain, with the node key as the validator identity.
+type testerValidator struct {
+	engine *Istanbul
+	chain  *core.BlockChain
+	fault  func(*types.Header) // Corruption applied to proposals, nil for valid ones
+}
+
+// newTesterValidator creates a validator service on top of the given genesis.
+func newTesterValidator(ctx *adapters.ServiceContext, genesis *core.Genesis) (node.Service, error) {
+	db, _ := ethdb.NewMemDatabase()
+	genesis.MustCommit(db)
+
+	engine := New(&params.IstanbulConfig{RequestTimeout: 250}, ctx.Config.PrivateKey, db)
+	chain, err := core.NewBlockChain(db, nil, genesis.Config, engine, vm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	return &testerValidator{engine: engine, chain: chain}, nil
+}
+
+func (v *testerValidator) Protocols() []p2p.Protocol { return v.engine.Protocols() }
+func (v *testerValidator) APIs() []rpc.API           { return nil }
+
+func (v *testerValidator) Start(*p2p.Server) error {
+	insert := func(block *types.Block) error {
+		_, err := v.chain.InsertChain(types.Blocks{block})
+		return err
+	}
+	return v.engine.Start(v.chain, v.chain.ValidateBlock, insert)
+}
+
+func (v *testerValidator) Stop() error {
+	v.engine.Stop()
+	v.chain.Stop()
+	return nil
+}
+
+// testerNetwork is a simulated network of validators agreeing on blocks.
+type testerNetwork struct {
+	*simulations.Network
+	ids []discover.NodeID
+}
+
+// newTesterNetwork creates a simulated network of the given number of validators,
+// starting and fully connecting all of them apart from the offline ones.
+func newTesterNetwork(t *testing.T, validators int, offline map[int]bool) *testerNetwork {
+	// Generate the validator identities and the genesis authorizing them
+	configs := make([]*adapters.NodeConfig, validators)
+	addresses := make([]common.Address, validators)
+	for i := range configs {
+		configs[i] = adapters.RandomNodeConfig()
+		configs[i].Name = fmt.Sprintf("validator-%d", i)
+		addresses[i] = crypto.PubkeyToAddress(configs[i].PrivateKey.PublicKey)
+	}
+	genesis := testerGenesis(addresses)
+
+	adapter := adapters.NewSimAdapter(adapters.Services{
+		"istanbul": func(ctx *adapters.ServiceContext) (node.Service, error) {
+			return newTesterValidator(ctx, genesis)
+		},
+	})
+	network := &testerNetwork{
+		Network: simulations.NewNetwork(adapter, &simulations.NetworkConfig{DefaultService: "istanbul"}),
+	}
+	for i, config := range configs {
+		if _, err := network.NewNodeWithConfig(config); err != nil {
+			network.Shutdown()
+			t.Fatalf("validator %d: failed to create node: %v", i, err)
+		}
+		if offline[i] {
+			continue
+		}
+		if err := network.Start(config.ID); err != nil {
+			network.Shutdown()
+			t.Fatalf("validator %d: failed to start node: %v", i, err)
+		}
+		network.ids = append(network.ids, config.ID)
+	}
+	// Connect the online validators with each other and wait for the handshakes
+	for i := 0; i < len(network.ids); i++ {
+		for j := i + 1; j < len(network.ids); j++ {
+			if err := network.Connect(network.ids[i], network.ids[j]); err != nil {
+				network.Shutdown()
+				t.Fatalf("failed to connect validators: %v", err)
+			}
+		}
+	}
+	for deadline := time.Now().Add(10 * time.Second); ; {
+		connected := true
+		for _, id := range network.ids {
+			engine := network.validator(id).engine
+
+			engine.network.lock.RLock()
+			peers := len(engine.network.peers)
+			engine.network.lock.RUnlock()
+
+			connected = connected && peers == len(network.ids)-1
+		}
+		if connected {
+			break
+		}
+		if time.Now().After(deadline) {
+			network.Shutdown()
+			t.Fatalf("validators failed to connect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return network
+}
+
+// validator retrieves the validator service running on a simulated node.
+func (n *testerNetwork) validator(id discover.NodeID) *testerValidator {
+	return n.GetNode(id).Node.(*adapters.SimNode).Services()[0].(*testerValidator)
+}
+
+// run keeps all the online validators proposing blocks until they all reach the
+// target height, returning the validators once done.
+func (n *testerNetwork) run(t *testing.T, target uint64) []*testerValidator {
+	validators := make([]*testerValidator, len(n.ids))
+	for i, id := range n.ids {
+		validators[i] = n.validator(id)
+	}
+	var (
+		wg   sync.WaitGroup
+		errc = make(chan error, len(validators))
+	)
+	for _, validator := range validators {
+		wg.Add(1)
+		go func(v *testerValidator) {
+			defer wg.Done()
+
+			deadline := time.Now().Add(time.Minute)
+			for v.chain.CurrentBlock().NumberU64() < target {
+				if time.Now().After(deadline) {
+					errc <- fmt.Errorf("validator %x stuck at block %d", v.engine.address, v.chain.CurrentBlock().NumberU64())
+					return
+				}
+				if err := v.propose(); err != nil {
+					errc <- err
+					return
+				}
+			}
+		}(validator)
+	}
+	wg.Wait()
+	close(errc)
+
+	for err := range errc {
+		t.Fatalf("failed to agree on blocks: %v", err)
+	}
+	return validators
+}
+
+// propose assembles an empty block on top of the validator's chain head and
+// runs it through the consensus, importing it if it was agreed upon. It returns
+// as soon as the chain head changes.
+func (v *testerValidator) propose() error {
+	parent := v.chain.CurrentBlock()
+
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), big.NewInt(1)),
+		GasLimit:   core.CalcGasLimit(parent),
+	}
+	if err := v.engine.Prepare(v.chain, header); err != nil {
+		return err
+	}
+	statedb, err := v.chain.StateAt(parent.Root())
+	if err != nil {
+		return err
+	}
+	block, err := v.engine.Finalize(v.chain, header, statedb, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	if v.fault != nil {
+		header := block.Header()
+		v.fault(header)
+		block = block.WithSeal(header)
+	}
+	// Abort sealing as soon as someone else's proposal got finalized
+	stop, done := make(chan struct{}), make(chan struct{})
+	defer close(done)
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+				if v.chain.CurrentBlock().Hash() != parent.Hash() {
+					close(stop)
+					return
+				}
+			}
+		}
+	}()
+	result, err := v.engine.Seal(v.chain, block, stop)
+	if err != nil || result == nil {
+		return err
+	}
+	_, err = v.chain.InsertChain(types.Blocks{result})
+	return err
+}
+
+// checkChains ensures all the validators finalized the same chain up to the
+// target height with valid seals, returning the addresses of the validators
+// that should have proposed each block in the first round, and the ones that
+// actually did.
+func checkChains(t *testing.T, validators []*testerValidator, target uint64) (expected, proposers []common.Address) {
+	for number := uint64(1); number <= target; number++ {
+		want := validators[0].chain.GetBlockByNumber(number)
+		if want == nil {
+			t.Fatalf("block %d: missing on validator 0", number)
+		}
+		for i, validator := range validators {
+			block := validator.chain.GetBlockByNumber(number)
+			if block == nil {
+				t.Fatalf("block %d: missing on validator %d", number, i)
+			}
+			if block.Hash() != want.Hash() {
+				t.Errorf("block %d: hash mismatch on validator %d: have %x, want %x", number, i, block.Hash(), want.Hash())
+			}
+			if err := validator.engine.VerifySeal(validator.chain, block.Header()); err != nil {
+				t.Errorf("block %d: invalid seal on validator %d: %v", number, i, err)
+			}
+		}
+		engine, chain := validators[0].engine, validators[0].chain
+
+		snap, err := engine.snapshot(chain, number-1, want.ParentHash(), nil)
+		if err != nil {
+			t.Fatalf("block %d: failed to retrieve snapshot: %v", number, err)
+		}
+		proposer, err := engine.Author(want.Header())
+		if err != nil {
+			t.Fatalf("block %d: failed to retrieve proposer: %v", number, err)
+		}
+		expected = append(expected, snap.proposer(number, 0))
+		proposers = append(proposers, proposer)
+	}
+	return expected, proposers
+}
+
+// Tests that a set of validators agrees on the same blocks, each finalized by
+// the committed seals of a quorum of them.
+func TestConsensus(t *testing.T) {
+	network := newTesterNetwork(t, 4, nil)
+	defer network.Shutdown()
+
+	validators := network.run(t, 5)
+	expected, proposers := checkChains(t, validators, 5)
+
+	// Without failures, every block should be agreed upon in the first round
+	for i := range expected {
+		if proposers[i] != expected[i] {
+			t.Errorf("block %d: proposer mismatch: have %x, want %x", i+1, proposers[i], expected[i])
+		}
+	}
+}
+
+// Tests that the validators change rounds if a proposer suggests invalid blocks,
+// agreeing on the proposal of the next validator instead.
+func TestConsensusFaultyProposer(t *testing.T) {
+	t.Run("difficulty", func(t *testing.T) {
+		testConsensusFaultyProposer(t, func(header *types.Header) { header.Difficulty = big.NewInt(2) })
+	})
+	t.Run("state root", func(t *testing.T) {
+		testConsensusFaultyProposer(t, func(header *types.Header) { header.Root = common.Hash{0x01} })
+	})
+}
+
+func testConsensusFaultyProposer(t *testing.T, fault func(*types.Header)) {
+	network := newTesterNetwork(t, 4, nil)
+	defer network.Shutdown()
+
+	faulty := network.validator(network.ids[0])
+	faulty.fault = fault
+
+	validators := network.run(t, 5)
+	expected, proposers := checkChains(t, validators, 5)
+
+	changes := 0
+	for i := range expected {
+		if proposers[i] == faulty.engine.address {
+			t.Errorf("block %d: invalid proposal of faulty validator accepted", i+1)
+		}
+		if expected[i] == faulty.engine.address {
+			changes++
+		}
+	}
+	if changes == 0 {
+		t.Errorf("faulty validator never had to propose")
+	}
+}
+
+// Tests that the validators keep agreeing on blocks while some of them are
+// offline, as long as a quorum is still reachable, changing rounds whenever an
+// offline validator should propose.
+func TestConsensusOfflineValidator(t *testing.T) {
+	network := newTesterNetwork(t, 4, map[int]bool{3: true})
+	defer network.Shutdown()
+
+	validators := network.run(t, 5)
+	expected, proposers := checkChains(t, validators, 5)
+
+	online := make(map[common.Address]bool)
+	for _, validator := range validators {
+		online[validator.engine.address] = true
+	}
+	changes := 0
+	for i := range expected {
+		if !online[proposers[i]] {
+			t.Errorf("block %d: proposed by offline validator %x", i+1, proposers[i])
+		}
+		if !online[expected[i]] {
+			changes++
+		}
+	}
+	if changes == 0 {
+		t.Errorf("offline validator never had to propose")
+	}
+}
+
+// Tests that the committed seals are part of the block identity, but not of the
+// proposal the validators commit to.
+func TestSealHash(t *testing.T) {
+	extra, _ := rlp.EncodeToBytes(&istanbulExtra{Seal: []byte{0x01}})
+	header := &types.Header{
+		Number:    big.NewInt(1),
+		MixDigest: istanbulDigest,
+		Extra:     append(make([]byte, extraVanity), extra...),
+	}
+	committed := types.CopyHeader(header)
+	extra, _ = rlp.EncodeToBytes(&istanbulExtra{Seal: []byte{0x01}, CommittedSeal: [][]byte{{0x02}}})
+	committed.Extra = append(make([]byte, extraVanity), extra...)
+
+	if header.Hash() == committed.Hash() {
+		t.Errorf("committed seals not part of the block hash")
+	}
+	want, _ := sealHash(header)
+	if have, _ := sealHash(committed); have != want {
+		t.Errorf("seal hash mismatch: have %x, want %x", have, want)
+	}
+	if want != header.Hash() {
+		t.Errorf("seal hash of uncommitted header mismatch: have %x, want %x", want, header.Hash())
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package istanbul
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Consensus message codes exchanged between the validators within a round.
+const (
+	msgPreprepare uint64 = iota
+	msgPrepare
+	msgCommit
+	msgRoundChange
+	msgFinal
+)
+
+// View identifies a round of the consensus: the sequence is the number of the
+// block being agreed upon, the round is incremented every time the validators
+// fail to agree on a proposal in time.
+type View struct {
+	Sequence uint64
+	Round    uint64
+}
+
+// Cmp compares two views, returning -1, 0 or +1 if v is before, the same or
+// after the other view.
+func (v View) Cmp(other View) int {
+	switch {
+	case v.Sequence < other.Sequence:
+		return -1
+	case v.Sequence > other.Sequence:
+		return 1
+	case v.Round < other.Round:
+		return -1
+	case v.Round > other.Round:
+		return 1
+	}
+	return 0
+}
+
+// String implements the stringer interface.
+func (v View) String() string {
+	return fmt.Sprintf("{Sequence: %d, Round: %d}", v.Sequence, v.Round)
+}
+
+// preprepare is the payload of the message a proposer uses to suggest a block.
+type preprepare struct {
+	View     View
+	Proposal *types.Block
+}
+
+// final is the payload of the message a proposer uses to distribute the block
+// finalized with the commit seals it gathered, so all validators import the same.
+type final struct {
+	View  View
+	Block *types.Block
+}
+
+// subject is the payload of the prepare, commit and round change messages,
+// referencing the proposal being voted on (zero for round changes).
+type subject struct {
+	View   View
+	Digest common.Hash
+}
+
+// message is the signed envelope of all the consensus messages.
+type message struct {
+	Code          uint64
+	Payload       []byte
+	Address       common.Address // Validator that sent the message
+	Signature     []byte         // Signature of the validator over the rest of the message
+	CommittedSeal []byte         // Commit seal of the proposal (commit messages only)
+}
+
+// sigHash returns the hash which the validator signs the message over.
+func (m *message) sigHash() common.Hash {
+	blob, _ := rlp.EncodeToBytes([]interface{}{m.Code, m.Payload, m.Address, m.CommittedSeal})
+	return crypto.Keccak256Hash(blob)
+}
+
+// newMessage creates a consensus message with the given payload, signed by
+// the validator key.
+func newMessage(code uint64, payload interface{}, key *ecdsa.PrivateKey) (*message, error) {
+	blob, err := rlp.EncodeToBytes(payload)
+	if err != nil {
+		return nil, err
+	}
+	msg := &message{
+		Code:    code,
+		Payload: blob,
+		Address: crypto.PubkeyToAddress(key.PublicKey),
+	}
+	return msg, msg.sign(key)
+}
+
+// sign (re)signs the message with the validator key.
+func (m *message) sign(key *ecdsa.PrivateKey) (err error) {
+	m.Signature, err = crypto.Sign(m.sigHash().Bytes(), key)
+	return err
+}
+
+// decodeMessage parses a consensus message from its wire format and verifies
+// that it was signed by the validator it claims to originate from.
+func decodeMessage(blob []byte) (*message, error) {
+	msg := new(message)
+	if err := rlp.DecodeBytes(blob, msg); err != nil {
+		return nil, err
+	}
+	pubkey, err := crypto.SigToPub(msg.sigHash().Bytes(), msg.Signature)
+	if err != nil {
+		return nil, err
+	}
+	if crypto.PubkeyToAddress(*pubkey) != msg.Address {
+		return nil, errInvalidSignature
+	}
+	return msg, nil
+}
+
+// decodePreprepare parses the payload of a preprepare message.
+func (m *message) decodePreprepare() (*preprepare, error) {
+	pre := new(preprepare)
+	if err := rlp.DecodeBytes(m.Payload, pre); err != nil {
+		return nil, err
+	}
+	if pre.Proposal == nil {
+		return nil, errInvalidMessage
+	}
+	return pre, nil
+}
+
+// decodeFinal parses the payload of a final message.
+func (m *message) decodeFinal() (*final, error) {
+	fin := new(final)
+	if err := rlp.DecodeBytes(m.Payload, fin); err != nil {
+		return nil, err
+	}
+	if fin.Block == nil {
+		return nil, errInvalidMessage
+	}
+	return fin, nil
+}
+
+// decodeSubject parses the payload of a prepare, commit or round change message.
+func (m *message) decodeSubject() (*subject, error) {
+	sub := new(subject)
+	if err := rlp.DecodeBytes(m.Payload, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// commitHash returns the hash the validators sign to commit to a proposal.
+func commitHash(hash common.Hash) []byte {
+	return crypto.Keccak256(hash.Bytes(), []byte{byte(msgCommit)})
+}
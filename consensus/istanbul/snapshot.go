@@ -0,0 +1,302 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package istanbul
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// Vote represents a single vote that a validator made to modify the validator set.
+type Vote struct {
+	Validator common.Address `json:"validator"` // Validator that cast this vote
+	Block     uint64         `json:"block"`     // Block number the vote was cast in (expire old votes)
+	Address   common.Address `json:"address"`   // Account being voted on to change its authorization
+	Authorize bool           `json:"authorize"` // Whether to authorize or deauthorize the voted account
+}
+
+// Tally is a simple vote tally to keep the current score of votes. Votes that
+// go against the proposal aren't counted since it's equivalent to not voting.
+type Tally struct {
+	Authorize bool `json:"authorize"` // Whether the vote is about authorizing or kicking someone
+	Votes     int  `json:"votes"`     // Number of votes until now wanting to pass the proposal
+}
+
+// Snapshot is the state of the validator set at a given point in time.
+type Snapshot struct {
+	epoch    uint64        // Number of blocks after which to checkpoint and reset the pending votes
+	sigcache *lru.ARCCache // Cache of recent block signatures to speed up ecrecover
+
+	Number     uint64                   `json:"number"`     // Block number where the snapshot was created
+	Hash       common.Hash              `json:"hash"`       // Block hash where the snapshot was created
+	Validators []common.Address         `json:"validators"` // Validator set at this moment, in ascending order
+	Votes      []*Vote                  `json:"votes"`      // List of votes cast in chronological order
+	Tally      map[common.Address]Tally `json:"tally"`      // Current vote tally to avoid recalculating
+}
+
+// newSnapshot creates a new snapshot with the specified startup parameters. This
+// method is only ever used for the genesis block.
+func newSnapshot(epoch uint64, sigcache *lru.ARCCache, number uint64, hash common.Hash, validators []common.Address) *Snapshot {
+	snap := &Snapshot{
+		epoch:      epoch,
+		sigcache:   sigcache,
+		Number:     number,
+		Hash:       hash,
+		Validators: make([]common.Address, len(validators)),
+		Tally:      make(map[common.Address]Tally),
+	}
+	copy(snap.Validators, validators)
+	sortAddresses(snap.Validators)
+
+	return snap
+}
+
+// loadSnapshot loads an existing snapshot from the database.
+func loadSnapshot(epoch uint64, sigcache *lru.ARCCache, db ethdb.Database, hash common.Hash) (*Snapshot, error) {
+	blob, err := db.Get(append([]byte("istanbul-"), hash[:]...))
+	if err != nil {
+		return nil, err
+	}
+	snap := new(Snapshot)
+	if err := json.Unmarshal(blob, snap); err != nil {
+		return nil, err
+	}
+	snap.epoch = epoch
+	snap.sigcache = sigcache
+
+	return snap, nil
+}
+
+// store inserts the snapshot into the database.
+func (s *Snapshot) store(db ethdb.Database) error {
+	blob, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return db.Put(append([]byte("istanbul-"), s.Hash[:]...), blob)
+}
+
+// copy creates a deep copy of the snapshot, though not the individual votes.
+func (s *Snapshot) copy() *Snapshot {
+	cpy := &Snapshot{
+		epoch:      s.epoch,
+		sigcache:   s.sigcache,
+		Number:     s.Number,
+		Hash:       s.Hash,
+		Validators: make([]common.Address, len(s.Validators)),
+		Votes:      make([]*Vote, len(s.Votes)),
+		Tally:      make(map[common.Address]Tally),
+	}
+	copy(cpy.Validators, s.Validators)
+	copy(cpy.Votes, s.Votes)
+	for address, tally := range s.Tally {
+		cpy.Tally[address] = tally
+	}
+	return cpy
+}
+
+// isValidator returns whether the given address is part of the validator set.
+func (s *Snapshot) isValidator(address common.Address) bool {
+	for _, validator := range s.Validators {
+		if validator == address {
+			return true
+		}
+	}
+	return false
+}
+
+// proposer returns the validator proposing the block of the given sequence in
+// the given round, rotating round robin over the validator set.
+func (s *Snapshot) proposer(sequence uint64, round uint64) common.Address {
+	if len(s.Validators) == 0 {
+		return common.Address{}
+	}
+	return s.Validators[(sequence+round)%uint64(len(s.Validators))]
+}
+
+// faulty returns the maximum number of byzantine validators the set tolerates.
+func (s *Snapshot) faulty() int {
+	return (len(s.Validators) - 1) / 3
+}
+
+// quorum returns the number of validators needed to agree on a proposal, which
+// is 2F+1 for a set of 3F+1 validators (i.e. two thirds of the set, rounded up).
+func (s *Snapshot) quorum() int {
+	return (2*len(s.Validators) + 2) / 3
+}
+
+// validVote returns whether it makes sense to cast the specified vote in the
+// given snapshot context (e.g. don't try to add an already authorized validator).
+func (s *Snapshot) validVote(address common.Address, authorize bool) bool {
+	validator := s.isValidator(address)
+	return (validator && !authorize) || (!validator && authorize)
+}
+
+// cast adds a new vote into the tally.
+func (s *Snapshot) cast(address common.Address, authorize bool) bool {
+	// Ensure the vote is meaningful
+	if !s.validVote(address, authorize) {
+		return false
+	}
+	// Cast the vote into an existing or new tally
+	if old, ok := s.Tally[address]; ok {
+		old.Votes++
+		s.Tally[address] = old
+	} else {
+		s.Tally[address] = Tally{Authorize: authorize, Votes: 1}
+	}
+	return true
+}
+
+// uncast removes a previously cast vote from the tally.
+func (s *Snapshot) uncast(address common.Address, authorize bool) bool {
+	// If there's no tally, it's a dangling vote, just drop
+	tally, ok := s.Tally[address]
+	if !ok {
+		return false
+	}
+	// Ensure we only revert counted votes
+	if tally.Authorize != authorize {
+		return false
+	}
+	// Otherwise revert the vote
+	if tally.Votes > 1 {
+		tally.Votes--
+		s.Tally[address] = tally
+	} else {
+		delete(s.Tally, address)
+	}
+	return true
+}
+
+// apply creates a new validator set snapshot by applying the given headers to
+// the original one.
+func (s *Snapshot) apply(headers []*types.Header) (*Snapshot, error) {
+	// Allow passing in no headers for cleaner code
+	if len(headers) == 0 {
+		return s, nil
+	}
+	// Sanity check that the headers can be applied
+	for i := 0; i < len(headers)-1; i++ {
+		if headers[i+1].Number.Uint64() != headers[i].Number.Uint64()+1 {
+			return nil, errInvalidVotingChain
+		}
+	}
+	if headers[0].Number.Uint64() != s.Number+1 {
+		return nil, errInvalidVotingChain
+	}
+	// Iterate through the headers and create a new snapshot
+	snap := s.copy()
+
+	for _, header := range headers {
+		// Remove any votes on checkpoint blocks
+		number := header.Number.Uint64()
+		if number%s.epoch == 0 {
+			snap.Votes = nil
+			snap.Tally = make(map[common.Address]Tally)
+		}
+		// Resolve the proposer and check against the validators
+		proposer, err := ecrecover(header, s.sigcache)
+		if err != nil {
+			return nil, err
+		}
+		if !snap.isValidator(proposer) {
+			return nil, errUnauthorized
+		}
+		extra, err := extractExtra(header)
+		if err != nil {
+			return nil, errInvalidExtraDataFormat
+		}
+		if extra.Candidate == (common.Address{}) {
+			continue
+		}
+		// Header authorized, discard any previous votes from the proposer
+		for i, vote := range snap.Votes {
+			if vote.Validator == proposer && vote.Address == extra.Candidate {
+				// Uncast the vote from the cached tally
+				snap.uncast(vote.Address, vote.Authorize)
+
+				// Uncast the vote from the chronological list
+				snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
+				break // only one vote allowed
+			}
+		}
+		// Tally up the new vote from the proposer
+		if snap.cast(extra.Candidate, extra.Authorize) {
+			snap.Votes = append(snap.Votes, &Vote{
+				Validator: proposer,
+				Block:     number,
+				Address:   extra.Candidate,
+				Authorize: extra.Authorize,
+			})
+		}
+		// If the vote passed, update the validator set
+		if tally := snap.Tally[extra.Candidate]; tally.Votes > len(snap.Validators)/2 {
+			if tally.Authorize {
+				snap.Validators = append(snap.Validators, extra.Candidate)
+				sortAddresses(snap.Validators)
+			} else {
+				for i, validator := range snap.Validators {
+					if validator == extra.Candidate {
+						snap.Validators = append(snap.Validators[:i], snap.Validators[i+1:]...)
+						break
+					}
+				}
+				// Discard any previous votes the deauthorized validator cast
+				for i := 0; i < len(snap.Votes); i++ {
+					if snap.Votes[i].Validator == extra.Candidate {
+						// Uncast the vote from the cached tally
+						snap.uncast(snap.Votes[i].Address, snap.Votes[i].Authorize)
+
+						// Uncast the vote from the chronological list
+						snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
+
+						i--
+					}
+				}
+			}
+			// Discard any previous votes around the just changed account
+			for i := 0; i < len(snap.Votes); i++ {
+				if snap.Votes[i].Address == extra.Candidate {
+					snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
+					i--
+				}
+			}
+			delete(snap.Tally, extra.Candidate)
+		}
+	}
+	snap.Number += uint64(len(headers))
+	snap.Hash = headers[len(headers)-1].Hash()
+
+	return snap, nil
+}
+
+// sortAddresses sorts a list of addresses in ascending order.
+func sortAddresses(addresses []common.Address) {
+	for i := 0; i < len(addresses); i++ {
+		for j := i + 1; j < len(addresses); j++ {
+			if bytes.Compare(addresses[i][:], addresses[j][:]) > 0 {
+				addresses[i], addresses[j] = addresses[j], addresses[i]
+			}
+		}
+	}
+}
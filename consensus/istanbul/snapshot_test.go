@@ -0,0 +1,243 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package istanbul
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+type testerVote struct {
+	validator string
+	voted     string
+	auth      bool
+}
+
+// testerAccountPool is a pool to maintain currently active tester accounts,
+// mapped from textual names used in the tests below to actual Ethereum private
+// keys capable of signing blocks.
+type testerAccountPool struct {
+	accounts map[string]*ecdsa.PrivateKey
+}
+
+func newTesterAccountPool() *testerAccountPool {
+	return &testerAccountPool{
+		accounts: make(map[string]*ecdsa.PrivateKey),
+	}
+}
+
+func (ap *testerAccountPool) key(account string) *ecdsa.PrivateKey {
+	// Ensure we have a persistent key for the account
+	if ap.accounts[account] == nil {
+		ap.accounts[account], _ = crypto.GenerateKey()
+	}
+	return ap.accounts[account]
+}
+
+func (ap *testerAccountPool) address(account string) common.Address {
+	if account == "" {
+		return common.Address{}
+	}
+	return crypto.PubkeyToAddress(ap.key(account).PublicKey)
+}
+
+func (ap *testerAccountPool) addresses(accounts []string) []common.Address {
+	addresses := make([]common.Address, len(accounts))
+	for i, account := range accounts {
+		addresses[i] = ap.address(account)
+	}
+	sortAddresses(addresses)
+	return addresses
+}
+
+// testerGenesis creates a genesis specification with the given validator set.
+func testerGenesis(validators []common.Address) *core.Genesis {
+	extra, _ := rlp.EncodeToBytes(&istanbulExtra{Validators: validators})
+
+	return &core.Genesis{
+		Config:     params.TestChainConfig,
+		ExtraData:  append(make([]byte, extraVanity), extra...),
+		GasLimit:   params.GenesisGasLimit,
+		Difficulty: big.NewInt(1),
+		Mixhash:    istanbulDigest,
+	}
+}
+
+// testerChainReader implements consensus.ChainReader to access the genesis
+// block. All other methods and requests will panic.
+type testerChainReader struct {
+	db ethdb.Database
+}
+
+func (r *testerChainReader) Config() *params.ChainConfig                 { return params.TestChainConfig }
+func (r *testerChainReader) CurrentHeader() *types.Header                { panic("not supported") }
+func (r *testerChainReader) GetHeader(common.Hash, uint64) *types.Header { panic("not supported") }
+func (r *testerChainReader) GetBlock(common.Hash, uint64) *types.Block   { panic("not supported") }
+func (r *testerChainReader) GetHeaderByHash(common.Hash) *types.Header   { panic("not supported") }
+func (r *testerChainReader) GetHeaderByNumber(number uint64) *types.Header {
+	if number == 0 {
+		return core.GetHeader(r.db, core.GetCanonicalHash(r.db, 0), 0)
+	}
+	panic("not supported")
+}
+
+// Tests that validator voting is evaluated correctly for various simple and
+// complex scenarios.
+func TestVoting(t *testing.T) {
+	tests := []struct {
+		epoch      uint64
+		validators []string
+		votes      []testerVote
+		results    []string
+	}{
+		{
+			// Single validator, no votes cast
+			validators: []string{"A"},
+			votes:      []testerVote{{validator: "A"}},
+			results:    []string{"A"},
+		}, {
+			// Single validator, voting to add two others (only accept first, second needs 2 votes)
+			validators: []string{"A"},
+			votes: []testerVote{
+				{validator: "A", voted: "B", auth: true},
+				{validator: "B"},
+				{validator: "A", voted: "C", auth: true},
+			},
+			results: []string{"A", "B"},
+		}, {
+			// Four validators, adding a fifth needs three votes
+			validators: []string{"A", "B", "C", "D"},
+			votes: []testerVote{
+				{validator: "A", voted: "E", auth: true},
+				{validator: "B", voted: "E", auth: true},
+				{validator: "C"},
+				{validator: "C", voted: "E", auth: true},
+			},
+			results: []string{"A", "B", "C", "D", "E"},
+		}, {
+			// Three validators, dropping one of them and discarding its pending votes
+			validators: []string{"A", "B", "C"},
+			votes: []testerVote{
+				{validator: "C", voted: "D", auth: true},
+				{validator: "A", voted: "C", auth: false},
+				{validator: "B", voted: "C", auth: false},
+				{validator: "A", voted: "D", auth: true},
+			},
+			results: []string{"A", "B"},
+		}, {
+			// Validator changing its mind, only the latest vote is tallied
+			validators: []string{"A", "B", "C"},
+			votes: []testerVote{
+				{validator: "A", voted: "D", auth: true},
+				{validator: "A", voted: "D", auth: true},
+				{validator: "B", voted: "C", auth: false},
+				{validator: "A", voted: "C", auth: false},
+			},
+			results: []string{"A", "B"},
+		}, {
+			// Epoch transitions reset all votes
+			epoch:      3,
+			validators: []string{"A", "B"},
+			votes: []testerVote{
+				{validator: "A", voted: "C", auth: true},
+				{validator: "B"},
+				{validator: "A"}, // Checkpoint block, (don't vote here, it's validated outside of snapshots)
+				{validator: "B", voted: "C", auth: true},
+			},
+			results: []string{"A", "B"},
+		},
+	}
+	for i, tt := range tests {
+		accounts := newTesterAccountPool()
+
+		// Create a pristine database with the genesis injected
+		db, _ := ethdb.NewMemDatabase()
+		testerGenesis(accounts.addresses(tt.validators)).MustCommit(db)
+
+		engine := New(&params.IstanbulConfig{Epoch: tt.epoch}, accounts.key("A"), db)
+
+		// Assemble a chain of headers from the cast votes
+		validators := accounts.addresses(tt.validators)
+		headers := make([]*types.Header, len(tt.votes))
+		for j, vote := range tt.votes {
+			extra, _ := rlp.EncodeToBytes(&istanbulExtra{
+				Validators: validators,
+				Candidate:  accounts.address(vote.voted),
+				Authorize:  vote.auth,
+			})
+			headers[j] = &types.Header{
+				Number:    big.NewInt(int64(j) + 1),
+				Time:      big.NewInt(int64(j)),
+				MixDigest: istanbulDigest,
+				Extra:     append(make([]byte, extraVanity), extra...),
+			}
+			if j > 0 {
+				headers[j].ParentHash = headers[j-1].Hash()
+			}
+			engine.key = accounts.key(vote.validator)
+			if err := engine.signProposal(headers[j]); err != nil {
+				t.Fatalf("test %d, vote %d: failed to sign header: %v", i, j, err)
+			}
+			// Track the validator set the next header needs to embed
+			snap, err := engine.snapshot(&testerChainReader{db: db}, headers[j].Number.Uint64(), headers[j].Hash(), headers[:j+1])
+			if err != nil {
+				t.Fatalf("test %d, vote %d: failed to create voting snapshot: %v", i, j, err)
+			}
+			validators = snap.Validators
+		}
+		// Verify the final list of validators against the expected ones
+		want := accounts.addresses(tt.results)
+		if len(validators) != len(want) {
+			t.Errorf("test %d: validators mismatch: have %x, want %x", i, validators, want)
+			continue
+		}
+		for j := range validators {
+			if validators[j] != want[j] {
+				t.Errorf("test %d, validator %d: validator mismatch: have %x, want %x", i, j, validators[j], want[j])
+			}
+		}
+	}
+}
+
+// Tests the byzantine fault tolerance thresholds of various validator set sizes.
+func TestQuorum(t *testing.T) {
+	tests := []struct {
+		validators int
+		faulty     int
+		quorum     int
+	}{
+		{1, 0, 1}, {2, 0, 2}, {3, 0, 2}, {4, 1, 3}, {5, 1, 4}, {6, 1, 4}, {7, 2, 5}, {10, 3, 7},
+	}
+	for i, tt := range tests {
+		snap := &Snapshot{Validators: make([]common.Address, tt.validators)}
+		if faulty := snap.faulty(); faulty != tt.faulty {
+			t.Errorf("test %d: faulty mismatch: have %d, want %d", i, faulty, tt.faulty)
+		}
+		if quorum := snap.quorum(); quorum != tt.quorum {
+			t.Errorf("test %d: quorum mismatch: have %d, want %d", i, quorum, tt.quorum)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+pragma solidity ^0.8.0;
+
+/// @title Checkpoint oracle for LES
+/// @notice A set of admins vote the CHT and BloomTrie roots of the LES sections
+/// into the contract. A checkpoint is accepted once exactly the threshold of the
+/// admins voted for the same section index, section head and trie roots.
+contract CheckpointOracle {
+    // Admins allowed to vote on checkpoints
+    mapping(address => bool) admins;
+
+    // Number of admin votes needed to accept a checkpoint
+    uint public threshold;
+
+    // Number of votes cast for each checkpoint hash
+    mapping(bytes32 => uint) votes;
+
+    // Admins that already voted for each checkpoint hash
+    mapping(bytes32 => mapping(address => bool)) voted;
+
+    // Latest accepted checkpoint, valid only once accepted is set
+    uint sectionIndex;
+    bytes32 sectionHead;
+    bytes32 chtRoot;
+    bytes32 bloomTrieRoot;
+    bool accepted;
+
+    /// @notice Emitted when a checkpoint reaches the vote threshold
+    event NewCheckpoint(uint indexed index, bytes32 sectionHead, bytes32 chtRoot, bytes32 bloomTrieRoot);
+
+    /// @notice Emitted for every vote cast by an admin
+    event CheckpointVote(uint indexed index, address indexed signer, bytes32 checkpointHash);
+
+    constructor(address[] memory _admins, uint _threshold) {
+        require(_threshold != 0 && _threshold <= _admins.length);
+
+        threshold = _threshold;
+        for (uint i = 0; i < _admins.length; i++) {
+            admins[_admins[i]] = true;
+        }
+    }
+
+    /// @notice Votes for a checkpoint, accepting it once the threshold is reached.
+    /// Only admins may vote, each at most once for the same checkpoint, and only
+    /// for sections newer than the latest accepted one.
+    function voteCheckpoint(uint _sectionIndex, bytes32 _sectionHead, bytes32 _chtRoot, bytes32 _bloomTrieRoot) public returns (bool) {
+        require(admins[msg.sender]);
+        require(!accepted || _sectionIndex > sectionIndex);
+
+        bytes32 hash = keccak256(abi.encodePacked(_sectionIndex, _sectionHead, _chtRoot, _bloomTrieRoot));
+        require(!voted[hash][msg.sender]);
+
+        voted[hash][msg.sender] = true;
+        votes[hash]++;
+        emit CheckpointVote(_sectionIndex, msg.sender, hash);
+
+        if (votes[hash] == threshold) {
+            sectionIndex = _sectionIndex;
+            sectionHead = _sectionHead;
+            chtRoot = _chtRoot;
+            bloomTrieRoot = _bloomTrieRoot;
+            accepted = true;
+
+            emit NewCheckpoint(_sectionIndex, _sectionHead, _chtRoot, _bloomTrieRoot);
+        }
+        return true;
+    }
+
+    /// @notice Returns the latest accepted checkpoint, zero if there is none yet.
+    function getLatestCheckpoint() public view returns (uint, bytes32, bytes32, bytes32) {
+        return (sectionIndex, sectionHead, chtRoot, bloomTrieRoot);
+    }
+
+    /// @notice Returns whether the given address is allowed to vote.
+    function isAdmin(address _addr) public view returns (bool) {
+        return admins[_addr];
+    }
+}
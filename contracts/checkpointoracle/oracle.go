@@ -0,0 +1,125 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package checkpointoracle is a wrapper of the on-chain checkpoint registrar,
+// into which a set of admins vote the CHT and BloomTrie roots of LES sections.
+// A checkpoint is accepted by the contract once a threshold of admins voted for
+// the exact same section index, section head and trie roots.
+package checkpointoracle
+
+//go:generate abigen --sol contract/oracle.sol --pkg contract --out contract/oracle.go
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/contracts/checkpointoracle/contract"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Checkpoint is a set of post-processed trie roots of a LES section.
+type Checkpoint struct {
+	SectionIdx    uint64      `json:"sectionIndex"`
+	SectionHead   common.Hash `json:"sectionHead"`
+	CHTRoot       common.Hash `json:"chtRoot"`
+	BloomTrieRoot common.Hash `json:"bloomTrieRoot"`
+}
+
+// Hash returns the hash admins vote on, which is the keccak256 of the section
+// index (as a 256 bit big endian number), the section head and the trie roots.
+func (c *Checkpoint) Hash() common.Hash {
+	var index [32]byte
+	binary.BigEndian.PutUint64(index[24:], c.SectionIdx)
+	return crypto.Keccak256Hash(index[:], c.SectionHead[:], c.CHTRoot[:], c.BloomTrieRoot[:])
+}
+
+// CheckpointReader provides read-only access to a checkpoint oracle.
+type CheckpointReader struct {
+	caller *contract.CheckpointOracleCaller
+}
+
+// NewCheckpointReader binds a read-only checkpoint oracle at the given address.
+func NewCheckpointReader(address common.Address, caller bind.ContractCaller) (*CheckpointReader, error) {
+	c, err := contract.NewCheckpointOracleCaller(address, caller)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckpointReader{c}, nil
+}
+
+// LatestCheckpoint returns the most recent checkpoint accepted by the oracle,
+// or nil if none was accepted yet. The oracle is read from the latest state
+// known to the backend, checkpoints accepted later than that aren't visible.
+func (r *CheckpointReader) LatestCheckpoint(opts *bind.CallOpts) (*Checkpoint, error) {
+	index, head, cht, bloom, err := r.caller.GetLatestCheckpoint(opts)
+	if err != nil {
+		return nil, err
+	}
+	if head == (common.Hash{}) {
+		return nil, nil
+	}
+	return &Checkpoint{SectionIdx: index.Uint64(), SectionHead: head, CHTRoot: cht, BloomTrieRoot: bloom}, nil
+}
+
+// IsAdmin returns whether the given address may vote on checkpoints.
+func (r *CheckpointReader) IsAdmin(opts *bind.CallOpts, addr common.Address) (bool, error) {
+	return r.caller.IsAdmin(opts, addr)
+}
+
+// Threshold returns the number of admin votes needed to accept a checkpoint.
+func (r *CheckpointReader) Threshold(opts *bind.CallOpts) (uint64, error) {
+	threshold, err := r.caller.Threshold(opts)
+	if err != nil {
+		return 0, err
+	}
+	return threshold.Uint64(), nil
+}
+
+// CheckpointOracle provides full access to a checkpoint oracle, including
+// voting on checkpoints.
+type CheckpointOracle struct {
+	*CheckpointReader
+	contract *contract.CheckpointOracle
+}
+
+// NewCheckpointOracle binds a checkpoint oracle at the given address.
+func NewCheckpointOracle(address common.Address, backend bind.ContractBackend) (*CheckpointOracle, error) {
+	c, err := contract.NewCheckpointOracle(address, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckpointOracle{&CheckpointReader{&c.CheckpointOracleCaller}, c}, nil
+}
+
+// DeployCheckpointOracle deploys a new checkpoint oracle with the given admins,
+// accepting checkpoints once threshold of them voted for the same one.
+func DeployCheckpointOracle(opts *bind.TransactOpts, backend bind.ContractBackend, admins []common.Address, threshold uint64) (common.Address, *types.Transaction, *CheckpointOracle, error) {
+	addr, tx, c, err := contract.DeployCheckpointOracle(opts, backend, admins, new(big.Int).SetUint64(threshold))
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	return addr, tx, &CheckpointOracle{&CheckpointReader{&c.CheckpointOracleCaller}, c}, nil
+}
+
+// Vote casts the vote of the transacting admin for the given checkpoint. The
+// transaction fails if the sender is not an admin, already voted for the same
+// checkpoint, or if a newer checkpoint was already accepted.
+func (o *CheckpointOracle) Vote(opts *bind.TransactOpts, cp *Checkpoint) (*types.Transaction, error) {
+	return o.contract.VoteCheckpoint(opts, new(big.Int).SetUint64(cp.SectionIdx), cp.SectionHead, cp.CHTRoot, cp.BloomTrieRoot)
+}
@@ -0,0 +1,145 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package checkpointoracle
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	key0, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	key1, _ = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	key2, _ = crypto.HexToECDSA("49a7b37aa6f6645917e7b807e9d1c00d4fa71f18343b0d4122a4d2df64dd6fee")
+	addr0   = crypto.PubkeyToAddress(key0.PublicKey)
+	addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+	addr2   = crypto.PubkeyToAddress(key2.PublicKey)
+)
+
+func newTestBackend() *backends.SimulatedBackend {
+	return backends.NewSimulatedBackend(core.GenesisAlloc{
+		addr0: {Balance: big.NewInt(1000000000000000000)},
+		addr1: {Balance: big.NewInt(1000000000000000000)},
+		addr2: {Balance: big.NewInt(1000000000000000000)},
+	})
+}
+
+func newTestCheckpoint(index uint64) *Checkpoint {
+	return &Checkpoint{
+		SectionIdx:    index,
+		SectionHead:   common.BytesToHash([]byte{byte(index), 1}),
+		CHTRoot:       common.BytesToHash([]byte{byte(index), 2}),
+		BloomTrieRoot: common.BytesToHash([]byte{byte(index), 3}),
+	}
+}
+
+// Tests that checkpoints are only accepted once the threshold of distinct admins
+// voted for them, and that only newer checkpoints can replace them.
+func TestCheckpointVoting(t *testing.T) {
+	backend := newTestBackend()
+
+	// Deploy an oracle with two admins out of which both need to agree
+	if _, _, _, err := DeployCheckpointOracle(bind.NewKeyedTransactor(key0), backend, []common.Address{addr0, addr1}, 3); err == nil {
+		t.Fatalf("deployment with unreachable threshold succeeded")
+	}
+	addr, _, _, err := DeployCheckpointOracle(bind.NewKeyedTransactor(key0), backend, []common.Address{addr0, addr1}, 2)
+	if err != nil {
+		t.Fatalf("failed to deploy oracle: %v", err)
+	}
+	backend.Commit()
+
+	oracle, err := NewCheckpointOracle(addr, backend)
+	if err != nil {
+		t.Fatalf("failed to bind oracle: %v", err)
+	}
+	if threshold, err := oracle.Threshold(nil); err != nil || threshold != 2 {
+		t.Fatalf("threshold mismatch: have %d/%v, want 2", threshold, err)
+	}
+	for addr, want := range map[common.Address]bool{addr0: true, addr1: true, addr2: false} {
+		if admin, err := oracle.IsAdmin(nil, addr); err != nil || admin != want {
+			t.Errorf("admin status of %x mismatch: have %v/%v, want %v", addr, admin, err, want)
+		}
+	}
+	vote := func(key *ecdsa.PrivateKey, cp *Checkpoint) error {
+		_, err := oracle.Vote(bind.NewKeyedTransactor(key), cp)
+		backend.Commit()
+		return err
+	}
+	latest := func() *Checkpoint {
+		cp, err := oracle.LatestCheckpoint(nil)
+		if err != nil {
+			t.Fatalf("failed to retrieve latest checkpoint: %v", err)
+		}
+		return cp
+	}
+	// Vote in a checkpoint, ensuring it's only accepted once both admins agree
+	cp := newTestCheckpoint(5)
+	if err := vote(key2, cp); err == nil {
+		t.Fatalf("non-admin vote succeeded")
+	}
+	if err := vote(key0, cp); err != nil {
+		t.Fatalf("admin vote failed: %v", err)
+	}
+	if err := vote(key0, cp); err == nil {
+		t.Fatalf("double vote succeeded")
+	}
+	if cp := latest(); cp != nil {
+		t.Fatalf("checkpoint accepted below threshold: %v", cp)
+	}
+	if err := vote(key1, cp); err != nil {
+		t.Fatalf("admin vote failed: %v", err)
+	}
+	if have := latest(); !reflect.DeepEqual(have, cp) {
+		t.Fatalf("accepted checkpoint mismatch: have %v, want %v", have, cp)
+	}
+	// Ensure the votes are logged with the checkpoint hash
+	votes, err := oracle.contract.FilterCheckpointVote(nil, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to filter votes: %v", err)
+	}
+	count := 0
+	for ; votes.Next(); count++ {
+		if votes.Event.CheckpointHash != cp.Hash() {
+			t.Errorf("vote %d: hash mismatch: have %x, want %x", count, votes.Event.CheckpointHash, cp.Hash())
+		}
+	}
+	if count != 2 {
+		t.Errorf("vote count mismatch: have %d, want 2", count)
+	}
+	// Ensure older checkpoints are rejected and newer ones replace the current
+	if err := vote(key0, newTestCheckpoint(4)); err == nil {
+		t.Fatalf("vote for stale checkpoint succeeded")
+	}
+	next := newTestCheckpoint(6)
+	if err := vote(key1, next); err != nil {
+		t.Fatalf("admin vote failed: %v", err)
+	}
+	if err := vote(key0, next); err != nil {
+		t.Fatalf("admin vote failed: %v", err)
+	}
+	if have := latest(); !reflect.DeepEqual(have, next) {
+		t.Fatalf("accepted checkpoint mismatch: have %v, want %v", have, next)
+	}
+}
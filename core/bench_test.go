@@ -31,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
 func BenchmarkInsertChain_empty_memdb(b *testing.B) {
@@ -69,6 +70,18 @@ func BenchmarkInsertChain_ring1000_memdb(b *testing.B) {
 func BenchmarkInsertChain_ring1000_diskdb(b *testing.B) {
 	benchInsertChain(b, true, genTxRing(1000))
 }
+func BenchmarkInsertChain_ring200_senders_memdb(b *testing.B) {
+	benchInsertChainSenders(b, true, genTxRing(200))
+}
+func BenchmarkInsertChain_ring200_inlineSenders_memdb(b *testing.B) {
+	benchInsertChainSenders(b, false, genTxRing(200))
+}
+func BenchmarkInsertChain_ring1000_senders_memdb(b *testing.B) {
+	benchInsertChainSenders(b, true, genTxRing(1000))
+}
+func BenchmarkInsertChain_ring1000_inlineSenders_memdb(b *testing.B) {
+	benchInsertChainSenders(b, false, genTxRing(1000))
+}
 
 var (
 	// This is the content of the genesis block used by the benchmarks.
@@ -182,6 +195,44 @@ func benchInsertChain(b *testing.B, disk bool, gen func(int, *BlockGen)) {
 	}
 }
 
+// benchInsertChainSenders measures the import of a chain whose transactions
+// arrive without cached senders, as when received from the network, either with
+// the senders recovered concurrently ahead of processing or inline.
+func benchInsertChainSenders(b *testing.B, recover bool, gen func(int, *BlockGen)) {
+	db, _ := ethdb.NewMemDatabase()
+	gspec := Genesis{
+		Config: params.TestChainConfig,
+		Alloc:  GenesisAlloc{benchRootAddr: {Balance: benchRootFunds}},
+	}
+	genesis := gspec.MustCommit(db)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, b.N, gen)
+
+	// Drop the senders cached during generation by round tripping the blocks
+	chain := make(types.Blocks, len(blocks))
+	for i, block := range blocks {
+		enc, err := rlp.EncodeToBytes(block)
+		if err != nil {
+			b.Fatalf("failed to encode block %d: %v", i, err)
+		}
+		chain[i] = new(types.Block)
+		if err := rlp.DecodeBytes(enc, chain[i]); err != nil {
+			b.Fatalf("failed to decode block %d: %v", i, err)
+		}
+	}
+	if !recover {
+		defer func(cacher *txSenderCacher) { senderCacher = cacher }(senderCacher)
+		senderCacher = newTxSenderCacher(0)
+	}
+	// Time the insertion of the new chain.
+	chainman, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer chainman.Stop()
+	b.ReportAllocs()
+	b.ResetTimer()
+	if i, err := chainman.InsertChain(chain); err != nil {
+		b.Fatalf("insert error (block %d): %v\n", i, err)
+	}
+}
+
 func BenchmarkChainRead_header_10k(b *testing.B) {
 	benchReadChain(b, false, 10000)
 }
@@ -48,6 +48,9 @@ import (
 var (
 	blockInsertTimer = metrics.NewTimer("chain/inserts")
 
+	blockPrefetchExecuteTimer   = metrics.NewTimer("chain/prefetch/executes")
+	blockPrefetchInterruptMeter = metrics.NewMeter("chain/prefetch/interrupts")
+
 	ErrNoGenesis = errors.New("Genesis not found in chain")
 )
 
@@ -66,9 +69,11 @@ const (
 // CacheConfig contains the configuration values for the trie caching/pruning
 // that's resident in a blockchain.
 type CacheConfig struct {
-	Disabled      bool          // Whether to disable trie write caching (archive node)
-	TrieNodeLimit int           // Memory limit (MB) at which to flush the current in-memory trie to disk
-	TrieTimeLimit time.Duration // Time limit after which to flush the current in-memory trie to disk
+	Disabled            bool          // Whether to disable trie write caching (archive node)
+	TrieNodeLimit       int           // Memory limit (MB) at which to flush the current in-memory trie to disk
+	TrieTimeLimit       time.Duration // Time limit after which to flush the current in-memory trie to disk
+	TrieCleanLimit      int           // Number of clean trie nodes to keep cached in memory
+	TrieCleanNoPrefetch bool          // Whether to disable speculative state prefetching of followup blocks
 }
 
 // BlockChain represents the canonical chain given a database with a genesis
@@ -122,10 +127,11 @@ type BlockChain struct {
 	procInterrupt int32          // interrupt signaler for block processing
 	wg            sync.WaitGroup // chain processing wait group for shutting down
 
-	engine    consensus.Engine
-	processor Processor // block processor interface
-	validator Validator // block and state validator interface
-	vmConfig  vm.Config
+	engine     consensus.Engine
+	prefetcher Prefetcher // block state prefetcher interface
+	processor  Processor  // block processor interface
+	validator  Validator  // block and state validator interface
+	vmConfig   vm.Config
 
 	badBlocks *lru.Cache // Bad block cache
 }
@@ -134,10 +140,14 @@ type BlockChain struct {
 // available in the database. It initialises the default Ethereum Validator and
 // Processor.
 func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *params.ChainConfig, engine consensus.Engine, vmConfig vm.Config) (*BlockChain, error) {
+	if err := CheckChainConfig(chainConfig); err != nil {
+		return nil, err
+	}
 	if cacheConfig == nil {
 		cacheConfig = &CacheConfig{
-			TrieNodeLimit: 256 * 1024 * 1024,
-			TrieTimeLimit: 5 * time.Minute,
+			TrieNodeLimit:  256 * 1024 * 1024,
+			TrieTimeLimit:  5 * time.Minute,
+			TrieCleanLimit: 64 * 1024,
 		}
 	}
 	bodyCache, _ := lru.New(bodyCacheLimit)
@@ -151,7 +161,7 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		cacheConfig:  cacheConfig,
 		db:           db,
 		triegc:       prque.New(),
-		stateCache:   state.NewDatabase(db),
+		stateCache:   state.NewDatabaseWithCache(db, cacheConfig.TrieCleanLimit),
 		quit:         make(chan struct{}),
 		bodyCache:    bodyCache,
 		bodyRLPCache: bodyRLPCache,
@@ -163,6 +173,7 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 	}
 	bc.SetValidator(NewBlockValidator(chainConfig, bc, engine))
 	bc.SetProcessor(NewStateProcessor(chainConfig, bc, engine))
+	bc.prefetcher = newStatePrefetcher(chainConfig, bc, engine)
 
 	var err error
 	bc.hc, err = NewHeaderChain(db, chainConfig, engine, bc.getProcInterrupt)
@@ -392,6 +403,25 @@ func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
 	return state.New(root, bc.stateCache)
 }
 
+// ValidateBlock fully validates a block on top of its parent without importing
+// it, checking its body and running its transactions to verify the resulting
+// state. The header is assumed to be verified already.
+func (bc *BlockChain) ValidateBlock(block *types.Block) error {
+	if err := bc.Validator().ValidateBody(block); err != nil {
+		return err
+	}
+	parent := bc.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	statedb, err := state.New(parent.Root(), bc.stateCache)
+	if err != nil {
+		return err
+	}
+	receipts, _, usedGas, err := bc.Processor().Process(block, statedb, bc.vmConfig)
+	if err != nil {
+		return err
+	}
+	return bc.Validator().ValidateState(block, parent, statedb, receipts, usedGas)
+}
+
 // Reset purges the entire blockchain, restoring it to its genesis state.
 func (bc *BlockChain) Reset() error {
 	return bc.ResetWithGenesisBlock(bc.genesisBlock)
@@ -1049,6 +1079,9 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 	abort, results := bc.engine.VerifyHeaders(bc, headers, seals)
 	defer close(abort)
 
+	// Start the parallel sender recovery of the transactions ahead of processing
+	senderCacher.recoverFromBlocks(bc.chainConfig, chain)
+
 	// Iterate over the blocks and insert when the verifier permits
 	for i, block := range chain {
 		// If the chain is terminating, stop processing blocks
@@ -1137,12 +1170,32 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 		} else {
 			parent = chain[i-1]
 		}
+		// If we have a followup block, run it on top of the same parent state in the
+		// background, pulling the state it touches into memory ahead of its import
+		var followupInterrupt uint32
+
+		if !bc.cacheConfig.TrieCleanNoPrefetch && i+1 < len(chain) {
+			go func(start time.Time, followup *types.Block) {
+				throwaway, err := state.New(parent.Root(), bc.stateCache)
+				if err != nil {
+					return
+				}
+				bc.prefetcher.Prefetch(followup, throwaway, bc.vmConfig, &followupInterrupt)
+
+				blockPrefetchExecuteTimer.UpdateSince(start)
+				if atomic.LoadUint32(&followupInterrupt) == 1 {
+					blockPrefetchInterruptMeter.Mark(1)
+				}
+			}(time.Now(), chain[i+1])
+		}
 		state, err := state.New(parent.Root(), bc.stateCache)
 		if err != nil {
+			atomic.StoreUint32(&followupInterrupt, 1)
 			return i, events, coalescedLogs, err
 		}
 		// Process block using the parent state as reference point.
 		receipts, logs, usedGas, err := bc.processor.Process(block, state, bc.vmConfig)
+		atomic.StoreUint32(&followupInterrupt, 1)
 		if err != nil {
 			bc.reportBlock(block, receipts, err)
 			return i, events, coalescedLogs, err
@@ -1344,3 +1344,22 @@ func TestLargeReorgTrieGC(t *testing.T) {
 		}
 	}
 }
+
+// Tests that a chain config scheduling unsupported EIPs or native contracts is
+// refused when creating the chain instead of crashing the EVM on first use.
+func TestBlockChainInvalidConfig(t *testing.T) {
+	configs := []*params.ChainConfig{
+		{HomesteadBlock: big.NewInt(0), EIPs: map[int]*big.Int{1: big.NewInt(0)}},
+		{HomesteadBlock: big.NewInt(0), Precompiles: map[common.Address]*params.PrecompileConfig{
+			common.BytesToAddress([]byte{0x10}): {Name: "test-unknown", Block: big.NewInt(0)},
+		}},
+	}
+	for i, config := range configs {
+		db, _ := ethdb.NewMemDatabase()
+		(&Genesis{Config: config}).MustCommit(db)
+
+		if _, err := NewBlockChain(db, nil, config, ethash.NewFaker(), vm.Config{}); err == nil {
+			t.Errorf("test %d: invalid chain config accepted", i)
+		}
+	}
+}
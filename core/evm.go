@@ -35,6 +35,14 @@ type ChainContext interface {
 	GetHeader(common.Hash, uint64) *types.Header
 }
 
+// chainHashReader is implemented by chain contexts that resolve the hashes of
+// ancestor blocks directly, without having their headers at hand (e.g. tools
+// executing transactions in a synthetic block environment).
+type chainHashReader interface {
+	// GetHash returns the hash of the ancestor block with the given number.
+	GetHash(number uint64) common.Hash
+}
+
 // NewEVMContext creates a new context for use in the EVM.
 func NewEVMContext(msg Message, header *types.Header, chain ChainContext, author *common.Address) vm.Context {
 	// If we don't have an explicit author (i.e. not mining), extract from the header
@@ -60,6 +68,9 @@ func NewEVMContext(msg Message, header *types.Header, chain ChainContext, author
 
 // GetHashFn returns a GetHashFunc which retrieves header hashes by number
 func GetHashFn(ref *types.Header, chain ChainContext) func(n uint64) common.Hash {
+	if reader, ok := chain.(chainHashReader); ok {
+		return reader.GetHash
+	}
 	return func(n uint64) common.Hash {
 		for header := chain.GetHeader(ref.ParentHash, ref.Number.Uint64()-1); header != nil; header = chain.GetHeader(header.ParentHash, header.Number.Uint64()-1) {
 			if header.Number.Uint64() == n {
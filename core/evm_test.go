@@ -0,0 +1,77 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// hashReaderChain is a chain context without any headers, resolving ancestor
+// hashes directly instead.
+type hashReaderChain struct {
+	hashes map[uint64]common.Hash
+}
+
+func (c *hashReaderChain) Engine() consensus.Engine                    { return nil }
+func (c *hashReaderChain) GetHeader(common.Hash, uint64) *types.Header { return nil }
+func (c *hashReaderChain) GetHash(number uint64) common.Hash           { return c.hashes[number] }
+
+// Tests that ancestor hashes are resolved directly from chain contexts able to
+// do so, and by walking the header chain otherwise.
+func TestGetHashFn(t *testing.T) {
+	// Resolve hashes from a chain context without headers
+	reader := &hashReaderChain{hashes: map[uint64]common.Hash{
+		1: common.HexToHash("0x01"),
+		2: common.HexToHash("0x02"),
+	}}
+	getHash := GetHashFn(&types.Header{Number: big.NewInt(3)}, reader)
+	for n, want := range reader.hashes {
+		if have := getHash(n); have != want {
+			t.Errorf("hash reader: block %d hash mismatch: have %x, want %x", n, have, want)
+		}
+	}
+	if have := getHash(0); have != (common.Hash{}) {
+		t.Errorf("hash reader: unknown block hash mismatch: have %x, want zero", have)
+	}
+	// Resolve hashes by walking the headers of a real chain
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		genesis = new(Genesis).MustCommit(db)
+	)
+	blocks, _ := GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 3, nil)
+	chain, _ := NewBlockChain(db, nil, params.TestChainConfig, ethash.NewFaker(), vm.Config{})
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	getHash = GetHashFn(blocks[2].Header(), chain)
+	for n := uint64(0); n < 2; n++ {
+		if have, want := getHash(n), chain.GetHeaderByNumber(n).Hash(); have != want {
+			t.Errorf("header chain: block %d hash mismatch: have %x, want %x", n, have, want)
+		}
+	}
+}
@@ -159,3 +159,25 @@ func TestSetupGenesis(t *testing.T) {
 		}
 	}
 }
+
+// Tests that a stored chain configuration activating native contracts unknown
+// to the running binary is refused on startup.
+func TestSetupGenesisUnknownPrecompile(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	genesis := &Genesis{
+		Config: &params.ChainConfig{
+			HomesteadBlock: big.NewInt(0),
+			Precompiles: map[common.Address]*params.PrecompileConfig{
+				common.BytesToAddress([]byte{0x10}): {Name: "test-unknown", Block: big.NewInt(0)},
+			},
+		},
+	}
+	if _, _, err := SetupGenesisBlock(db, genesis); err == nil {
+		t.Fatalf("genesis with unknown precompile accepted")
+	}
+	// Write the config directly, as if the contract was registered at the time
+	genesis.MustCommit(db)
+	if _, _, err := SetupGenesisBlock(db, nil); err == nil {
+		t.Fatalf("stored config with unknown precompile accepted")
+	}
+}
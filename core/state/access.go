@@ -0,0 +1,259 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// AccessSet is the set of state entries a transaction's execution depended on.
+type AccessSet struct {
+	accounts map[common.Address]struct{}                 // Accounts whose existence, balance, nonce or code was read
+	storage  map[common.Address]map[common.Hash]struct{} // Storage slots read
+	dirty    map[common.Address]struct{}                 // Accounts newly marked dirty
+}
+
+func newAccessSet() *AccessSet {
+	return &AccessSet{
+		accounts: make(map[common.Address]struct{}),
+		storage:  make(map[common.Address]map[common.Hash]struct{}),
+		dirty:    make(map[common.Address]struct{}),
+	}
+}
+
+// accountWrite is the set of changes a transaction made to a single account.
+type accountWrite struct {
+	header   bool     // Whether the existence, balance, nonce or code may have changed
+	created  bool     // Whether the account was (re)created, replacing any previous one
+	wiped    bool     // Whether the storage of the account may have been discarded
+	balance  *big.Int // Balance before the first change, nil if unchanged
+	nonce    bool     // Whether the nonce was changed
+	code     bool     // Whether the code was changed
+	suicided bool     // Whether the account was destructed
+
+	storage map[common.Hash]struct{} // Storage slots changed
+}
+
+// WriteSet is the set of state entries modified by one or more transactions.
+type WriteSet struct {
+	accounts map[common.Address]*accountWrite
+}
+
+// NewWriteSet creates an empty write set, to accumulate the changes of multiple
+// transactions into.
+func NewWriteSet() *WriteSet {
+	return &WriteSet{accounts: make(map[common.Address]*accountWrite)}
+}
+
+// account retrieves the changes made to an account, creating an empty entry if
+// none exists yet.
+func (w *WriteSet) account(addr common.Address) *accountWrite {
+	if write := w.accounts[addr]; write != nil {
+		return write
+	}
+	write := &accountWrite{storage: make(map[common.Hash]struct{})}
+	w.accounts[addr] = write
+	return write
+}
+
+// Conflicts reports whether any of the state entries read by a transaction was
+// modified by the writes.
+func (w *WriteSet) Conflicts(reads *AccessSet) bool {
+	for addr := range reads.accounts {
+		if write := w.accounts[addr]; write != nil && write.header {
+			return true
+		}
+	}
+	for addr, keys := range reads.storage {
+		write := w.accounts[addr]
+		if write == nil {
+			continue
+		}
+		if write.wiped {
+			return true
+		}
+		for key := range keys {
+			if _, ok := write.storage[key]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Merge accumulates the state entries modified by another write set. Only the
+// information needed for conflict detection is retained.
+func (w *WriteSet) Merge(other *WriteSet) {
+	for addr, write := range other.accounts {
+		merged := w.account(addr)
+		merged.header = merged.header || write.header
+		merged.wiped = merged.wiped || write.wiped
+		for key := range write.storage {
+			merged.storage[key] = struct{}{}
+		}
+	}
+}
+
+// StartAccessRecording starts recording the state entries the execution of a
+// transaction reads and writes, until StopAccessRecording is called.
+func (self *StateDB) StartAccessRecording() {
+	self.access = newAccessSet()
+}
+
+// StopAccessRecording stops recording state accesses, returning the entries the
+// transaction read and modified since recording was started. It must be called
+// before the state is finalised.
+func (self *StateDB) StopAccessRecording() (*AccessSet, *WriteSet) {
+	reads, writes := self.access, NewWriteSet()
+	self.access = nil
+
+	// Gather the changes that weren't reverted from the journal
+	for _, entry := range self.journal {
+		switch ch := entry.(type) {
+		case createObjectChange:
+			write := writes.account(*ch.account)
+			write.header, write.created, write.wiped = true, true, true
+		case resetObjectChange:
+			write := writes.account(ch.prev.address)
+			write.header, write.created, write.wiped = true, true, true
+		case suicideChange:
+			write := writes.account(*ch.account)
+			write.header, write.suicided, write.wiped = true, true, true
+			if write.balance == nil {
+				write.balance = new(big.Int).Set(ch.prevbalance)
+			}
+		case balanceChange:
+			write := writes.account(*ch.account)
+			write.header = true
+			if write.balance == nil {
+				write.balance = new(big.Int).Set(ch.prev)
+			}
+		case nonceChange:
+			write := writes.account(*ch.account)
+			write.header, write.nonce = true, true
+		case codeChange:
+			write := writes.account(*ch.account)
+			write.header, write.code = true, true
+		case storageChange:
+			writes.account(*ch.account).storage[ch.key] = struct{}{}
+		case touchChange:
+			writes.account(*ch.account).header = true
+		}
+	}
+	// Accounts left dirty by reverted changes are still finalised, deleting them
+	// if they are empty
+	for addr := range reads.dirty {
+		write := writes.account(addr)
+		if obj := self.stateObjects[addr]; obj == nil || obj.empty() {
+			write.header, write.wiped = true, true
+		}
+	}
+	return reads, writes
+}
+
+// recordAccount marks an account's existence, balance, nonce and code as read
+// by the current transaction.
+func (self *StateDB) recordAccount(addr common.Address) {
+	if self.access != nil {
+		self.access.accounts[addr] = struct{}{}
+	}
+}
+
+// recordStorage marks a storage slot as read by the current transaction.
+func (self *StateDB) recordStorage(addr common.Address, key common.Hash) {
+	if self.access == nil {
+		return
+	}
+	keys := self.access.storage[addr]
+	if keys == nil {
+		keys = make(map[common.Hash]struct{})
+		self.access.storage[addr] = keys
+	}
+	keys[key] = struct{}{}
+}
+
+// ApplyWrites transfers the changes a transaction made to src, as recorded by
+// the given reads and writes, into this state, along with the logs and
+// preimages of the transaction. Balances which were modified without being read
+// are adjusted by the same amount instead of overwritten, so that independent
+// transfers to the same account can be combined.
+//
+// The reads of the transaction must have been validated not to conflict with
+// the changes made to this state since src was copied from it. If a blindly
+// modified account was since deleted, false is returned and no changes are
+// made. The state must be finalised afterwards, as with a transaction applied
+// directly.
+func (self *StateDB) ApplyWrites(src *StateDB, reads *AccessSet, writes *WriteSet) bool {
+	// Make sure all the accounts modified in place are still present
+	for addr, write := range writes.accounts {
+		if src.stateObjects[addr] != nil && !write.created && self.getStateObject(addr) == nil {
+			return false
+		}
+	}
+	for addr, write := range writes.accounts {
+		srcobj := src.stateObjects[addr]
+		if srcobj == nil {
+			continue // Creation reverted, nothing to transfer
+		}
+		// Accounts implicitly created by a blind modification are modified in place
+		// if they were created since
+		_, read := reads.accounts[addr]
+		obj := self.getStateObject(addr)
+
+		if write.created && (read || obj == nil) {
+			self.setStateObject(srcobj.deepCopy(self, nil))
+			self.stateObjectsDirty[addr] = struct{}{}
+			continue
+		}
+		if write.balance != nil {
+			if read {
+				obj.setBalance(new(big.Int).Set(srcobj.Balance()))
+			} else {
+				delta := new(big.Int).Sub(srcobj.Balance(), write.balance)
+				obj.setBalance(new(big.Int).Add(obj.Balance(), delta))
+			}
+		}
+		if write.nonce {
+			obj.setNonce(srcobj.Nonce())
+		}
+		if write.code {
+			obj.setCode(common.BytesToHash(srcobj.CodeHash()), srcobj.Code(src.db))
+		}
+		for key := range write.storage {
+			obj.setState(key, srcobj.GetState(src.db, key))
+		}
+		if write.suicided {
+			obj.suicided = true
+		}
+		self.stateObjectsDirty[addr] = struct{}{}
+		obj.onDirty = nil
+	}
+	// Transfer the logs, renumbering them within this state
+	for _, log := range src.logs[src.thash] {
+		log.Index = self.logSize
+		self.logs[src.thash] = append(self.logs[src.thash], log)
+		self.logSize++
+	}
+	for hash, preimage := range src.preimages {
+		if _, ok := self.preimages[hash]; !ok {
+			self.preimages[hash] = preimage
+		}
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// Tests that the changes of independently executed transactions can be merged
+// and that dependent ones are detected as conflicting.
+func TestApplyWrites(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, NewDatabase(db))
+
+	var (
+		alice = common.Address{0x01}
+		bob   = common.Address{0x02}
+		miner = common.Address{0x03}
+		slot  = common.Hash{0x01}
+	)
+	state.SetBalance(alice, big.NewInt(100))
+	state.SetBalance(bob, big.NewInt(100))
+	state.SetState(bob, slot, common.Hash{0x01})
+	state.Finalise(true)
+
+	// Execute a transfer of alice and a storage update of bob, both paying the miner
+	transfer := state.Copy()
+	transfer.StartAccessRecording()
+	transfer.SetBalance(alice, new(big.Int).Sub(transfer.GetBalance(alice), big.NewInt(10)))
+	transfer.AddBalance(miner, big.NewInt(1))
+	transferReads, transferWrites := transfer.StopAccessRecording()
+
+	update := state.Copy()
+	update.StartAccessRecording()
+	if update.GetState(bob, slot) == (common.Hash{0x01}) {
+		update.SetState(bob, slot, common.Hash{0x02})
+	}
+	update.AddBalance(miner, big.NewInt(2))
+	updateReads, updateWrites := update.StopAccessRecording()
+
+	// Both transactions are independent and should merge cleanly
+	written := NewWriteSet()
+	if written.Conflicts(transferReads) || !state.ApplyWrites(transfer, transferReads, transferWrites) {
+		t.Fatalf("failed to apply transfer")
+	}
+	state.Finalise(true)
+	written.Merge(transferWrites)
+
+	if written.Conflicts(updateReads) || !state.ApplyWrites(update, updateReads, updateWrites) {
+		t.Fatalf("failed to apply storage update")
+	}
+	state.Finalise(true)
+	written.Merge(updateWrites)
+
+	if balance := state.GetBalance(alice); balance.Cmp(big.NewInt(90)) != 0 {
+		t.Errorf("sender balance mismatch: have %v, want %v", balance, 90)
+	}
+	if balance := state.GetBalance(miner); balance.Cmp(big.NewInt(3)) != 0 {
+		t.Errorf("miner balance mismatch: have %v, want %v", balance, 3)
+	}
+	if value := state.GetState(bob, slot); value != (common.Hash{0x02}) {
+		t.Errorf("storage mismatch: have %x, want %x", value, common.Hash{0x02})
+	}
+	// Reading any of the modified entries must conflict, untouched ones not
+	reader := state.Copy()
+	reader.StartAccessRecording()
+	reader.GetState(alice, slot)
+	reader.GetBalance(bob)
+	reads, _ := reader.StopAccessRecording()
+	if written.Conflicts(reads) {
+		t.Errorf("reads of unmodified entries conflict")
+	}
+	for i, read := range []func(*StateDB){
+		func(s *StateDB) { s.GetNonce(alice) },
+		func(s *StateDB) { s.GetBalance(miner) },
+		func(s *StateDB) { s.GetState(bob, slot) },
+	} {
+		reader := state.Copy()
+		reader.StartAccessRecording()
+		read(reader)
+		reads, _ := reader.StopAccessRecording()
+		if !written.Conflicts(reads) {
+			t.Errorf("read %d: conflict not detected", i)
+		}
+	}
+}
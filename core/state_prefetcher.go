@@ -0,0 +1,86 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// statePrefetcher is a basic Prefetcher, which blindly executes a block on top
+// of an arbitrary state with the goal of prefetching potentially useful state
+// data from disk before the main block processor start executing.
+type statePrefetcher struct {
+	config *params.ChainConfig // Chain configuration options
+	bc     *BlockChain         // Canonical block chain
+	engine consensus.Engine    // Consensus engine used for block rewards
+}
+
+// newStatePrefetcher initialises a new statePrefetcher.
+func newStatePrefetcher(config *params.ChainConfig, bc *BlockChain, engine consensus.Engine) *statePrefetcher {
+	return &statePrefetcher{
+		config: config,
+		bc:     bc,
+		engine: engine,
+	}
+}
+
+// Prefetch processes the state changes according to the Ethereum rules by running
+// the transaction messages using the statedb, but any changes are discarded. The
+// only goal is to pre-cache the trie nodes and contract code touched by the block,
+// so the block's real execution finds them in memory.
+//
+// Transactions failing on the throwaway state, e.g. because the state lacks the
+// changes of the preceding block, are skipped. Prefetching stops as soon as the
+// interrupt flag is set.
+func (p *statePrefetcher) Prefetch(block *types.Block, statedb *state.StateDB, cfg vm.Config, interrupt *uint32) {
+	var (
+		header = block.Header()
+		signer = types.MakeSigner(p.config, header.Number)
+	)
+	// Tracing and profiling must only observe the real execution
+	cfg.Debug, cfg.Tracer, cfg.Profiler = false, nil, nil
+
+	for i, tx := range block.Transactions() {
+		// If block precaching was interrupted, abort
+		if interrupt != nil && atomic.LoadUint32(interrupt) == 1 {
+			return
+		}
+		// Ignore the nonces, the state might not contain the preceding transactions
+		msg, err := tx.AsMessage(signer)
+		if err != nil {
+			continue
+		}
+		msg = types.NewMessage(msg.From(), msg.To(), msg.Nonce(), msg.Value(), msg.Gas(), msg.GasPrice(), msg.Data(), false)
+
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+		vmenv := vm.NewEVM(NewEVMContext(msg, header, p.bc, nil), statedb, p.config, cfg)
+		if _, _, _, err := ApplyMessage(vmenv, msg, new(GasPool).AddGas(msg.Gas())); err != nil {
+			continue
+		}
+		statedb.Finalise(p.config.IsEIP158(header.Number))
+	}
+	// Warm the account trie paths the block's state root calculation will hash
+	if interrupt == nil || atomic.LoadUint32(interrupt) == 0 {
+		statedb.IntermediateRoot(p.config.IsEIP158(header.Number))
+	}
+}
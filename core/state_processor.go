@@ -85,11 +85,22 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 // and uses the input parameters for its environment. It returns the receipt
 // for the transaction, gas used and an error if the transaction failed,
 // indicating the block was invalid.
-func ApplyTransaction(config *params.ChainConfig, bc *BlockChain, author *common.Address, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *uint64, cfg vm.Config) (*types.Receipt, uint64, error) {
-	msg, err := tx.AsMessage(types.MakeSigner(config, header.Number))
+func ApplyTransaction(config *params.ChainConfig, bc ChainContext, author *common.Address, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *uint64, cfg vm.Config) (*types.Receipt, uint64, error) {
+	msg, gas, failed, err := applyTransactionMessage(config, bc, author, gp, statedb, header, tx, cfg)
 	if err != nil {
 		return nil, 0, err
 	}
+	return finaliseTransaction(config, statedb, header, tx, msg, gas, failed, usedGas), gas, err
+}
+
+// applyTransactionMessage executes a transaction on top of the given state, without
+// finalising it. It returns the message derived from the transaction, the gas used
+// and whether the execution failed.
+func applyTransactionMessage(config *params.ChainConfig, bc ChainContext, author *common.Address, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, cfg vm.Config) (types.Message, uint64, bool, error) {
+	msg, err := tx.AsMessage(types.MakeSigner(config, header.Number))
+	if err != nil {
+		return msg, 0, false, err
+	}
 	// Create a new context to be used in the EVM environment
 	context := NewEVMContext(msg, header, bc, author)
 	// Create a new environment which holds all relevant information
@@ -97,9 +108,12 @@ func ApplyTransaction(config *params.ChainConfig, bc *BlockChain, author *common
 	vmenv := vm.NewEVM(context, statedb, config, cfg)
 	// Apply the transaction to the current state (included in the env)
 	_, gas, failed, err := ApplyMessage(vmenv, msg, gp)
-	if err != nil {
-		return nil, 0, err
-	}
+	return msg, gas, failed, err
+}
+
+// finaliseTransaction finalises the state after a transaction was applied and
+// creates its receipt, accumulating the gas used by the transaction into usedGas.
+func finaliseTransaction(config *params.ChainConfig, statedb *state.StateDB, header *types.Header, tx *types.Transaction, msg types.Message, gas uint64, failed bool, usedGas *uint64) *types.Receipt {
 	// Update the state with pending changes
 	var root []byte
 	if config.IsByzantium(header.Number) {
@@ -116,11 +130,11 @@ func ApplyTransaction(config *params.ChainConfig, bc *BlockChain, author *common
 	receipt.GasUsed = gas
 	// if the transaction created a contract, store the creation address in the receipt.
 	if msg.To() == nil {
-		receipt.ContractAddress = crypto.CreateAddress(vmenv.Context.Origin, tx.Nonce())
+		receipt.ContractAddress = crypto.CreateAddress(msg.From(), tx.Nonce())
 	}
 	// Set the receipt logs and create a bloom for filtering
 	receipt.Logs = statedb.GetLogs(tx.Hash())
 	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
 
-	return receipt, gas, err
+	return receipt
 }
@@ -0,0 +1,178 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ParallelStateProcessor is a Processor executing the transactions of a block
+// concurrently. Every transaction is run speculatively on its own copy of the
+// state at the start of the block, recording the state entries it accesses. The
+// results are then committed in block order, re-executing any transaction which
+// read state modified by an earlier transaction of the same block, producing the
+// exact same state as sequential execution would.
+//
+// ParallelStateProcessor implements Processor.
+type ParallelStateProcessor struct {
+	*StateProcessor
+	workers int // Number of transactions to execute concurrently
+}
+
+// NewParallelStateProcessor initialises a new ParallelStateProcessor, executing
+// at most workers transactions concurrently.
+func NewParallelStateProcessor(config *params.ChainConfig, bc *BlockChain, engine consensus.Engine, workers int) *ParallelStateProcessor {
+	if workers < 1 {
+		workers = 1
+	}
+	return &ParallelStateProcessor{
+		StateProcessor: NewStateProcessor(config, bc, engine),
+		workers:        workers,
+	}
+}
+
+// speculativeResult is the outcome of executing a transaction on a private copy
+// of the state at the start of the block.
+type speculativeResult struct {
+	statedb *state.StateDB   // State the transaction was executed on
+	msg     types.Message    // Message derived from the transaction
+	gas     uint64           // Gas used by the transaction
+	failed  bool             // Whether the execution failed
+	err     error            // Error preventing the transaction from being applied
+	reads   *state.AccessSet // State entries read by the transaction
+	writes  *state.WriteSet  // State entries modified by the transaction
+}
+
+// Process processes the state changes according to the Ethereum rules, running
+// the transaction messages in parallel where they don't depend on each other.
+//
+// Process returns the receipts and logs accumulated during the process and
+// returns the amount of gas that was used in the process. If any of the
+// transactions failed to execute due to insufficient gas it will return an error.
+func (p *ParallelStateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg vm.Config) (types.Receipts, []*types.Log, uint64, error) {
+	// Tracing and profiling need to observe every execution exactly once, in order
+	txs := block.Transactions()
+	if cfg.Debug || cfg.Profiler != nil || p.workers < 2 || len(txs) < 2 {
+		return p.StateProcessor.Process(block, statedb, cfg)
+	}
+	var (
+		receipts types.Receipts
+		usedGas  = new(uint64)
+		header   = block.Header()
+		allLogs  []*types.Log
+		gp       = new(GasPool).AddGas(block.GasLimit())
+	)
+	// Mutate the the block and state according to any hard-fork specs
+	if p.config.DAOForkSupport && p.config.DAOForkBlock != nil && p.config.DAOForkBlock.Cmp(block.Number()) == 0 {
+		misc.ApplyDAOHardFork(statedb)
+	}
+	// Execute all the transactions speculatively on top of the block's initial state
+	base := statedb.Copy()
+
+	results := make([]chan *speculativeResult, len(txs))
+	for i := range results {
+		results[i] = make(chan *speculativeResult, 1)
+	}
+	var (
+		tasks = make(chan int, len(txs))
+		abort = make(chan struct{})
+	)
+	for i := range txs {
+		tasks <- i
+	}
+	close(tasks)
+	defer close(abort)
+
+	for i := 0; i < p.workers && i < len(txs); i++ {
+		go func() {
+			for i := range tasks {
+				select {
+				case <-abort:
+					return
+				default:
+				}
+				results[i] <- p.speculate(base.Copy(), block, header, i, cfg)
+			}
+		}()
+	}
+	// Commit the results in order, re-executing the ones invalidated by their
+	// predecessors on top of the real state
+	var (
+		written = state.NewWriteSet()
+		reruns  int
+	)
+	for i, tx := range txs {
+		res := <-results[i]
+
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+		if res.err != nil || gp.Gas() < res.msg.Gas() || written.Conflicts(res.reads) || !statedb.ApplyWrites(res.statedb, res.reads, res.writes) {
+			reruns++
+
+			statedb.StartAccessRecording()
+			msg, gas, failed, err := applyTransactionMessage(p.config, p.bc, nil, gp, statedb, header, tx, cfg)
+			reads, writes := statedb.StopAccessRecording()
+			if err != nil {
+				return nil, nil, 0, err
+			}
+			res = &speculativeResult{msg: msg, gas: gas, failed: failed, reads: reads, writes: writes}
+		} else {
+			gp.SubGas(res.gas)
+		}
+		written.Merge(res.writes)
+
+		receipt := finaliseTransaction(p.config, statedb, header, tx, res.msg, res.gas, res.failed, usedGas)
+		receipts = append(receipts, receipt)
+		allLogs = append(allLogs, receipt.Logs...)
+	}
+	log.Debug("Processed transactions in parallel", "number", block.Number(), "txs", len(txs), "reruns", reruns)
+
+	// Finalize the block, applying any consensus engine specific extras (e.g. block rewards)
+	p.engine.Finalize(p.bc, header, statedb, txs, block.Uncles(), receipts)
+
+	return receipts, allLogs, *usedGas, nil
+}
+
+// speculate executes the index-th transaction of the block on the given private
+// copy of the state, recording the state entries it accesses.
+func (p *ParallelStateProcessor) speculate(statedb *state.StateDB, block *types.Block, header *types.Header, index int, cfg vm.Config) *speculativeResult {
+	tx := block.Transactions()[index]
+
+	statedb.Prepare(tx.Hash(), block.Hash(), index)
+	statedb.StartAccessRecording()
+
+	gp := new(GasPool).AddGas(tx.Gas())
+	msg, gas, failed, err := applyTransactionMessage(p.config, p.bc, nil, gp, statedb, header, tx, cfg)
+	reads, writes := statedb.StopAccessRecording()
+	if err == nil {
+		err = statedb.Error()
+	}
+	return &speculativeResult{
+		statedb: statedb,
+		msg:     msg,
+		gas:     gas,
+		failed:  failed,
+		err:     err,
+		reads:   reads,
+		writes:  writes,
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	// parallelCounter increments storage slot 0 and emits an empty log.
+	parallelCounter = common.FromHex("60005460010160005560006000a000")
+	// parallelSetter sets the storage slot keyed by the caller to 1.
+	parallelSetter = common.FromHex("6001335560006000a000")
+	// parallelSuicider self destructs, sending its funds to the caller.
+	parallelSuicider = common.FromHex("33ff")
+	// parallelBalance stores the balance of the coinbase in storage slot 0.
+	parallelBalance = common.FromHex("4131600055")
+	// parallelReverter sets storage slot 0 and reverts.
+	parallelReverter = common.FromHex("600160005560006000a060006000fd")
+	// parallelInit stores 42 in storage slot 0 of the created contract.
+	parallelInit = common.FromHex("602a60005500")
+)
+
+// Tests that executing transactions in parallel produces the same results as
+// executing them sequentially, for a mix of independent and conflicting
+// transactions across different forks.
+func TestParallelStateProcessor(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *params.ChainConfig
+	}{
+		{"homestead", &params.ChainConfig{ChainId: big.NewInt(1), HomesteadBlock: new(big.Int)}},
+		{"eip158", &params.ChainConfig{ChainId: big.NewInt(1), HomesteadBlock: new(big.Int), EIP150Block: new(big.Int), EIP155Block: new(big.Int), EIP158Block: new(big.Int)}},
+		{"byzantium", &params.ChainConfig{ChainId: big.NewInt(1), HomesteadBlock: new(big.Int), EIP150Block: new(big.Int), EIP155Block: new(big.Int), EIP158Block: new(big.Int), ByzantiumBlock: new(big.Int)}},
+		{"all", params.AllEthashProtocolChanges},
+	}
+	for _, tt := range tests {
+		testParallelStateProcessor(t, tt.name, tt.config)
+	}
+}
+
+func testParallelStateProcessor(t *testing.T, name string, config *params.ChainConfig) {
+	var (
+		keys     = make([]*ecdsa.PrivateKey, 4)
+		addrs    = make([]common.Address, 4)
+		funds    = big.NewInt(1000000000000000000)
+		counter  = common.Address{0xc1}
+		setter   = common.Address{0xc2}
+		suicider = common.Address{0xc3}
+		balance  = common.Address{0xc4}
+		reverter = common.Address{0xc5}
+		empty    = common.Address{0xe1}
+	)
+	alloc := GenesisAlloc{
+		counter:  {Balance: new(big.Int), Code: parallelCounter},
+		setter:   {Balance: new(big.Int), Code: parallelSetter},
+		suicider: {Balance: big.NewInt(1000), Code: parallelSuicider},
+		balance:  {Balance: new(big.Int), Code: parallelBalance},
+		reverter: {Balance: new(big.Int), Code: parallelReverter},
+		empty:    {Balance: new(big.Int)},
+	}
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+		alloc[addrs[i]] = GenesisAccount{Balance: funds}
+	}
+	var (
+		db, _ = ethdb.NewMemDatabase()
+		gspec = &Genesis{Config: config, Alloc: alloc}
+
+		genesis = gspec.MustCommit(db)
+	)
+	blocks, _ := GenerateChain(config, genesis, ethash.NewFaker(), db, 8, func(i int, block *BlockGen) {
+		signer := types.MakeSigner(config, block.Number())
+
+		send := func(sender int, to *common.Address, value int64, data []byte) {
+			var tx *types.Transaction
+			if to == nil {
+				tx = types.NewContractCreation(block.TxNonce(addrs[sender]), big.NewInt(value), 100000, big.NewInt(1), data)
+			} else {
+				tx = types.NewTransaction(block.TxNonce(addrs[sender]), *to, big.NewInt(value), 100000, big.NewInt(1), data)
+			}
+			tx, err := types.SignTx(tx, signer, keys[sender])
+			if err != nil {
+				t.Fatalf("%s: failed to sign transaction: %v", name, err)
+			}
+			block.AddTx(tx)
+		}
+		fresh := common.BytesToAddress([]byte{0xf0, byte(i)})
+		switch i {
+		case 0:
+			// Independent transfers, including to the same recipient
+			block.SetCoinbase(common.Address{0xcb})
+			for j := range addrs {
+				send(j, &fresh, 1000, nil)
+			}
+		case 1:
+			// Independent storage writes and conflicting counter increments
+			for j := range addrs {
+				send(j, &setter, 0, nil)
+				send(j, &counter, 0, nil)
+			}
+		case 2:
+			// Chained transactions of the same sender and contract creations
+			send(0, &addrs[1], 1000, nil)
+			send(1, &addrs[2], 1000, nil)
+			send(0, nil, 0, parallelInit)
+			send(0, nil, 0, parallelInit)
+			send(2, nil, 10, nil)
+		case 3:
+			// Zero value touches of empty and missing accounts, reverts
+			send(0, &empty, 0, nil)
+			send(1, &fresh, 0, nil)
+			send(2, &empty, 0, nil)
+			send(3, &reverter, 0, nil)
+			send(0, &counter, 0, nil)
+		case 4:
+			// Self destruct and accesses of the destructed contract
+			send(0, &suicider, 0, nil)
+			send(1, &suicider, 0, nil)
+			send(2, &suicider, 1000, nil)
+			send(3, &setter, 0, nil)
+		case 5:
+			// Reads of the coinbase balance, being credited by every transaction
+			send(0, &counter, 0, nil)
+			send(1, &balance, 0, nil)
+			send(2, &balance, 0, nil)
+			send(3, &fresh, 1000, nil)
+		case 6:
+			// Sender being the coinbase
+			block.SetCoinbase(addrs[0])
+			send(0, &fresh, 1000, nil)
+			send(1, &addrs[0], 1000, nil)
+			send(2, &counter, 0, nil)
+		case 7:
+			// Failing transactions consuming all their gas
+			send(0, &reverter, 0, nil)
+			send(1, &reverter, 0, nil)
+			send(2, &setter, 0, nil)
+		}
+	})
+	// Import the chain with the parallel processor, validating the state roots
+	db, _ = ethdb.NewMemDatabase()
+	gspec.MustCommit(db)
+
+	chain, _ := NewBlockChain(db, nil, config, ethash.NewFaker(), vm.Config{})
+	defer chain.Stop()
+
+	chain.SetProcessor(NewParallelStateProcessor(config, chain, chain.Engine(), 4))
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("%s: failed to import block %d: %v", name, n, err)
+	}
+	if head := chain.CurrentBlock().Hash(); head != blocks[len(blocks)-1].Hash() {
+		t.Errorf("%s: head mismatch: have %x, want %x", name, head, blocks[len(blocks)-1].Hash())
+	}
+}
@@ -204,6 +204,11 @@ func (st *StateTransition) preCheck() error {
 // returning the result including the the used gas. It returns an error if it
 // failed. An error indicates a consensus issue.
 func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bool, err error) {
+	// An EVM on an invalid chain config can't run anything, fail the whole block
+	// instead of letting the transaction fail and diverging from the network
+	if err = st.evm.Err(); err != nil {
+		return
+	}
 	if err = st.preCheck(); err != nil {
 		return
 	}
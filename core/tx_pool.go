@@ -798,6 +798,10 @@ func (pool *TxPool) addTx(tx *types.Transaction, local bool) error {
 
 // addTxs attempts to queue a batch of transactions if they are valid.
 func (pool *TxPool) addTxs(txs []*types.Transaction, local bool) []error {
+	// Recover the senders of the batch in the background before taking the pool
+	// lock, so validation finds them cached instead of waiting on ecrecover
+	senderCacher.recover(pool.signer, txs)
+
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
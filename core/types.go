@@ -44,3 +44,11 @@ type Validator interface {
 type Processor interface {
 	Process(block *types.Block, statedb *state.StateDB, cfg vm.Config) (types.Receipts, []*types.Log, uint64, error)
 }
+
+// Prefetcher is an interface for pre-caching the state a block accesses.
+//
+// Prefetch speculatively executes the block on top of the given throwaway
+// statedb, discarding the results, until the interrupt flag is set.
+type Prefetcher interface {
+	Prefetch(block *types.Block, statedb *state.StateDB, cfg vm.Config, interrupt *uint32)
+}
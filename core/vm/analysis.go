@@ -17,11 +17,33 @@
 package vm
 
 import (
+	"encoding/binary"
 	"math/big"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
 )
 
+// analysisCacheSize is the number of code bitmaps retained by the process wide
+// analysis cache. Bitmaps are an eighth of the code size, so even a cache full
+// of maximum sized contracts stays below 8MB.
+const analysisCacheSize = 2048
+
+// analysisCache is a process wide cache of code bitmaps keyed by code hash, so
+// popular contracts don't need to be analysed anew by every transaction.
+//
+// Code hashes are uniformly distributed, so the cache is direct mapped on their
+// low bits instead of being an LRU: both lookups and insertions are a single
+// slot access without allocations, keeping code missing the cache as cheap as
+// analysing it without one.
+var analysisCache struct {
+	lock  sync.RWMutex
+	slots [analysisCacheSize]struct {
+		hash common.Hash
+		bits bitvec
+	}
+}
+
 // destinations stores one map per contract (keyed by hash of code).
 // The maps contain an entry for each location of a JUMPDEST
 // instruction.
@@ -38,12 +60,38 @@ func (d destinations) has(codehash common.Hash, code []byte, dest *big.Int) bool
 
 	m, analysed := d[codehash]
 	if !analysed {
-		m = codeBitmap(code)
+		m = analyse(codehash, code)
 		d[codehash] = m
 	}
 	return OpCode(code[udest]) == JUMPDEST && m.codeSegment(udest)
 }
 
+// analyse retrieves the bitmap of the given code from the process wide analysis
+// cache, or generates and caches it if it's not yet known. Code without a known
+// hash is always analysed from scratch.
+func analyse(codehash common.Hash, code []byte) bitvec {
+	if codehash == (common.Hash{}) {
+		return codeBitmap(code)
+	}
+	slot := &analysisCache.slots[binary.BigEndian.Uint64(codehash[24:])%analysisCacheSize]
+
+	analysisCache.lock.RLock()
+	if slot.hash == codehash {
+		bits := slot.bits
+		analysisCache.lock.RUnlock()
+		return bits
+	}
+	analysisCache.lock.RUnlock()
+
+	bits := codeBitmap(code)
+
+	analysisCache.lock.Lock()
+	slot.hash, slot.bits = codehash, bits
+	analysisCache.lock.Unlock()
+
+	return bits
+}
+
 // bitvec is a bit vector which maps bytes in a program.
 // An unset bit means the byte is an opcode, a set bit means
 // it's data (i.e. argument of PUSHxx).
@@ -16,7 +16,15 @@
 
 package vm
 
-import "testing"
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
 
 func TestJumpDestAnalysis(t *testing.T) {
 	tests := []struct {
@@ -51,3 +59,65 @@ func TestJumpDestAnalysis(t *testing.T) {
 	}
 
 }
+
+// Tests that code bitmaps are shared through the analysis cache by code hash,
+// but only if the hash is actually known.
+func TestJumpDestCache(t *testing.T) {
+	code := []byte{byte(PUSH1), byte(JUMPDEST), byte(JUMPDEST)}
+	hash := crypto.Keccak256Hash(code)
+
+	bits := analyse(hash, code)
+	if cached := analyse(hash, code); &cached[0] != &bits[0] {
+		t.Errorf("bitmap of known code not cached")
+	}
+	if fresh := analyse(common.Hash{}, code); &fresh[0] == &bits[0] {
+		t.Errorf("bitmap of unhashed code retrieved from cache")
+	}
+	// Separate contracts running the same code reuse the same analysis
+	for i := 0; i < 2; i++ {
+		d := make(destinations)
+		if d.has(hash, code, big.NewInt(1)) {
+			t.Errorf("contract %d: jump into push data allowed", i)
+		}
+		if !d.has(hash, code, big.NewInt(2)) {
+			t.Errorf("contract %d: jump to jumpdest denied", i)
+		}
+	}
+}
+
+// Benchmarks the jumpdest analysis of a contract with and without the analysis
+// cache: "hot" code is found in the cache, while "cold" code misses it and has
+// to be analysed and inserted, replacing an older bitmap, which must not cost
+// noticeably more than always analysing from scratch ("uncached").
+func BenchmarkJumpdestAnalysis(b *testing.B) {
+	for _, size := range []int{1024, 24576} {
+		code := make([]byte, size)
+		for i := range code {
+			code[i] = byte(i)
+		}
+		hash := crypto.Keccak256Hash(code)
+
+		b.Run(fmt.Sprintf("uncached/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				codeBitmap(code)
+			}
+		})
+		b.Run(fmt.Sprintf("hot/%d", size), func(b *testing.B) {
+			analyse(hash, code)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				analyse(hash, code)
+			}
+		})
+		// Every iteration runs code under a fresh hash, unique across benchmark rounds
+		var seq uint64
+		b.Run(fmt.Sprintf("cold/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				seq++
+				var cold common.Hash
+				binary.BigEndian.PutUint64(cold[:], seq)
+				analyse(cold, code)
+			}
+		})
+	}
+}
@@ -18,14 +18,17 @@ package vm
 
 import (
 	"crypto/sha256"
+	"encoding/binary"
 	"errors"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/blake2b"
 	"github.com/ethereum/go-ethereum/crypto/bn256"
 	"github.com/ethereum/go-ethereum/params"
+	"golang.org/x/crypto/ed25519"
 	"golang.org/x/crypto/ripemd160"
 )
 
@@ -59,6 +62,13 @@ var PrecompiledContractsByzantium = map[common.Address]PrecompiledContract{
 	common.BytesToAddress([]byte{8}): &bn256Pairing{},
 }
 
+// PrecompiledContractsEIPs contains the pre-compiled contracts introduced by the
+// individually activable EIPs, added on top of the ones of the current release.
+var PrecompiledContractsEIPs = map[int]map[common.Address]PrecompiledContract{
+	params.EIP152: {common.BytesToAddress([]byte{9}): &blake2F{}},
+	params.EIP665: {common.BytesToAddress([]byte{10}): &ed25519Verify{}},
+}
+
 // RunPrecompiledContract runs and evaluates the output of a precompiled contract.
 func RunPrecompiledContract(p PrecompiledContract, input []byte, contract *Contract) (ret []byte, err error) {
 	gas := p.RequiredGas(input)
@@ -377,3 +387,103 @@ func (c *bn256Pairing) Run(input []byte) ([]byte, error) {
 	}
 	return false32Byte, nil
 }
+
+const (
+	blake2FInputLength        = 213
+	blake2FFinalBlockBytes    = byte(1)
+	blake2FNonFinalBlockBytes = byte(0)
+)
+
+var (
+	// errBlake2FInvalidInputLength is returned if the BLAKE2b F input isn't
+	// exactly 213 bytes long.
+	errBlake2FInvalidInputLength = errors.New("invalid input length")
+
+	// errBlake2FInvalidFinalFlag is returned if the final block indicator flag
+	// of the BLAKE2b F input is neither 0 nor 1.
+	errBlake2FInvalidFinalFlag = errors.New("invalid final flag")
+)
+
+// blake2F implements the BLAKE2b F compression function pre-compile (EIP-152).
+type blake2F struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *blake2F) RequiredGas(input []byte) uint64 {
+	// If the input is malformed, we can't calculate the gas, return 0 and let the
+	// actual call choke and fault.
+	if len(input) != blake2FInputLength {
+		return 0
+	}
+	return uint64(binary.BigEndian.Uint32(input[0:4])) * params.Blake2FRoundGas
+}
+
+func (c *blake2F) Run(input []byte) ([]byte, error) {
+	// Make sure the input is valid (correct length and final flag)
+	if len(input) != blake2FInputLength {
+		return nil, errBlake2FInvalidInputLength
+	}
+	if input[212] != blake2FNonFinalBlockBytes && input[212] != blake2FFinalBlockBytes {
+		return nil, errBlake2FInvalidFinalFlag
+	}
+	// Parse the input into the BLAKE2b call parameters
+	var (
+		rounds = binary.BigEndian.Uint32(input[0:4])
+		final  = input[212] == blake2FFinalBlockBytes
+
+		h [8]uint64
+		m [16]uint64
+		t [2]uint64
+	)
+	for i := 0; i < 8; i++ {
+		offset := 4 + i*8
+		h[i] = binary.LittleEndian.Uint64(input[offset : offset+8])
+	}
+	for i := 0; i < 16; i++ {
+		offset := 68 + i*8
+		m[i] = binary.LittleEndian.Uint64(input[offset : offset+8])
+	}
+	t[0] = binary.LittleEndian.Uint64(input[196:204])
+	t[1] = binary.LittleEndian.Uint64(input[204:212])
+
+	// Execute the compression function, extract and return the result
+	blake2b.F(&h, &m, t, final, rounds)
+
+	output := make([]byte, 64)
+	for i := 0; i < 8; i++ {
+		offset := i * 8
+		binary.LittleEndian.PutUint64(output[offset:offset+8], h[i])
+	}
+	return output, nil
+}
+
+var (
+	// ed25519Valid is returned if the ed25519 signature verification succeeds.
+	ed25519Valid = []byte{0, 0, 0, 0}
+
+	// ed25519Invalid is returned if the ed25519 signature verification fails.
+	ed25519Invalid = []byte{0, 0, 0, 1}
+
+	// errBadEd25519Input is returned if the ed25519 verification input isn't
+	// exactly 128 bytes long.
+	errBadEd25519Input = errors.New("bad ed25519 verification input size")
+)
+
+// ed25519Verify implements an ed25519 signature verification pre-compile
+// (EIP-665), checking a signature over a 32 byte message.
+type ed25519Verify struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *ed25519Verify) RequiredGas(input []byte) uint64 {
+	return params.Ed25519VerifyGas
+}
+
+func (c *ed25519Verify) Run(input []byte) ([]byte, error) {
+	// "input" is (message, public key, signature), 32, 32 and 64 bytes
+	if len(input) != 128 {
+		return nil, errBadEd25519Input
+	}
+	if ed25519.Verify(ed25519.PublicKey(input[32:64]), input[:32], input[64:128]) {
+		return ed25519Valid, nil
+	}
+	return ed25519Invalid, nil
+}
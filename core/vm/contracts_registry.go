@@ -0,0 +1,281 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/hashicorp/golang-lru"
+)
+
+var (
+	registeredPrecompiles = make(map[string]PrecompiledContract) // Native contracts activable through the chain config
+	registeredLock        sync.RWMutex
+)
+
+// RegisterPrecompiledContract makes a native contract available for activation
+// through the Precompiles map of the chain configuration, under the given name.
+// Registration is meant to happen from init functions, before any EVM is run.
+func RegisterPrecompiledContract(name string, p PrecompiledContract) error {
+	if name == "" {
+		return errors.New("empty precompiled contract name")
+	}
+	if p == nil {
+		return fmt.Errorf("nil precompiled contract %q", name)
+	}
+	registeredLock.Lock()
+	defer registeredLock.Unlock()
+
+	if _, ok := registeredPrecompiles[name]; ok {
+		return fmt.Errorf("precompiled contract %q already registered", name)
+	}
+	registeredPrecompiles[name] = p
+	return nil
+}
+
+// RegisteredPrecompiledContracts returns the names of all the native contracts
+// registered for activation through the chain configuration, in sorted order.
+func RegisteredPrecompiledContracts() []string {
+	registeredLock.RLock()
+	defer registeredLock.RUnlock()
+
+	names := make([]string, 0, len(registeredPrecompiles))
+	for name := range registeredPrecompiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckPrecompiles verifies that all the native contracts activated by the chain
+// configuration are registered, have an activation block set and don't shadow
+// any of the standard precompiled contracts.
+func CheckPrecompiles(config *params.ChainConfig) error {
+	registeredLock.RLock()
+	defer registeredLock.RUnlock()
+
+	for addr, precompile := range config.Precompiles {
+		if precompile == nil || precompile.Block == nil {
+			return fmt.Errorf("missing activation block for precompile %x", addr)
+		}
+		if _, ok := PrecompiledContractsByzantium[addr]; ok {
+			return fmt.Errorf("precompile %x overrides a standard contract", addr)
+		}
+		for eip, contracts := range PrecompiledContractsEIPs {
+			if _, ok := contracts[addr]; ok {
+				return fmt.Errorf("precompile %x overrides the EIP-%d contract", addr, eip)
+			}
+		}
+		if _, ok := registeredPrecompiles[precompile.Name]; !ok {
+			return fmt.Errorf("unknown contract %q for precompile %x", precompile.Name, addr)
+		}
+	}
+	return nil
+}
+
+// precompileSchedule is the precompiled contract set of a chain configuration at
+// each block where it changes, letting EVMs look it up instead of rebuilding it.
+type precompileSchedule struct {
+	blocks []*big.Int                               // Blocks where the contract set changes, ascending
+	sets   []map[common.Address]PrecompiledContract // Contract set active from the matching block on
+	err    error                                    // Error building the schedule, if any
+}
+
+// precompileScheduleCacheSize is the number of distinct chain configurations
+// whose precompiled contract schedules are retained by the process wide cache.
+const precompileScheduleCacheSize = 64
+
+// precompileSchedules is a process wide cache of precompiled contract schedules,
+// keyed by the fields of the chain configuration they are derived from, so that
+// equal configurations share them and modified ones don't see stale results.
+var precompileSchedules, _ = lru.New(precompileScheduleCacheSize)
+
+// activePrecompiles returns the precompiled contracts active at the given block,
+// the standard ones of the current fork extended with the ones of individually
+// activated EIPs and the registered contracts activated by the chain config.
+func activePrecompiles(config *params.ChainConfig, num *big.Int) (map[common.Address]PrecompiledContract, error) {
+	if num == nil || (len(config.EIPs) == 0 && len(config.Precompiles) == 0) {
+		if config.IsByzantium(num) {
+			return PrecompiledContractsByzantium, nil
+		}
+		return PrecompiledContractsHomestead, nil
+	}
+	key := precompileScheduleKey(config)
+
+	var schedule *precompileSchedule
+	if cached, ok := precompileSchedules.Get(key); ok {
+		schedule = cached.(*precompileSchedule)
+	} else {
+		schedule = newPrecompileSchedule(config)
+		precompileSchedules.Add(key, schedule)
+	}
+	if schedule.err != nil {
+		return nil, schedule.err
+	}
+	i := sort.Search(len(schedule.blocks), func(i int) bool { return schedule.blocks[i].Cmp(num) > 0 })
+	return schedule.sets[i-1], nil
+}
+
+// precompileScheduleKey returns the cache key of the precompiled contract schedule
+// of a chain config, consisting of all the fields the schedule is derived from.
+func precompileScheduleKey(config *params.ChainConfig) string {
+	key := new(bytes.Buffer)
+	fmt.Fprintf(key, "%v/%v", config.ByzantiumBlock, config.ConstantinopleBlock)
+
+	eips := make([]int, 0, len(config.EIPs))
+	for eip := range config.EIPs {
+		eips = append(eips, eip)
+	}
+	sort.Ints(eips)
+	for _, eip := range eips {
+		fmt.Fprintf(key, "/%d:%v", eip, config.EIPs[eip])
+	}
+	addrs := make([]string, 0, len(config.Precompiles))
+	for addr, precompile := range config.Precompiles {
+		if precompile == nil {
+			addrs = append(addrs, fmt.Sprintf("%x:", addr))
+		} else {
+			addrs = append(addrs, fmt.Sprintf("%x:%q:%v", addr, precompile.Name, precompile.Block))
+		}
+	}
+	sort.Strings(addrs)
+	for _, addr := range addrs {
+		fmt.Fprintf(key, "/%s", addr)
+	}
+	return key.String()
+}
+
+// newPrecompileSchedule assembles the precompiled contract sets of a chain config
+// at all the blocks where any of the forks, EIPs or contracts activate.
+func newPrecompileSchedule(config *params.ChainConfig) *precompileSchedule {
+	blocks := []*big.Int{new(big.Int)}
+	for _, block := range []*big.Int{config.ByzantiumBlock, config.ConstantinopleBlock} {
+		if block != nil {
+			blocks = append(blocks, block)
+		}
+	}
+	for _, block := range config.EIPs {
+		if block != nil {
+			blocks = append(blocks, block)
+		}
+	}
+	for _, precompile := range config.Precompiles {
+		if precompile != nil && precompile.Block != nil {
+			blocks = append(blocks, precompile.Block)
+		}
+	}
+	sort.Sort(bigIntsByValue(blocks))
+
+	schedule := new(precompileSchedule)
+	for _, block := range blocks {
+		if n := len(schedule.blocks); n > 0 && schedule.blocks[n-1].Cmp(block) == 0 {
+			continue
+		}
+		set, err := assemblePrecompiles(config, block)
+		if err != nil {
+			return &precompileSchedule{err: err}
+		}
+		schedule.blocks = append(schedule.blocks, new(big.Int).Set(block))
+		schedule.sets = append(schedule.sets, set)
+	}
+	return schedule
+}
+
+// assemblePrecompiles builds the precompiled contract set active at the given
+// block, failing if the chain config activates a contract that isn't registered.
+func assemblePrecompiles(config *params.ChainConfig, num *big.Int) (map[common.Address]PrecompiledContract, error) {
+	precompiles := PrecompiledContractsHomestead
+	if config.IsByzantium(num) {
+		precompiles = PrecompiledContractsByzantium
+	}
+	extended := make(map[common.Address]PrecompiledContract, len(precompiles))
+	for addr, p := range precompiles {
+		extended[addr] = p
+	}
+	for eip, contracts := range PrecompiledContractsEIPs {
+		if config.IsEIPActive(eip, num) {
+			for addr, p := range contracts {
+				extended[addr] = p
+			}
+		}
+	}
+	registeredLock.RLock()
+	defer registeredLock.RUnlock()
+
+	for addr, name := range config.ActivePrecompiles(num) {
+		p, ok := registeredPrecompiles[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown contract %q for precompile %x", name, addr)
+		}
+		extended[addr] = p
+	}
+	return extended, nil
+}
+
+type bigIntsByValue []*big.Int
+
+func (s bigIntsByValue) Len() int           { return len(s) }
+func (s bigIntsByValue) Less(i, j int) bool { return s[i].Cmp(s[j]) < 0 }
+func (s bigIntsByValue) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// PrecompileGasFunc calculates the gas required to run a native contract on the
+// given input.
+type PrecompileGasFunc func(input []byte) uint64
+
+// FixedPrecompileGas returns a gas function charging the same amount of gas for
+// any input.
+func FixedPrecompileGas(gas uint64) PrecompileGasFunc {
+	return func(input []byte) uint64 {
+		return gas
+	}
+}
+
+// LinearPrecompileGas returns a gas function charging a base amount of gas plus
+// a fixed amount per started 32 byte word of input, like the hash contracts.
+func LinearPrecompileGas(base, word uint64) PrecompileGasFunc {
+	return func(input []byte) uint64 {
+		return uint64(len(input)+31)/32*word + base
+	}
+}
+
+// nativeContract is a precompiled contract assembled from a gas function and a
+// plain Go function.
+type nativeContract struct {
+	gas PrecompileGasFunc
+	run func(input []byte) ([]byte, error)
+}
+
+// NewPrecompiledContract creates a precompiled contract charging gas according
+// to the given gas function and executing run on its input.
+func NewPrecompiledContract(gas PrecompileGasFunc, run func(input []byte) ([]byte, error)) PrecompiledContract {
+	return &nativeContract{gas: gas, run: run}
+}
+
+func (c *nativeContract) RequiredGas(input []byte) uint64 {
+	return c.gas(input)
+}
+
+func (c *nativeContract) Run(input []byte) ([]byte, error) {
+	return c.run(input)
+}
@@ -0,0 +1,184 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// registerTestPrecompile registers a native contract, returning a function to
+// drop the registration at the end of the test.
+func registerTestPrecompile(t *testing.T, name string, p PrecompiledContract) func() {
+	if err := RegisterPrecompiledContract(name, p); err != nil {
+		t.Fatalf("failed to register precompiled contract: %v", err)
+	}
+	return func() {
+		registeredLock.Lock()
+		delete(registeredPrecompiles, name)
+		registeredLock.Unlock()
+	}
+}
+
+// Tests that native contracts can only be registered once, under a valid name.
+func TestRegisterPrecompiledContract(t *testing.T) {
+	echo := NewPrecompiledContract(FixedPrecompileGas(1), func(input []byte) ([]byte, error) { return input, nil })
+	defer registerTestPrecompile(t, "test-echo", echo)()
+
+	if err := RegisterPrecompiledContract("test-echo", echo); err == nil {
+		t.Errorf("duplicate registration accepted")
+	}
+	if err := RegisterPrecompiledContract("", echo); err == nil {
+		t.Errorf("empty name accepted")
+	}
+	if err := RegisterPrecompiledContract("test-nil", nil); err == nil {
+		t.Errorf("nil contract accepted")
+	}
+	found := false
+	for _, name := range RegisteredPrecompiledContracts() {
+		found = found || name == "test-echo"
+	}
+	if !found {
+		t.Errorf("registered contract not listed")
+	}
+}
+
+// Tests that chain configurations activating unknown contracts or overriding
+// the standard ones are rejected.
+func TestCheckPrecompiles(t *testing.T) {
+	defer registerTestPrecompile(t, "test-echo", NewPrecompiledContract(FixedPrecompileGas(1), func(input []byte) ([]byte, error) { return input, nil }))()
+
+	tests := []struct {
+		precompiles map[common.Address]*params.PrecompileConfig
+		fail        bool
+	}{
+		{nil, false},
+		{map[common.Address]*params.PrecompileConfig{common.BytesToAddress([]byte{0x10}): {Name: "test-echo", Block: big.NewInt(0)}}, false},
+		{map[common.Address]*params.PrecompileConfig{common.BytesToAddress([]byte{0x10}): {Name: "test-echo"}}, true},
+		{map[common.Address]*params.PrecompileConfig{common.BytesToAddress([]byte{0x10}): {Name: "test-unknown", Block: big.NewInt(0)}}, true},
+		{map[common.Address]*params.PrecompileConfig{common.BytesToAddress([]byte{0x05}): {Name: "test-echo", Block: big.NewInt(0)}}, true},
+	}
+	for i, tt := range tests {
+		err := CheckPrecompiles(&params.ChainConfig{Precompiles: tt.precompiles})
+		if (err != nil) != tt.fail {
+			t.Errorf("test %d: error mismatch: have %v, want failure %v", i, err, tt.fail)
+		}
+	}
+}
+
+// Tests that registered native contracts are callable from their activation
+// block onwards, charging gas according to their gas functions.
+func TestActivatedPrecompiles(t *testing.T) {
+	defer registerTestPrecompile(t, "test-echo", NewPrecompiledContract(LinearPrecompileGas(10, 3), func(input []byte) ([]byte, error) { return input, nil }))()
+
+	address := common.BytesToAddress([]byte{0x10})
+	config := *params.AllEthashProtocolChanges
+	config.Precompiles = map[common.Address]*params.PrecompileConfig{
+		address: {Name: "test-echo", Block: big.NewInt(5)},
+	}
+	input := make([]byte, 33)
+	input[0] = 0xff
+
+	tests := []struct {
+		number int64
+		output []byte
+		used   uint64
+	}{
+		{4, nil, 0},
+		{5, input, 16},
+	}
+	for _, tt := range tests {
+		db, _ := ethdb.NewMemDatabase()
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+
+		vmctx := Context{
+			CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+			BlockNumber: big.NewInt(tt.number),
+		}
+		evm := NewEVM(vmctx, statedb, &config, Config{})
+
+		ret, gas, err := evm.Call(AccountRef(common.Address{}), address, input, 100, new(big.Int))
+		if err != nil {
+			t.Errorf("block %d: failed to call contract: %v", tt.number, err)
+			continue
+		}
+		if !bytes.Equal(ret, tt.output) {
+			t.Errorf("block %d: output mismatch: have %x, want %x", tt.number, ret, tt.output)
+		}
+		if used := 100 - gas; used != tt.used {
+			t.Errorf("block %d: gas used mismatch: have %d, want %d", tt.number, used, tt.used)
+		}
+	}
+	// Make sure the standard precompile sets are left untouched
+	if _, ok := PrecompiledContractsByzantium[address]; ok {
+		t.Errorf("standard precompiled contracts modified")
+	}
+}
+
+// Tests that activating a contract which isn't registered fails instead of being
+// silently skipped, refusing any execution, and that the contract sets are only assembled once per config.
+func TestActivePrecompilesUnknown(t *testing.T) {
+	address := common.BytesToAddress([]byte{0x10})
+	config := *params.AllEthashProtocolChanges
+	config.Precompiles = map[common.Address]*params.PrecompileConfig{
+		address: {Name: "test-unknown", Block: big.NewInt(5)},
+	}
+	if _, err := activePrecompiles(&config, big.NewInt(5)); err == nil {
+		t.Fatalf("unknown contract activated")
+	}
+	evm := NewEVM(Context{BlockNumber: big.NewInt(5)}, nil, &config, Config{})
+	if evm.Err() == nil {
+		t.Errorf("EVM created with unknown contract")
+	}
+	if _, _, err := evm.Call(AccountRef(common.Address{}), address, nil, 100000, new(big.Int)); err != evm.Err() {
+		t.Errorf("call error mismatch: have %v, want %v", err, evm.Err())
+	}
+
+	cached := *params.AllEthashProtocolChanges
+	cached.EIPs = map[int]*big.Int{params.EIP152: big.NewInt(5)}
+
+	first, err := activePrecompiles(&cached, big.NewInt(6))
+	if err != nil {
+		t.Fatalf("failed to assemble contracts: %v", err)
+	}
+	second, _ := activePrecompiles(&cached, big.NewInt(7))
+	if reflect.ValueOf(first).Pointer() != reflect.ValueOf(second).Pointer() {
+		t.Errorf("contract set rebuilt for the same config")
+	}
+	if before, _ := activePrecompiles(&cached, big.NewInt(4)); before[common.BytesToAddress([]byte{9})] != nil {
+		t.Errorf("contract active before its activation block")
+	}
+	// Equal configs share the contract sets, modified ones get their own
+	equal := *params.AllEthashProtocolChanges
+	equal.EIPs = map[int]*big.Int{params.EIP152: big.NewInt(5)}
+
+	if shared, _ := activePrecompiles(&equal, big.NewInt(6)); reflect.ValueOf(first).Pointer() != reflect.ValueOf(shared).Pointer() {
+		t.Errorf("contract set rebuilt for an equal config")
+	}
+	cached.EIPs[params.EIP152] = big.NewInt(10)
+	if modified, _ := activePrecompiles(&cached, big.NewInt(7)); modified[common.BytesToAddress([]byte{9})] != nil {
+		t.Errorf("contract set of the config before its modification used")
+	}
+}
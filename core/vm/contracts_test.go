@@ -16,6 +16,29 @@ type precompiledTest struct {
 	noBenchmark     bool // Benchmark primarily the worst-cases
 }
 
+// precompiledFailureTest defines the input/error pairs for precompiled contract
+// failure tests.
+type precompiledFailureTest struct {
+	input         string
+	expectedError error
+	name          string
+}
+
+// allPrecompiles contains the pre-compiled contracts of the latest release, along
+// with the ones introduced by individually activable EIPs.
+var allPrecompiles = func() map[common.Address]PrecompiledContract {
+	precompiles := make(map[common.Address]PrecompiledContract)
+	for addr, p := range PrecompiledContractsByzantium {
+		precompiles[addr] = p
+	}
+	for _, contracts := range PrecompiledContractsEIPs {
+		for addr, p := range contracts {
+			precompiles[addr] = p
+		}
+	}
+	return precompiles
+}()
+
 // modexpTests are the test and benchmark data for the modexp precompiled contract.
 var modexpTests = []precompiledTest{
 	{
@@ -321,7 +344,7 @@ var bn256PairingTests = []precompiledTest{
 }
 
 func testPrecompiled(addr string, test precompiledTest, t *testing.T) {
-	p := PrecompiledContractsByzantium[common.HexToAddress(addr)]
+	p := allPrecompiles[common.HexToAddress(addr)]
 	in := common.Hex2Bytes(test.input)
 	contract := NewContract(AccountRef(common.HexToAddress("1337")),
 		nil, new(big.Int), p.RequiredGas(in))
@@ -334,11 +357,24 @@ func testPrecompiled(addr string, test precompiledTest, t *testing.T) {
 	})
 }
 
+func testPrecompiledFailure(addr string, test precompiledFailureTest, t *testing.T) {
+	p := allPrecompiles[common.HexToAddress(addr)]
+	in := common.Hex2Bytes(test.input)
+	contract := NewContract(AccountRef(common.HexToAddress("1337")),
+		nil, new(big.Int), p.RequiredGas(in))
+	t.Run(test.name, func(t *testing.T) {
+		_, err := RunPrecompiledContract(p, in, contract)
+		if err != test.expectedError {
+			t.Errorf("Expected error %v, got %v", test.expectedError, err)
+		}
+	})
+}
+
 func benchmarkPrecompiled(addr string, test precompiledTest, bench *testing.B) {
 	if test.noBenchmark {
 		return
 	}
-	p := PrecompiledContractsByzantium[common.HexToAddress(addr)]
+	p := allPrecompiles[common.HexToAddress(addr)]
 	in := common.Hex2Bytes(test.input)
 	reqGas := p.RequiredGas(in)
 	contract := NewContract(AccountRef(common.HexToAddress("1337")),
@@ -410,6 +446,74 @@ func BenchmarkPrecompiledIdentity(bench *testing.B) {
 	benchmarkPrecompiled("04", t, bench)
 }
 
+// blake2FTests are the test and benchmark data for the BLAKE2b F precompiled
+// contract, taken from EIP 152.
+var blake2FTests = []precompiledTest{
+	{
+		input:    "0000000048c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000001",
+		expected: "08c9bcf367e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d282e6ad7f520e511f6c3e2b8c68059b9442be0454267ce079217e1319cde05b",
+		name:     "vector 4",
+	}, {
+		input:    "0000000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000001",
+		expected: "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923",
+		name:     "vector 5",
+	}, {
+		input:    "0000000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000000",
+		expected: "75ab69d3190a562c51aef8d88f1c2775876944407270c42c9844252c26d2875298743e7f6d5ea2f2d3e8d226039cd31b4e426ac4f2d3d666a610c2116fde4735",
+		name:     "vector 6",
+	}, {
+		input:    "0000000148c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000001",
+		expected: "b63a380cb2897d521994a85234ee2c181b5f844d2c624c002677e9703449d2fba551b3a8333bcdf5f2f7e08993d53923de3d64fcc68c034e717b9293fed7a421",
+		name:     "vector 7",
+	},
+}
+
+// blake2FMalformedInputTests are the failure cases of the BLAKE2b F precompiled
+// contract, taken from EIP 152.
+var blake2FMalformedInputTests = []precompiledFailureTest{
+	{
+		input:         "",
+		expectedError: errBlake2FInvalidInputLength,
+		name:          "vector 0: empty input",
+	}, {
+		input:         "00000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000001",
+		expectedError: errBlake2FInvalidInputLength,
+		name:          "vector 1: less than 213 bytes input",
+	}, {
+		input:         "000000000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000001",
+		expectedError: errBlake2FInvalidInputLength,
+		name:          "vector 2: more than 213 bytes input",
+	}, {
+		input:         "0000000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000002",
+		expectedError: errBlake2FInvalidFinalFlag,
+		name:          "vector 3: malformed final block indicator flag",
+	},
+}
+
+// ed25519VerifyTests are the test and benchmark data for the ed25519 signature
+// verification precompiled contract.
+var ed25519VerifyTests = []precompiledTest{
+	{
+		input:    "74f2bab0f7b496db35967b365a4bedc0f6378888dea671ec307ee99e677fe21d1b8d9ee4d3d86186b12f3b3e0aba288af6e9d3abd7714a18a36c847b994f464ce5f6342b9711ec555e7fd4114b20b6f469178348b461c7437603f6f90546f211f1052e0f37c50f2371ed35c88481fb9af55f958ea56621c48cc518cab9725307",
+		expected: "00000000",
+		name:     "valid-1",
+	}, {
+		input:    "b526aef1a341cfe6e5c377ed4c222888eeb81f913a107110a867e009c1758f24bfcc7cb2ede8a75daa5c61809632e2f3a418b75fd97ee4ae4fd4a8bbb2fc9418eeeec83cf22837ea6f19e6cfb74cb9b522f69342bc5c88d9b42ae8e4dd415544b54854e93b13745c0b38998d76885d714a805bc76b22f7fa97a63f0ab400c10b",
+		expected: "00000000",
+		name:     "valid-2",
+	}, {
+		input:       "b526aef1a341cfe6e5c377ed4c222888eeb81f913a107110a867e009c1758f25bfcc7cb2ede8a75daa5c61809632e2f3a418b75fd97ee4ae4fd4a8bbb2fc9418eeeec83cf22837ea6f19e6cfb74cb9b522f69342bc5c88d9b42ae8e4dd415544b54854e93b13745c0b38998d76885d714a805bc76b22f7fa97a63f0ab400c10b",
+		expected:    "00000001",
+		name:        "wrong-message",
+		noBenchmark: true,
+	}, {
+		input:       "b526aef1a341cfe6e5c377ed4c222888eeb81f913a107110a867e009c1758f241b8d9ee4d3d86186b12f3b3e0aba288af6e9d3abd7714a18a36c847b994f464ceeeec83cf22837ea6f19e6cfb74cb9b522f69342bc5c88d9b42ae8e4dd415544b54854e93b13745c0b38998d76885d714a805bc76b22f7fa97a63f0ab400c10b",
+		expected:    "00000001",
+		name:        "wrong-key",
+		noBenchmark: true,
+	},
+}
+
 // Tests the sample inputs from the ModExp EIP 198.
 func TestPrecompiledModExp(t *testing.T) {
 	for _, test := range modexpTests {
@@ -465,3 +569,39 @@ func BenchmarkPrecompiledBn256Pairing(bench *testing.B) {
 		benchmarkPrecompiled("08", test, bench)
 	}
 }
+
+// Tests the sample inputs from the BLAKE2b F compression EIP 152.
+func TestPrecompiledBlake2F(t *testing.T) {
+	for _, test := range blake2FTests {
+		testPrecompiled("09", test, t)
+	}
+}
+
+// Tests the malformed inputs from the BLAKE2b F compression EIP 152.
+func TestPrecompiledBlake2FMalformedInput(t *testing.T) {
+	for _, test := range blake2FMalformedInputTests {
+		testPrecompiledFailure("09", test, t)
+	}
+}
+
+// Benchmarks the sample inputs from the BLAKE2b F compression EIP 152.
+func BenchmarkPrecompiledBlake2F(bench *testing.B) {
+	for _, test := range blake2FTests {
+		benchmarkPrecompiled("09", test, bench)
+	}
+}
+
+// Tests the sample inputs of the ed25519 signature verification EIP 665.
+func TestPrecompiledEd25519Verify(t *testing.T) {
+	for _, test := range ed25519VerifyTests {
+		testPrecompiled("0a", test, t)
+	}
+	testPrecompiledFailure("0a", precompiledFailureTest{input: "00", expectedError: errBadEd25519Input, name: "short-input"}, t)
+}
+
+// Benchmarks the sample inputs of the ed25519 signature verification EIP 665.
+func BenchmarkPrecompiledEd25519Verify(bench *testing.B) {
+	for _, test := range ed25519VerifyTests {
+		benchmarkPrecompiled("0a", test, bench)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// activators contains the changes an individually activable EIP applies to the
+// instruction set and its gas schedule. EIPs without EVM changes (e.g. consensus
+// rule changes) have no activator.
+var activators = map[int]func(*[256]operation){
+	params.EIP145:  enable145,
+	params.EIP1014: enable1014,
+	params.EIP1052: enable1052,
+	params.EIP1283: enable1283,
+}
+
+// activatorForks contains the forks the EVM changes of an individually activable
+// EIP build upon, which must be active by the time the EIP gets activated.
+var activatorForks = map[int]struct {
+	name   string
+	active func(*params.ChainConfig, *big.Int) bool
+}{
+	params.EIP1014: {"EIP-150", (*params.ChainConfig).IsEIP150}, // CREATE2 passes on all but one 64th of the gas
+	params.EIP1052: {"EIP-158", (*params.ChainConfig).IsEIP158}, // EXTCODEHASH treats empty accounts as missing
+}
+
+// CheckEIPs verifies that the individually scheduled EIPs of the chain config are
+// only activated on top of the forks their EVM changes rely on.
+func CheckEIPs(config *params.ChainConfig) error {
+	for eip, block := range config.EIPs {
+		if fork, ok := activatorForks[eip]; ok && !fork.active(config, block) {
+			return fmt.Errorf("EIP-%d activated at block %v before %s", eip, block, fork.name)
+		}
+	}
+	return nil
+}
+
+// EnableEIP applies the EVM changes of the given EIP to the instruction set. It
+// returns an error if the EIP is not supported by the EVM.
+func EnableEIP(eip int, jt *[256]operation) error {
+	enable, ok := activators[eip]
+	if !ok {
+		return fmt.Errorf("undefined EVM changes for EIP-%d", eip)
+	}
+	enable(jt)
+	return nil
+}
+
+// enable145 adds the bitwise shifting instructions SHL, SHR and SAR.
+func enable145(jt *[256]operation) {
+	jt[SHL] = operation{
+		execute:       opSHL,
+		gasCost:       constGasFunc(GasFastestStep),
+		validateStack: makeStackFunc(2, 1),
+		valid:         true,
+	}
+	jt[SHR] = operation{
+		execute:       opSHR,
+		gasCost:       constGasFunc(GasFastestStep),
+		validateStack: makeStackFunc(2, 1),
+		valid:         true,
+	}
+	jt[SAR] = operation{
+		execute:       opSAR,
+		gasCost:       constGasFunc(GasFastestStep),
+		validateStack: makeStackFunc(2, 1),
+		valid:         true,
+	}
+}
+
+// enable1014 adds the CREATE2 instruction deploying contracts to addresses
+// derived from the init code and a salt.
+func enable1014(jt *[256]operation) {
+	jt[CREATE2] = operation{
+		execute:       opCreate2,
+		gasCost:       gasCreate2,
+		validateStack: makeStackFunc(4, 1),
+		memorySize:    memoryCreate2,
+		valid:         true,
+		writes:        true,
+		returns:       true,
+	}
+}
+
+// enable1052 adds the EXTCODEHASH instruction.
+func enable1052(jt *[256]operation) {
+	jt[EXTCODEHASH] = operation{
+		execute:       opExtCodeHash,
+		gasCost:       constGasFunc(params.ExtcodeHashGas),
+		validateStack: makeStackFunc(1, 1),
+		valid:         true,
+	}
+}
+
+// enable1283 switches SSTORE to net gas metering.
+func enable1283(jt *[256]operation) {
+	jt[SSTORE].gasCost = gasSStoreEIP1283
+}
@@ -0,0 +1,148 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that every EVM activator belongs to a supported EIP.
+func TestActivatorsSupported(t *testing.T) {
+	for eip := range activators {
+		if _, ok := params.ActivableEIPs[eip]; !ok {
+			t.Errorf("EIP-%d has an activator but is not activable", eip)
+		}
+	}
+}
+
+// Tests that EIPs building upon earlier forks can't be scheduled before them.
+func TestScheduledEIPsForks(t *testing.T) {
+	tests := []struct {
+		eip     int
+		block   int64
+		fork    int64
+		invalid bool
+	}{
+		{params.EIP1014, 10, 10, false},
+		{params.EIP1014, 9, 10, true},
+		{params.EIP1052, 10, 5, false},
+		{params.EIP1052, 4, 5, true},
+		{params.EIP145, 0, 5, false},
+	}
+	for i, tt := range tests {
+		config := &params.ChainConfig{
+			EIP150Block: big.NewInt(tt.fork),
+			EIP158Block: big.NewInt(tt.fork),
+			EIPs:        map[int]*big.Int{tt.eip: big.NewInt(tt.block)},
+		}
+		if err := CheckEIPs(config); (err != nil) != tt.invalid {
+			t.Errorf("test %d: error mismatch: have %v, want invalid %v", i, err, tt.invalid)
+		}
+	}
+	// Configs without the forks at all can't schedule the EIPs either
+	config := &params.ChainConfig{EIPs: map[int]*big.Int{params.EIP1052: big.NewInt(0)}}
+	if err := CheckEIPs(config); err == nil {
+		t.Errorf("EXTCODEHASH accepted without EIP-158")
+	}
+}
+
+// Tests that individually scheduled EIPs are applied to the instruction set of
+// the interpreter from their activation block onwards.
+func TestScheduledEIPs(t *testing.T) {
+	config := &params.ChainConfig{
+		ChainId:        big.NewInt(1),
+		HomesteadBlock: big.NewInt(0),
+		EIP150Block:    big.NewInt(0),
+		EIP155Block:    big.NewInt(0),
+		EIP158Block:    big.NewInt(0),
+		ByzantiumBlock: big.NewInt(0),
+		EIPs: map[int]*big.Int{
+			params.EIP145:  big.NewInt(5),
+			params.EIP1052: big.NewInt(10),
+		},
+	}
+	if err := CheckEIPs(config); err != nil {
+		t.Fatalf("valid config rejected: %v", err)
+	}
+	tests := []struct {
+		number                    int64
+		shl, extcodehash, create2 bool
+	}{
+		{4, false, false, false},
+		{5, true, false, false},
+		{10, true, true, false},
+	}
+	for _, tt := range tests {
+		evm := NewEVM(Context{BlockNumber: big.NewInt(tt.number)}, nil, config, Config{})
+		jt := evm.interpreter.cfg.JumpTable
+		if jt[SHL].valid != tt.shl || jt[SAR].valid != tt.shl {
+			t.Errorf("block %d: shift validity mismatch: have %v, want %v", tt.number, jt[SHL].valid, tt.shl)
+		}
+		if jt[EXTCODEHASH].valid != tt.extcodehash {
+			t.Errorf("block %d: EXTCODEHASH validity mismatch: have %v, want %v", tt.number, jt[EXTCODEHASH].valid, tt.extcodehash)
+		}
+		if jt[CREATE2].valid != tt.create2 {
+			t.Errorf("block %d: CREATE2 validity mismatch: have %v, want %v", tt.number, jt[CREATE2].valid, tt.create2)
+		}
+	}
+	// Make sure the shared fork instruction sets are left untouched
+	if byzantiumInstructionSet[SHL].valid {
+		t.Errorf("byzantium instruction set modified")
+	}
+}
+
+// Tests that the precompiled contracts of individually scheduled EIPs are only
+// available from their activation block onwards.
+func TestScheduledPrecompiles(t *testing.T) {
+	config := &params.ChainConfig{
+		ChainId:        big.NewInt(1),
+		HomesteadBlock: big.NewInt(0),
+		ByzantiumBlock: big.NewInt(0),
+		EIPs: map[int]*big.Int{
+			params.EIP152: big.NewInt(5),
+			params.EIP665: big.NewInt(10),
+		},
+	}
+	var (
+		blake2f = common.BytesToAddress([]byte{9})
+		ed25519 = common.BytesToAddress([]byte{10})
+	)
+	tests := []struct {
+		number           int64
+		blake2f, ed25519 bool
+	}{
+		{4, false, false},
+		{5, true, false},
+		{10, true, true},
+	}
+	for _, tt := range tests {
+		evm := NewEVM(Context{BlockNumber: big.NewInt(tt.number)}, nil, config, Config{})
+		if _, ok := evm.precompiles[blake2f]; ok != tt.blake2f {
+			t.Errorf("block %d: blake2f availability mismatch: have %v, want %v", tt.number, ok, tt.blake2f)
+		}
+		if _, ok := evm.precompiles[ed25519]; ok != tt.ed25519 {
+			t.Errorf("block %d: ed25519 availability mismatch: have %v, want %v", tt.number, ok, tt.ed25519)
+		}
+		if _, ok := evm.precompiles[common.BytesToAddress([]byte{8})]; !ok {
+			t.Errorf("block %d: standard precompile missing", tt.number)
+		}
+	}
+}
@@ -19,6 +19,7 @@ package vm
 import (
 	"fmt"
 	"sync/atomic"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
@@ -43,6 +44,9 @@ type Config struct {
 	DisableGasMetering bool
 	// Enable recording of SHA3/keccak preimages
 	EnablePreimageRecording bool
+	// Profiler accumulates opcode level execution statistics
+	// if set.
+	Profiler *Profiler
 	// JumpTable contains the EVM instruction table. This
 	// may be left uninitialised and will be set to the default
 	// table.
@@ -61,6 +65,9 @@ type Interpreter struct {
 
 	readOnly   bool   // Whether to throw on stateful modifications
 	returnData []byte // Last CALL's return data for subsequent reuse
+
+	childGas  uint64        // Gas spent by the last call or create operation, for the profiler
+	childTime time.Duration // Time spent by the last returned call frame, for the profiler
 }
 
 // NewInterpreter returns a new instance of the Interpreter.
@@ -70,6 +77,8 @@ func NewInterpreter(evm *EVM, cfg Config) *Interpreter {
 	// we'll set the default jump table.
 	if !cfg.JumpTable[STOP].valid {
 		switch {
+		case evm.ChainConfig().IsConstantinople(evm.BlockNumber):
+			cfg.JumpTable = constantinopleInstructionSet
 		case evm.ChainConfig().IsByzantium(evm.BlockNumber):
 			cfg.JumpTable = byzantiumInstructionSet
 		case evm.ChainConfig().IsHomestead(evm.BlockNumber):
@@ -77,6 +86,12 @@ func NewInterpreter(evm *EVM, cfg Config) *Interpreter {
 		default:
 			cfg.JumpTable = frontierInstructionSet
 		}
+		// Apply any EIPs scheduled individually on top of the fork rules
+		for eip := range evm.ChainConfig().EIPs {
+			if enable, ok := activators[eip]; ok && evm.ChainConfig().IsEIPActive(eip, evm.BlockNumber) {
+				enable(&cfg.JumpTable)
+			}
+		}
 	}
 
 	return &Interpreter{
@@ -87,6 +102,12 @@ func NewInterpreter(evm *EVM, cfg Config) *Interpreter {
 	}
 }
 
+// ReturnData returns the return data of the last call made by the currently
+// executing contract.
+func (in *Interpreter) ReturnData() []byte {
+	return in.returnData
+}
+
 func (in *Interpreter) enforceRestrictions(op OpCode, operation operation, stack *Stack) error {
 	if in.evm.chainRules.IsByzantium {
 		if in.readOnly {
@@ -141,9 +162,22 @@ func (in *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err er
 		pcCopy  uint64 // needed for the deferred Tracer
 		gasCopy uint64 // for Tracer to log gas remaining before execution
 		logged  bool   // deferred Tracer should ignore already logged steps
+		// copies used by profiler
+		profPc    uint64    // program counter of the profiled operation
+		profGas   uint64    // gas remaining before the profiled operation
+		profStart time.Time // start time of the profiled operation
 	)
 	contract.Input = input
 
+	if in.cfg.Profiler != nil {
+		// Report the time spent by this call frame to the calling operation, so it
+		// doesn't get accounted for it too. The gas is reported by the calling
+		// operation itself, as failed calls burn their leftover only after returning.
+		defer func(start time.Time) {
+			in.childTime = time.Since(start)
+		}(time.Now())
+	}
+
 	if in.cfg.Debug {
 		defer func() {
 			if err != nil {
@@ -164,6 +198,10 @@ func (in *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err er
 			// Capture pre-execution values for tracing.
 			logged, pcCopy, gasCopy = false, pc, contract.Gas
 		}
+		if in.cfg.Profiler != nil {
+			profPc, profGas, profStart = pc, contract.Gas, time.Now()
+			in.childGas, in.childTime = 0, 0
+		}
 
 		// Get the operation from the jump table and validate the stack to ensure there are
 		// enough stack items available to perform the operation.
@@ -219,6 +257,13 @@ func (in *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err er
 		if verifyPool {
 			verifyIntegerPool(in.intPool)
 		}
+		if in.cfg.Profiler != nil {
+			var gas uint64
+			if spent := profGas - contract.Gas; spent > in.childGas {
+				gas = spent - in.childGas
+			}
+			in.cfg.Profiler.record(contract, profPc, op, gas, time.Since(profStart)-in.childTime)
+		}
 		// if the operation clears the return data (e.g. it has returning data)
 		// set the last return to the result of the operation.
 		if operation.returns {
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/compiler"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -196,6 +197,47 @@ func (l *StructLogger) Error() error { return l.err }
 // Output returns the VM return value captured by the trace.
 func (l *StructLogger) Output() []byte { return l.output }
 
+// SourceLogger is a struct logger annotating the captured steps with the source
+// locations of the executed instructions, resolved from the compiled contracts.
+type SourceLogger struct {
+	*StructLogger
+
+	tracker   *compiler.SourceTracker
+	location  *compiler.SourceLocation   // Source location of the current instruction
+	locations []*compiler.SourceLocation // Source locations of the captured steps
+}
+
+// NewSourceLogger wraps a struct logger, resolving the steps it captures to their
+// sources with the given tracker.
+func NewSourceLogger(logger *StructLogger, tracker *compiler.SourceTracker) *SourceLogger {
+	return &SourceLogger{StructLogger: logger, tracker: tracker}
+}
+
+// CaptureState resolves the source location of the instruction about to be
+// executed, and logs it along with the EVM state.
+func (l *SourceLogger) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	// Errors are reported on the instruction already stepped to
+	if err == nil {
+		l.location = l.tracker.Step(contract.Code, pc, depth)
+	}
+	logs := len(l.StructLogs())
+	if err := l.StructLogger.CaptureState(env, pc, op, gas, cost, memory, stack, contract, depth, err); err != nil {
+		return err
+	}
+	if len(l.StructLogs()) > logs {
+		l.locations = append(l.locations, l.location)
+	}
+	return nil
+}
+
+// Locations returns the source locations of the captured steps, nil for the
+// ones without known sources.
+func (l *SourceLogger) Locations() []*compiler.SourceLocation { return l.locations }
+
+// StackTrace returns the Solidity level call stack of the last executed
+// instruction, innermost call first.
+func (l *SourceLogger) StackTrace() []*compiler.SourceLocation { return l.tracker.StackTrace() }
+
 // WriteTrace writes a formatted trace to the given writer
 func WriteTrace(writer io.Writer, logs []StructLog) {
 	for _, log := range logs {
@@ -0,0 +1,233 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"compress/gzip"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// OpProfile is the accumulated execution statistics of a single instruction.
+// Gas and time are exclusive: the resources spent by the callee of a call or
+// create are accounted to the callee's instructions, not the caller's.
+type OpProfile struct {
+	Pc    uint64        `json:"pc"`
+	Op    string        `json:"op"`
+	Count uint64        `json:"count"`
+	Gas   uint64        `json:"gas"`
+	Time  time.Duration `json:"time"`
+}
+
+// ContractProfile is the accumulated execution statistics of a contract's code,
+// both in total and broken down by instruction.
+type ContractProfile struct {
+	Address common.Address `json:"address"`
+	Count   uint64         `json:"count"`
+	Gas     uint64         `json:"gas"`
+	Time    time.Duration  `json:"time"`
+	Ops     []*OpProfile   `json:"ops"`
+}
+
+// Profiler accumulates opcode level execution statistics of EVM runs, tracking
+// execution counts, gas spent and wall time per contract and program counter.
+// It is safe for concurrent use by multiple interpreters.
+type Profiler struct {
+	contracts map[common.Address]map[uint64]*OpProfile
+	lock      sync.Mutex
+}
+
+// NewProfiler creates an empty EVM profiler.
+func NewProfiler() *Profiler {
+	return &Profiler{
+		contracts: make(map[common.Address]map[uint64]*OpProfile),
+	}
+}
+
+// record accounts a single execution of an instruction to the profile.
+func (p *Profiler) record(contract *Contract, pc uint64, op OpCode, gas uint64, elapsed time.Duration) {
+	addr := contract.Address()
+	if contract.CodeAddr != nil {
+		addr = *contract.CodeAddr
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	ops := p.contracts[addr]
+	if ops == nil {
+		ops = make(map[uint64]*OpProfile)
+		p.contracts[addr] = ops
+	}
+	stats := ops[pc]
+	if stats == nil {
+		stats = &OpProfile{Pc: pc, Op: op.String()}
+		ops[pc] = stats
+	}
+	stats.Count++
+	stats.Gas += gas
+	stats.Time += elapsed
+}
+
+// Reset discards all the statistics accumulated so far.
+func (p *Profiler) Reset() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.contracts = make(map[common.Address]map[uint64]*OpProfile)
+}
+
+// Profile returns a snapshot of the accumulated statistics, with the contracts
+// ordered by the gas they consumed, heaviest first, and their instructions by
+// program counter.
+func (p *Profiler) Profile() []*ContractProfile {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	profiles := make([]*ContractProfile, 0, len(p.contracts))
+	for addr, ops := range p.contracts {
+		profile := &ContractProfile{Address: addr, Ops: make([]*OpProfile, 0, len(ops))}
+		for _, stats := range ops {
+			cpy := *stats
+			profile.Ops = append(profile.Ops, &cpy)
+
+			profile.Count += stats.Count
+			profile.Gas += stats.Gas
+			profile.Time += stats.Time
+		}
+		sort.Sort(opsByPc(profile.Ops))
+		profiles = append(profiles, profile)
+	}
+	sort.Sort(contractsByGas(profiles))
+	return profiles
+}
+
+type opsByPc []*OpProfile
+
+func (s opsByPc) Len() int           { return len(s) }
+func (s opsByPc) Less(i, j int) bool { return s[i].Pc < s[j].Pc }
+func (s opsByPc) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+type contractsByGas []*ContractProfile
+
+func (s contractsByGas) Len() int { return len(s) }
+func (s contractsByGas) Less(i, j int) bool {
+	if s[i].Gas != s[j].Gas {
+		return s[i].Gas > s[j].Gas
+	}
+	return s[i].Time > s[j].Time
+}
+func (s contractsByGas) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+// WritePprof writes the accumulated statistics in the gzipped protocol buffer
+// format understood by pprof. Every contract is represented as a function whose
+// lines are the program counters, with the samples labeled by their opcodes.
+func (p *Profiler) WritePprof(w io.Writer) error {
+	var (
+		profile protobuf
+		indices = map[string]int64{"": 0}
+		table   = []string{""}
+	)
+	index := func(s string) int64 {
+		if idx, ok := indices[s]; ok {
+			return idx
+		}
+		indices[s] = int64(len(table))
+		table = append(table, s)
+		return indices[s]
+	}
+	for _, kind := range [][2]string{{"executions", "count"}, {"gas", "gas"}, {"time", "nanoseconds"}} {
+		var valueType protobuf
+		valueType.int64(1, index(kind[0]))
+		valueType.int64(2, index(kind[1]))
+		profile.message(1, &valueType)
+	}
+	var location uint64
+	for i, contract := range p.Profile() {
+		name := index(contract.Address.Hex())
+
+		var function protobuf
+		function.uint64(1, uint64(i+1))
+		function.int64(2, name)
+		function.int64(3, name)
+		function.int64(4, name)
+		profile.message(5, &function)
+
+		for _, op := range contract.Ops {
+			location++
+
+			var line, loc, label, sample protobuf
+			line.uint64(1, uint64(i+1))
+			line.int64(2, int64(op.Pc))
+			loc.uint64(1, location)
+			loc.message(4, &line)
+			profile.message(4, &loc)
+
+			label.int64(1, index("op"))
+			label.int64(2, index(op.Op))
+			sample.uint64(1, location)
+			sample.int64(2, int64(op.Count))
+			sample.int64(2, int64(op.Gas))
+			sample.int64(2, int64(op.Time))
+			sample.message(3, &label)
+			profile.message(2, &sample)
+		}
+	}
+	profile.int64(14, index("gas"))
+	for _, s := range table {
+		profile.string(6, s)
+	}
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(profile); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// protobuf is a minimal protocol buffer encoder, sufficient to assemble pprof
+// profiles without depending on the full protobuf library.
+type protobuf []byte
+
+func (b *protobuf) varint(x uint64) {
+	for x >= 0x80 {
+		*b = append(*b, byte(x)|0x80)
+		x >>= 7
+	}
+	*b = append(*b, byte(x))
+}
+
+func (b *protobuf) uint64(tag int, x uint64) {
+	b.varint(uint64(tag) << 3)
+	b.varint(x)
+}
+
+func (b *protobuf) int64(tag int, x int64) {
+	b.uint64(tag, uint64(x))
+}
+
+func (b *protobuf) string(tag int, s string) {
+	b.varint(uint64(tag)<<3 | 2)
+	b.varint(uint64(len(s)))
+	*b = append(*b, s...)
+}
+
+func (b *protobuf) message(tag int, msg *protobuf) {
+	b.string(tag, string(*msg))
+}
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -49,8 +50,8 @@ type Config struct {
 	GetHashFn func(n uint64) common.Hash
 }
 
-// sets defaults on the config
-func setDefaults(cfg *Config) {
+// sets defaults on the config and validates the chain config
+func setDefaults(cfg *Config) error {
 	if cfg.ChainConfig == nil {
 		cfg.ChainConfig = &params.ChainConfig{
 			ChainId:        big.NewInt(1),
@@ -86,6 +87,7 @@ func setDefaults(cfg *Config) {
 			return common.BytesToHash(crypto.Keccak256([]byte(new(big.Int).SetUint64(n).String())))
 		}
 	}
+	return core.CheckChainConfig(cfg.ChainConfig)
 }
 
 // Execute executes the code using the input as call data during the execution.
@@ -98,8 +100,9 @@ func Execute(code, input []byte, cfg *Config) ([]byte, *state.StateDB, error) {
 	if cfg == nil {
 		cfg = new(Config)
 	}
-	setDefaults(cfg)
-
+	if err := setDefaults(cfg); err != nil {
+		return nil, nil, err
+	}
 	if cfg.State == nil {
 		db, _ := ethdb.NewMemDatabase()
 		cfg.State, _ = state.New(common.Hash{}, state.NewDatabase(db))
@@ -129,8 +132,9 @@ func Create(input []byte, cfg *Config) ([]byte, common.Address, uint64, error) {
 	if cfg == nil {
 		cfg = new(Config)
 	}
-	setDefaults(cfg)
-
+	if err := setDefaults(cfg); err != nil {
+		return nil, common.Address{}, 0, err
+	}
 	if cfg.State == nil {
 		db, _ := ethdb.NewMemDatabase()
 		cfg.State, _ = state.New(common.Hash{}, state.NewDatabase(db))
@@ -156,8 +160,9 @@ func Create(input []byte, cfg *Config) ([]byte, common.Address, uint64, error) {
 // Call, unlike Execute, requires a config and also requires the State field to
 // be set.
 func Call(address common.Address, input []byte, cfg *Config) ([]byte, uint64, error) {
-	setDefaults(cfg)
-
+	if err := setDefaults(cfg); err != nil {
+		return nil, 0, err
+	}
 	vmenv := NewEnv(cfg)
 
 	sender := cfg.State.GetOrNewStateObject(cfg.Origin)
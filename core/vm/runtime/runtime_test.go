@@ -17,6 +17,7 @@
 package runtime
 
 import (
+	"bytes"
 	"math/big"
 	"strings"
 	"testing"
@@ -26,11 +27,14 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 func TestDefaults(t *testing.T) {
 	cfg := new(Config)
-	setDefaults(cfg)
+	if err := setDefaults(cfg); err != nil {
+		t.Fatalf("failed to set defaults: %v", err)
+	}
 
 	if cfg.Difficulty == nil {
 		t.Error("expected difficulty to be non nil")
@@ -56,6 +60,25 @@ func TestDefaults(t *testing.T) {
 	}
 }
 
+// Tests that a chain config scheduling unsupported EIPs or native contracts is
+// reported as an error instead of crashing the EVM.
+func TestInvalidChainConfig(t *testing.T) {
+	cfg := &Config{
+		ChainConfig: &params.ChainConfig{
+			HomesteadBlock: new(big.Int),
+			Precompiles: map[common.Address]*params.PrecompileConfig{
+				common.BytesToAddress([]byte{0x10}): {Name: "test-unknown", Block: new(big.Int)},
+			},
+		},
+	}
+	if _, _, err := Execute([]byte{byte(vm.STOP)}, nil, cfg); err == nil {
+		t.Error("execute: invalid chain config accepted")
+	}
+	if _, _, _, err := Create([]byte{byte(vm.STOP)}, cfg); err == nil {
+		t.Error("create: invalid chain config accepted")
+	}
+}
+
 func TestEVM(t *testing.T) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -117,6 +140,105 @@ func TestCall(t *testing.T) {
 	}
 }
 
+// Tests that the profiler accounts the executed instructions to the contracts
+// running them, excluding the resources spent by callees from their callers.
+func TestProfiler(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := state.New(common.Hash{}, state.NewDatabase(db))
+
+	caller, callee := common.HexToAddress("0x0a"), common.HexToAddress("0x0b")
+	state.SetCode(caller, []byte{
+		byte(vm.PUSH1), 0, // return size
+		byte(vm.PUSH1), 0, // return offset
+		byte(vm.PUSH1), 0, // input size
+		byte(vm.PUSH1), 0, // input offset
+		byte(vm.PUSH1), 0, // value
+		byte(vm.PUSH1), 0x0b,
+		byte(vm.GAS),
+		byte(vm.CALL),
+		byte(vm.STOP),
+	})
+	state.SetCode(callee, []byte{
+		byte(vm.PUSH1), 1,
+		byte(vm.PUSH1), 0,
+		byte(vm.SSTORE),
+		byte(vm.STOP),
+	})
+	profiler := vm.NewProfiler()
+	for i := 0; i < 2; i++ {
+		if _, _, err := Call(caller, nil, &Config{State: state, EVMConfig: vm.Config{Profiler: profiler}}); err != nil {
+			t.Fatalf("run %d: failed to call contract: %v", i, err)
+		}
+	}
+	profile := profiler.Profile()
+	if len(profile) != 2 {
+		t.Fatalf("profiled contract count mismatch: have %d, want %d", len(profile), 2)
+	}
+	// The callee writes storage, so it must be the heaviest, even if called
+	if profile[0].Address != callee || profile[1].Address != caller {
+		t.Fatalf("contract order mismatch: have [%x %x], want [%x %x]", profile[0].Address, profile[1].Address, callee, caller)
+	}
+	if len(profile[0].Ops) != 4 || len(profile[1].Ops) != 9 {
+		t.Fatalf("profiled instruction count mismatch: have [%d %d], want [4 9]", len(profile[0].Ops), len(profile[1].Ops))
+	}
+	for _, op := range append(profile[0].Ops, profile[1].Ops...) {
+		if op.Count != 2 {
+			t.Errorf("%s at %d: execution count mismatch: have %d, want %d", op.Op, op.Pc, op.Count, 2)
+		}
+	}
+	// The first run sets the storage slot, the second one only resets it
+	if sstore := profile[0].Ops[2]; sstore.Op != "SSTORE" || sstore.Gas != 20000+5000 {
+		t.Errorf("callee SSTORE mismatch: have %s using %d gas, want SSTORE using %d", sstore.Op, sstore.Gas, 20000+5000)
+	}
+	if call := profile[1].Ops[7]; call.Op != "CALL" || call.Gas != 2*700 {
+		t.Errorf("caller CALL mismatch: have %s using %d gas, want CALL using %d", call.Op, call.Gas, 2*700)
+	}
+	var pprof bytes.Buffer
+	if err := profiler.WritePprof(&pprof); err != nil {
+		t.Fatalf("failed to write pprof profile: %v", err)
+	}
+	profiler.Reset()
+	if profile := profiler.Profile(); len(profile) != 0 {
+		t.Errorf("profile not reset: %v", profile)
+	}
+}
+
+// Tests that the gas burned by a failed callee is not accounted to the calling
+// instruction.
+func TestProfilerFailedCall(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := state.New(common.Hash{}, state.NewDatabase(db))
+
+	caller, callee := common.HexToAddress("0x0a"), common.HexToAddress("0x0b")
+	state.SetCode(caller, []byte{
+		byte(vm.PUSH1), 0, // return size
+		byte(vm.PUSH1), 0, // return offset
+		byte(vm.PUSH1), 0, // input size
+		byte(vm.PUSH1), 0, // input offset
+		byte(vm.PUSH1), 0, // value
+		byte(vm.PUSH1), 0x0b,
+		byte(vm.GAS),
+		byte(vm.CALL),
+		byte(vm.STOP),
+	})
+	state.SetCode(callee, []byte{0xfe}) // invalid opcode, burning all the gas
+
+	profiler := vm.NewProfiler()
+	if _, _, err := Call(caller, nil, &Config{State: state, EVMConfig: vm.Config{Profiler: profiler}}); err != nil {
+		t.Fatalf("failed to call contract: %v", err)
+	}
+	for _, contract := range profiler.Profile() {
+		if contract.Address != caller {
+			continue
+		}
+		if call := contract.Ops[7]; call.Op != "CALL" || call.Gas != 700 {
+			t.Errorf("caller CALL mismatch: have %s using %d gas, want CALL using %d", call.Op, call.Gas, 700)
+		}
+		return
+	}
+	t.Fatalf("caller not profiled")
+}
+
 func BenchmarkCall(b *testing.B) {
 	var definition = `[{"constant":true,"inputs":[],"name":"seller","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"abort","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"value","outputs":[{"name":"","type":"uint256"}],"type":"function"},{"constant":false,"inputs":[],"name":"refund","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"buyer","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmReceived","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"state","outputs":[{"name":"","type":"uint8"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmPurchase","outputs":[],"type":"function"},{"inputs":[],"type":"constructor"},{"anonymous":false,"inputs":[],"name":"Aborted","type":"event"},{"anonymous":false,"inputs":[],"name":"PurchaseConfirmed","type":"event"},{"anonymous":false,"inputs":[],"name":"ItemReceived","type":"event"},{"anonymous":false,"inputs":[],"name":"Refunded","type":"event"}]`
 
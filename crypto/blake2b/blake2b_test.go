@@ -0,0 +1,85 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package blake2b
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+)
+
+// sum512 computes the unkeyed BLAKE2b-512 digest of data on top of F.
+func sum512(data []byte) []byte {
+	h := IV
+	h[0] ^= 0x01010000 | 64 // Parameter block: no key, 64 byte digest
+
+	var (
+		m [16]uint64
+		t uint64
+	)
+	for {
+		block := make([]byte, 128)
+		n := copy(block, data)
+		data = data[n:]
+		t += uint64(n)
+
+		for i := range m {
+			m[i] = binary.LittleEndian.Uint64(block[i*8:])
+		}
+		final := len(data) == 0
+		F(&h, &m, [2]uint64{t, 0}, final, 12)
+		if final {
+			break
+		}
+	}
+	digest := make([]byte, 64)
+	for i, word := range h {
+		binary.LittleEndian.PutUint64(digest[i*8:], word)
+	}
+	return digest
+}
+
+// Tests that the compression function produces standard BLAKE2b digests.
+func TestF(t *testing.T) {
+	data := make([]byte, 200)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{nil, "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce"},
+		{[]byte("abc"), "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923"},
+		{data, "fb3c1f0f56a56f8e316fdf5d853c8c872c39635d083634c3904fc3ac07d1b578e85ff0e480e92d44ade33b62e893ee32343e79ddf6ef292e89b582d312502314"},
+	}
+	for i, tt := range tests {
+		if have := hex.EncodeToString(sum512(tt.data)); have != tt.want {
+			t.Errorf("test %d: digest mismatch: have %s, want %s", i, have, tt.want)
+		}
+	}
+}
+
+func BenchmarkF(b *testing.B) {
+	var (
+		h = IV
+		m [16]uint64
+	)
+	for i := 0; i < b.N; i++ {
+		F(&h, &m, [2]uint64{128, 0}, false, 12)
+	}
+}
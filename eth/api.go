@@ -19,6 +19,7 @@ package eth
 import (
 	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -30,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/miner"
 	"github.com/ethereum/go-ethereum/params"
@@ -73,7 +75,7 @@ type PublicMinerAPI struct {
 
 // NewPublicMinerAPI create a new PublicMinerAPI instance.
 func NewPublicMinerAPI(e *Ethereum) *PublicMinerAPI {
-	agent := miner.NewRemoteAgent(e.BlockChain(), e.Engine())
+	agent := miner.NewRemoteAgent(e.BlockChain(), e.Engine(), e.config.MinerNotify)
 	e.Miner().Register(agent)
 
 	return &PublicMinerAPI{e, agent}
@@ -107,6 +109,17 @@ func (api *PublicMinerAPI) GetWork() ([3]string, error) {
 	return work, nil
 }
 
+// SubmitSealedBlock can be used by external sealers to submit the RLP encoded,
+// sealed header of a pending block (e.g. signed by a clique signer whose key is
+// kept off the node). It returns an indication if the block was accepted.
+func (api *PublicMinerAPI) SubmitSealedBlock(blob hexutil.Bytes) (bool, error) {
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(blob, header); err != nil {
+		return false, err
+	}
+	return api.agent.SubmitSealedBlock(header), nil
+}
+
 // SubmitHashrate can be used for remote miners to submit their hash rate. This enables the node to report the combined
 // hash rate of all miners which submit work through this node. It accepts the miner hash rate and an identifier which
 // must be unique between nodes.
@@ -353,6 +366,50 @@ func (api *PrivateDebugAPI) GetBadBlocks(ctx context.Context) ([]core.BadBlockAr
 	return api.eth.BlockChain().BadBlocks()
 }
 
+// errEVMProfilingDisabled is returned by the EVM profile API calls if the node
+// was not started with opcode level profiling enabled.
+var errEVMProfilingDisabled = errors.New("EVM profiling not enabled")
+
+// EvmProfile returns the opcode level execution statistics of the contracts run
+// during block imports, heaviest gas consumers first, optionally capped to the
+// given number of contracts.
+func (api *PrivateDebugAPI) EvmProfile(count *int) ([]*vm.ContractProfile, error) {
+	if api.eth.evmProfiler == nil {
+		return nil, errEVMProfilingDisabled
+	}
+	profile := api.eth.evmProfiler.Profile()
+	if count != nil && *count >= 0 && *count < len(profile) {
+		profile = profile[:*count]
+	}
+	return profile, nil
+}
+
+// WriteEvmProfile writes the opcode level execution statistics of the contracts
+// run during block imports to the given file in pprof format.
+func (api *PrivateDebugAPI) WriteEvmProfile(file string) error {
+	if api.eth.evmProfiler == nil {
+		return errEVMProfilingDisabled
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := api.eth.evmProfiler.WritePprof(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ResetEvmProfile discards the execution statistics accumulated so far.
+func (api *PrivateDebugAPI) ResetEvmProfile() error {
+	if api.eth.evmProfiler == nil {
+		return errEVMProfilingDisabled
+	}
+	api.eth.evmProfiler.Reset()
+	return nil
+}
+
 // StorageRangeResult is the result of a debug_storageRangeAt API call.
 type StorageRangeResult struct {
 	Storage storageMap   `json:"storage"`
@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/compiler"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
@@ -57,6 +58,7 @@ type TraceConfig struct {
 	Tracer  *string
 	Timeout *string
 	Reexec  *uint64
+	Sources map[string]*compiler.Contract // Compiled contracts to annotate struct logs with
 }
 
 // txTraceResult is the result of a single transaction trace.
@@ -582,6 +584,9 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 	case config == nil:
 		tracer = vm.NewStructLogger(nil)
 
+	case len(config.Sources) > 0:
+		tracer = vm.NewSourceLogger(vm.NewStructLogger(config.LogConfig), compiler.NewSourceTracker(config.Sources))
+
 	default:
 		tracer = vm.NewStructLogger(config.LogConfig)
 	}
@@ -602,6 +607,22 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 			StructLogs:  ethapi.FormatLogs(tracer.StructLogs()),
 		}, nil
 
+	case *vm.SourceLogger:
+		logs := ethapi.FormatLogs(tracer.StructLogs())
+		for i, loc := range tracer.Locations() {
+			logs[i].Source = loc
+		}
+		result := &ethapi.ExecutionResult{
+			Gas:         gas,
+			Failed:      failed,
+			ReturnValue: fmt.Sprintf("%x", ret),
+			StructLogs:  logs,
+		}
+		if failed {
+			result.StackTrace = tracer.StackTrace()
+		}
+		return result, nil
+
 	case *tracers.Tracer:
 		return tracer.GetResult()
 
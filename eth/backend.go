@@ -31,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/istanbul"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -42,6 +43,7 @@ import (
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/miner"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
@@ -54,6 +56,7 @@ type LesServer interface {
 	Start(srvr *p2p.Server)
 	Stop()
 	Protocols() []p2p.Protocol
+	APIs() []rpc.API
 	SetBloomBitsIndexer(bbIndexer *core.ChainIndexer)
 }
 
@@ -81,10 +84,12 @@ type Ethereum struct {
 
 	bloomRequests chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer  *core.ChainIndexer             // Bloom indexer operating during block imports
+	evmProfiler   *vm.Profiler                   // Opcode level profiler of block imports, if enabled
 
 	ApiBackend *EthApiBackend
 
 	miner     *miner.Miner
+	stratum   *miner.StratumServer
 	gasPrice  *big.Int
 	etherbase common.Address
 
@@ -146,12 +151,25 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	}
 	var (
 		vmConfig    = vm.Config{EnablePreimageRecording: config.EnablePreimageRecording}
-		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieNodeLimit: config.TrieCache, TrieTimeLimit: config.TrieTimeout}
+		cacheConfig = &core.CacheConfig{
+			Disabled:            config.NoPruning,
+			TrieNodeLimit:       config.TrieCache,
+			TrieTimeLimit:       config.TrieTimeout,
+			TrieCleanLimit:      config.TrieCleanCache,
+			TrieCleanNoPrefetch: config.NoPrefetch,
+		}
 	)
+	if config.EnableEVMProfiling {
+		eth.evmProfiler = vm.NewProfiler()
+		vmConfig.Profiler = eth.evmProfiler
+	}
 	eth.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, eth.chainConfig, eth.engine, vmConfig)
 	if err != nil {
 		return nil, err
 	}
+	if config.EnableParallelProcessing {
+		eth.blockchain.SetProcessor(core.NewParallelStateProcessor(eth.chainConfig, eth.blockchain, eth.engine, runtime.NumCPU()))
+	}
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
 		log.Warn("Rewinding chain to upgrade configuration", "err", compat)
@@ -171,6 +189,15 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	eth.miner = miner.New(eth, eth.chainConfig, eth.EventMux(), eth.engine)
 	eth.miner.SetExtra(makeExtraData(config.ExtraData))
 
+	if config.StratumAddr != "" {
+		pow, ok := eth.engine.(*ethash.Ethash)
+		if !ok {
+			return nil, errors.New("stratum mining requires the ethash engine")
+		}
+		eth.stratum = miner.NewStratumServer(eth.blockchain, pow, new(big.Int).SetUint64(config.StratumDifficulty))
+		eth.miner.Register(eth.stratum)
+	}
+
 	eth.ApiBackend = &EthApiBackend{eth, nil}
 	gpoParams := config.GPO
 	if gpoParams.Default == nil {
@@ -216,6 +243,10 @@ func CreateConsensusEngine(ctx *node.ServiceContext, config *ethash.Config, chai
 	if chainConfig.Clique != nil {
 		return clique.New(chainConfig.Clique, db)
 	}
+	// If byzantine fault tolerance is requested, validate with the node key
+	if chainConfig.Istanbul != nil {
+		return istanbul.New(chainConfig.Istanbul, ctx.NodeKey(), db)
+	}
 	// Otherwise assume proof-of-work
 	switch {
 	case config.PowMode == ethash.ModeFake:
@@ -235,6 +266,7 @@ func CreateConsensusEngine(ctx *node.ServiceContext, config *ethash.Config, chai
 			DatasetDir:     config.DatasetDir,
 			DatasetsInMem:  config.DatasetsInMem,
 			DatasetsOnDisk: config.DatasetsOnDisk,
+			SharedDirs:     config.SharedDirs,
 		})
 		engine.SetThreads(-1) // Disable CPU mining
 		return engine
@@ -249,6 +281,11 @@ func (s *Ethereum) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
+	// Append any APIs exposed by the light server
+	if s.lesServer != nil {
+		apis = append(apis, s.lesServer.APIs()...)
+	}
+
 	// Append all the local APIs and return
 	return append(apis, []rpc.API{
 		{
@@ -342,11 +379,17 @@ func (s *Ethereum) StartMining(local bool) error {
 	}
 	if clique, ok := s.engine.(*clique.Clique); ok {
 		wallet, err := s.accountManager.Find(accounts.Account{Address: eb})
-		if wallet == nil || err != nil {
+		switch {
+		case (wallet == nil || err != nil) && (s.config.MinerRemoteSigner || len(s.config.MinerNotify) > 0):
+			// Signer key held by a remote sealer, only track who we're signing for
+			log.Info("Etherbase account unavailable locally, relying on remote sealer", "etherbase", eb)
+			clique.Authorize(eb, nil)
+		case wallet == nil || err != nil:
 			log.Error("Etherbase account unavailable locally", "err", err)
 			return fmt.Errorf("signer missing: %v", err)
+		default:
+			clique.Authorize(eb, wallet.SignHash)
 		}
-		clique.Authorize(eb, wallet.SignHash)
 	}
 	if local {
 		// If local (CPU) mining is started, we can disable the transaction rejection
@@ -377,10 +420,14 @@ func (s *Ethereum) Downloader() *downloader.Downloader { return s.protocolManage
 // Protocols implements node.Service, returning all the currently configured
 // network protocols to start.
 func (s *Ethereum) Protocols() []p2p.Protocol {
+	protos := append([]p2p.Protocol{}, s.protocolManager.SubProtocols...)
+	if handler, ok := s.engine.(consensus.Handler); ok {
+		protos = append(protos, handler.Protocols()...)
+	}
 	if s.lesServer == nil {
-		return s.protocolManager.SubProtocols
+		return protos
 	}
-	return append(s.protocolManager.SubProtocols, s.lesServer.Protocols()...)
+	return append(protos, s.lesServer.Protocols()...)
 }
 
 // Start implements node.Service, starting all internal goroutines needed by the
@@ -400,14 +447,53 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 		}
 		maxPeers -= s.config.LightPeers
 	}
+	// Start the Stratum mining server if requested
+	if s.stratum != nil {
+		if err := s.stratum.Listen(s.config.StratumAddr); err != nil {
+			return err
+		}
+	}
 	// Start the networking layer and the light server if requested
 	s.protocolManager.Start(maxPeers)
 	if s.lesServer != nil {
 		s.lesServer.Start(srvr)
 	}
+	// Start the consensus message processing if the engine needs it
+	if handler, ok := s.engine.(consensus.Handler); ok {
+		insert := func(block *types.Block) error {
+			_, err := s.blockchain.InsertChain(types.Blocks{block})
+			return err
+		}
+		if err := handler.Start(s.blockchain, s.blockchain.ValidateBlock, insert); err != nil {
+			return err
+		}
+	}
+	// Track the liveness of the clique signers as blocks get inserted
+	if clique, ok := s.engine.(*clique.Clique); ok && metrics.Enabled {
+		go s.cliqueMetricsLoop(clique)
+	}
 	return nil
 }
 
+// cliqueMetricsLoop feeds the liveness of the clique signers into the metrics
+// system whenever a block is inserted into the canonical chain.
+func (s *Ethereum) cliqueMetricsLoop(engine *clique.Clique) {
+	events := make(chan core.ChainEvent, 16)
+	sub := s.blockchain.SubscribeChainEvent(events)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-events:
+			engine.MarkInserted(s.blockchain, ev.Block.Header())
+		case <-sub.Err():
+			return
+		case <-s.shutdownChan:
+			return
+		}
+	}
+}
+
 // Stop implements node.Service, terminating all internal goroutines used by the
 // Ethereum protocol.
 func (s *Ethereum) Stop() error {
@@ -415,6 +501,9 @@ func (s *Ethereum) Stop() error {
 		s.stopDbUpgrade()
 	}
 	s.bloomIndexer.Close()
+	if handler, ok := s.engine.(consensus.Handler); ok {
+		handler.Stop()
+	}
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
 	if s.lesServer != nil {
@@ -422,6 +511,9 @@ func (s *Ethereum) Stop() error {
 	}
 	s.txPool.Stop()
 	s.miner.Stop()
+	if s.stratum != nil {
+		s.stratum.Close()
+	}
 	s.eventMux.Stop()
 
 	s.chainDb.Close()
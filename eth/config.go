@@ -43,12 +43,15 @@ var DefaultConfig = Config{
 		DatasetsInMem:  1,
 		DatasetsOnDisk: 2,
 	},
-	NetworkId:     1,
-	LightPeers:    100,
-	DatabaseCache: 768,
-	TrieCache:     256,
-	TrieTimeout:   5 * time.Minute,
-	GasPrice:      big.NewInt(18 * params.Shannon),
+	NetworkId:      1,
+	LightPeers:     100,
+	DatabaseCache:  768,
+	TrieCache:      256,
+	TrieTimeout:    5 * time.Minute,
+	TrieCleanCache: 64 * 1024,
+	GasPrice:       big.NewInt(18 * params.Shannon),
+
+	StratumDifficulty: 1 << 32,
 
 	TxPool: core.DefaultTxPoolConfig,
 	GPO: gasprice.Config{
@@ -87,19 +90,35 @@ type Config struct {
 	LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightPeers int `toml:",omitempty"` // Maximum number of LES client peers
 
+	LightPinnedServers    []string       `toml:",omitempty"` // Enode URLs of LES servers always dialed first in light client mode
+	LightCheckpointOracle common.Address `toml:",omitempty"` // Address of the checkpoint oracle to adopt CHT checkpoints from in light client mode
+
+	// Ultra light client options
+	ULC *ULCConfig `toml:",omitempty"`
+
 	// Database options
 	SkipBcVersionCheck bool `toml:"-"`
 	DatabaseHandles    int  `toml:"-"`
 	DatabaseCache      int
 	TrieCache          int
 	TrieTimeout        time.Duration
+	TrieCleanCache     int  // Number of clean trie nodes to keep cached in memory
+	NoPrefetch         bool // Whether to disable prefetching the state of followup blocks during import
 
 	// Mining-related options
 	Etherbase    common.Address `toml:",omitempty"`
 	MinerThreads int            `toml:",omitempty"`
+	MinerNotify  []string       `toml:",omitempty"`
 	ExtraData    []byte         `toml:",omitempty"`
 	GasPrice     *big.Int
 
+	// Clique signing left to a remote sealer if the etherbase key is unavailable
+	MinerRemoteSigner bool `toml:",omitempty"`
+
+	// Stratum mining server options
+	StratumAddr       string `toml:",omitempty"`
+	StratumDifficulty uint64
+
 	// Ethash options
 	Ethash ethash.Config
 
@@ -112,10 +131,28 @@ type Config struct {
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
+	// Enables opcode level profiling of the VM during block imports
+	EnableEVMProfiling bool
+
+	// Enables parallel execution of the transactions of imported blocks
+	EnableParallelProcessing bool
+
 	// Miscellaneous options
 	DocRoot string `toml:"-"`
 }
 
+// DefaultULCMinTrustedFraction is the default percentage of trusted servers that
+// need to announce a head for an ultra light client to accept it.
+const DefaultULCMinTrustedFraction = 75
+
+// ULCConfig contains the configuration of the ultra light client mode, in which
+// block headers are accepted without proof-of-work verification once a large
+// enough fraction of a trusted set of LES servers signed the same announcement.
+type ULCConfig struct {
+	TrustedServers     []string `toml:",omitempty"` // Enode URLs of the trusted LES servers
+	MinTrustedFraction int      `toml:",omitempty"` // Percentage of trusted servers needed to accept a head
+}
+
 type configMarshaling struct {
 	ExtraData hexutil.Bytes
 }
@@ -17,23 +17,32 @@ var _ = (*configMarshaling)(nil)
 
 func (c Config) MarshalTOML() (interface{}, error) {
 	type Config struct {
-		Genesis                 *core.Genesis `toml:",omitempty"`
-		NetworkId               uint64
-		SyncMode                downloader.SyncMode
-		LightServ               int  `toml:",omitempty"`
-		LightPeers              int  `toml:",omitempty"`
-		SkipBcVersionCheck      bool `toml:"-"`
-		DatabaseHandles         int  `toml:"-"`
-		DatabaseCache           int
-		Etherbase               common.Address `toml:",omitempty"`
-		MinerThreads            int            `toml:",omitempty"`
-		ExtraData               hexutil.Bytes  `toml:",omitempty"`
-		GasPrice                *big.Int
-		Ethash                  ethash.Config
-		TxPool                  core.TxPoolConfig
-		GPO                     gasprice.Config
-		EnablePreimageRecording bool
-		DocRoot                 string `toml:"-"`
+		Genesis                  *core.Genesis `toml:",omitempty"`
+		NetworkId                uint64
+		SyncMode                 downloader.SyncMode
+		LightServ                int            `toml:",omitempty"`
+		LightPeers               int            `toml:",omitempty"`
+		LightPinnedServers       []string       `toml:",omitempty"`
+		LightCheckpointOracle    common.Address `toml:",omitempty"`
+		ULC                      *ULCConfig     `toml:",omitempty"`
+		SkipBcVersionCheck       bool           `toml:"-"`
+		DatabaseHandles          int            `toml:"-"`
+		DatabaseCache            int
+		Etherbase                common.Address `toml:",omitempty"`
+		MinerThreads             int            `toml:",omitempty"`
+		MinerNotify              []string       `toml:",omitempty"`
+		ExtraData                hexutil.Bytes  `toml:",omitempty"`
+		GasPrice                 *big.Int
+		MinerRemoteSigner        bool   `toml:",omitempty"`
+		StratumAddr              string `toml:",omitempty"`
+		StratumDifficulty        uint64
+		Ethash                   ethash.Config
+		TxPool                   core.TxPoolConfig
+		GPO                      gasprice.Config
+		EnablePreimageRecording  bool
+		EnableEVMProfiling       bool
+		EnableParallelProcessing bool
+		DocRoot                  string `toml:"-"`
 	}
 	var enc Config
 	enc.Genesis = c.Genesis
@@ -41,40 +50,58 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.SyncMode = c.SyncMode
 	enc.LightServ = c.LightServ
 	enc.LightPeers = c.LightPeers
+	enc.LightPinnedServers = c.LightPinnedServers
+	enc.LightCheckpointOracle = c.LightCheckpointOracle
+	enc.ULC = c.ULC
 	enc.SkipBcVersionCheck = c.SkipBcVersionCheck
 	enc.DatabaseHandles = c.DatabaseHandles
 	enc.DatabaseCache = c.DatabaseCache
 	enc.Etherbase = c.Etherbase
 	enc.MinerThreads = c.MinerThreads
+	enc.MinerNotify = c.MinerNotify
 	enc.ExtraData = c.ExtraData
 	enc.GasPrice = c.GasPrice
+	enc.MinerRemoteSigner = c.MinerRemoteSigner
+	enc.StratumAddr = c.StratumAddr
+	enc.StratumDifficulty = c.StratumDifficulty
 	enc.Ethash = c.Ethash
 	enc.TxPool = c.TxPool
 	enc.GPO = c.GPO
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
+	enc.EnableEVMProfiling = c.EnableEVMProfiling
+	enc.EnableParallelProcessing = c.EnableParallelProcessing
 	enc.DocRoot = c.DocRoot
 	return &enc, nil
 }
 
 func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	type Config struct {
-		Genesis                 *core.Genesis `toml:",omitempty"`
-		NetworkId               *uint64
-		SyncMode                *downloader.SyncMode
-		LightServ               *int  `toml:",omitempty"`
-		LightPeers              *int  `toml:",omitempty"`
-		SkipBcVersionCheck      *bool `toml:"-"`
-		DatabaseHandles         *int  `toml:"-"`
-		DatabaseCache           *int
-		Etherbase               *common.Address `toml:",omitempty"`
-		MinerThreads            *int            `toml:",omitempty"`
-		ExtraData               *hexutil.Bytes  `toml:",omitempty"`
-		GasPrice                *big.Int
-		Ethash                  *ethash.Config
-		TxPool                  *core.TxPoolConfig
-		GPO                     *gasprice.Config
-		EnablePreimageRecording *bool
-		DocRoot                 *string `toml:"-"`
+		Genesis                  *core.Genesis `toml:",omitempty"`
+		NetworkId                *uint64
+		SyncMode                 *downloader.SyncMode
+		LightServ                *int            `toml:",omitempty"`
+		LightPeers               *int            `toml:",omitempty"`
+		LightPinnedServers       []string        `toml:",omitempty"`
+		LightCheckpointOracle    *common.Address `toml:",omitempty"`
+		ULC                      *ULCConfig      `toml:",omitempty"`
+		SkipBcVersionCheck       *bool           `toml:"-"`
+		DatabaseHandles          *int            `toml:"-"`
+		DatabaseCache            *int
+		Etherbase                *common.Address `toml:",omitempty"`
+		MinerThreads             *int            `toml:",omitempty"`
+		MinerNotify              []string        `toml:",omitempty"`
+		ExtraData                *hexutil.Bytes  `toml:",omitempty"`
+		GasPrice                 *big.Int
+		MinerRemoteSigner        *bool   `toml:",omitempty"`
+		StratumAddr              *string `toml:",omitempty"`
+		StratumDifficulty        *uint64
+		Ethash                   *ethash.Config
+		TxPool                   *core.TxPoolConfig
+		GPO                      *gasprice.Config
+		EnablePreimageRecording  *bool
+		EnableEVMProfiling       *bool
+		EnableParallelProcessing *bool
+		DocRoot                  *string `toml:"-"`
 	}
 	var dec Config
 	if err := unmarshal(&dec); err != nil {
@@ -95,6 +122,15 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.LightPeers != nil {
 		c.LightPeers = *dec.LightPeers
 	}
+	if dec.LightPinnedServers != nil {
+		c.LightPinnedServers = dec.LightPinnedServers
+	}
+	if dec.LightCheckpointOracle != nil {
+		c.LightCheckpointOracle = *dec.LightCheckpointOracle
+	}
+	if dec.ULC != nil {
+		c.ULC = dec.ULC
+	}
 	if dec.SkipBcVersionCheck != nil {
 		c.SkipBcVersionCheck = *dec.SkipBcVersionCheck
 	}
@@ -110,12 +146,24 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.MinerThreads != nil {
 		c.MinerThreads = *dec.MinerThreads
 	}
+	if dec.MinerNotify != nil {
+		c.MinerNotify = dec.MinerNotify
+	}
 	if dec.ExtraData != nil {
 		c.ExtraData = *dec.ExtraData
 	}
 	if dec.GasPrice != nil {
 		c.GasPrice = dec.GasPrice
 	}
+	if dec.MinerRemoteSigner != nil {
+		c.MinerRemoteSigner = *dec.MinerRemoteSigner
+	}
+	if dec.StratumAddr != nil {
+		c.StratumAddr = *dec.StratumAddr
+	}
+	if dec.StratumDifficulty != nil {
+		c.StratumDifficulty = *dec.StratumDifficulty
+	}
 	if dec.Ethash != nil {
 		c.Ethash = *dec.Ethash
 	}
@@ -128,6 +176,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.EnablePreimageRecording != nil {
 		c.EnablePreimageRecording = *dec.EnablePreimageRecording
 	}
+	if dec.EnableEVMProfiling != nil {
+		c.EnableEVMProfiling = *dec.EnableEVMProfiling
+	}
+	if dec.EnableParallelProcessing != nil {
+		c.EnableParallelProcessing = *dec.EnableParallelProcessing
+	}
 	if dec.DocRoot != nil {
 		c.DocRoot = *dec.DocRoot
 	}
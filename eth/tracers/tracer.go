@@ -289,6 +289,7 @@ type Tracer struct {
 	memoryWrapper   *memoryWrapper   // Wrapper around the VM memory
 	contractWrapper *contractWrapper // Wrapper around the contract object
 	dbWrapper       *dbWrapper       // Wrapper around the VM environment
+	env             *vm.EVM          // EVM being traced, to resolve its active precompiles
 
 	pcValue    *uint   // Swappable pc value wrapped by a log accessor
 	gasValue   *uint   // Swappable gas value wrapped by a log accessor
@@ -366,8 +367,13 @@ func New(code string) (*Tracer, error) {
 		return 1
 	})
 	tracer.vm.PushGlobalGoFunction("isPrecompiled", func(ctx *duktape.Context) int {
-		_, ok := vm.PrecompiledContractsByzantium[common.BytesToAddress(popSlice(ctx))]
-		ctx.PushBoolean(ok)
+		addr := common.BytesToAddress(popSlice(ctx))
+		if tracer.env != nil {
+			ctx.PushBoolean(tracer.env.IsPrecompile(addr))
+		} else {
+			_, ok := vm.PrecompiledContractsByzantium[addr]
+			ctx.PushBoolean(ok)
+		}
 		return 1
 	})
 	tracer.vm.PushGlobalGoFunction("slice", func(ctx *duktape.Context) int {
@@ -527,6 +533,7 @@ func (jst *Tracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost
 		jst.memoryWrapper.memory = memory
 		jst.contractWrapper.contract = contract
 		jst.dbWrapper.db = env.StateDB
+		jst.env = env
 
 		*jst.pcValue = uint(pc)
 		*jst.gasValue = uint(gas)
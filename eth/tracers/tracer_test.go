@@ -138,3 +138,30 @@ func TestHaltBetweenSteps(t *testing.T) {
 		t.Errorf("Expected timeout error, got %v", err)
 	}
 }
+
+// Tests that precompiles activated through the chain config are reported by the
+// tracer as such, in line with the traced EVM.
+func TestIsPrecompiled(t *testing.T) {
+	config := *params.TestChainConfig
+	config.EIPs = map[int]*big.Int{params.EIP152: big.NewInt(1)}
+
+	tracer, err := New("{res: [], step: function() { this.res = [isPrecompiled(toAddress('0x08')), isPrecompiled(toAddress('0x09')), isPrecompiled(toAddress('0x0a'))]; }, fault: function() {}, result: function() { return this.res; }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := vm.NewEVM(vm.Context{BlockNumber: big.NewInt(1)}, nil, &config, vm.Config{Debug: true, Tracer: tracer})
+
+	contract := vm.NewContract(account{}, account{}, big.NewInt(0), 10000)
+	contract.Code = []byte{byte(vm.STOP)}
+
+	if _, err := env.Interpreter().Run(contract, []byte{}); err != nil {
+		t.Fatal(err)
+	}
+	ret, err := tracer.GetResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ret, []byte("[true,true,false]")) {
+		t.Errorf("precompile status mismatch: have %s, want [true,true,false]", ret)
+	}
+}
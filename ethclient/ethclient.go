@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
@@ -476,6 +477,42 @@ func (ec *Client) SendTransaction(ctx context.Context, tx *types.Transaction) er
 	return ec.c.CallContext(ctx, nil, "eth_sendRawTransaction", common.ToHex(data))
 }
 
+// Light Client Transactions
+
+// TxStatus is the delivery and inclusion state of a transaction sent through a
+// light client node.
+type TxStatus struct {
+	Hash        common.Hash       `json:"hash"`
+	State       string            `json:"state"`
+	BlockHash   *common.Hash      `json:"blockHash,omitempty"`
+	BlockNumber *hexutil.Uint64   `json:"blockNumber,omitempty"`
+	ReorgedOut  []common.Hash     `json:"reorgedOut,omitempty"`
+	StateNonce  *hexutil.Uint64   `json:"stateNonce,omitempty"`
+	NonceGap    bool              `json:"nonceGap"`
+	SentTo      []string          `json:"sentTo"`
+	AckedBy     []string          `json:"ackedBy"`
+	Rejected    map[string]string `json:"rejected,omitempty"`
+	LastSent    *time.Time        `json:"lastSent,omitempty"`
+	Sends       int               `json:"sends"`
+}
+
+// LightTransactionStatus returns the status of a transaction sent through a light
+// client node, including the servers it was relayed to. It returns nil if the
+// node does not track the transaction. Only light client nodes support this call.
+func (ec *Client) LightTransactionStatus(ctx context.Context, txHash common.Hash) (*TxStatus, error) {
+	var status *TxStatus
+	err := ec.c.CallContext(ctx, &status, "les_txStatus", txHash)
+	return status, err
+}
+
+// LightPendingTransactionStatus returns the status of all transactions sent through
+// a light client node that are not yet included in the canonical chain.
+func (ec *Client) LightPendingTransactionStatus(ctx context.Context) ([]*TxStatus, error) {
+	var stats []*TxStatus
+	err := ec.c.CallContext(ctx, &stats, "les_pendingTxStatus")
+	return stats, err
+}
+
 func toCallArg(msg ethereum.CallMsg) interface{} {
 	arg := map[string]interface{}{
 		"from": msg.From,
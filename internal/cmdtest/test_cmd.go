@@ -27,6 +27,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"syscall"
 	"testing"
 	"text/template"
 	"time"
@@ -45,6 +46,7 @@ type TestCmd struct {
 	Func    template.FuncMap
 	Data    interface{}
 	Cleanup func()
+	Err     error
 
 	cmd    *exec.Cmd
 	stdout *bufio.Reader
@@ -182,7 +184,18 @@ func (tt *TestCmd) ExpectExit() {
 }
 
 func (tt *TestCmd) WaitExit() {
-	tt.cmd.Wait()
+	tt.Err = tt.cmd.Wait()
+}
+
+// ExitStatus returns the exit code of the child process, which is only valid
+// after it has exited (e.g. after ExpectExit or WaitExit returned).
+func (tt *TestCmd) ExitStatus() int {
+	if exitErr, ok := tt.Err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			return status.ExitStatus()
+		}
+	}
+	return 0
 }
 
 func (tt *TestCmd) Interrupt() {
@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/compiler"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
@@ -733,24 +734,26 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 // while replaying a transaction in debug mode as well as transaction
 // execution status, the amount of gas used and the return value
 type ExecutionResult struct {
-	Gas         uint64         `json:"gas"`
-	Failed      bool           `json:"failed"`
-	ReturnValue string         `json:"returnValue"`
-	StructLogs  []StructLogRes `json:"structLogs"`
+	Gas         uint64                     `json:"gas"`
+	Failed      bool                       `json:"failed"`
+	ReturnValue string                     `json:"returnValue"`
+	StructLogs  []StructLogRes             `json:"structLogs"`
+	StackTrace  []*compiler.SourceLocation `json:"stackTrace,omitempty"`
 }
 
 // StructLogRes stores a structured log emitted by the EVM while replaying a
 // transaction in debug mode
 type StructLogRes struct {
-	Pc      uint64             `json:"pc"`
-	Op      string             `json:"op"`
-	Gas     uint64             `json:"gas"`
-	GasCost uint64             `json:"gasCost"`
-	Depth   int                `json:"depth"`
-	Error   error              `json:"error,omitempty"`
-	Stack   *[]string          `json:"stack,omitempty"`
-	Memory  *[]string          `json:"memory,omitempty"`
-	Storage *map[string]string `json:"storage,omitempty"`
+	Pc      uint64                   `json:"pc"`
+	Op      string                   `json:"op"`
+	Gas     uint64                   `json:"gas"`
+	GasCost uint64                   `json:"gasCost"`
+	Depth   int                      `json:"depth"`
+	Error   error                    `json:"error,omitempty"`
+	Stack   *[]string                `json:"stack,omitempty"`
+	Memory  *[]string                `json:"memory,omitempty"`
+	Storage *map[string]string       `json:"storage,omitempty"`
+	Source  *compiler.SourceLocation `json:"source,omitempty"`
 }
 
 // formatLogs formats EVM returned structured logs for json output
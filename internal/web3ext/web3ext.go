@@ -23,6 +23,8 @@ var Modules = map[string]string{
 	"clique":     Clique_JS,
 	"debug":      Debug_JS,
 	"eth":        Eth_JS,
+	"istanbul":   Istanbul_JS,
+	"les":        LES_JS,
 	"miner":      Miner_JS,
 	"net":        Net_JS,
 	"personal":   Personal_JS,
@@ -89,6 +91,12 @@ web3._extend({
 			call: 'clique_getSignersAtHash',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'getSignerStatus',
+			call: 'clique_getSignerStatus',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
 		new web3._extend.Method({
 			name: 'propose',
 			call: 'clique_propose',
@@ -99,12 +107,62 @@ web3._extend({
 			call: 'clique_discard',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'proposeConfig',
+			call: 'clique_proposeConfig',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'discardConfig',
+			call: 'clique_discardConfig',
+			params: 1
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
 			name: 'proposals',
 			getter: 'clique_proposals'
 		}),
+		new web3._extend.Property({
+			name: 'configProposals',
+			getter: 'clique_configProposals'
+		}),
+	]
+});
+`
+
+const Istanbul_JS = `
+web3._extend({
+	property: 'istanbul',
+	methods: [
+		new web3._extend.Method({
+			name: 'getSnapshot',
+			call: 'istanbul_getSnapshot',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'getValidators',
+			call: 'istanbul_getValidators',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'propose',
+			call: 'istanbul_propose',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'discard',
+			call: 'istanbul_discard',
+			params: 1
+		}),
+	],
+	properties: [
+		new web3._extend.Property({
+			name: 'candidates',
+			getter: 'istanbul_candidates'
+		}),
 	]
 });
 `
@@ -348,6 +406,22 @@ web3._extend({
 			params: 1,
 			inputFormatter: [null]
 		}),
+		new web3._extend.Method({
+			name: 'evmProfile',
+			call: 'debug_evmProfile',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'writeEvmProfile',
+			call: 'debug_writeEvmProfile',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'resetEvmProfile',
+			call: 'debug_resetEvmProfile',
+			params: 0
+		}),
 		new web3._extend.Method({
 			name: 'getBadBlocks',
 			call: 'debug_getBadBlocks',
@@ -416,6 +490,11 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.utils.toHex]
 		}),
+		new web3._extend.Method({
+			name: 'submitSealedBlock',
+			call: 'eth_submitSealedBlock',
+			params: 1
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -616,3 +695,92 @@ web3._extend({
 	]
 });
 `
+
+const LES_JS = `
+web3._extend({
+	property: 'les',
+	methods: [
+		new web3._extend.Method({
+			name: 'txStatus',
+			call: 'les_txStatus',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'setTier',
+			call: 'les_setTier',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'removeTier',
+			call: 'les_removeTier',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'setClientTier',
+			call: 'les_setClientTier',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'setTotalCapacity',
+			call: 'les_setTotalCapacity',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'pinServer',
+			call: 'les_pinServer',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'unpinServer',
+			call: 'les_unpinServer',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'banServer',
+			call: 'les_banServer',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'unbanServer',
+			call: 'les_unbanServer',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getCheckpoint',
+			call: 'les_getCheckpoint',
+			params: 1
+		}),
+	],
+	properties:
+	[
+		new web3._extend.Property({
+			name: 'pendingTxStatus',
+			getter: 'les_pendingTxStatus'
+		}),
+		new web3._extend.Property({
+			name: 'tiers',
+			getter: 'les_tiers'
+		}),
+		new web3._extend.Property({
+			name: 'clientTiers',
+			getter: 'les_clientTiers'
+		}),
+		new web3._extend.Property({
+			name: 'clients',
+			getter: 'les_clients'
+		}),
+		new web3._extend.Property({
+			name: 'totalCapacity',
+			getter: 'les_totalCapacity'
+		}),
+		new web3._extend.Property({
+			name: 'servers',
+			getter: 'les_servers'
+		}),
+		new web3._extend.Property({
+			name: 'latestCheckpoint',
+			getter: 'les_latestCheckpoint'
+		}),
+	]
+});
+`
@@ -0,0 +1,321 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/contracts/checkpointoracle"
+	"github.com/ethereum/go-ethereum/les/flowcontrol"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+var errNoCheckpoint = errors.New("checkpoint not available")
+
+// Transaction states reported by the light client transaction status API.
+const (
+	TxStatePending = "pending" // Sent to the network, not yet included in a block
+	TxStateMined   = "mined"   // Included in the canonical chain
+)
+
+// TxStatus is the light client's view of a locally sent transaction, combining
+// the inclusion tracking of the light transaction pool with the delivery state
+// of the transaction relay. Its JSON encoding is mirrored by ethclient.TxStatus.
+type TxStatus struct {
+	Hash        common.Hash       `json:"hash"`
+	State       string            `json:"state"`
+	BlockHash   *common.Hash      `json:"blockHash,omitempty"`
+	BlockNumber *hexutil.Uint64   `json:"blockNumber,omitempty"`
+	ReorgedOut  []common.Hash     `json:"reorgedOut,omitempty"`
+	StateNonce  *hexutil.Uint64   `json:"stateNonce,omitempty"`
+	NonceGap    bool              `json:"nonceGap"`
+	SentTo      []string          `json:"sentTo"`
+	AckedBy     []string          `json:"ackedBy"`
+	Rejected    map[string]string `json:"rejected,omitempty"`
+	LastSent    *time.Time        `json:"lastSent,omitempty"`
+	Sends       int               `json:"sends"`
+}
+
+// PublicLightTxPoolAPI provides an API to inspect the transactions sent by the
+// light client and to find out why they may be stuck.
+type PublicLightTxPoolAPI struct {
+	les *LightEthereum
+}
+
+// NewPublicLightTxPoolAPI creates a new light transaction pool API.
+func NewPublicLightTxPoolAPI(les *LightEthereum) *PublicLightTxPoolAPI {
+	return &PublicLightTxPoolAPI{les}
+}
+
+// TxStatus returns the status of a locally sent transaction, or nil if the
+// transaction is not tracked by the light client.
+func (api *PublicLightTxPoolAPI) TxStatus(ctx context.Context, hash common.Hash) (*TxStatus, error) {
+	local, err := api.les.txPool.LocalStatus(ctx, hash)
+	if err != nil || local == nil {
+		return nil, err
+	}
+	status := &TxStatus{
+		Hash:       hash,
+		State:      TxStatePending,
+		ReorgedOut: local.RolledBack,
+		NonceGap:   local.NonceGap,
+	}
+	if local.Pending {
+		nonce := hexutil.Uint64(local.StateNonce)
+		status.StateNonce = &nonce
+	} else {
+		number := hexutil.Uint64(local.BlockNumber)
+		status.State, status.BlockHash, status.BlockNumber = TxStateMined, &local.BlockHash, &number
+	}
+	if relay := api.les.relay.Status(hash); relay != nil {
+		status.SentTo, status.AckedBy, status.Rejected = relay.SentTo, relay.AckedBy, relay.Rejected
+		status.Sends = relay.Sends
+		if !relay.LastSent.IsZero() {
+			status.LastSent = &relay.LastSent
+		}
+	}
+	return status, nil
+}
+
+// PendingTxStatus returns the status of all locally sent transactions that are
+// not yet included in the canonical chain.
+func (api *PublicLightTxPoolAPI) PendingTxStatus(ctx context.Context) ([]*TxStatus, error) {
+	txs, err := api.les.txPool.GetTransactions()
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]*TxStatus, 0, len(txs))
+	for _, tx := range txs {
+		status, err := api.TxStatus(ctx, tx.Hash())
+		if err != nil {
+			return nil, err
+		}
+		if status != nil {
+			stats = append(stats, status)
+		}
+	}
+	return stats, nil
+}
+
+// TierInfo contains the flow control parameters of a client tier.
+type TierInfo struct {
+	BufLimit    uint64 `json:"bufLimit"`
+	MinRecharge uint64 `json:"minRecharge"`
+}
+
+// ClientInfo contains the tier and flow control state of a connected client.
+type ClientInfo struct {
+	ID          discover.NodeID `json:"id"`
+	Tier        string          `json:"tier"`
+	BufLimit    uint64          `json:"bufLimit"`
+	MinRecharge uint64          `json:"minRecharge"`
+	BufValue    uint64          `json:"bufValue"`
+}
+
+// CapacityInfo contains the total and the currently allocated serving capacity
+// of the server, measured in the sum of client recharge rates.
+type CapacityInfo struct {
+	Total uint64 `json:"total"`
+	Used  uint64 `json:"used"`
+}
+
+// PrivateLightServerAPI provides an API to manage the client tiers of a light
+// server and to inspect the flow control state of its clients.
+type PrivateLightServerAPI struct {
+	server *LesServer
+}
+
+// NewPrivateLightServerAPI creates a new light server management API.
+func NewPrivateLightServerAPI(server *LesServer) *PrivateLightServerAPI {
+	return &PrivateLightServerAPI{server}
+}
+
+// SetTier creates or updates a client tier with the given flow control params.
+// Connected clients of the tier are served with the new parameters after they
+// reconnect.
+func (api *PrivateLightServerAPI) SetTier(name string, bufLimit, minRecharge uint64) error {
+	return api.server.clientTiers.setTier(name, flowcontrol.ServerParams{BufLimit: bufLimit, MinRecharge: minRecharge})
+}
+
+// RemoveTier deletes a client tier, turning all its clients into free ones.
+func (api *PrivateLightServerAPI) RemoveTier(name string) error {
+	return api.server.clientTiers.removeTier(name)
+}
+
+// Tiers returns the configured client tiers.
+func (api *PrivateLightServerAPI) Tiers() map[string]TierInfo {
+	ct := api.server.clientTiers
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+
+	tiers := map[string]TierInfo{
+		freeTier: {BufLimit: ct.defParams.BufLimit, MinRecharge: ct.defParams.MinRecharge},
+	}
+	for name, params := range ct.tiers {
+		tiers[name] = TierInfo{BufLimit: params.BufLimit, MinRecharge: params.MinRecharge}
+	}
+	return tiers
+}
+
+// SetClientTier assigns a client to a tier. An empty tier name turns the client
+// into a free one.
+func (api *PrivateLightServerAPI) SetClientTier(id discover.NodeID, tier string) error {
+	return api.server.clientTiers.assign(id, tier)
+}
+
+// ClientTiers returns the tiers assigned to clients, whether connected or not.
+func (api *PrivateLightServerAPI) ClientTiers() map[discover.NodeID]string {
+	ct := api.server.clientTiers
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+
+	assigned := make(map[discover.NodeID]string, len(ct.assigned))
+	for id, tier := range ct.assigned {
+		assigned[id] = tier
+	}
+	return assigned
+}
+
+// Clients returns the tier and flow control buffer state of all connected clients.
+func (api *PrivateLightServerAPI) Clients() []ClientInfo {
+	ct := api.server.clientTiers
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+
+	infos := make([]ClientInfo, 0, len(ct.clients))
+	for id, c := range ct.clients {
+		info := ClientInfo{
+			ID:          id,
+			Tier:        c.tier,
+			BufLimit:    c.params.BufLimit,
+			MinRecharge: c.params.MinRecharge,
+		}
+		if c.peer.fcClient != nil {
+			info.BufValue = c.peer.fcClient.BufferValue()
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID.String() < infos[j].ID.String() })
+	return infos
+}
+
+// SetTotalCapacity changes the total serving capacity of the server. Free
+// clients are disconnected if the allocated capacity exceeds the new limit.
+func (api *PrivateLightServerAPI) SetTotalCapacity(capacity uint64) error {
+	return api.server.clientTiers.setCapacity(capacity)
+}
+
+// TotalCapacity returns the total and the currently allocated serving capacity.
+func (api *PrivateLightServerAPI) TotalCapacity() CapacityInfo {
+	ct := api.server.clientTiers
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+
+	return CapacityInfo{Total: ct.capacity, Used: ct.used}
+}
+
+// LatestCheckpoint returns the trie roots of the most recent section processed
+// by the server, to be voted into a checkpoint oracle by its admins.
+func (api *PrivateLightServerAPI) LatestCheckpoint() (*checkpointoracle.Checkpoint, error) {
+	if cp := api.server.latestCheckpoint(); cp != nil {
+		return cp, nil
+	}
+	return nil, errNoCheckpoint
+}
+
+// GetCheckpoint returns the trie roots of the given section, to be voted into a
+// checkpoint oracle by its admins.
+func (api *PrivateLightServerAPI) GetCheckpoint(index uint64) (*checkpointoracle.Checkpoint, error) {
+	if cp := api.server.checkpoint(index); cp != nil {
+		return cp, nil
+	}
+	return nil, errNoCheckpoint
+}
+
+// ServerInfo contains the state and quality statistics of a light server known
+// by the client's server pool.
+type ServerInfo struct {
+	ID           discover.NodeID    `json:"id"`
+	Addresses    []string           `json:"addresses"`
+	State        string             `json:"state"`
+	Known        bool               `json:"known"`
+	Pinned       bool               `json:"pinned"`
+	Banned       bool               `json:"banned"`
+	Score        float64            `json:"score"`
+	ConnectRatio float64            `json:"connectRatio"`
+	BlockDelay   time.Duration      `json:"blockDelay"`
+	ResponseTime time.Duration      `json:"responseTime"`
+	TimeoutRatio float64            `json:"timeoutRatio"`
+	Fails        uint               `json:"fails"`
+	History      []ServerConnRecord `json:"history"`
+}
+
+// ServerConnRecord is a recent connection attempt to a light server.
+type ServerConnRecord struct {
+	Address  string        `json:"address"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Result   string        `json:"result"`
+}
+
+// PrivateLightClientAPI provides an API to inspect the light servers known by
+// the client and to manually override their selection.
+type PrivateLightClientAPI struct {
+	les *LightEthereum
+}
+
+// NewPrivateLightClientAPI creates a new light client server pool API.
+func NewPrivateLightClientAPI(les *LightEthereum) *PrivateLightClientAPI {
+	return &PrivateLightClientAPI{les}
+}
+
+// Servers returns the known light servers with their scores, current state
+// and recent connection history, best scoring ones first.
+func (api *PrivateLightClientAPI) Servers() []ServerInfo {
+	return api.les.serverPool.serverInfos()
+}
+
+// PinServer marks a light server as trusted. Pinned servers are always dialed
+// first and are reconnected to regardless of their statistics.
+func (api *PrivateLightClientAPI) PinServer(url string) error {
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return err
+	}
+	api.les.serverPool.pin(node)
+	return nil
+}
+
+// UnpinServer returns a pinned light server to the statistics based selection.
+func (api *PrivateLightClientAPI) UnpinServer(id discover.NodeID) {
+	api.les.serverPool.unpin(id)
+}
+
+// BanServer prevents a light server from being used, disconnecting it if needed.
+func (api *PrivateLightClientAPI) BanServer(id discover.NodeID) {
+	api.les.serverPool.ban(id)
+}
+
+// UnbanServer lifts the ban of a light server.
+func (api *PrivateLightClientAPI) UnbanServer(id discover.NodeID) {
+	api.les.serverPool.unban(id)
+}
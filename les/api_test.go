@@ -0,0 +1,68 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Tests that the transaction status served by the light client API decodes into
+// the client side type without losing any fields.
+func TestTxStatusClientCompat(t *testing.T) {
+	var (
+		hash     = common.HexToHash("0x01")
+		number   = hexutil.Uint64(1)
+		nonce    = hexutil.Uint64(2)
+		lastSent = time.Unix(1000, 0).UTC()
+	)
+	status := &TxStatus{
+		Hash:        hash,
+		State:       "mined",
+		BlockHash:   &hash,
+		BlockNumber: &number,
+		ReorgedOut:  []common.Hash{hash},
+		StateNonce:  &nonce,
+		NonceGap:    true,
+		SentTo:      []string{"a", "b"},
+		AckedBy:     []string{"a"},
+		Rejected:    map[string]string{"b": "nonce too low"},
+		LastSent:    &lastSent,
+		Sends:       2,
+	}
+	served, err := json.Marshal(status)
+	if err != nil {
+		t.Fatalf("failed to encode status: %v", err)
+	}
+	var decoded ethclient.TxStatus
+	if err := json.Unmarshal(served, &decoded); err != nil {
+		t.Fatalf("failed to decode status: %v", err)
+	}
+	reencoded, err := json.Marshal(&decoded)
+	if err != nil {
+		t.Fatalf("failed to reencode status: %v", err)
+	}
+	if !bytes.Equal(served, reencoded) {
+		t.Errorf("status mismatch:\nserved  %s\ndecoded %s", served, reencoded)
+	}
+}
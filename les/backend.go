@@ -26,6 +26,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -40,6 +41,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
 	"github.com/ethereum/go-ethereum/p2p/discv5"
 	"github.com/ethereum/go-ethereum/params"
 	rpc "github.com/ethereum/go-ethereum/rpc"
@@ -59,6 +61,7 @@ type LightEthereum struct {
 	blockchain      *light.LightChain
 	protocolManager *ProtocolManager
 	serverPool      *serverPool
+	oracle          *checkpointOracle
 	reqDist         *requestDistributor
 	retriever       *retrieveManager
 	// DB interfaces
@@ -90,6 +93,18 @@ func New(ctx *node.ServiceContext, config *eth.Config) (*LightEthereum, error) {
 	}
 	log.Info("Initialised chain configuration", "config", chainConfig)
 
+	var ulc *ulc
+	ethashConfig := config.Ethash
+	if config.ULC != nil {
+		if ulc, err = newULC(config.ULC); err != nil {
+			return nil, err
+		}
+		// Heads are accepted on the word of the trusted servers, which are the only
+		// ones synced from, so skip the PoW check. Clique signatures are still
+		// verified, as they are cheap to check.
+		ethashConfig.PowMode = ethash.ModeFake
+		log.Info("Running in ultra light client mode", "servers", len(ulc.servers), "fraction", ulc.minTrustedFraction)
+	}
 	peers := newPeerSet()
 	quitSync := make(chan struct{})
 
@@ -101,7 +116,7 @@ func New(ctx *node.ServiceContext, config *eth.Config) (*LightEthereum, error) {
 		peers:            peers,
 		reqDist:          newRequestDistributor(peers, quitSync),
 		accountManager:   ctx.AccountManager,
-		engine:           eth.CreateConsensusEngine(ctx, &config.Ethash, chainConfig, chainDb),
+		engine:           eth.CreateConsensusEngine(ctx, &ethashConfig, chainConfig, chainDb),
 		shutdownChan:     make(chan bool),
 		networkId:        config.NetworkId,
 		bloomRequests:    make(chan chan *bloombits.Retrieval),
@@ -110,13 +125,29 @@ func New(ctx *node.ServiceContext, config *eth.Config) (*LightEthereum, error) {
 		bloomTrieIndexer: light.NewBloomTrieIndexer(chainDb, true),
 	}
 
+	var pinned []*discover.Node
+	for _, url := range config.LightPinnedServers {
+		node, err := discover.ParseNode(url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pinned light server %q: %v", url, err)
+		}
+		pinned = append(pinned, node)
+	}
+	if ulc != nil {
+		pinned = append(pinned, ulc.servers...)
+	}
 	leth.relay = NewLesTxRelay(peers, leth.reqDist)
-	leth.serverPool = newServerPool(chainDb, quitSync, &leth.wg)
+	leth.serverPool = newServerPool(chainDb, quitSync, &leth.wg, pinned)
 	leth.retriever = newRetrieveManager(peers, leth.reqDist, leth.serverPool)
 	leth.odr = NewLesOdr(chainDb, leth.chtIndexer, leth.bloomTrieIndexer, leth.bloomIndexer, leth.retriever)
 	if leth.blockchain, err = light.NewLightChain(leth.odr, leth.chainConfig, leth.engine); err != nil {
 		return nil, err
 	}
+	if config.LightCheckpointOracle != (common.Address{}) {
+		if leth.oracle, err = newCheckpointOracle(leth, config.LightCheckpointOracle, quitSync, &leth.wg); err != nil {
+			return nil, err
+		}
+	}
 	leth.bloomIndexer.Start(leth.blockchain)
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
@@ -129,6 +160,8 @@ func New(ctx *node.ServiceContext, config *eth.Config) (*LightEthereum, error) {
 	if leth.protocolManager, err = NewProtocolManager(leth.chainConfig, true, ClientProtocolVersions, config.NetworkId, leth.eventMux, leth.engine, leth.peers, leth.blockchain, nil, chainDb, leth.odr, leth.relay, quitSync, &leth.wg); err != nil {
 		return nil, err
 	}
+	leth.protocolManager.serverPool = leth.serverPool
+	leth.protocolManager.ulc = ulc
 	leth.ApiBackend = &LesApiBackend{leth, nil}
 	gpoParams := config.GPO
 	if gpoParams.Default == nil {
@@ -197,6 +230,16 @@ func (s *LightEthereum) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   s.netRPCService,
 			Public:    true,
+		}, {
+			Namespace: "les",
+			Version:   "1.0",
+			Service:   NewPublicLightTxPoolAPI(s),
+			Public:    true,
+		}, {
+			Namespace: "les",
+			Version:   "1.0",
+			Service:   NewPrivateLightClientAPI(s),
+			Public:    false,
 		},
 	}...)
 }
@@ -228,6 +271,9 @@ func (s *LightEthereum) Start(srvr *p2p.Server) error {
 	protocolVersion := AdvertiseProtocolVersions[0]
 	s.serverPool.start(srvr, lesTopic(s.blockchain.Genesis().Hash(), protocolVersion))
 	s.protocolManager.Start(s.config.LightPeers)
+	if s.oracle != nil {
+		s.oracle.start()
+	}
 	return nil
 }
 
@@ -0,0 +1,161 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/contracts/checkpointoracle"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/light"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	oracleCheckInterval = 10 * time.Minute // Time between two queries of the checkpoint oracle
+	oracleCheckTimeout  = time.Minute      // Maximum time allowed for a single oracle query
+)
+
+// lightContractCaller implements bind.ContractCaller on top of the ODR backed
+// state of the light chain. Calls are always executed on the state of the
+// current head, every piece of which is verified against the state root of the
+// (consensus verified) head header. The requested block number is ignored, as
+// older states can't be retrieved without trusting the server for the header.
+type lightContractCaller struct {
+	les *LightEthereum
+}
+
+// CodeAt returns the code of the given account at the current head.
+func (c *lightContractCaller) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	statedb := light.NewState(ctx, c.les.blockchain.CurrentHeader(), c.les.odr)
+	code := statedb.GetCode(contract)
+	return code, statedb.Error()
+}
+
+// CallContract executes a message call on the state of the current head.
+func (c *lightContractCaller) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	header := c.les.blockchain.CurrentHeader()
+	statedb := light.NewState(ctx, header, c.les.odr)
+
+	gas := call.Gas
+	if gas == 0 {
+		gas = header.GasLimit
+	}
+	msg := types.NewMessage(call.From, call.To, 0, new(big.Int), gas, new(big.Int), call.Data, false)
+	context := core.NewEVMContext(msg, header, c.les.blockchain, nil)
+	evm := vm.NewEVM(context, statedb, c.les.chainConfig, vm.Config{})
+
+	ret, _, _, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(math.MaxUint64))
+	if err := statedb.Error(); err != nil {
+		return nil, err
+	}
+	return ret, err
+}
+
+// checkpointOracle periodically queries the on-chain checkpoint oracle through
+// the light client's verified state and adopts any newer checkpoint signed by
+// the oracle admins, so that the next sync can start from it instead of from
+// the hard-coded checkpoint.
+//
+// The oracle is read from the state of the local head, so it can't help with
+// the initial sync: a fresh client syncs from the hard-coded checkpoint first
+// and only picks up oracle checkpoints once its head passed their acceptance.
+type checkpointOracle struct {
+	address common.Address
+	reader  *checkpointoracle.CheckpointReader
+	chain   *light.LightChain
+
+	quit chan struct{}
+	wg   *sync.WaitGroup
+}
+
+// newCheckpointOracle creates a checkpoint oracle client for the contract at the
+// given address.
+func newCheckpointOracle(les *LightEthereum, address common.Address, quit chan struct{}, wg *sync.WaitGroup) (*checkpointOracle, error) {
+	reader, err := checkpointoracle.NewCheckpointReader(address, &lightContractCaller{les})
+	if err != nil {
+		return nil, err
+	}
+	return &checkpointOracle{
+		address: address,
+		reader:  reader,
+		chain:   les.blockchain,
+		quit:    quit,
+		wg:      wg,
+	}, nil
+}
+
+// start launches the periodic oracle queries.
+func (o *checkpointOracle) start() {
+	o.wg.Add(1)
+	go o.loop()
+}
+
+// loop queries the oracle in regular intervals until the client is stopped.
+func (o *checkpointOracle) loop() {
+	defer o.wg.Done()
+
+	ticker := time.NewTicker(oracleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			o.update()
+		case <-o.quit:
+			return
+		}
+	}
+}
+
+// update retrieves the latest checkpoint accepted by the oracle and adopts it if
+// it's newer than the one currently in use.
+func (o *checkpointOracle) update() {
+	ctx, cancel := context.WithTimeout(context.Background(), oracleCheckTimeout)
+	defer cancel()
+
+	cp, err := o.reader.LatestCheckpoint(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		log.Debug("Failed to query checkpoint oracle", "address", o.address, "err", err)
+		return
+	}
+	if cp == nil {
+		return
+	}
+	if current := o.chain.TrustedCheckpoint(); current != nil && cp.SectionIdx <= current.SectionIdx {
+		return
+	}
+	err = o.chain.AddTrustedCheckpoint(&light.TrustedCheckpoint{
+		Name:          "oracle",
+		SectionIdx:    cp.SectionIdx,
+		SectionHead:   cp.SectionHead,
+		CHTRoot:       cp.CHTRoot,
+		BloomTrieRoot: cp.BloomTrieRoot,
+	})
+	if err != nil {
+		log.Warn("Rejected oracle checkpoint", "section", cp.SectionIdx, "head", cp.SectionHead, "err", err)
+	}
+}
@@ -0,0 +1,324 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/les/flowcontrol"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	errUnknownTier     = errors.New("unknown client tier")
+	errInvalidTier     = errors.New("invalid client tier parameters")
+	errReservedTier    = errors.New("reserved client tier name")
+	errAlreadyAdmitted = errors.New("client is already connected")
+)
+
+// clientTiersKey is the database key the tier configuration is persisted under.
+var clientTiersKey = []byte("_lesClientTiers")
+
+// freeTier is the tier name reported for clients without an assigned tier.
+const freeTier = "free"
+
+// tieredClient is a connected client along with the capacity allocated to it.
+type tieredClient struct {
+	peer      *peer
+	tier      string // freeTier if the client has no tier assigned
+	params    *flowcontrol.ServerParams
+	connected mclock.AbsTime
+}
+
+// clientTiers assigns flow control parameters to LES clients based on their node
+// ID. Clients assigned to a tier are served with the tier's (usually higher)
+// buffer limit and recharge rate, while all other clients get the server's
+// default parameters. The sum of the recharge rates of connected clients can not
+// exceed the total capacity of the server: free clients are rejected if there is
+// no capacity left, and they are kicked if a tiered client needs their share.
+type clientTiers struct {
+	lock sync.Mutex
+	db   ethdb.Database // Database to persist the tier configuration into (nil = memory only)
+
+	defParams *flowcontrol.ServerParams            // Parameters of clients without a tier
+	tiers     map[string]*flowcontrol.ServerParams // Parameters of the configured tiers
+	assigned  map[discover.NodeID]string           // Tiers assigned to clients
+
+	clients        map[discover.NodeID]*tieredClient // Currently connected clients
+	capacity, used uint64                            // Total and allocated recharge capacity
+}
+
+// newClientTiers creates a client tier manager, loading any previously stored
+// configuration from the database. The given capacity is used unless one was
+// explicitly set before.
+func newClientTiers(db ethdb.Database, defParams *flowcontrol.ServerParams, capacity uint64) *clientTiers {
+	ct := &clientTiers{
+		db:        db,
+		defParams: defParams,
+		tiers:     make(map[string]*flowcontrol.ServerParams),
+		assigned:  make(map[discover.NodeID]string),
+		clients:   make(map[discover.NodeID]*tieredClient),
+		capacity:  capacity,
+	}
+	ct.load()
+	return ct
+}
+
+// clientTiersRLP is the persisted form of the tier configuration.
+type clientTiersRLP struct {
+	Tiers []struct {
+		Name                  string
+		BufLimit, MinRecharge uint64
+	}
+	Clients []struct {
+		ID   discover.NodeID
+		Tier string
+	}
+	Capacity uint64
+}
+
+// load retrieves the tier configuration from the database.
+func (ct *clientTiers) load() {
+	if ct.db == nil {
+		return
+	}
+	data, err := ct.db.Get(clientTiersKey)
+	if err != nil {
+		return
+	}
+	var enc clientTiersRLP
+	if err := rlp.DecodeBytes(data, &enc); err != nil {
+		log.Error("Failed to decode LES client tiers", "err", err)
+		return
+	}
+	for _, t := range enc.Tiers {
+		ct.tiers[t.Name] = &flowcontrol.ServerParams{BufLimit: t.BufLimit, MinRecharge: t.MinRecharge}
+	}
+	for _, c := range enc.Clients {
+		ct.assigned[c.ID] = c.Tier
+	}
+	if enc.Capacity != 0 {
+		ct.capacity = enc.Capacity
+	}
+}
+
+// store writes the tier configuration into the database. The lock is assumed
+// to be held by the caller.
+func (ct *clientTiers) store() {
+	if ct.db == nil {
+		return
+	}
+	var enc clientTiersRLP
+	for name, params := range ct.tiers {
+		enc.Tiers = append(enc.Tiers, struct {
+			Name                  string
+			BufLimit, MinRecharge uint64
+		}{name, params.BufLimit, params.MinRecharge})
+	}
+	for id, tier := range ct.assigned {
+		enc.Clients = append(enc.Clients, struct {
+			ID   discover.NodeID
+			Tier string
+		}{id, tier})
+	}
+	enc.Capacity = ct.capacity
+
+	data, err := rlp.EncodeToBytes(&enc)
+	if err != nil {
+		log.Error("Failed to encode LES client tiers", "err", err)
+		return
+	}
+	if err := ct.db.Put(clientTiersKey, data); err != nil {
+		log.Error("Failed to store LES client tiers", "err", err)
+	}
+}
+
+// tierOf returns the tier name and flow control parameters of a client. The lock
+// is assumed to be held by the caller.
+func (ct *clientTiers) tierOf(id discover.NodeID) (string, *flowcontrol.ServerParams) {
+	if name, ok := ct.assigned[id]; ok {
+		if params, ok := ct.tiers[name]; ok {
+			return name, params
+		}
+	}
+	return freeTier, ct.defParams
+}
+
+// prioritized returns whether the client with the given ID is assigned to a tier.
+func (ct *clientTiers) prioritized(id discover.NodeID) bool {
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+
+	tier, _ := ct.tierOf(id)
+	return tier != freeTier
+}
+
+// connect allocates capacity for a newly connected client and returns the flow
+// control parameters it should be served with. If there is not enough capacity
+// left, free clients are kicked to make room for tiered ones, while free clients
+// are rejected.
+func (ct *clientTiers) connect(p *peer) (*flowcontrol.ServerParams, error) {
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+
+	id := p.ID()
+	if _, ok := ct.clients[id]; ok {
+		return nil, errAlreadyAdmitted
+	}
+	tier, params := ct.tierOf(id)
+	if ct.used+params.MinRecharge > ct.capacity {
+		if tier == freeTier {
+			return nil, p2p.DiscTooManyPeers
+		}
+		// Kick the most recently connected free clients until the new one fits
+		free := ct.freeClients()
+		avail := ct.capacity - ct.used
+		if ct.used > ct.capacity {
+			avail = 0
+		}
+		kick := 0
+		for ; kick < len(free) && avail < params.MinRecharge; kick++ {
+			avail += free[kick].params.MinRecharge
+		}
+		if avail < params.MinRecharge {
+			return nil, p2p.DiscTooManyPeers
+		}
+		for _, c := range free[:kick] {
+			log.Debug("Kicking free LES client", "id", c.peer.id, "for", p.id, "tier", tier)
+			ct.release(c)
+			c.peer.Peer.Disconnect(p2p.DiscTooManyPeers)
+		}
+	}
+	ct.clients[id] = &tieredClient{peer: p, tier: tier, params: params, connected: mclock.Now()}
+	ct.used += params.MinRecharge
+	return params, nil
+}
+
+// disconnect releases the capacity allocated to a client.
+func (ct *clientTiers) disconnect(p *peer) {
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+
+	if c, ok := ct.clients[p.ID()]; ok && c.peer == p {
+		ct.release(c)
+	}
+}
+
+// freeClients returns the connected clients without a tier, the most recently
+// connected ones first. The lock is assumed to be held by the caller.
+func (ct *clientTiers) freeClients() []*tieredClient {
+	var free []*tieredClient
+	for _, c := range ct.clients {
+		if c.tier == freeTier {
+			free = append(free, c)
+		}
+	}
+	sort.Slice(free, func(i, j int) bool { return free[i].connected > free[j].connected })
+	return free
+}
+
+// release removes a connected client, freeing up its capacity. The lock is
+// assumed to be held by the caller.
+func (ct *clientTiers) release(c *tieredClient) {
+	delete(ct.clients, c.peer.ID())
+	ct.used -= c.params.MinRecharge
+}
+
+// setTier creates or updates a client tier. Connected clients keep their current
+// parameters until they reconnect.
+func (ct *clientTiers) setTier(name string, params flowcontrol.ServerParams) error {
+	if name == "" || name == freeTier {
+		return errReservedTier
+	}
+	if params.BufLimit == 0 || params.MinRecharge == 0 {
+		return errInvalidTier
+	}
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+
+	ct.tiers[name] = &params
+	ct.store()
+	return nil
+}
+
+// removeTier deletes a client tier along with all client assignments to it.
+func (ct *clientTiers) removeTier(name string) error {
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+
+	if _, ok := ct.tiers[name]; !ok {
+		return errUnknownTier
+	}
+	delete(ct.tiers, name)
+	for id, tier := range ct.assigned {
+		if tier == name {
+			delete(ct.assigned, id)
+		}
+	}
+	ct.store()
+	return nil
+}
+
+// assign sets the tier of a client. An empty tier name turns the client into a
+// free one. The change takes effect at the next connection of the client.
+func (ct *clientTiers) assign(id discover.NodeID, tier string) error {
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+
+	if tier == "" || tier == freeTier {
+		delete(ct.assigned, id)
+	} else {
+		if _, ok := ct.tiers[tier]; !ok {
+			return errUnknownTier
+		}
+		ct.assigned[id] = tier
+	}
+	ct.store()
+	return nil
+}
+
+// setCapacity changes the total recharge capacity of the server. If the new
+// capacity is lower than the currently allocated one, free clients are kicked
+// until the allocation fits or no free clients are left.
+func (ct *clientTiers) setCapacity(capacity uint64) error {
+	if capacity == 0 {
+		return fmt.Errorf("capacity must be positive")
+	}
+	ct.lock.Lock()
+	defer ct.lock.Unlock()
+
+	ct.capacity = capacity
+	if ct.used > ct.capacity {
+		for _, c := range ct.freeClients() {
+			if ct.used <= ct.capacity {
+				break
+			}
+			ct.release(c)
+			c.peer.Peer.Disconnect(p2p.DiscTooManyPeers)
+		}
+	}
+	ct.store()
+	return nil
+}
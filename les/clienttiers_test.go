@@ -0,0 +1,118 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/les/flowcontrol"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+func newTierTestPeer(i byte) *peer {
+	var id discover.NodeID
+	id[0] = i
+	return newPeer(lpv2, NetworkId, p2p.NewPeer(id, "tier-test", nil), nil)
+}
+
+// Tests that free clients are admitted up to the total capacity and that they
+// are kicked to make room for tiered clients.
+func TestClientTiersAdmission(t *testing.T) {
+	defParams := &flowcontrol.ServerParams{BufLimit: 100, MinRecharge: 1}
+	ct := newClientTiers(nil, defParams, 3)
+
+	if err := ct.setTier("gold", flowcontrol.ServerParams{BufLimit: 1000, MinRecharge: 2}); err != nil {
+		t.Fatalf("failed to create tier: %v", err)
+	}
+	if err := ct.setTier(freeTier, flowcontrol.ServerParams{BufLimit: 1000, MinRecharge: 2}); err != errReservedTier {
+		t.Fatalf("reserved tier error mismatch: have %v, want %v", err, errReservedTier)
+	}
+	if err := ct.assign(newTierTestPeer(100).ID(), "silver"); err != errUnknownTier {
+		t.Fatalf("unknown tier error mismatch: have %v, want %v", err, errUnknownTier)
+	}
+	// Fill up the capacity with free clients and ensure more are rejected
+	for i := byte(0); i < 3; i++ {
+		params, err := ct.connect(newTierTestPeer(i))
+		if err != nil {
+			t.Fatalf("free client %d: failed to connect: %v", i, err)
+		}
+		if *params != *defParams {
+			t.Fatalf("free client %d: params mismatch: have %v, want %v", i, params, defParams)
+		}
+	}
+	if _, err := ct.connect(newTierTestPeer(3)); err != p2p.DiscTooManyPeers {
+		t.Fatalf("overflowing free client error mismatch: have %v, want %v", err, p2p.DiscTooManyPeers)
+	}
+	// Connect a tiered client and ensure it kicks enough free ones
+	gold := newTierTestPeer(100)
+	if err := ct.assign(gold.ID(), "gold"); err != nil {
+		t.Fatalf("failed to assign tier: %v", err)
+	}
+	params, err := ct.connect(gold)
+	if err != nil {
+		t.Fatalf("tiered client failed to connect: %v", err)
+	}
+	if params.MinRecharge != 2 || params.BufLimit != 1000 {
+		t.Fatalf("tiered client params mismatch: have %v", params)
+	}
+	if len(ct.clients) != 2 || ct.used != 3 {
+		t.Fatalf("allocation mismatch: have %d clients using %d, want 2 using 3", len(ct.clients), ct.used)
+	}
+	// A second tiered client can't be admitted if kicking all free ones is not enough
+	silver := newTierTestPeer(101)
+	ct.assign(silver.ID(), "gold")
+	if _, err := ct.connect(silver); err != p2p.DiscTooManyPeers {
+		t.Fatalf("overflowing tiered client error mismatch: have %v, want %v", err, p2p.DiscTooManyPeers)
+	}
+	ct.disconnect(newTierTestPeer(0)) // not a connected peer instance, must not free capacity
+	if ct.used != 3 {
+		t.Fatalf("allocation mismatch after unknown disconnect: have %d, want 3", ct.used)
+	}
+	ct.disconnect(gold)
+	if ct.used != 1 {
+		t.Fatalf("allocation mismatch after disconnect: have %d, want 1", ct.used)
+	}
+	if _, err := ct.connect(silver); err != nil {
+		t.Fatalf("tiered client failed to connect: %v", err)
+	}
+}
+
+// Tests that the tier configuration survives a restart.
+func TestClientTiersPersistence(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	defParams := &flowcontrol.ServerParams{BufLimit: 100, MinRecharge: 1}
+
+	ct := newClientTiers(db, defParams, 10)
+	ct.setTier("gold", flowcontrol.ServerParams{BufLimit: 1000, MinRecharge: 5})
+	id := newTierTestPeer(1).ID()
+	ct.assign(id, "gold")
+	ct.setCapacity(20)
+
+	ct = newClientTiers(db, defParams, 10)
+	if ct.capacity != 20 {
+		t.Errorf("capacity mismatch: have %d, want 20", ct.capacity)
+	}
+	if tier, params := ct.tierOf(id); tier != "gold" || params.MinRecharge != 5 {
+		t.Errorf("tier mismatch: have %s/%v, want gold", tier, params)
+	}
+	ct.removeTier("gold")
+	if tier, _ := newClientTiers(db, defParams, 10).tierOf(id); tier != freeTier {
+		t.Errorf("tier mismatch after removal: have %s, want %s", tier, freeTier)
+	}
+}
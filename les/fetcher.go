@@ -387,6 +387,18 @@ func (f *lightFetcher) requestedID(reqID uint64) bool {
 	return ok
 }
 
+// trustedAnnounces returns the number of trusted servers that announced the
+// given head. Only used in ultra light client mode.
+func (f *lightFetcher) trustedAnnounces(hash common.Hash) int {
+	count := 0
+	for p, fp := range f.peers {
+		if p.trusted && fp.nodeByHash[hash] != nil {
+			count++
+		}
+	}
+	return count
+}
+
 // nextRequest selects the peer and announced head to be requested next, amount
 // to be downloaded starting from the head backwards is also returned
 func (f *lightFetcher) nextRequest() (*distReq, uint64) {
@@ -399,6 +411,9 @@ func (f *lightFetcher) nextRequest() (*distReq, uint64) {
 
 	for p, fp := range f.peers {
 		for hash, n := range fp.nodeByHash {
+			if f.pm.ulc != nil && f.trustedAnnounces(hash) < f.pm.ulc.minTrustedServers() {
+				continue
+			}
 			if !f.checkKnownNode(p, n) && !n.requested && (bestTd == nil || n.td.Cmp(bestTd) >= 0) {
 				amount := f.requestAmount(p, n)
 				if bestTd == nil || n.td.Cmp(bestTd) > 0 || amount < bestAmount {
@@ -425,6 +440,9 @@ func (f *lightFetcher) nextRequest() (*distReq, uint64) {
 			},
 			canSend: func(dp distPeer) bool {
 				p := dp.(*peer)
+				if f.pm.ulc != nil && !p.trusted {
+					return false
+				}
 				fp := f.peers[p]
 				return fp != nil && fp.nodeByHash[bestHash] != nil
 			},
@@ -446,6 +464,9 @@ func (f *lightFetcher) nextRequest() (*distReq, uint64) {
 			},
 			canSend: func(dp distPeer) bool {
 				p := dp.(*peer)
+				if f.pm.ulc != nil && !p.trusted {
+					return false
+				}
 				f.lock.Lock()
 				defer f.lock.Unlock()
 
@@ -54,6 +54,21 @@ func (peer *ClientNode) Remove(cm *ClientManager) {
 	cm.removeNode(peer.cmNode)
 }
 
+// Params returns the flow control parameters the client is served with.
+func (peer *ClientNode) Params() ServerParams {
+	return *peer.params
+}
+
+// BufferValue returns the current buffer value of the client, including the
+// amount recharged since the last request.
+func (peer *ClientNode) BufferValue() uint64 {
+	peer.lock.Lock()
+	defer peer.lock.Unlock()
+
+	peer.recalcBV(mclock.Now())
+	return peer.bufValue
+}
+
 func (peer *ClientNode) recalcBV(time mclock.AbsTime) {
 	dt := uint64(time - peer.lastTime)
 	if time < peer.lastTime {
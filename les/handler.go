@@ -102,6 +102,7 @@ type ProtocolManager struct {
 	odr         *LesOdr
 	server      *LesServer
 	serverPool  *serverPool
+	ulc         *ulc // Trusted server set in ultra light client mode, nil otherwise
 	lesTopic    discv5.Topic
 	reqDist     *requestDistributor
 	retriever   *retrieveManager
@@ -163,6 +164,9 @@ func NewProtocolManager(chainConfig *params.ChainConfig, lightSync bool, protoco
 				var entry *poolEntry
 				peer := manager.newPeer(int(version), networkId, p, rw)
 				if manager.serverPool != nil {
+					if manager.serverPool.isBanned(p.ID()) {
+						return p2p.DiscUselessPeer
+					}
 					addr := p.RemoteAddr().(*net.TCPAddr)
 					entry = manager.serverPool.connect(peer, addr.IP, uint16(addr.Port))
 				}
@@ -254,18 +258,31 @@ func (pm *ProtocolManager) Stop() {
 }
 
 func (pm *ProtocolManager) newPeer(pv int, nv uint64, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
-	return newPeer(pv, nv, p, newMeteredMsgWriter(rw))
+	peer := newPeer(pv, nv, p, newMeteredMsgWriter(rw))
+	peer.trusted = pm.ulc != nil && pm.ulc.isTrusted(p.ID())
+	return peer
 }
 
 // handle is the callback invoked to manage the life cycle of a les peer. When
 // this function terminates, the peer is disconnected.
 func (pm *ProtocolManager) handle(p *peer) error {
-	if pm.peers.Len() >= pm.maxPeers {
+	if pm.peers.Len() >= pm.maxPeers && (pm.server == nil || !pm.server.clientTiers.prioritized(p.ID())) {
 		return p2p.DiscTooManyPeers
 	}
 
 	p.Log().Debug("Light Ethereum peer connected", "name", p.Name())
 
+	// Allocate serving capacity for the client according to its tier
+	if pm.server != nil {
+		params, err := pm.server.clientTiers.connect(p)
+		if err != nil {
+			p.Log().Debug("Light Ethereum client rejected", "err", err)
+			return err
+		}
+		defer pm.server.clientTiers.disconnect(p)
+		p.fcParams = params
+	}
+
 	// Execute the LES handshake
 	var (
 		genesis = pm.blockchain.Genesis()
@@ -348,11 +365,11 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 		bufValue, _ := p.fcClient.AcceptRequest()
 		cost := costs.baseCost + reqCnt*costs.reqCost
-		if cost > pm.server.defParams.BufLimit {
-			cost = pm.server.defParams.BufLimit
+		if cost > p.fcParams.BufLimit {
+			cost = p.fcParams.BufLimit
 		}
 		if cost > bufValue {
-			recharge := time.Duration((cost - bufValue) * 1000000 / pm.server.defParams.MinRecharge)
+			recharge := time.Duration((cost - bufValue) * 1000000 / p.fcParams.MinRecharge)
 			p.Log().Error("Request came too early", "recharge", common.PrettyDuration(recharge))
 			return true
 		}
@@ -1074,6 +1091,9 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 
 		p.fcServer.GotReply(resp.ReqID, resp.BV)
+		if pm.txrelay != nil {
+			pm.txrelay.deliverStatus(p, resp.ReqID, resp.Status)
+		}
 
 	default:
 		p.Log().Trace("Received unknown message", "code", msg.Code)
@@ -1236,6 +1256,11 @@ func (pc *peerConnection) RequestHeadersByNumber(origin uint64, amount int, skip
 
 func (d *downloaderPeerNotify) registerPeer(p *peer) {
 	pm := (*ProtocolManager)(d)
+	// Ultra light clients don't verify the PoW of the headers, so never let the
+	// downloader fetch them from servers outside the trusted set
+	if pm.ulc != nil && !p.trusted {
+		return
+	}
 	pc := &peerConnection{
 		manager: pm,
 		peer:    p,
@@ -1245,5 +1270,8 @@ func (d *downloaderPeerNotify) registerPeer(p *peer) {
 
 func (d *downloaderPeerNotify) unregisterPeer(p *peer) {
 	pm := (*ProtocolManager)(d)
+	if pm.ulc != nil && !p.trusted {
+		return
+	}
 	pm.downloader.UnregisterPeer(p.id)
 }
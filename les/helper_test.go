@@ -192,6 +192,7 @@ func newTestProtocolManager(lightSync bool, blocks int, generator func(int, *cor
 		}
 
 		srv.fcManager = flowcontrol.NewClientManager(50, 10, 1000000000)
+		srv.clientTiers = newClientTiers(nil, srv.defParams, 1000)
 		srv.fcCostStats = newCostStats(nil)
 	}
 	pm.Start(1000)
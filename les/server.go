@@ -24,6 +24,7 @@ import (
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/contracts/checkpointoracle"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/eth"
@@ -34,6 +35,7 @@ import (
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/discv5"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 type LesServer struct {
@@ -42,6 +44,7 @@ type LesServer struct {
 	fcManager       *flowcontrol.ClientManager // nil if our node is client only
 	fcCostStats     *requestCostStats
 	defParams       *flowcontrol.ServerParams
+	clientTiers     *clientTiers
 	lesTopics       []discv5.Topic
 	privateKey      *ecdsa.PrivateKey
 	quitSync        chan struct{}
@@ -98,10 +101,59 @@ func NewLesServer(eth *eth.Ethereum, config *eth.Config) (*LesServer, error) {
 		MinRecharge: 50000,
 	}
 	srv.fcManager = flowcontrol.NewClientManager(uint64(config.LightServ), 10, 1000000000)
+	srv.clientTiers = newClientTiers(eth.ChainDb(), srv.defParams, srv.defParams.MinRecharge*uint64(config.LightPeers))
 	srv.fcCostStats = newCostStats(eth.ChainDb())
 	return srv, nil
 }
 
+// APIs returns the collection of RPC services the LES server offers.
+func (s *LesServer) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "les",
+			Version:   "1.0",
+			Service:   NewPrivateLightServerAPI(s),
+			Public:    false,
+		},
+	}
+}
+
+// checkpoint returns the post-processed trie roots of the given LES/2 section, or
+// nil if the section is not yet processed by both the CHT and BloomTrie indexers.
+func (s *LesServer) checkpoint(index uint64) *checkpointoracle.Checkpoint {
+	chtSections, _, _ := s.chtIndexer.Sections()
+	bloomTrieSections, _, _ := s.bloomTrieIndexer.Sections()
+
+	// The CHT indexer still uses the LES/1 section size, convert the index
+	chtIndexV1 := (index+1)*(light.CHTFrequencyClient/light.CHTFrequencyServer) - 1
+	if chtIndexV1 >= chtSections || index >= bloomTrieSections {
+		return nil
+	}
+	head := s.chtIndexer.SectionHead(chtIndexV1)
+	return &checkpointoracle.Checkpoint{
+		SectionIdx:    index,
+		SectionHead:   head,
+		CHTRoot:       light.GetChtV2Root(s.protocolManager.chainDb, index, head),
+		BloomTrieRoot: light.GetBloomTrieRoot(s.protocolManager.chainDb, index, s.bloomTrieIndexer.SectionHead(index)),
+	}
+}
+
+// latestCheckpoint returns the post-processed trie roots of the most recent LES/2
+// section processed by both the CHT and BloomTrie indexers, or nil if there is none.
+func (s *LesServer) latestCheckpoint() *checkpointoracle.Checkpoint {
+	chtSections, _, _ := s.chtIndexer.Sections()
+	bloomTrieSections, _, _ := s.bloomTrieIndexer.Sections()
+
+	sections := chtSections / (light.CHTFrequencyClient / light.CHTFrequencyServer)
+	if bloomTrieSections < sections {
+		sections = bloomTrieSections
+	}
+	if sections == 0 {
+		return nil
+	}
+	return s.checkpoint(sections - 1)
+}
+
 func (s *LesServer) Protocols() []p2p.Protocol {
 	return s.protocolManager.SubProtocols
 }
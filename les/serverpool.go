@@ -23,6 +23,7 @@ import (
 	"math"
 	"math/rand"
 	"net"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -89,6 +90,9 @@ const (
 	// initStatsWeight is used to initialize previously unknown peers with good
 	// statistics to give a chance to prove themselves
 	initStatsWeight = 1
+	// maxConnHistory is the number of recent connection attempts remembered for
+	// each server node
+	maxConnHistory = 10
 )
 
 // serverPool implements a pool for storing and selecting newly discovered and already
@@ -117,15 +121,23 @@ type serverPool struct {
 	knownSelect, newSelect     *weightedRandomSelect
 	knownSelected, newSelected int
 	fastDiscover               bool
+
+	pinned    map[discover.NodeID]*discover.Node // servers always dialed first, regardless of their stats
+	banned    map[discover.NodeID]struct{}       // servers never dialed or accepted
+	cfgPinned []*discover.Node                   // pinned servers from the node configuration
 }
 
-// newServerPool creates a new serverPool instance
-func newServerPool(db ethdb.Database, quit chan struct{}, wg *sync.WaitGroup) *serverPool {
+// newServerPool creates a new serverPool instance. The pinned servers are always
+// dialed first and reconnected to without considering their statistics.
+func newServerPool(db ethdb.Database, quit chan struct{}, wg *sync.WaitGroup, pinned []*discover.Node) *serverPool {
 	pool := &serverPool{
 		db:           db,
 		quit:         quit,
 		wg:           wg,
 		entries:      make(map[discover.NodeID]*poolEntry),
+		pinned:       make(map[discover.NodeID]*discover.Node),
+		banned:       make(map[discover.NodeID]struct{}),
+		cfgPinned:    pinned,
 		timeout:      make(chan *poolEntry, 1),
 		adjustStats:  make(chan poolStatAdjust, 100),
 		enableRetry:  make(chan *poolEntry, 1),
@@ -144,6 +156,14 @@ func (pool *serverPool) start(server *p2p.Server, topic discv5.Topic) {
 	pool.dbKey = append([]byte("serverPool/"), []byte(topic)...)
 	pool.wg.Add(1)
 	pool.loadNodes()
+	pool.loadOverrides()
+
+	pool.lock.Lock()
+	for _, node := range pool.cfgPinned {
+		pool.pinned[node.ID] = node
+		pool.findOrNewNode(node.ID, node.IP, node.TCP)
+	}
+	pool.lock.Unlock()
 
 	if pool.server.DiscV5 != nil {
 		pool.discSetPeriod = make(chan time.Duration, 1)
@@ -180,6 +200,7 @@ func (pool *serverPool) connect(p *peer, ip net.IP, port uint16) *poolEntry {
 		port:     port,
 		lastSeen: mclock.Now(),
 	}
+	entry.addConnRecord(addr, "connected")
 	entry.lastConnected = addr
 	entry.addr = make(map[string]*poolEntryAddress)
 	entry.addr[addr.strKey()] = addr
@@ -212,6 +233,14 @@ func (pool *serverPool) disconnect(entry *poolEntry) {
 	pool.lock.Lock()
 	defer pool.lock.Unlock()
 
+	if rec := entry.lastConnRecord(); rec != nil {
+		if entry.state == psRegistered {
+			rec.result = "disconnected"
+		} else {
+			rec.result = "handshakeFailed"
+		}
+		rec.duration = time.Since(rec.start)
+	}
 	if entry.state == psRegistered {
 		connTime := mclock.Now() - entry.regTime
 		connAdjust := float64(connTime) / float64(targetConnTime)
@@ -232,13 +261,22 @@ func (pool *serverPool) disconnect(entry *poolEntry) {
 	}
 
 	entry.state = psNotConnected
-	if entry.knownSelected {
+	pool.deselect(entry)
+	pool.setRetryDial(entry)
+	pool.connWg.Done()
+}
+
+// deselect decreases the count of the selection set a dialed or connected entry
+// was counted in.
+func (pool *serverPool) deselect(entry *poolEntry) {
+	switch {
+	case entry.pinnedSelected:
+		// pinned servers are dialed on top of the selection targets
+	case entry.knownSelected:
 		pool.knownSelected--
-	} else {
+	default:
 		pool.newSelected--
 	}
-	pool.setRetryDial(entry)
-	pool.connWg.Done()
 }
 
 const (
@@ -355,6 +393,8 @@ func (pool *serverPool) findOrNewNode(id discover.NodeID, ip net.IP, port uint16
 			addrSelect: *newWeightedRandomSelect(),
 			shortRetry: shortRetryCnt,
 		}
+		_, entry.pinned = pool.pinned[id]
+		_, entry.banned = pool.banned[id]
 		pool.entries[id] = entry
 		// initialize previously unknown peers with good statistics to give a chance to prove themselves
 		entry.connectStats.add(1, initStatsWeight)
@@ -398,6 +438,8 @@ func (pool *serverPool) loadNodes() {
 			"delay", fmt.Sprintf("%v/%v", time.Duration(e.delayStats.avg), e.delayStats.weight),
 			"response", fmt.Sprintf("%v/%v", time.Duration(e.responseStats.avg), e.responseStats.weight),
 			"timeout", fmt.Sprintf("%v/%v", e.timeoutStats.avg, e.timeoutStats.weight))
+		_, e.pinned = pool.pinned[e.id]
+		_, e.banned = pool.banned[e.id]
 		pool.entries[e.id] = e
 		pool.knownQueue.setLatest(e)
 		pool.knownSelect.update((*knownEntry)(e))
@@ -417,6 +459,183 @@ func (pool *serverPool) saveNodes() {
 	}
 }
 
+// overridesKey returns the database key the manual server overrides are stored under.
+func (pool *serverPool) overridesKey() []byte {
+	return append(append([]byte{}, pool.dbKey...), []byte("/overrides")...)
+}
+
+// poolOverrides is the persisted form of the manual pin and ban settings.
+type poolOverrides struct {
+	Pinned []string
+	Banned []discover.NodeID
+}
+
+// loadOverrides loads the manually pinned and banned servers from the database
+func (pool *serverPool) loadOverrides() {
+	enc, err := pool.db.Get(pool.overridesKey())
+	if err != nil {
+		return
+	}
+	var overrides poolOverrides
+	if err := rlp.DecodeBytes(enc, &overrides); err != nil {
+		log.Debug("Failed to decode server overrides", "err", err)
+		return
+	}
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	for _, url := range overrides.Pinned {
+		node, err := discover.ParseNode(url)
+		if err != nil {
+			log.Debug("Failed to parse pinned server", "url", url, "err", err)
+			continue
+		}
+		pool.pinned[node.ID] = node
+		pool.findOrNewNode(node.ID, node.IP, node.TCP).pinned = true
+	}
+	for _, id := range overrides.Banned {
+		pool.banned[id] = struct{}{}
+		if entry := pool.entries[id]; entry != nil {
+			entry.banned = true
+		}
+	}
+}
+
+// saveOverrides stores the manually pinned and banned servers into the database.
+// Servers pinned through the node configuration are not saved.
+func (pool *serverPool) saveOverrides() {
+	var overrides poolOverrides
+	for id, node := range pool.pinned {
+		configured := false
+		for _, n := range pool.cfgPinned {
+			if n.ID == id {
+				configured = true
+				break
+			}
+		}
+		if !configured {
+			overrides.Pinned = append(overrides.Pinned, node.String())
+		}
+	}
+	for id := range pool.banned {
+		overrides.Banned = append(overrides.Banned, id)
+	}
+	enc, err := rlp.EncodeToBytes(&overrides)
+	if err == nil {
+		pool.db.Put(pool.overridesKey(), enc)
+	}
+}
+
+// pin marks a server as trusted, making the pool always dial it first and
+// reconnect to it quickly after a disconnection. Pinning lifts any ban.
+func (pool *serverPool) pin(node *discover.Node) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	delete(pool.banned, node.ID)
+	pool.pinned[node.ID] = node
+
+	entry := pool.findOrNewNode(node.ID, node.IP, node.TCP)
+	entry.pinned, entry.banned = true, false
+	pool.saveOverrides()
+	pool.updateCheckDial(entry)
+}
+
+// unpin removes the trusted mark of a server, returning it to the regular
+// statistics based selection.
+func (pool *serverPool) unpin(id discover.NodeID) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	delete(pool.pinned, id)
+	if entry := pool.entries[id]; entry != nil {
+		entry.pinned = false
+		pool.updateCheckDial(entry)
+	}
+	pool.saveOverrides()
+}
+
+// ban prevents a server from being dialed or accepted, dropping it if currently
+// connected. Banning lifts any pin.
+func (pool *serverPool) ban(id discover.NodeID) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	delete(pool.pinned, id)
+	pool.banned[id] = struct{}{}
+	if entry := pool.entries[id]; entry != nil {
+		entry.pinned, entry.banned = false, true
+		pool.updateCheckDial(entry)
+		if entry.peer != nil && (entry.state == psConnected || entry.state == psRegistered) {
+			entry.peer.Peer.Disconnect(p2p.DiscUselessPeer)
+		}
+	}
+	pool.saveOverrides()
+}
+
+// unban lifts the ban of a server.
+func (pool *serverPool) unban(id discover.NodeID) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	delete(pool.banned, id)
+	if entry := pool.entries[id]; entry != nil {
+		entry.banned = false
+		pool.updateCheckDial(entry)
+	}
+	pool.saveOverrides()
+}
+
+// isBanned returns whether the given server has been banned manually.
+func (pool *serverPool) isBanned(id discover.NodeID) bool {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	_, banned := pool.banned[id]
+	return banned
+}
+
+// serverInfos returns the state, statistics and connection history of all
+// servers known by the pool, ordered by their score.
+func (pool *serverPool) serverInfos() []ServerInfo {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	infos := make([]ServerInfo, 0, len(pool.entries))
+	for id, e := range pool.entries {
+		info := ServerInfo{
+			ID:           id,
+			State:        poolStateNames[e.state],
+			Known:        e.known,
+			Pinned:       e.pinned,
+			Banned:       e.banned,
+			Score:        e.score(),
+			ConnectRatio: e.connectStats.recentAvg(),
+			BlockDelay:   time.Duration(e.delayStats.recentAvg()),
+			ResponseTime: time.Duration(e.responseStats.recentAvg()),
+			TimeoutRatio: e.timeoutStats.recentAvg(),
+		}
+		for key := range e.addr {
+			info.Addresses = append(info.Addresses, key)
+		}
+		sort.Strings(info.Addresses)
+		if e.lastConnected != nil {
+			info.Fails = e.lastConnected.fails
+		}
+		for _, rec := range e.history {
+			info.History = append(info.History, ServerConnRecord{
+				Address:  rec.address,
+				Start:    rec.start,
+				Duration: rec.duration,
+				Result:   rec.result,
+			})
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Score > infos[j].Score })
+	return infos
+}
+
 // removeEntry removes a pool entry when the entry count limit is reached.
 // Note that it is called by the new/known queues from which the entry has already
 // been removed so removing it from the queues is not necessary.
@@ -430,7 +649,9 @@ func (pool *serverPool) removeEntry(entry *poolEntry) {
 // setRetryDial starts the timer which will enable dialing a certain node again
 func (pool *serverPool) setRetryDial(entry *poolEntry) {
 	delay := longRetryDelay
-	if entry.shortRetry > 0 {
+	if entry.pinned {
+		delay = shortRetryDelay
+	} else if entry.shortRetry > 0 {
 		entry.shortRetry--
 		delay = shortRetryDelay
 	}
@@ -459,6 +680,19 @@ func (pool *serverPool) updateCheckDial(entry *poolEntry) {
 // checkDial checks if new dials can/should be made. It tries to select servers both
 // based on good statistics and recent discovery.
 func (pool *serverPool) checkDial() {
+	if pool.server == nil {
+		return // pool not started yet
+	}
+	// Dial the pinned servers first, on top of the selection targets
+	for id, node := range pool.pinned {
+		entry := pool.entries[id]
+		if entry == nil {
+			entry = pool.findOrNewNode(id, node.IP, node.TCP)
+		}
+		if !entry.delayedRetry {
+			pool.dial(entry, entry.known)
+		}
+	}
 	fillWithKnownSelects := !pool.fastDiscover
 	for pool.knownSelected < targetKnownSelect {
 		entry := pool.knownSelect.choose()
@@ -496,9 +730,13 @@ func (pool *serverPool) dial(entry *poolEntry, knownSelected bool) {
 	}
 	entry.state = psDialed
 	entry.knownSelected = knownSelected
-	if knownSelected {
+	entry.pinnedSelected = entry.pinned
+	switch {
+	case entry.pinnedSelected:
+		// pinned servers are dialed on top of the selection targets
+	case knownSelected:
 		pool.knownSelected++
-	} else {
+	default:
 		pool.newSelected++
 	}
 	addr := entry.addrSelect.choose().(*poolEntryAddress)
@@ -525,11 +763,8 @@ func (pool *serverPool) checkDialTimeout(entry *poolEntry) {
 	}
 	log.Debug("Dial timeout", "lesaddr", entry.id.String()+"@"+entry.dialed.strKey())
 	entry.state = psNotConnected
-	if entry.knownSelected {
-		pool.knownSelected--
-	} else {
-		pool.newSelected--
-	}
+	pool.deselect(entry)
+	entry.addConnRecord(entry.dialed, "dialTimeout")
 	entry.connectStats.add(0, 1)
 	entry.dialed.fails++
 	pool.setRetryDial(entry)
@@ -542,6 +777,14 @@ const (
 	psRegistered
 )
 
+// poolStateNames are the human readable names of the pool entry states.
+var poolStateNames = []string{
+	psNotConnected: "notConnected",
+	psDialed:       "dialed",
+	psConnected:    "connected",
+	psRegistered:   "registered",
+}
+
 // poolEntry represents a server node and stores its current state and statistics.
 type poolEntry struct {
 	peer                  *peer
@@ -561,10 +804,78 @@ type poolEntry struct {
 
 	delayedRetry bool
 	shortRetry   int
+
+	pinned, banned bool // manual overrides of the statistics based selection
+	pinnedSelected bool // entry has been dialed as a pinned server
+	history        []*poolConnRecord
+}
+
+// poolConnRecord is a recent connection attempt to a server node.
+type poolConnRecord struct {
+	address  string
+	start    time.Time
+	duration time.Duration
+	result   string // outcome of the attempt: connected, disconnected, handshakeFailed or dialTimeout
+}
+
+func (r *poolConnRecord) EncodeRLP(w io.Writer) error {
+	return rlp.Encode(w, []interface{}{r.address, uint64(r.start.UnixNano()), uint64(r.duration), r.result})
+}
+
+func (r *poolConnRecord) DecodeRLP(s *rlp.Stream) error {
+	var rec struct {
+		Address         string
+		Start, Duration uint64
+		Result          string
+	}
+	if err := s.Decode(&rec); err != nil {
+		return err
+	}
+	r.address = rec.Address
+	r.start = time.Unix(0, int64(rec.Start))
+	r.duration = time.Duration(rec.Duration)
+	r.result = rec.Result
+	return nil
+}
+
+// addConnRecord appends a new connection attempt to the history of the entry,
+// dropping the oldest one if the history is full.
+func (e *poolEntry) addConnRecord(addr *poolEntryAddress, result string) {
+	rec := &poolConnRecord{start: time.Now(), result: result}
+	if addr != nil {
+		rec.address = addr.strKey()
+	}
+	if len(e.history) == maxConnHistory {
+		copy(e.history, e.history[1:])
+		e.history = e.history[:maxConnHistory-1]
+	}
+	e.history = append(e.history, rec)
+}
+
+// lastConnRecord returns the latest connection attempt to the entry, if any.
+func (e *poolEntry) lastConnRecord() *poolConnRecord {
+	if len(e.history) == 0 {
+		return nil
+	}
+	return e.history[len(e.history)-1]
+}
+
+// score calculates the quality of a known server from its connection, response
+// time, block delay and timeout statistics. Scores are in the range of [0, 1].
+func (e *poolEntry) score() float64 {
+	fails := uint(0)
+	if e.lastConnected != nil {
+		fails = e.lastConnected.fails
+	}
+	return e.connectStats.recentAvg() * math.Exp(-float64(fails)*failDropLn-e.responseStats.recentAvg()/float64(responseScoreTC)-e.delayStats.recentAvg()/float64(delayScoreTC)) * math.Pow(1-e.timeoutStats.recentAvg(), timeoutPow)
 }
 
 func (e *poolEntry) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, []interface{}{e.id, e.lastConnected.ip, e.lastConnected.port, e.lastConnected.fails, &e.connectStats, &e.delayStats, &e.responseStats, &e.timeoutStats})
+	fields := []interface{}{e.id, e.lastConnected.ip, e.lastConnected.port, e.lastConnected.fails, &e.connectStats, &e.delayStats, &e.responseStats, &e.timeoutStats}
+	for _, rec := range e.history {
+		fields = append(fields, rec)
+	}
+	return rlp.Encode(w, fields)
 }
 
 func (e *poolEntry) DecodeRLP(s *rlp.Stream) error {
@@ -574,6 +885,7 @@ func (e *poolEntry) DecodeRLP(s *rlp.Stream) error {
 		Port                       uint16
 		Fails                      uint
 		CStat, DStat, RStat, TStat poolStats
+		History                    []*poolConnRecord `rlp:"tail"` // missing from entries saved before the history was kept
 	}
 	if err := s.Decode(&entry); err != nil {
 		return err
@@ -589,6 +901,7 @@ func (e *poolEntry) DecodeRLP(s *rlp.Stream) error {
 	e.delayStats = entry.DStat
 	e.responseStats = entry.RStat
 	e.timeoutStats = entry.TStat
+	e.history = entry.History
 	e.shortRetry = shortRetryCnt
 	e.known = true
 	return nil
@@ -599,7 +912,7 @@ type discoveredEntry poolEntry
 
 // Weight calculates random selection weight for newly discovered entries
 func (e *discoveredEntry) Weight() int64 {
-	if e.state != psNotConnected || e.delayedRetry {
+	if e.state != psNotConnected || e.delayedRetry || e.pinned || e.banned {
 		return 0
 	}
 	t := time.Duration(mclock.Now() - e.lastDiscovered)
@@ -615,10 +928,10 @@ type knownEntry poolEntry
 
 // Weight calculates random selection weight for known entries
 func (e *knownEntry) Weight() int64 {
-	if e.state != psNotConnected || !e.known || e.delayedRetry {
+	if e.state != psNotConnected || !e.known || e.delayedRetry || e.pinned || e.banned {
 		return 0
 	}
-	return int64(1000000000 * e.connectStats.recentAvg() * math.Exp(-float64(e.lastConnected.fails)*failDropLn-e.responseStats.recentAvg()/float64(responseScoreTC)-e.delayStats.recentAvg()/float64(delayScoreTC)) * math.Pow(1-e.timeoutStats.recentAvg(), timeoutPow))
+	return int64(1000000000 * (*poolEntry)(e).score())
 }
 
 // poolEntryAddress is a separate object because currently it is necessary to remember
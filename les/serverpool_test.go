@@ -0,0 +1,125 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func newOverrideTestPool(db ethdb.Database, pinned []*discover.Node) *serverPool {
+	pool := newServerPool(db, make(chan struct{}), new(sync.WaitGroup), pinned)
+	pool.dbKey = []byte("serverPool/test")
+	return pool
+}
+
+// Tests that manual pin and ban overrides are applied to the pool entries, that
+// they exclude the entries from random selection and that they are persisted.
+func TestServerPoolOverrides(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	var pinnedID, bannedID, cfgID discover.NodeID
+	pinnedID[0], bannedID[0], cfgID[0] = 1, 2, 3
+	cfgNode := discover.NewNode(cfgID, net.IP{127, 0, 0, 3}, 30303, 30303)
+
+	pool := newOverrideTestPool(db, []*discover.Node{cfgNode})
+	pool.pin(discover.NewNode(pinnedID, net.IP{127, 0, 0, 1}, 30303, 30303))
+	pool.lock.Lock()
+	pool.findOrNewNode(bannedID, net.IP{127, 0, 0, 2}, 30303)
+	pool.lock.Unlock()
+	pool.ban(bannedID)
+
+	if !pool.isBanned(bannedID) || pool.isBanned(pinnedID) {
+		t.Fatalf("ban state mismatch")
+	}
+	for _, id := range []discover.NodeID{pinnedID, bannedID} {
+		if w := (*discoveredEntry)(pool.entries[id]).Weight(); w != 0 {
+			t.Errorf("entry %x: selection weight mismatch: have %d, want 0", id[:1], w)
+		}
+	}
+	// Reload the overrides and ensure the configured pin is not persisted
+	pool = newOverrideTestPool(db, nil)
+	pool.loadOverrides()
+	if e := pool.entries[pinnedID]; e == nil || !e.pinned {
+		t.Errorf("pinned server not restored")
+	}
+	if _, ok := pool.pinned[cfgID]; ok {
+		t.Errorf("configured pinned server persisted")
+	}
+	if !pool.isBanned(bannedID) {
+		t.Errorf("banned server not restored")
+	}
+	// Pinning a banned server lifts the ban
+	pool.pin(discover.NewNode(bannedID, net.IP{127, 0, 0, 2}, 30303, 30303))
+	if pool.isBanned(bannedID) || !pool.entries[bannedID].pinned {
+		t.Errorf("pinning did not lift the ban")
+	}
+	pool.unpin(bannedID)
+	pool.unpin(pinnedID)
+	if infos := pool.serverInfos(); len(infos) != 2 || infos[0].Pinned || infos[1].Pinned {
+		t.Errorf("server infos mismatch: %+v", infos)
+	}
+}
+
+// Tests that the connection history of the servers is persisted along with their
+// statistics, and that entries saved without a history can still be loaded.
+func TestServerPoolHistoryPersistence(t *testing.T) {
+	var id discover.NodeID
+	id[0] = 1
+
+	entry := &poolEntry{
+		id:            id,
+		lastConnected: &poolEntryAddress{ip: net.IP{127, 0, 0, 1}, port: 30303},
+	}
+	entry.addConnRecord(entry.lastConnected, "connected")
+	entry.lastConnRecord().duration = time.Minute
+	entry.lastConnRecord().result = "disconnected"
+	entry.addConnRecord(entry.lastConnected, "dialTimeout")
+
+	enc, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		t.Fatalf("failed to encode entry: %v", err)
+	}
+	loaded := new(poolEntry)
+	if err := rlp.DecodeBytes(enc, loaded); err != nil {
+		t.Fatalf("failed to decode entry: %v", err)
+	}
+	if len(loaded.history) != len(entry.history) {
+		t.Fatalf("history length mismatch: have %d, want %d", len(loaded.history), len(entry.history))
+	}
+	for i, rec := range loaded.history {
+		want := entry.history[i]
+		if rec.address != want.address || !rec.start.Equal(want.start) || rec.duration != want.duration || rec.result != want.result {
+			t.Errorf("record %d mismatch: have %+v, want %+v", i, rec, want)
+		}
+	}
+	// Entries saved before the history was kept decode without one
+	legacy, _ := rlp.EncodeToBytes([]interface{}{id, net.IP{127, 0, 0, 1}, uint16(30303), uint(0), &entry.connectStats, &entry.delayStats, &entry.responseStats, &entry.timeoutStats})
+	loaded = new(poolEntry)
+	if err := rlp.DecodeBytes(legacy, loaded); err != nil {
+		t.Fatalf("failed to decode legacy entry: %v", err)
+	}
+	if len(loaded.history) != 0 {
+		t.Errorf("legacy entry history mismatch: have %d records, want none", len(loaded.history))
+	}
+}
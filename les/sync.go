@@ -71,6 +71,10 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 	if peer == nil {
 		return
 	}
+	// Ultra light clients only sync from their trusted servers
+	if pm.ulc != nil && !peer.trusted {
+		return
+	}
 
 	// Make sure the peer's TD is higher than our own.
 	if !pm.needToSync(peer.headBlockInfo()) {
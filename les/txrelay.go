@@ -17,20 +17,53 @@
 package les
 
 import (
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
+const (
+	// txResendTimeout is the time after which a pending transaction that has not
+	// been mined yet is sent to another server.
+	txResendTimeout = time.Minute
+
+	// txAckTimeout is the time after which an unanswered send request is dropped.
+	txAckTimeout = time.Minute
+)
+
 type ltrInfo struct {
-	tx     *types.Transaction
-	sentTo map[*peer]struct{}
+	tx       *types.Transaction
+	sentTo   map[*peer]struct{}
+	ackedBy  map[*peer]core.TxStatus // pool status reported by the servers (LES/2 only)
+	rejected map[*peer]string        // errors reported by the servers (LES/2 only)
+	lastSent time.Time
+	sends    int
+}
+
+// ltrRequest is a send request awaiting the tx status reply of a server.
+type ltrRequest struct {
+	peer   *peer
+	hashes []common.Hash
+	sent   time.Time
+}
+
+// TxRelayStatus is the relay state of a single transaction.
+type TxRelayStatus struct {
+	SentTo   []string          // Servers the transaction was sent to
+	AckedBy  []string          // Servers that confirmed having pooled or included the transaction
+	Rejected map[string]string // Servers that refused the transaction, with their reasons
+	LastSent time.Time         // Time of the latest (re)submission
+	Sends    int               // Number of send rounds including resubmissions
 }
 
 type LesTxRelay struct {
 	txSent       map[common.Hash]*ltrInfo
 	txPending    map[common.Hash]struct{}
+	reqSent      map[uint64]*ltrRequest
 	ps           *peerSet
 	peerList     []*peer
 	peerStartPos int
@@ -43,6 +76,7 @@ func NewLesTxRelay(ps *peerSet, reqDist *requestDistributor) *LesTxRelay {
 	r := &LesTxRelay{
 		txSent:    make(map[common.Hash]*ltrInfo),
 		txPending: make(map[common.Hash]struct{}),
+		reqSent:   make(map[uint64]*ltrRequest),
 		ps:        ps,
 		reqDist:   reqDist,
 	}
@@ -65,9 +99,11 @@ func (self *LesTxRelay) unregisterPeer(p *peer) {
 }
 
 // send sends a list of transactions to at most a given number of peers at
-// once, never resending any particular transaction to the same peer twice
+// once, preferring the peers a particular transaction was not sent to yet. If
+// it was sent to all of them already, it is resubmitted to the earlier ones.
 func (self *LesTxRelay) send(txs types.Transactions, count int) {
 	sendTo := make(map[*peer]types.Transactions)
+	now := time.Now()
 
 	self.peerStartPos++ // rotate the starting position of the peer list
 	if self.peerStartPos >= len(self.peerList) {
@@ -79,34 +115,28 @@ func (self *LesTxRelay) send(txs types.Transactions, count int) {
 		ltr, ok := self.txSent[hash]
 		if !ok {
 			ltr = &ltrInfo{
-				tx:     tx,
-				sentTo: make(map[*peer]struct{}),
+				tx:       tx,
+				sentTo:   make(map[*peer]struct{}),
+				ackedBy:  make(map[*peer]core.TxStatus),
+				rejected: make(map[*peer]string),
 			}
 			self.txSent[hash] = ltr
 			self.txPending[hash] = struct{}{}
 		}
 
-		if len(self.peerList) > 0 {
-			cnt := count
-			pos := self.peerStartPos
-			for {
-				peer := self.peerList[pos]
-				if _, ok := ltr.sentTo[peer]; !ok {
-					sendTo[peer] = append(sendTo[peer], tx)
-					ltr.sentTo[peer] = struct{}{}
-					cnt--
-				}
-				if cnt == 0 {
-					break // sent it to the desired number of peers
-				}
-				pos++
-				if pos == len(self.peerList) {
-					pos = 0
-				}
-				if pos == self.peerStartPos {
-					break // tried all available peers
-				}
-			}
+		peers := self.selectPeers(ltr, count, false)
+		if len(peers) == 0 && ltr.sends > 0 {
+			// All available peers were tried already, but they might have dropped
+			// the transaction since (e.g. evicted it or lost it in a reorg)
+			peers = self.selectPeers(ltr, count, true)
+		}
+		for _, peer := range peers {
+			sendTo[peer] = append(sendTo[peer], tx)
+			ltr.sentTo[peer] = struct{}{}
+		}
+		if len(peers) > 0 {
+			ltr.lastSent = now
+			ltr.sends++
 		}
 	}
 
@@ -115,6 +145,13 @@ func (self *LesTxRelay) send(txs types.Transactions, count int) {
 		ll := list
 
 		reqID := genReqID()
+		if pp.version >= lpv2 {
+			hashes := make([]common.Hash, len(ll))
+			for i, tx := range ll {
+				hashes[i] = tx.Hash()
+			}
+			self.reqSent[reqID] = &ltrRequest{peer: pp, hashes: hashes, sent: now}
+		}
 		rq := &distReq{
 			getCost: func(dp distPeer) uint64 {
 				peer := dp.(*peer)
@@ -134,6 +171,21 @@ func (self *LesTxRelay) send(txs types.Transactions, count int) {
 	}
 }
 
+// selectPeers picks at most count peers to send a transaction to, starting at
+// the current rotating position of the peer list. Peers the transaction was
+// sent to already are only picked if resend is set.
+func (self *LesTxRelay) selectPeers(ltr *ltrInfo, count int, resend bool) []*peer {
+	var peers []*peer
+	for i := 0; i < len(self.peerList) && len(peers) < count; i++ {
+		peer := self.peerList[(self.peerStartPos+i)%len(self.peerList)]
+		if _, ok := ltr.sentTo[peer]; ok && !resend {
+			continue
+		}
+		peers = append(peers, peer)
+	}
+	return peers
+}
+
 func (self *LesTxRelay) Send(txs types.Transactions) {
 	self.lock.Lock()
 	defer self.lock.Unlock()
@@ -153,15 +205,76 @@ func (self *LesTxRelay) NewHead(head common.Hash, mined []common.Hash, rollback
 		self.txPending[hash] = struct{}{}
 	}
 
-	if len(self.txPending) > 0 {
-		txs := make(types.Transactions, len(self.txPending))
-		i := 0
-		for hash := range self.txPending {
-			txs[i] = self.txSent[hash].tx
-			i++
+	// Resend the transactions that were not mined in time to another server
+	now := time.Now()
+	var txs types.Transactions
+	for hash := range self.txPending {
+		if ltr := self.txSent[hash]; now.Sub(ltr.lastSent) >= txResendTimeout {
+			txs = append(txs, ltr.tx)
 		}
+	}
+	if len(txs) > 0 {
 		self.send(txs, 1)
 	}
+	// Drop the send requests the servers never answered
+	for reqID, req := range self.reqSent {
+		if now.Sub(req.sent) >= txAckTimeout {
+			delete(self.reqSent, reqID)
+		}
+	}
+}
+
+// deliverStatus processes the tx status reply of a server to an earlier send
+// request, recording which transactions it accepted or rejected.
+func (self *LesTxRelay) deliverStatus(p *peer, reqID uint64, stats []txStatus) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	req, ok := self.reqSent[reqID]
+	if !ok || req.peer != p {
+		return
+	}
+	delete(self.reqSent, reqID)
+
+	for i, hash := range req.hashes {
+		ltr, ok := self.txSent[hash]
+		if !ok || i >= len(stats) {
+			continue
+		}
+		if stats[i].Status == core.TxStatusUnknown {
+			ltr.rejected[p] = stats[i].Error
+		} else {
+			ltr.ackedBy[p] = stats[i].Status
+		}
+	}
+}
+
+// Status returns the relay state of a transaction, or nil if it is unknown.
+func (self *LesTxRelay) Status(hash common.Hash) *TxRelayStatus {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+
+	ltr, ok := self.txSent[hash]
+	if !ok {
+		return nil
+	}
+	status := &TxRelayStatus{
+		Rejected: make(map[string]string),
+		LastSent: ltr.lastSent,
+		Sends:    ltr.sends,
+	}
+	for p := range ltr.sentTo {
+		status.SentTo = append(status.SentTo, p.id)
+	}
+	for p := range ltr.ackedBy {
+		status.AckedBy = append(status.AckedBy, p.id)
+	}
+	for p, err := range ltr.rejected {
+		status.Rejected[p.id] = err
+	}
+	sort.Strings(status.SentTo)
+	sort.Strings(status.AckedBy)
+	return status
 }
 
 func (self *LesTxRelay) Discard(hashes []common.Hash) {
@@ -0,0 +1,111 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"math/big"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// queuedTxSends drains the send requests queued in the distributor, returning
+// the ids of the peers they are addressed to.
+func queuedTxSends(dist *requestDistributor, peers []*peer) []string {
+	dist.lock.Lock()
+	defer dist.lock.Unlock()
+
+	var ids []string
+	for el := dist.reqQueue.Front(); el != nil; el = el.Next() {
+		for _, p := range peers {
+			if el.Value.(*distReq).canSend(p) {
+				ids = append(ids, p.id)
+			}
+		}
+	}
+	dist.reqQueue.Init()
+	sort.Strings(ids)
+	return ids
+}
+
+// Tests that transactions not mined in time are resubmitted, preferring servers
+// they were not sent to yet, but falling back to the earlier ones if there are
+// no others left.
+func TestTxRelayResend(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	var (
+		dist  = newRequestDistributor(nil, stop)
+		relay = NewLesTxRelay(newPeerSet(), dist)
+		peers = []*peer{{id: "a", version: lpv1}, {id: "b", version: lpv1}, {id: "c", version: lpv1}}
+		tx    = types.NewTransaction(0, common.Address{}, big.NewInt(1), 21000, big.NewInt(1), nil)
+		hash  = tx.Hash()
+	)
+	relay.peerList = peers[:2]
+
+	// expire pretends the resend timeout of the transaction passed, and triggers
+	// the relay with a new head.
+	expire := func(mined, rollback []common.Hash) {
+		relay.lock.Lock()
+		relay.txSent[hash].lastSent = time.Now().Add(-txResendTimeout)
+		relay.lock.Unlock()
+
+		relay.NewHead(common.Hash{}, mined, rollback)
+	}
+	// check verifies the servers the transaction was just sent to (or only their
+	// count if they are not deterministic) and the number of send rounds.
+	check := func(step string, sent []string, count int, sends int) {
+		have := queuedTxSends(dist, relay.peerList)
+		if len(have) != count || (sent != nil && !reflect.DeepEqual(have, sent)) {
+			t.Errorf("%s: sent to mismatch: have %v, want %v (%d servers)", step, have, sent, count)
+		}
+		if have := relay.Status(hash).Sends; have != sends {
+			t.Errorf("%s: send rounds mismatch: have %d, want %d", step, have, sends)
+		}
+	}
+	// Initial send reaches all available servers, nothing happens before the timeout
+	relay.Send(types.Transactions{tx})
+	check("initial send", []string{"a", "b"}, 2, 1)
+
+	relay.NewHead(common.Hash{}, nil, nil)
+	check("before timeout", nil, 0, 1)
+
+	// All servers were tried, resubmit to one of them after the timeout
+	expire(nil, nil)
+	check("resubmission", nil, 1, 2)
+
+	// A new server connected, which should be preferred over the earlier ones
+	relay.peerList = peers
+	expire(nil, nil)
+	check("new server", []string{"c"}, 1, 3)
+
+	// Mined transactions are not resent, but rolled back ones are
+	expire([]common.Hash{hash}, nil)
+	check("mined", nil, 0, 3)
+
+	expire(nil, []common.Hash{hash})
+	check("rollback", nil, 1, 4)
+
+	if have := relay.Status(hash).SentTo; !reflect.DeepEqual(have, []string{"a", "b", "c"}) {
+		t.Errorf("servers mismatch: have %v, want %v", have, []string{"a", "b", "c"})
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+// ulc holds the trusted server set of an ultra light client. Ultra light clients
+// request signed announcements from the trusted servers and accept a head without
+// proof-of-work verification once enough of them announced it.
+type ulc struct {
+	servers            []*discover.Node
+	trusted            map[discover.NodeID]struct{}
+	minTrustedFraction int
+}
+
+// newULC parses the trusted servers of an ultra light client configuration.
+func newULC(config *eth.ULCConfig) (*ulc, error) {
+	fraction := config.MinTrustedFraction
+	if fraction == 0 {
+		fraction = eth.DefaultULCMinTrustedFraction
+	}
+	if fraction < 0 || fraction > 100 {
+		return nil, fmt.Errorf("invalid trusted server fraction %d%%", fraction)
+	}
+	if len(config.TrustedServers) == 0 {
+		return nil, fmt.Errorf("no trusted servers configured")
+	}
+	u := &ulc{
+		trusted:            make(map[discover.NodeID]struct{}),
+		minTrustedFraction: fraction,
+	}
+	for _, url := range config.TrustedServers {
+		node, err := discover.ParseNode(url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted server %q: %v", url, err)
+		}
+		if _, ok := u.trusted[node.ID]; ok {
+			continue
+		}
+		u.servers = append(u.servers, node)
+		u.trusted[node.ID] = struct{}{}
+	}
+	return u, nil
+}
+
+// isTrusted returns whether the given server is in the trusted set.
+func (u *ulc) isTrusted(id discover.NodeID) bool {
+	_, ok := u.trusted[id]
+	return ok
+}
+
+// minTrustedServers returns the number of trusted servers that need to announce
+// a head for it to be accepted.
+func (u *ulc) minTrustedServers() int {
+	return (len(u.trusted)*u.minTrustedFraction + 99) / 100
+}
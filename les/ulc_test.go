@@ -0,0 +1,142 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/light"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+// testULCServers generates n random trusted server enode URLs.
+func testULCServers(t *testing.T, n int) ([]string, []discover.NodeID) {
+	var (
+		urls []string
+		ids  []discover.NodeID
+	)
+	for i := 0; i < n; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		id := discover.PubkeyID(&key.PublicKey)
+		urls = append(urls, fmt.Sprintf("enode://%x@127.0.0.1:%d", id[:], 30303+i))
+		ids = append(ids, id)
+	}
+	return urls, ids
+}
+
+// Tests that the trusted server set of an ultra light client is parsed correctly
+// and invalid configurations are rejected.
+func TestULCConfig(t *testing.T) {
+	urls, ids := testULCServers(t, 4)
+
+	// Duplicate servers should be counted once, the default fraction applied
+	u, err := newULC(&eth.ULCConfig{TrustedServers: append(urls, urls[0])})
+	if err != nil {
+		t.Fatalf("failed to create ulc: %v", err)
+	}
+	if len(u.servers) != len(urls) {
+		t.Errorf("server count mismatch: have %d, want %d", len(u.servers), len(urls))
+	}
+	for _, id := range ids {
+		if !u.isTrusted(id) {
+			t.Errorf("server %x not trusted", id[:8])
+		}
+	}
+	if u.isTrusted(discover.NodeID{}) {
+		t.Errorf("unknown server trusted")
+	}
+	if n := u.minTrustedServers(); n != 3 {
+		t.Errorf("min trusted servers mismatch: have %d, want %d", n, 3)
+	}
+	// Custom fractions should round upwards
+	tests := []struct {
+		fraction int
+		want     int
+	}{
+		{1, 1}, {25, 1}, {26, 2}, {50, 2}, {100, 4},
+	}
+	for _, tt := range tests {
+		u, err := newULC(&eth.ULCConfig{TrustedServers: urls, MinTrustedFraction: tt.fraction})
+		if err != nil {
+			t.Fatalf("fraction %d: failed to create ulc: %v", tt.fraction, err)
+		}
+		if n := u.minTrustedServers(); n != tt.want {
+			t.Errorf("fraction %d: min trusted servers mismatch: have %d, want %d", tt.fraction, n, tt.want)
+		}
+	}
+	// Invalid configurations should be rejected
+	invalid := []*eth.ULCConfig{
+		{},
+		{TrustedServers: urls, MinTrustedFraction: -1},
+		{TrustedServers: urls, MinTrustedFraction: 101},
+		{TrustedServers: []string{"enode://invalid"}},
+	}
+	for i, config := range invalid {
+		if _, err := newULC(config); err == nil {
+			t.Errorf("config %d: invalid config accepted", i)
+		}
+	}
+}
+
+// Tests that an ultra light client, which doesn't verify the PoW of the headers,
+// never syncs from servers outside its trusted set.
+func TestULCSyncUntrustedServer(t *testing.T) {
+	urls, _ := testULCServers(t, 2)
+	trusted, err := newULC(&eth.ULCConfig{TrustedServers: urls})
+	if err != nil {
+		t.Fatalf("failed to create ulc: %v", err)
+	}
+	// The server's chain is generated without PoW, so its headers would all be
+	// rejected by a verifying client
+	for _, ulc := range []*ulc{nil, trusted} {
+		peers := newPeerSet()
+		dist := newRequestDistributor(peers, make(chan struct{}))
+		rm := newRetrieveManager(peers, dist, nil)
+		db, _ := ethdb.NewMemDatabase()
+		ldb, _ := ethdb.NewMemDatabase()
+		odr := NewLesOdr(ldb, light.NewChtIndexer(db, true), light.NewBloomTrieIndexer(db, true), eth.NewBloomIndexer(db, light.BloomTrieFrequency), rm)
+		pm := newTestProtocolManagerMust(t, false, 4, testChainGen, nil, nil, db)
+		lpm := newTestProtocolManagerMust(t, true, 0, nil, peers, odr, ldb)
+		lpm.ulc = ulc
+
+		_, err1, lpeer, err2 := newTestPeerPair("peer", lpv2, pm, lpm)
+		select {
+		case <-time.After(time.Millisecond * 100):
+		case err := <-err1:
+			t.Fatalf("server handshake error: %v", err)
+		case err := <-err2:
+			t.Fatalf("client handshake error: %v", err)
+		}
+		lpm.synchronise(lpeer)
+
+		want := uint64(4)
+		if ulc != nil {
+			want = 0
+		}
+		if head := lpm.blockchain.CurrentHeader().Number.Uint64(); head != want {
+			t.Errorf("ulc %v: head mismatch: have %d, want %d", ulc != nil, head, want)
+		}
+	}
+}
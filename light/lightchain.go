@@ -54,6 +54,7 @@ type LightChain struct {
 	chainHeadFeed event.Feed
 	scope         event.SubscriptionScope
 	genesisBlock  *types.Block
+	checkpoint    *TrustedCheckpoint // Most recent trusted checkpoint in use (nil = none)
 
 	mu      sync.RWMutex
 	chainmu sync.RWMutex
@@ -75,6 +76,9 @@ type LightChain struct {
 // available in the database. It initialises the default Ethereum header
 // validator.
 func NewLightChain(odr OdrBackend, config *params.ChainConfig, engine consensus.Engine) (*LightChain, error) {
+	if err := core.CheckChainConfig(config); err != nil {
+		return nil, err
+	}
 	bodyCache, _ := lru.New(bodyCacheLimit)
 	bodyRLPCache, _ := lru.New(bodyCacheLimit)
 	blockCache, _ := lru.New(blockCacheLimit)
@@ -97,8 +101,13 @@ func NewLightChain(odr OdrBackend, config *params.ChainConfig, engine consensus.
 	if bc.genesisBlock == nil {
 		return nil, core.ErrNoGenesis
 	}
-	if cp, ok := trustedCheckpoints[bc.genesisBlock.Hash()]; ok {
-		bc.addTrustedCheckpoint(cp)
+	// Start from the hard-coded checkpoint, unless a newer one was adopted before
+	cp, ok := trustedCheckpoints[bc.genesisBlock.Hash()]
+	if stored := readTrustedCheckpoint(bc.chainDb); stored != nil && (!ok || stored.SectionIdx > cp.SectionIdx) {
+		cp, ok = *stored, true
+	}
+	if ok {
+		bc.addTrustedCheckpoint(&cp)
 	}
 	if err := bc.loadLastState(); err != nil {
 		return nil, err
@@ -115,19 +124,48 @@ func NewLightChain(odr OdrBackend, config *params.ChainConfig, engine consensus.
 }
 
 // addTrustedCheckpoint adds a trusted checkpoint to the blockchain
-func (self *LightChain) addTrustedCheckpoint(cp trustedCheckpoint) {
+func (self *LightChain) addTrustedCheckpoint(cp *TrustedCheckpoint) {
 	if self.odr.ChtIndexer() != nil {
-		StoreChtRoot(self.chainDb, cp.sectionIdx, cp.sectionHead, cp.chtRoot)
-		self.odr.ChtIndexer().AddKnownSectionHead(cp.sectionIdx, cp.sectionHead)
+		StoreChtRoot(self.chainDb, cp.SectionIdx, cp.SectionHead, cp.CHTRoot)
+		self.odr.ChtIndexer().AddKnownSectionHead(cp.SectionIdx, cp.SectionHead)
 	}
 	if self.odr.BloomTrieIndexer() != nil {
-		StoreBloomTrieRoot(self.chainDb, cp.sectionIdx, cp.sectionHead, cp.bloomTrieRoot)
-		self.odr.BloomTrieIndexer().AddKnownSectionHead(cp.sectionIdx, cp.sectionHead)
+		StoreBloomTrieRoot(self.chainDb, cp.SectionIdx, cp.SectionHead, cp.BloomTrieRoot)
+		self.odr.BloomTrieIndexer().AddKnownSectionHead(cp.SectionIdx, cp.SectionHead)
 	}
 	if self.odr.BloomIndexer() != nil {
-		self.odr.BloomIndexer().AddKnownSectionHead(cp.sectionIdx, cp.sectionHead)
+		self.odr.BloomIndexer().AddKnownSectionHead(cp.SectionIdx, cp.SectionHead)
+	}
+	self.checkpoint = cp
+	log.Info("Added trusted checkpoint", "chain", cp.Name, "block", (cp.SectionIdx+1)*CHTFrequencyClient-1, "hash", cp.SectionHead)
+}
+
+// AddTrustedCheckpoint adds a checkpoint obtained from an external source (e.g.
+// an on-chain checkpoint oracle) to the blockchain and persists it, so that it
+// is also used after a restart. Checkpoints not newer than the current one and
+// ones contradicting the locally known canonical chain are rejected.
+func (self *LightChain) AddTrustedCheckpoint(cp *TrustedCheckpoint) error {
+	self.chainmu.Lock()
+	defer self.chainmu.Unlock()
+
+	if self.checkpoint != nil && cp.SectionIdx <= self.checkpoint.SectionIdx {
+		return ErrStaleCheckpoint
+	}
+	if header := self.GetHeaderByNumber((cp.SectionIdx+1)*CHTFrequencyClient - 1); header != nil && header.Hash() != cp.SectionHead {
+		return ErrCheckpointMismatch
 	}
-	log.Info("Added trusted checkpoint", "chain", cp.name, "block", (cp.sectionIdx+1)*CHTFrequencyClient-1, "hash", cp.sectionHead)
+	self.addTrustedCheckpoint(cp)
+	writeTrustedCheckpoint(self.chainDb, cp)
+	return nil
+}
+
+// TrustedCheckpoint returns the most recent trusted checkpoint in use, or nil
+// if there is none.
+func (self *LightChain) TrustedCheckpoint() *TrustedCheckpoint {
+	self.chainmu.RLock()
+	defer self.chainmu.RUnlock()
+
+	return self.checkpoint
 }
 
 func (self *LightChain) getProcInterrupt() bool {
@@ -275,6 +275,10 @@ func (odr *dummyOdr) Retrieve(ctx context.Context, req OdrRequest) error {
 	return nil
 }
 
+func (odr *dummyOdr) ChtIndexer() *core.ChainIndexer       { return nil }
+func (odr *dummyOdr) BloomTrieIndexer() *core.ChainIndexer { return nil }
+func (odr *dummyOdr) BloomIndexer() *core.ChainIndexer     { return nil }
+
 // Tests that reorganizing a long difficult chain after a short easy one
 // overwrites the canonical numbers and links in the database.
 func TestReorgLongHeaders(t *testing.T) {
@@ -346,3 +350,29 @@ func TestReorgBadHeaderHashes(t *testing.T) {
 		t.Errorf("last header hash mismatch: have: %x, want %x", ncm.CurrentHeader().Hash(), headers[2].Hash())
 	}
 }
+
+// Tests that externally added checkpoints are only accepted if newer than the
+// current one, and that they are reused after a restart.
+func TestTrustedCheckpointPersistence(t *testing.T) {
+	db, bc, err := newCanonical(0)
+	if err != nil {
+		t.Fatalf("failed to create light chain: %v", err)
+	}
+	if cp := bc.TrustedCheckpoint(); cp != nil {
+		t.Fatalf("unexpected initial checkpoint: %v", cp)
+	}
+	cp := &TrustedCheckpoint{Name: "test", SectionIdx: 3, SectionHead: common.Hash{1}, CHTRoot: common.Hash{2}, BloomTrieRoot: common.Hash{3}}
+	if err := bc.AddTrustedCheckpoint(cp); err != nil {
+		t.Fatalf("failed to add checkpoint: %v", err)
+	}
+	if err := bc.AddTrustedCheckpoint(&TrustedCheckpoint{SectionIdx: 3}); err != ErrStaleCheckpoint {
+		t.Fatalf("stale checkpoint error mismatch: have %v, want %v", err, ErrStaleCheckpoint)
+	}
+	bc, err = NewLightChain(&dummyOdr{db: db}, params.TestChainConfig, ethash.NewFaker())
+	if err != nil {
+		t.Fatalf("failed to reopen light chain: %v", err)
+	}
+	if have := bc.TrustedCheckpoint(); have == nil || *have != *cp {
+		t.Fatalf("checkpoint mismatch after restart: have %v, want %v", have, cp)
+	}
+}
@@ -46,43 +46,75 @@ const (
 	HelperTrieProcessConfirmations = 256  // number of confirmations before a HelperTrie is generated
 )
 
-// trustedCheckpoint represents a set of post-processed trie roots (CHT and BloomTrie) associated with
+// TrustedCheckpoint represents a set of post-processed trie roots (CHT and BloomTrie) associated with
 // the appropriate section index and head hash. It is used to start light syncing from this checkpoint
 // and avoid downloading the entire header chain while still being able to securely access old headers/logs.
-type trustedCheckpoint struct {
-	name                                string
-	sectionIdx                          uint64
-	sectionHead, chtRoot, bloomTrieRoot common.Hash
+type TrustedCheckpoint struct {
+	Name                                string
+	SectionIdx                          uint64
+	SectionHead, CHTRoot, BloomTrieRoot common.Hash
 }
 
 var (
-	mainnetCheckpoint = trustedCheckpoint{
-		name:          "mainnet",
-		sectionIdx:    153,
-		sectionHead:   common.HexToHash("04c2114a8cbe49ba5c37a03cc4b4b8d3adfc0bd2c78e0e726405dd84afca1d63"),
-		chtRoot:       common.HexToHash("d7ec603e5d30b567a6e894ee7704e4603232f206d3e5a589794cec0c57bf318e"),
-		bloomTrieRoot: common.HexToHash("0b139b8fb692e21f663ff200da287192201c28ef5813c1ac6ba02a0a4799eef9"),
+	mainnetCheckpoint = TrustedCheckpoint{
+		Name:          "mainnet",
+		SectionIdx:    153,
+		SectionHead:   common.HexToHash("04c2114a8cbe49ba5c37a03cc4b4b8d3adfc0bd2c78e0e726405dd84afca1d63"),
+		CHTRoot:       common.HexToHash("d7ec603e5d30b567a6e894ee7704e4603232f206d3e5a589794cec0c57bf318e"),
+		BloomTrieRoot: common.HexToHash("0b139b8fb692e21f663ff200da287192201c28ef5813c1ac6ba02a0a4799eef9"),
 	}
 
-	ropstenCheckpoint = trustedCheckpoint{
-		name:          "ropsten",
-		sectionIdx:    79,
-		sectionHead:   common.HexToHash("1b1ba890510e06411fdee9bb64ca7705c56a1a4ce3559ddb34b3680c526cb419"),
-		chtRoot:       common.HexToHash("71d60207af74e5a22a3e1cfbfc89f9944f91b49aa980c86fba94d568369eaf44"),
-		bloomTrieRoot: common.HexToHash("70aca4b3b6d08dde8704c95cedb1420394453c1aec390947751e69ff8c436360"),
+	ropstenCheckpoint = TrustedCheckpoint{
+		Name:          "ropsten",
+		SectionIdx:    79,
+		SectionHead:   common.HexToHash("1b1ba890510e06411fdee9bb64ca7705c56a1a4ce3559ddb34b3680c526cb419"),
+		CHTRoot:       common.HexToHash("71d60207af74e5a22a3e1cfbfc89f9944f91b49aa980c86fba94d568369eaf44"),
+		BloomTrieRoot: common.HexToHash("70aca4b3b6d08dde8704c95cedb1420394453c1aec390947751e69ff8c436360"),
 	}
 )
 
 // trustedCheckpoints associates each known checkpoint with the genesis hash of the chain it belongs to
-var trustedCheckpoints = map[common.Hash]trustedCheckpoint{
+var trustedCheckpoints = map[common.Hash]TrustedCheckpoint{
 	params.MainnetGenesisHash: mainnetCheckpoint,
 	params.TestnetGenesisHash: ropstenCheckpoint,
 }
 
+// trustedCheckpointKey is the database key of the most recently adopted
+// external checkpoint.
+var trustedCheckpointKey = []byte("trustedCheckpoint")
+
+// readTrustedCheckpoint retrieves the most recently adopted external checkpoint
+// from the database.
+func readTrustedCheckpoint(db ethdb.Database) *TrustedCheckpoint {
+	data, _ := db.Get(trustedCheckpointKey)
+	if len(data) == 0 {
+		return nil
+	}
+	cp := new(TrustedCheckpoint)
+	if err := rlp.DecodeBytes(data, cp); err != nil {
+		log.Error("Invalid trusted checkpoint RLP", "err", err)
+		return nil
+	}
+	return cp
+}
+
+// writeTrustedCheckpoint stores an adopted external checkpoint into the database.
+func writeTrustedCheckpoint(db ethdb.Database, cp *TrustedCheckpoint) {
+	data, err := rlp.EncodeToBytes(cp)
+	if err != nil {
+		log.Crit("Failed to RLP encode trusted checkpoint", "err", err)
+	}
+	if err := db.Put(trustedCheckpointKey, data); err != nil {
+		log.Crit("Failed to store trusted checkpoint", "err", err)
+	}
+}
+
 var (
 	ErrNoTrustedCht       = errors.New("No trusted canonical hash trie")
 	ErrNoTrustedBloomTrie = errors.New("No trusted bloom trie")
 	ErrNoHeader           = errors.New("Header not found")
+	ErrStaleCheckpoint    = errors.New("Checkpoint not newer than the current one")
+	ErrCheckpointMismatch = errors.New("Checkpoint contradicts the local chain")
 	chtPrefix             = []byte("chtRoot-") // chtPrefix + chtNum (uint64 big endian) -> trie root hash
 	ChtTablePrefix        = "cht-"
 )
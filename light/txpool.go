@@ -64,6 +64,7 @@ type TxPool struct {
 	nonce        map[common.Address]uint64            // "pending" nonce
 	pending      map[common.Hash]*types.Transaction   // pending transactions by tx hash
 	mined        map[common.Hash][]*types.Transaction // mined transactions by block hash
+	rolledBack   map[common.Hash][]common.Hash        // blocks a transaction was rolled back from by tx hash
 	clearIdx     uint64                               // earliest block nr that can contain mined tx info
 
 	homestead bool
@@ -92,6 +93,7 @@ func NewTxPool(config *params.ChainConfig, chain *LightChain, relay TxRelayBacke
 		nonce:       make(map[common.Address]uint64),
 		pending:     make(map[common.Hash]*types.Transaction),
 		mined:       make(map[common.Hash][]*types.Transaction),
+		rolledBack:  make(map[common.Hash][]common.Hash),
 		quit:        make(chan bool),
 		chainHeadCh: make(chan core.ChainHeadEvent, chainHeadChanSize),
 		chain:       chain,
@@ -204,6 +206,7 @@ func (pool *TxPool) rollbackTxs(hash common.Hash, txc txStateChanges) {
 			txHash := tx.Hash()
 			core.DeleteTxLookupEntry(pool.chainDb, txHash)
 			pool.pending[txHash] = tx
+			pool.rolledBack[txHash] = append(pool.rolledBack[txHash], hash)
 			txc.setState(txHash, false)
 		}
 		delete(pool.mined, hash)
@@ -263,6 +266,7 @@ func (pool *TxPool) reorgOnNewHead(ctx context.Context, newHeader *types.Header)
 					hashes := make([]common.Hash, len(list))
 					for i, tx := range list {
 						hashes[i] = tx.Hash()
+						delete(pool.rolledBack, hashes[i])
 					}
 					pool.relay.Discard(hashes)
 					delete(pool.mined, hash)
@@ -500,6 +504,74 @@ func (self *TxPool) Content() (map[common.Address]types.Transactions, map[common
 	return pending, queued
 }
 
+// LocalTxStatus describes the life cycle of a locally created transaction as
+// seen by the light transaction pool.
+type LocalTxStatus struct {
+	Tx          *types.Transaction
+	Pending     bool          // Transaction is not included in the canonical chain
+	BlockHash   common.Hash   // Hash of the block including the transaction (if mined)
+	BlockNumber uint64        // Number of the block including the transaction (if mined)
+	RolledBack  []common.Hash // Blocks the transaction was included in before being reorged out
+	StateNonce  uint64        // Nonce of the sender at the current head (if pending)
+	NonceGap    bool          // Transaction is blocked by a missing lower nonce of its sender
+}
+
+// LocalStatus returns the tracked status of a locally created transaction, or
+// nil if the transaction is not known by the pool. For pending transactions the
+// sender's nonce is retrieved at the current head in order to detect nonce gaps
+// which prevent the transaction from ever being mined.
+func (pool *TxPool) LocalStatus(ctx context.Context, hash common.Hash) (*LocalTxStatus, error) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	status := &LocalTxStatus{RolledBack: pool.rolledBack[hash]}
+	if tx, ok := pool.pending[hash]; ok {
+		from, err := types.Sender(pool.signer, tx)
+		if err != nil {
+			return nil, err
+		}
+		state := pool.currentState(ctx)
+		nonce := state.GetNonce(from)
+		if err := state.Error(); err != nil {
+			return nil, err
+		}
+		status.Tx, status.Pending, status.StateNonce = tx, true, nonce
+		status.NonceGap = pool.hasNonceGap(from, nonce, tx.Nonce())
+		return status, nil
+	}
+	for blockHash, list := range pool.mined {
+		for _, tx := range list {
+			if tx.Hash() == hash {
+				status.Tx, status.BlockHash = tx, blockHash
+				status.BlockNumber = core.GetBlockNumber(pool.chainDb, blockHash)
+				return status, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// hasNonceGap reports whether any nonce between the sender's current state nonce
+// and the given one is missing from the pending set, meaning that the transaction
+// with the given nonce cannot be executed until the gap is filled.
+func (pool *TxPool) hasNonceGap(from common.Address, stateNonce, nonce uint64) bool {
+	if nonce <= stateNonce {
+		return false
+	}
+	known := make(map[uint64]struct{})
+	for _, tx := range pool.pending {
+		if sender, _ := types.Sender(pool.signer, tx); sender == from {
+			known[tx.Nonce()] = struct{}{}
+		}
+	}
+	for n := stateNonce; n < nonce; n++ {
+		if _, ok := known[n]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
 // RemoveTransactions removes all given transactions from the pool.
 func (self *TxPool) RemoveTransactions(txs types.Transactions) {
 	self.mu.Lock()
@@ -509,6 +581,7 @@ func (self *TxPool) RemoveTransactions(txs types.Transactions) {
 		//self.RemoveTx(tx.Hash())
 		hash := tx.Hash()
 		delete(self.pending, hash)
+		delete(self.rolledBack, hash)
 		self.chainDb.Delete(hash[:])
 		hashes = append(hashes, hash)
 	}
@@ -521,6 +594,7 @@ func (pool *TxPool) RemoveTx(hash common.Hash) {
 	defer pool.mu.Unlock()
 	// delete from pending pool
 	delete(pool.pending, hash)
+	delete(pool.rolledBack, hash)
 	pool.chainDb.Delete(hash[:])
 	pool.relay.Discard([]common.Hash{hash})
 }
@@ -141,3 +141,57 @@ func TestTxPool(t *testing.T) {
 		}
 	}
 }
+
+func TestTxPoolLocalStatus(t *testing.T) {
+	var (
+		sdb, _ = ethdb.NewMemDatabase()
+		ldb, _ = ethdb.NewMemDatabase()
+		gspec  = core.Genesis{Alloc: core.GenesisAlloc{testBankAddress: {Balance: testBankFunds}}}
+	)
+	gspec.MustCommit(sdb)
+	gspec.MustCommit(ldb)
+
+	odr := &testOdr{sdb: sdb, ldb: ldb}
+	relay := &testTxRelay{
+		send:    make(chan int, 10),
+		discard: make(chan int, 10),
+		mined:   make(chan int, 10),
+	}
+	lightchain, _ := NewLightChain(odr, params.TestChainConfig, ethash.NewFullFaker())
+	pool := NewTxPool(params.TestChainConfig, lightchain, relay)
+	defer pool.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	txs := make([]*types.Transaction, 3)
+	for i := range txs {
+		txs[i], _ = types.SignTx(types.NewTransaction(uint64(i), acc1Addr, big.NewInt(10000), params.TxGas, nil, nil), types.HomesteadSigner{}, testBankKey)
+	}
+	if status, err := pool.LocalStatus(ctx, txs[0].Hash()); err != nil || status != nil {
+		t.Fatalf("unknown transaction status mismatch: have %v, %v, want nil, nil", status, err)
+	}
+	// Send the first and the third transaction, leaving a nonce gap
+	for _, tx := range []*types.Transaction{txs[0], txs[2]} {
+		if err := pool.Add(ctx, tx); err != nil {
+			t.Fatalf("failed to add transaction: %v", err)
+		}
+	}
+	check := func(tx *types.Transaction, gap bool) {
+		status, err := pool.LocalStatus(ctx, tx.Hash())
+		if err != nil {
+			t.Fatalf("failed to retrieve status: %v", err)
+		}
+		if !status.Pending || status.StateNonce != 0 || status.NonceGap != gap {
+			t.Errorf("tx %d: status mismatch: pending %v, state nonce %d, gap %v", tx.Nonce(), status.Pending, status.StateNonce, status.NonceGap)
+		}
+	}
+	check(txs[0], false)
+	check(txs[2], true)
+
+	// Fill the gap and ensure it's not reported any more
+	if err := pool.Add(ctx, txs[1]); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	check(txs[2], false)
+}
@@ -17,19 +17,29 @@
 package miner
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"math/big"
+	"net/http"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
+// notifyTimeout is the maximum time to wait for a remote sealer to accept a
+// new work notification.
+const notifyTimeout = time.Second
+
 type hashrate struct {
 	ping time.Time
 	rate uint64
@@ -45,7 +55,11 @@ type RemoteAgent struct {
 	chain       consensus.ChainReader
 	engine      consensus.Engine
 	currentWork *Work
-	work        map[common.Hash]*Work
+	work        map[common.Hash]*Work // Work packages handed out via GetWork, keyed by PoW hash
+	sealing     map[common.Hash]*Work // Sealing tasks awaiting an external seal, keyed by seal-less hash
+
+	notify []string     // HTTP URLs to push new work packages to
+	client *http.Client // HTTP client to push the work notifications with
 
 	hashrateMu sync.RWMutex
 	hashrate   map[common.Hash]hashrate
@@ -53,11 +67,16 @@ type RemoteAgent struct {
 	running int32 // running indicates whether the agent is active. Call atomically
 }
 
-func NewRemoteAgent(chain consensus.ChainReader, engine consensus.Engine) *RemoteAgent {
+// NewRemoteAgent creates an agent handing out the sealing tasks to external
+// sealers, optionally pushing every new work package to the given HTTP URLs.
+func NewRemoteAgent(chain consensus.ChainReader, engine consensus.Engine, notify []string) *RemoteAgent {
 	return &RemoteAgent{
 		chain:    chain,
 		engine:   engine,
 		work:     make(map[common.Hash]*Work),
+		sealing:  make(map[common.Hash]*Work),
+		notify:   notify,
+		client:   &http.Client{Timeout: notifyTimeout},
 		hashrate: make(map[common.Hash]hashrate),
 	}
 }
@@ -110,25 +129,31 @@ func (a *RemoteAgent) GetWork() ([3]string, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	var res [3]string
-
 	if a.currentWork != nil {
 		block := a.currentWork.Block
 
-		res[0] = block.HashNoNonce().Hex()
-		seedHash := ethash.SeedHash(block.NumberU64())
-		res[1] = common.BytesToHash(seedHash).Hex()
-		// Calculate the "target" to be returned to the external miner
-		n := big.NewInt(1)
-		n.Lsh(n, 255)
-		n.Div(n, block.Difficulty())
-		n.Lsh(n, 1)
-		res[2] = common.BytesToHash(n.Bytes()).Hex()
-
 		a.work[block.HashNoNonce()] = a.currentWork
-		return res, nil
+		return powWorkPackage(block), nil
 	}
-	return res, errors.New("No work available yet, don't panic.")
+	return [3]string{}, errors.New("No work available yet, don't panic.")
+}
+
+// powWorkPackage assembles the ethash work package of a block: the pow-hash of
+// the header, the seed hash of the DAG and the boundary condition ("target").
+func powWorkPackage(block *types.Block) [3]string {
+	var res [3]string
+
+	res[0] = block.HashNoNonce().Hex()
+	seedHash := ethash.SeedHash(block.NumberU64())
+	res[1] = common.BytesToHash(seedHash).Hex()
+	// Calculate the "target" to be returned to the external miner
+	n := big.NewInt(1)
+	n.Lsh(n, 255)
+	n.Div(n, block.Difficulty())
+	n.Lsh(n, 1)
+	res[2] = common.BytesToHash(n.Bytes()).Hex()
+
+	return res
 }
 
 // SubmitWork tries to inject a pow solution into the remote agent, returning
@@ -162,6 +187,92 @@ func (a *RemoteAgent) SubmitWork(nonce types.BlockNonce, mixDigest, hash common.
 	return true
 }
 
+// SubmitSealedBlock tries to inject an externally sealed header into the remote
+// agent, returning whether it was accepted or not. The header must be that of a
+// pending sealing task, differing only in the fields the consensus engine seals
+// (extra-data, mix digest and nonce), which is how engines signing blocks (e.g.
+// clique) can have the signing key kept on a separate machine.
+//
+// Note, the remote sealer is expected to respect the timestamp of the header,
+// the block is relayed to the network as soon as it's accepted.
+func (a *RemoteAgent) SubmitSealedBlock(header *types.Header) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Make sure the header submitted belongs to a pending sealing task
+	hash := sealingHash(header)
+	work := a.sealing[hash]
+	if work == nil {
+		log.Info("Sealed block submitted but none pending", "number", header.Number, "hash", hash)
+		return false
+	}
+	// Only take over the sealed fields, everything else is as assembled locally
+	result := work.Block.Header()
+	if len(header.Extra) != len(result.Extra) {
+		log.Warn("Invalid sealed block submitted", "number", result.Number, "hash", hash, "err", "extra-data length mismatch")
+		return false
+	}
+	result.Extra = common.CopyBytes(header.Extra)
+	result.MixDigest = header.MixDigest
+	result.Nonce = header.Nonce
+
+	if err := a.engine.VerifySeal(a.chain, result); err != nil {
+		log.Warn("Invalid sealed block submitted", "number", result.Number, "hash", hash, "err", err)
+		return false
+	}
+	block := work.Block.WithSeal(result)
+
+	// Seal seems to be valid, return to the miner and notify acceptance
+	a.returnCh <- &Result{work, block}
+	delete(a.sealing, hash)
+
+	return true
+}
+
+// sealingHash returns the hash identifying a sealing task, which is the hash of
+// the header without any of the fields the consensus engines seal.
+func sealingHash(header *types.Header) common.Hash {
+	cpy := types.CopyHeader(header)
+	cpy.Extra = nil
+	cpy.MixDigest = common.Hash{}
+	cpy.Nonce = types.BlockNonce{}
+
+	blob, _ := rlp.EncodeToBytes(cpy)
+	return crypto.Keccak256Hash(blob)
+}
+
+// notifyWork pushes a new work package to the configured remote sealers. Proof
+// of work engines receive the same package as GetWork returns, any other engine
+// the RLP encoded header to be sealed.
+func (a *RemoteAgent) notifyWork(work *Work) {
+	var pack interface{}
+	if _, ok := a.engine.(consensus.PoW); ok {
+		pack = powWorkPackage(work.Block)
+	} else {
+		blob, err := rlp.EncodeToBytes(work.Block.Header())
+		if err != nil {
+			log.Error("Failed to encode work package", "err", err)
+			return
+		}
+		pack = hexutil.Bytes(blob)
+	}
+	blob, err := json.Marshal(pack)
+	if err != nil {
+		log.Error("Failed to encode work package", "err", err)
+		return
+	}
+	for _, url := range a.notify {
+		go func(url string) {
+			res, err := a.client.Post(url, "application/json", bytes.NewReader(blob))
+			if err != nil {
+				log.Warn("Failed to notify remote sealer", "url", url, "err", err)
+				return
+			}
+			res.Body.Close()
+		}(url)
+	}
+}
+
 // loop monitors mining events on the work and quit channels, updating the internal
 // state of the remote miner until a termination is requested.
 //
@@ -179,7 +290,12 @@ func (a *RemoteAgent) loop(workCh chan *Work, quitCh chan struct{}) {
 		case work := <-workCh:
 			a.mu.Lock()
 			a.currentWork = work
+			a.sealing[sealingHash(work.Block.Header())] = work
 			a.mu.Unlock()
+
+			if len(a.notify) > 0 {
+				a.notifyWork(work)
+			}
 		case <-ticker.C:
 			// cleanup
 			a.mu.Lock()
@@ -188,6 +304,11 @@ func (a *RemoteAgent) loop(workCh chan *Work, quitCh chan struct{}) {
 					delete(a.work, hash)
 				}
 			}
+			for hash, work := range a.sealing {
+				if time.Since(work.createdAt) > 7*(12*time.Second) {
+					delete(a.sealing, hash)
+				}
+			}
 			a.mu.Unlock()
 
 			a.hashrateMu.Lock()
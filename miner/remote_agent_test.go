@@ -0,0 +1,147 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that new clique sealing tasks are pushed to the remote sealers, and that
+// the blocks they sign are accepted via the remote agent.
+func TestRemoteSealing(t *testing.T) {
+	// Create a clique chain with a single signer, whose key the node doesn't have
+	key, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+
+	config := *params.AllCliqueProtocolChanges
+	config.Clique = &params.CliqueConfig{Period: 1, Epoch: 30000}
+
+	genesis := &core.Genesis{
+		Config:    &config,
+		ExtraData: make([]byte, 32+len(signer)+65),
+	}
+	copy(genesis.ExtraData[32:], signer[:])
+
+	db, _ := ethdb.NewMemDatabase()
+	genesis.MustCommit(db)
+
+	engine := clique.New(config.Clique, db)
+	engine.Authorize(signer, nil)
+
+	chain, err := core.NewBlockChain(db, nil, &config, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	// Assemble a sealing task on top of the genesis block
+	header := &types.Header{
+		ParentHash: chain.Genesis().Hash(),
+		Number:     big.NewInt(1),
+		GasLimit:   core.CalcGasLimit(chain.Genesis()),
+	}
+	if err := engine.Prepare(chain, header); err != nil {
+		t.Fatalf("failed to prepare header: %v", err)
+	}
+	work := &Work{Block: types.NewBlock(header, nil, nil, nil), createdAt: time.Now()}
+
+	// Start a remote agent notifying a mock remote sealer
+	notifications := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		blob, _ := ioutil.ReadAll(r.Body)
+		notifications <- blob
+	}))
+	defer server.Close()
+
+	results := make(chan *Result, 1)
+
+	agent := NewRemoteAgent(chain, engine, []string{server.URL})
+	agent.SetReturnCh(results)
+	agent.Start()
+	defer agent.Stop()
+
+	agent.Work() <- work
+
+	// Ensure the remote sealer got notified of the header to seal
+	var notified *types.Header
+	select {
+	case blob := <-notifications:
+		var pack hexutil.Bytes
+		if err := json.Unmarshal(blob, &pack); err != nil {
+			t.Fatalf("failed to decode work package: %v", err)
+		}
+		notified = new(types.Header)
+		if err := rlp.DecodeBytes(pack, notified); err != nil {
+			t.Fatalf("failed to decode notified header: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("remote sealer not notified")
+	}
+	if notified.Hash() != work.Block.Hash() {
+		t.Fatalf("notified header mismatch: have %x, want %x", notified.Hash(), work.Block.Hash())
+	}
+	// Unsealed and unknown headers must be rejected
+	if agent.SubmitSealedBlock(notified) {
+		t.Errorf("unsealed block accepted")
+	}
+	unknown := types.CopyHeader(notified)
+	unknown.Time = new(big.Int).Add(unknown.Time, big.NewInt(1))
+	if agent.SubmitSealedBlock(unknown) {
+		t.Errorf("unknown block accepted")
+	}
+	// Seal the header on the remote side and ensure it's accepted
+	remote := clique.New(config.Clique, db)
+	remote.Authorize(signer, func(account accounts.Account, hash []byte) ([]byte, error) {
+		return crypto.Sign(hash, key)
+	})
+	sealed, err := remote.Seal(chain, types.NewBlockWithHeader(notified), nil)
+	if err != nil {
+		t.Fatalf("failed to seal block remotely: %v", err)
+	}
+	if !agent.SubmitSealedBlock(sealed.Header()) {
+		t.Fatalf("sealed block rejected")
+	}
+	select {
+	case result := <-results:
+		if result.Block.Hash() != sealed.Hash() {
+			t.Errorf("sealed block mismatch: have %x, want %x", result.Block.Hash(), sealed.Hash())
+		}
+	default:
+		t.Fatalf("sealed block not returned to the miner")
+	}
+	// Sealing tasks can only be submitted once
+	if agent.SubmitSealedBlock(sealed.Header()) {
+		t.Errorf("sealed block accepted twice")
+	}
+}
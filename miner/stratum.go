@@ -0,0 +1,676 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	stratumVersion        = "EthereumStratum/1.0.0" // Stratum protocol dialect spoken by the server
+	stratumExtranonceSize = 2                       // Number of nonce bytes assigned by the server to each session
+	stratumMaxRequestSize = 4096                    // Maximum size of a single Stratum request line
+
+	stratumIdleTimeout  = 10 * time.Minute // Maximum time a session may stay silent before being dropped
+	stratumWriteTimeout = 10 * time.Second // Maximum time to wait for a message to be written to a miner
+	stratumSendQueue    = 16               // Number of messages to queue up for a slow miner before dropping it
+
+	stratumJobLifetime     = 7 * (12 * time.Second) // Time after which a job is considered stale
+	stratumHashrateWindow  = 10 * time.Minute       // Time window over which to estimate the worker hashrates
+	stratumMaxWorkerLength = 64                     // Maximum length of a worker name
+)
+
+// Stratum error codes as defined by the EthereumStratum/1.0.0 specification.
+var (
+	errStratumOther         = &stratumError{20, "Other/Unknown"}
+	errStratumJobNotFound   = &stratumError{21, "Job not found (=stale)"}
+	errStratumDuplicate     = &stratumError{22, "Duplicate share"}
+	errStratumLowDifficulty = &stratumError{23, "Low difficulty share"}
+	errStratumUnauthorized  = &stratumError{24, "Unauthorized worker"}
+	errStratumNotSubscribed = &stratumError{25, "Not subscribed"}
+)
+
+// stratumError is an error reported back to a miner, encoded as a triple of an
+// error code, a message and an optional traceback.
+type stratumError struct {
+	code    int
+	message string
+}
+
+func (e *stratumError) Error() string {
+	return e.message
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e *stratumError) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{e.code, e.message, nil})
+}
+
+// stratumRequest is a request or notification sent by a miner.
+type stratumRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// stratumResponse is the reply to a miner's request.
+type stratumResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result interface{}     `json:"result"`
+	Error  *stratumError   `json:"error"`
+}
+
+// stratumNotification is a server initiated message sent to a miner.
+type stratumNotification struct {
+	ID     interface{}   `json:"id"` // Always null
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+}
+
+// stratumJob is a sealing task handed out to the miners.
+type stratumJob struct {
+	id     string
+	work   *Work
+	shares map[uint64]struct{} // Nonces already submitted for this job
+}
+
+// notification creates the mining.notify message announcing the job.
+func (job *stratumJob) notification() *stratumNotification {
+	block := job.work.Block
+	return &stratumNotification{
+		Method: "mining.notify",
+		Params: []interface{}{
+			job.id,
+			hex.EncodeToString(ethash.SeedHash(block.NumberU64())),
+			hex.EncodeToString(block.HashNoNonce().Bytes()),
+			true,
+		},
+	}
+}
+
+// stratumWorker tracks the shares of a single worker for hashrate estimation.
+type stratumWorker struct {
+	first  time.Time      // Time of the first share within the current window
+	shares []stratumShare // Recent shares, in ascending order of their time
+}
+
+// stratumShare is a valid share accounted for the hashrate of a worker.
+type stratumShare struct {
+	time       time.Time
+	difficulty float64 // Difficulty the share was verified against
+}
+
+// stratumSession is a single miner connection.
+type stratumSession struct {
+	conn       net.Conn
+	id         string // Subscription identifier
+	extranonce []byte // Nonce prefix assigned to the session
+
+	subscribed bool                // Whether the miner subscribed to the jobs (server lock)
+	authorized map[string]struct{} // Workers authorized on this connection (server lock)
+	welcomed   bool                // Whether the miner was handed its first job (server lock)
+
+	out  chan interface{} // Messages queued up to be written to the miner
+	quit chan struct{}    // Closed when the session terminates
+}
+
+// send queues up a message to the miner, dropping the connection if the miner
+// can't keep up.
+func (s *stratumSession) send(msg interface{}) {
+	select {
+	case s.out <- msg:
+	case <-s.quit:
+	default:
+		log.Debug("Stratum miner too slow, dropping", "addr", s.conn.RemoteAddr())
+		s.conn.Close()
+	}
+}
+
+// writeLoop writes the queued up messages to the miner.
+func (s *stratumSession) writeLoop() {
+	enc := json.NewEncoder(s.conn)
+	for {
+		select {
+		case msg := <-s.out:
+			s.conn.SetWriteDeadline(time.Now().Add(stratumWriteTimeout))
+			if err := enc.Encode(msg); err != nil {
+				log.Debug("Failed to write to Stratum miner", "addr", s.conn.RemoteAddr(), "err", err)
+				s.conn.Close()
+				return
+			}
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// StratumServer is a miner agent distributing the sealing work to pool miners
+// over the EthereumStratum/1.0.0 protocol. Shares are validated with ethash and
+// the ones meeting the block difficulty are returned to the miner as sealed
+// blocks.
+type StratumServer struct {
+	chain      consensus.ChainReader
+	engine     *ethash.Ethash
+	difficulty *big.Int // Difficulty of a share, capped at the block difficulty
+
+	listener    net.Listener
+	sessions    map[*stratumSession]struct{}
+	extranonces map[string]struct{} // Extranonces held by the live sessions
+	nonces      uint32              // Next extranonce to try assigning, guarded by the lock
+
+	current *stratumJob            // Most recent job handed out to the miners
+	jobs    map[string]*stratumJob // Recent jobs still accepting shares
+	counter uint64                 // Counter to assign the job identifiers from
+	workers map[string]*stratumWorker
+	mu      sync.Mutex
+
+	workCh   chan *Work
+	returnCh chan<- *Result
+	quitCh   chan struct{}
+	running  int32 // running indicates whether the agent is active. Call atomically
+
+	wg sync.WaitGroup
+}
+
+// NewStratumServer creates a Stratum mining agent, handing out shares of the
+// given difficulty. The server only accepts miners after calling Listen.
+func NewStratumServer(chain consensus.ChainReader, engine *ethash.Ethash, difficulty *big.Int) *StratumServer {
+	return &StratumServer{
+		chain:       chain,
+		engine:      engine,
+		difficulty:  new(big.Int).Set(difficulty),
+		sessions:    make(map[*stratumSession]struct{}),
+		extranonces: make(map[string]struct{}),
+		jobs:        make(map[string]*stratumJob),
+		workers:     make(map[string]*stratumWorker),
+	}
+}
+
+// Listen starts accepting Stratum miners on the given TCP endpoint.
+func (s *StratumServer) Listen(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+
+	log.Info("Stratum mining server started", "addr", listener.Addr())
+
+	s.wg.Add(1)
+	go s.serve(listener)
+	return nil
+}
+
+// Addr returns the listening address of the server, or nil if not listening.
+func (s *StratumServer) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Close stops accepting miners and disconnects all the connected ones.
+func (s *StratumServer) Close() {
+	s.mu.Lock()
+	if s.listener != nil {
+		s.listener.Close()
+		s.listener = nil
+	}
+	for session := range s.sessions {
+		session.conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *StratumServer) Work() chan<- *Work {
+	return s.workCh
+}
+
+func (s *StratumServer) SetReturnCh(returnCh chan<- *Result) {
+	s.returnCh = returnCh
+}
+
+func (s *StratumServer) Start() {
+	if !atomic.CompareAndSwapInt32(&s.running, 0, 1) {
+		return
+	}
+	s.quitCh = make(chan struct{})
+	s.workCh = make(chan *Work, 1)
+	go s.loop(s.workCh, s.quitCh)
+}
+
+func (s *StratumServer) Stop() {
+	if !atomic.CompareAndSwapInt32(&s.running, 1, 0) {
+		return
+	}
+	close(s.quitCh)
+	close(s.workCh)
+
+	// Drop all the jobs, the miners can't seal anything useful until restarted
+	s.mu.Lock()
+	s.current = nil
+	s.jobs = make(map[string]*stratumJob)
+	s.mu.Unlock()
+}
+
+// GetHashRate returns the accumulated hashrate of all the Stratum workers.
+func (s *StratumServer) GetHashRate() (tot int64) {
+	for _, rate := range s.Hashrates() {
+		tot += rate
+	}
+	return tot
+}
+
+// Hashrates returns the estimated hashrate of each Stratum worker, based on the
+// shares submitted within the recent hashrate window.
+func (s *StratumServer) Hashrates() map[string]int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	rates := make(map[string]int64)
+	for name, worker := range s.workers {
+		s.pruneShares(worker, now)
+
+		var work float64
+		for _, share := range worker.shares {
+			work += share.difficulty
+		}
+		elapsed := now.Sub(worker.first)
+		if elapsed < time.Second {
+			elapsed = time.Second
+		}
+		rates[name] = int64(work / elapsed.Seconds())
+	}
+	return rates
+}
+
+// pruneShares drops the shares of a worker that fell out of the hashrate window.
+func (s *StratumServer) pruneShares(worker *stratumWorker, now time.Time) {
+	cutoff := now.Add(-stratumHashrateWindow)
+	for len(worker.shares) > 0 && worker.shares[0].time.Before(cutoff) {
+		worker.shares = worker.shares[1:]
+	}
+	if worker.first.Before(cutoff) {
+		worker.first = cutoff
+	}
+}
+
+// loop monitors mining events on the work and quit channels, announcing the new
+// jobs to the miners until a termination is requested.
+//
+// Note, the reason the work and quit channels are passed as parameters is because
+// StratumServer.Start() constantly recreates these channels, so the loop code
+// cannot assume data stability in these member fields.
+func (s *StratumServer) loop(workCh chan *Work, quitCh chan struct{}) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-quitCh:
+			return
+		case work := <-workCh:
+			if work == nil {
+				continue
+			}
+			s.mu.Lock()
+			s.counter++
+			job := &stratumJob{
+				id:     fmt.Sprintf("%08x", s.counter),
+				work:   work,
+				shares: make(map[uint64]struct{}),
+			}
+			s.current, s.jobs[job.id] = job, job
+
+			notification := job.notification()
+			for session := range s.sessions {
+				if session.welcomed {
+					session.send(notification)
+				}
+			}
+			s.mu.Unlock()
+		case <-ticker.C:
+			// cleanup
+			now := time.Now()
+
+			s.mu.Lock()
+			for id, job := range s.jobs {
+				if now.Sub(job.work.createdAt) > stratumJobLifetime {
+					delete(s.jobs, id)
+				}
+			}
+			for name, worker := range s.workers {
+				s.pruneShares(worker, now)
+				if len(worker.shares) == 0 {
+					delete(s.workers, name)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// serve accepts the Stratum miners until the listener is closed.
+func (s *StratumServer) serve(listener net.Listener) {
+	defer s.wg.Done()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+				log.Debug("Temporary Stratum accept error", "err", err)
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			log.Debug("Stratum mining server stopped", "err", err)
+			return
+		}
+		s.wg.Add(1)
+		go s.handle(conn)
+	}
+}
+
+// handle runs a single miner session, processing its requests until the miner
+// disconnects or misbehaves.
+func (s *StratumServer) handle(conn net.Conn) {
+	defer s.wg.Done()
+	defer conn.Close()
+
+	// Assign the session its identifier and its share of the nonce space
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		log.Error("Failed to generate Stratum session id", "err", err)
+		return
+	}
+	session := &stratumSession{
+		conn:       conn,
+		id:         hex.EncodeToString(id),
+		authorized: make(map[string]struct{}),
+		out:        make(chan interface{}, stratumSendQueue),
+		quit:       make(chan struct{}),
+	}
+	s.mu.Lock()
+	if s.listener == nil {
+		s.mu.Unlock()
+		return
+	}
+	if session.extranonce = s.assignExtranonce(); session.extranonce == nil {
+		s.mu.Unlock()
+		log.Warn("Stratum nonce space exhausted, rejecting miner", "addr", conn.RemoteAddr(), "sessions", len(s.sessions))
+		return
+	}
+	s.sessions[session] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.sessions, session)
+		delete(s.extranonces, string(session.extranonce))
+		s.mu.Unlock()
+		close(session.quit)
+	}()
+	go session.writeLoop()
+
+	log.Debug("Stratum miner connected", "addr", conn.RemoteAddr(), "extranonce", hex.EncodeToString(session.extranonce))
+	reader := bufio.NewReaderSize(conn, stratumMaxRequestSize)
+	for {
+		conn.SetReadDeadline(time.Now().Add(stratumIdleTimeout))
+		line, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			log.Debug("Stratum miner disconnected", "addr", conn.RemoteAddr(), "err", err)
+			return
+		}
+		if isPrefix {
+			log.Debug("Stratum request too large", "addr", conn.RemoteAddr())
+			return
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		req := new(stratumRequest)
+		if err := json.Unmarshal(line, req); err != nil {
+			log.Debug("Invalid Stratum request", "addr", conn.RemoteAddr(), "err", err)
+			return
+		}
+		result, err := s.dispatch(session, req)
+		if err != nil {
+			log.Trace("Stratum request failed", "addr", conn.RemoteAddr(), "method", req.Method, "err", err)
+			serr, ok := err.(*stratumError)
+			if !ok {
+				serr = &stratumError{errStratumOther.code, err.Error()}
+			}
+			session.send(&stratumResponse{ID: req.ID, Error: serr})
+			continue
+		}
+		session.send(&stratumResponse{ID: req.ID, Result: result})
+		s.welcome(session)
+	}
+}
+
+// assignExtranonce reserves a nonce prefix not held by any live session, reusing
+// the ones of disconnected sessions once the counter wraps around, so no two
+// miners ever search the same nonces. It returns nil if all the prefixes are in
+// use. The caller must hold the server lock.
+func (s *StratumServer) assignExtranonce() []byte {
+	const prefixes = 1 << (8 * stratumExtranonceSize)
+
+	for i := 0; i < prefixes; i++ {
+		nonce := make([]byte, 4)
+		binary.BigEndian.PutUint32(nonce, s.nonces)
+		s.nonces = (s.nonces + 1) % prefixes
+
+		extranonce := nonce[len(nonce)-stratumExtranonceSize:]
+		if _, ok := s.extranonces[string(extranonce)]; !ok {
+			s.extranonces[string(extranonce)] = struct{}{}
+			return extranonce
+		}
+	}
+	return nil
+}
+
+// welcome hands out the share difficulty and the current job to a session once
+// it has an authorized worker.
+func (s *StratumServer) welcome(session *stratumSession) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session.welcomed || len(session.authorized) == 0 {
+		return
+	}
+	session.welcomed = true
+
+	session.send(&stratumNotification{Method: "mining.set_difficulty", Params: []interface{}{s.stratumDifficulty()}})
+	if s.current != nil {
+		session.send(s.current.notification())
+	}
+}
+
+// dispatch executes a single miner request.
+func (s *StratumServer) dispatch(session *stratumSession, req *stratumRequest) (interface{}, error) {
+	switch req.Method {
+	case "mining.subscribe":
+		// The miner may announce its agent and protocol version, check the latter
+		if len(req.Params) > 1 {
+			var version string
+			if err := json.Unmarshal(req.Params[1], &version); err != nil {
+				return nil, err
+			}
+			if version != stratumVersion {
+				return nil, fmt.Errorf("unsupported protocol: %s", version)
+			}
+		}
+		s.mu.Lock()
+		session.subscribed = true
+		s.mu.Unlock()
+
+		return []interface{}{
+			[]string{"mining.notify", session.id, stratumVersion},
+			hex.EncodeToString(session.extranonce),
+		}, nil
+
+	case "mining.extranonce.subscribe":
+		// Extranonces never change during a session, nothing to do
+		return true, nil
+
+	case "mining.authorize":
+		var worker string
+		if len(req.Params) == 0 {
+			return nil, errors.New("missing worker")
+		}
+		if err := json.Unmarshal(req.Params[0], &worker); err != nil {
+			return nil, err
+		}
+		if worker == "" || len(worker) > stratumMaxWorkerLength {
+			return nil, errStratumUnauthorized
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if !session.subscribed {
+			return nil, errStratumNotSubscribed
+		}
+		session.authorized[worker] = struct{}{}
+		return true, nil
+
+	case "mining.submit":
+		var params [3]string
+		if len(req.Params) < len(params) {
+			return nil, errors.New("missing share parameters")
+		}
+		for i := range params {
+			if err := json.Unmarshal(req.Params[i], &params[i]); err != nil {
+				return nil, err
+			}
+		}
+		s.mu.Lock()
+		_, authorized := session.authorized[params[0]]
+		s.mu.Unlock()
+
+		if !authorized {
+			return nil, errStratumUnauthorized
+		}
+		if err := s.submit(session, params[0], params[1], params[2]); err != nil {
+			return nil, err
+		}
+		return true, nil
+
+	default:
+		return nil, fmt.Errorf("method not found: %s", req.Method)
+	}
+}
+
+// stratumDifficulty converts the share difficulty into the Stratum notion of
+// it, where difficulty 1 is the target 0x00000000ffff0000...0000.
+func (s *StratumServer) stratumDifficulty() float64 {
+	difficulty, _ := new(big.Float).SetInt(s.difficulty).Float64()
+	return difficulty * 0xffff / (1 << 48)
+}
+
+// submit validates a share submitted by a worker, returning a sealed block to
+// the miner if the share meets the block difficulty too.
+func (s *StratumServer) submit(session *stratumSession, worker string, id string, nonceHex string) error {
+	// Assemble the full nonce from the session's and the miner's part
+	blob, err := hex.DecodeString(strings.TrimPrefix(nonceHex, "0x"))
+	if err != nil || len(session.extranonce)+len(blob) != 8 {
+		return fmt.Errorf("invalid nonce: %s", nonceHex)
+	}
+	nonce := binary.BigEndian.Uint64(append(common.CopyBytes(session.extranonce), blob...))
+
+	// Make sure the job is still current and the share wasn't seen yet
+	s.mu.Lock()
+	job := s.jobs[id]
+	if job == nil {
+		s.mu.Unlock()
+		return errStratumJobNotFound
+	}
+	if _, ok := job.shares[nonce]; ok {
+		s.mu.Unlock()
+		return errStratumDuplicate
+	}
+	job.shares[nonce] = struct{}{}
+	difficulty := s.difficulty
+	s.mu.Unlock()
+
+	// Verify the solution against the share difficulty and complete it
+	header := job.work.Block.Header()
+	header.Nonce = types.EncodeNonce(nonce)
+
+	if difficulty.Cmp(header.Difficulty) > 0 {
+		difficulty = header.Difficulty
+	}
+	digest, err := s.engine.VerifyShare(header, difficulty)
+	if err != nil {
+		log.Debug("Invalid Stratum share", "worker", worker, "job", id, "err", err)
+		return errStratumLowDifficulty
+	}
+	header.MixDigest = digest
+
+	s.mu.Lock()
+	now := time.Now()
+	if _, ok := s.workers[worker]; !ok {
+		s.workers[worker] = &stratumWorker{first: now}
+	}
+	weight, _ := new(big.Float).SetInt(difficulty).Float64()
+	s.workers[worker].shares = append(s.workers[worker].shares, stratumShare{now, weight})
+	s.mu.Unlock()
+
+	// If the share meets the block difficulty too, we've sealed a block
+	if err := s.engine.VerifySeal(s.chain, header); err != nil {
+		return nil
+	}
+	s.mu.Lock()
+	if s.jobs[id] != job {
+		s.mu.Unlock()
+		return nil // Job dropped in the meantime (e.g. stopped or block already found)
+	}
+	delete(s.jobs, id)
+	s.mu.Unlock()
+
+	block := job.work.Block.WithSeal(header)
+	log.Info("Stratum worker sealed new block", "worker", worker, "number", block.Number(), "hash", block.Hash())
+
+	s.returnCh <- &Result{job.work, block}
+	return nil
+}
@@ -0,0 +1,272 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// stratumTester is a mock pool miner speaking Stratum to the server.
+type stratumTester struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+	id     int
+}
+
+func newStratumTester(t *testing.T, addr net.Addr) *stratumTester {
+	conn, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatalf("failed to connect to stratum server: %v", err)
+	}
+	return &stratumTester{t: t, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+// read retrieves the next message sent by the server.
+func (st *stratumTester) read() map[string]json.RawMessage {
+	st.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := st.reader.ReadBytes('\n')
+	if err != nil {
+		st.t.Fatalf("failed to read stratum message: %v", err)
+	}
+	var msg map[string]json.RawMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		st.t.Fatalf("failed to decode stratum message %s: %v", line, err)
+	}
+	return msg
+}
+
+// notification waits for the next server notification of the given method.
+func (st *stratumTester) notification(method string) []interface{} {
+	msg := st.read()
+
+	var have string
+	json.Unmarshal(msg["method"], &have)
+	if have != method {
+		st.t.Fatalf("notification mismatch: have %q, want %q", have, method)
+	}
+	var params []interface{}
+	json.Unmarshal(msg["params"], &params)
+	return params
+}
+
+// request sends a request to the server, returning its result and error code.
+func (st *stratumTester) request(method string, params ...interface{}) (json.RawMessage, int) {
+	st.id++
+	req := map[string]interface{}{"id": st.id, "method": method, "params": params}
+	if err := json.NewEncoder(st.conn).Encode(req); err != nil {
+		st.t.Fatalf("failed to send stratum request: %v", err)
+	}
+	msg := st.read()
+
+	var id int
+	if err := json.Unmarshal(msg["id"], &id); err != nil || id != st.id {
+		st.t.Fatalf("response id mismatch: have %s, want %d", msg["id"], st.id)
+	}
+	var failure []interface{}
+	if json.Unmarshal(msg["error"], &failure); len(failure) > 0 {
+		return nil, int(failure[0].(float64))
+	}
+	return msg["result"], 0
+}
+
+// Tests the Stratum session lifecycle: subscription, authorization, job
+// distribution and share validation, up until a block is sealed.
+func TestStratumMining(t *testing.T) {
+	server := NewStratumServer(nil, ethash.NewTester(), big.NewInt(1))
+	if err := server.Listen("127.0.0.1:0"); err != nil {
+		t.Fatalf("failed to start stratum server: %v", err)
+	}
+	defer server.Close()
+
+	results := make(chan *Result, 1)
+	server.SetReturnCh(results)
+	server.Start()
+	defer server.Stop()
+
+	miner := newStratumTester(t, server.Addr())
+	defer miner.conn.Close()
+
+	// Workers must subscribe before authorizing
+	if _, code := miner.request("mining.authorize", "worker", "x"); code != errStratumNotSubscribed.code {
+		t.Fatalf("authorization before subscription: error code mismatch: have %d, want %d", code, errStratumNotSubscribed.code)
+	}
+	result, code := miner.request("mining.subscribe", "tester/1.0", stratumVersion)
+	if code != 0 {
+		t.Fatalf("failed to subscribe: error code %d", code)
+	}
+	var subscription []interface{}
+	if err := json.Unmarshal(result, &subscription); err != nil || len(subscription) != 2 {
+		t.Fatalf("invalid subscription result: %s", result)
+	}
+	extranonce := subscription[1].(string)
+	if len(extranonce) != 2*stratumExtranonceSize {
+		t.Fatalf("extranonce length mismatch: have %d, want %d", len(extranonce), 2*stratumExtranonceSize)
+	}
+	if _, code := miner.request("mining.authorize", "worker", "x"); code != 0 {
+		t.Fatalf("failed to authorize: error code %d", code)
+	}
+	if params := miner.notification("mining.set_difficulty"); len(params) != 1 {
+		t.Fatalf("invalid difficulty notification: %v", params)
+	}
+	// Hand out a job too hard to seal a block with, and submit shares for it
+	hard := &Work{
+		Block:     types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Difficulty: new(big.Int).Lsh(big.NewInt(1), 128)}),
+		createdAt: time.Now(),
+	}
+	server.Work() <- hard
+
+	job := miner.notification("mining.notify")
+	if len(job) != 4 || job[2] != hard.Block.HashNoNonce().Hex()[2:] {
+		t.Fatalf("invalid job notification: %v", job)
+	}
+	if _, code := miner.request("mining.submit", "stranger", job[0], "000000000001"); code != errStratumUnauthorized.code {
+		t.Errorf("unauthorized share: error code mismatch: have %d, want %d", code, errStratumUnauthorized.code)
+	}
+	if _, code := miner.request("mining.submit", "worker", "deadbeef", "000000000001"); code != errStratumJobNotFound.code {
+		t.Errorf("unknown job share: error code mismatch: have %d, want %d", code, errStratumJobNotFound.code)
+	}
+	if _, code := miner.request("mining.submit", "worker", job[0], "000000000001"); code != 0 {
+		t.Errorf("valid share rejected: error code %d", code)
+	}
+	if _, code := miner.request("mining.submit", "worker", job[0], "000000000001"); code != errStratumDuplicate.code {
+		t.Errorf("duplicate share: error code mismatch: have %d, want %d", code, errStratumDuplicate.code)
+	}
+	server.mu.Lock()
+	server.difficulty = new(big.Int).Lsh(big.NewInt(1), 100)
+	server.mu.Unlock()
+
+	if _, code := miner.request("mining.submit", "worker", job[0], "000000000002"); code != errStratumLowDifficulty.code {
+		t.Errorf("low difficulty share: error code mismatch: have %d, want %d", code, errStratumLowDifficulty.code)
+	}
+	server.mu.Lock()
+	server.difficulty = big.NewInt(1)
+	server.mu.Unlock()
+
+	select {
+	case <-results:
+		t.Fatalf("share sealed a block above its difficulty")
+	default:
+	}
+	if rate := server.GetHashRate(); rate == 0 {
+		t.Errorf("hashrate not accounted")
+	}
+	// Hand out a trivial job and ensure the first share seals the block
+	easy := &Work{
+		Block:     types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1)}),
+		createdAt: time.Now(),
+	}
+	server.Work() <- easy
+
+	job = miner.notification("mining.notify")
+	if _, code := miner.request("mining.submit", "worker", job[0], "000000000003"); code != 0 {
+		t.Fatalf("valid share rejected: error code %d", code)
+	}
+	select {
+	case result := <-results:
+		if result.Work != easy {
+			t.Errorf("sealed block for wrong work")
+		}
+		if have, want := fmt.Sprintf("%016x", result.Block.Nonce()), extranonce+"000000000003"; have != want {
+			t.Errorf("nonce mismatch: have %s, want %s", have, want)
+		}
+		if err := ethash.NewTester().VerifySeal(nil, result.Block.Header()); err != nil {
+			t.Errorf("invalid sealed block: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("block not sealed")
+	}
+	// Shares of already sealed jobs are stale
+	if _, code := miner.request("mining.submit", "worker", job[0], "000000000004"); code != errStratumJobNotFound.code {
+		t.Errorf("sealed job share: error code mismatch: have %d, want %d", code, errStratumJobNotFound.code)
+	}
+}
+
+// Tests that shares are accounted in the hashrate with the difficulty they were
+// verified against, which is capped at the difficulty of their block.
+func TestStratumHashrate(t *testing.T) {
+	server := NewStratumServer(nil, ethash.NewTester(), new(big.Int).Lsh(big.NewInt(1), 20))
+	if err := server.Listen("127.0.0.1:0"); err != nil {
+		t.Fatalf("failed to start stratum server: %v", err)
+	}
+	defer server.Close()
+
+	server.SetReturnCh(make(chan *Result, 1))
+	server.Start()
+	defer server.Stop()
+
+	miner := newStratumTester(t, server.Addr())
+	defer miner.conn.Close()
+
+	if _, code := miner.request("mining.subscribe", "tester/1.0", stratumVersion); code != 0 {
+		t.Fatalf("failed to subscribe: error code %d", code)
+	}
+	if _, code := miner.request("mining.authorize", "worker", "x"); code != 0 {
+		t.Fatalf("failed to authorize: error code %d", code)
+	}
+	miner.notification("mining.set_difficulty")
+
+	// Hand out a job easier than the share difficulty and submit a share for it
+	server.Work() <- &Work{
+		Block:     types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1)}),
+		createdAt: time.Now(),
+	}
+	job := miner.notification("mining.notify")
+	if _, code := miner.request("mining.submit", "worker", job[0], "000000000001"); code != 0 {
+		t.Fatalf("valid share rejected: error code %d", code)
+	}
+	if rate := server.Hashrates()["worker"]; rate != 1 {
+		t.Errorf("hashrate mismatch: have %d, want %d", rate, 1)
+	}
+}
+
+// Tests that extranonces are never shared by live sessions, and that the ones of
+// closed sessions are reused once the counter wraps around.
+func TestStratumExtranonces(t *testing.T) {
+	server := NewStratumServer(nil, ethash.NewTester(), big.NewInt(1))
+
+	// Take all the nonce prefixes but the last, then rewind the counter to check
+	// that the taken ones are skipped
+	const prefixes = 1 << (8 * stratumExtranonceSize)
+	for i := 0; i < prefixes-1; i++ {
+		if server.assignExtranonce() == nil {
+			t.Fatalf("extranonce %d: nonce space exhausted", i)
+		}
+	}
+	server.nonces = 0
+	free := server.assignExtranonce()
+	if free == nil || len(free) != stratumExtranonceSize {
+		t.Fatalf("last free extranonce not assigned: %x", free)
+	}
+	if extranonce := server.assignExtranonce(); extranonce != nil {
+		t.Fatalf("extranonce %x assigned twice", extranonce)
+	}
+	// Release a prefix in the middle and ensure it's reused
+	delete(server.extranonces, string([]byte{0x12, 0x34}))
+	if extranonce := server.assignExtranonce(); string(extranonce) != string([]byte{0x12, 0x34}) {
+		t.Fatalf("released extranonce not reused: have %x, want 1234", extranonce)
+	}
+}
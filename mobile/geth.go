@@ -70,6 +70,15 @@ type NodeConfig struct {
 	// It has the form "nodename:secret@host:port"
 	EthereumNetStats string
 
+	// UltraLightServers is the set of trusted LES servers of an ultra light client.
+	// If it's non-empty, headers are accepted without proof-of-work verification
+	// once enough of these servers announced them.
+	UltraLightServers *Enodes
+
+	// UltraLightFraction is the minimum percentage of trusted servers that need to
+	// announce a head for it to be accepted. Zero means the default of 75%.
+	UltraLightFraction int
+
 	// WhisperEnabled specifies whether the node should run the Whisper protocol.
 	WhisperEnabled bool
 }
@@ -149,6 +158,13 @@ func NewNode(datadir string, config *NodeConfig) (stack *Node, _ error) {
 		ethConf.SyncMode = downloader.LightSync
 		ethConf.NetworkId = uint64(config.EthereumNetworkID)
 		ethConf.DatabaseCache = config.EthereumDatabaseCache
+		if config.UltraLightServers != nil && config.UltraLightServers.Size() > 0 {
+			ulc := &eth.ULCConfig{MinTrustedFraction: config.UltraLightFraction}
+			for _, node := range config.UltraLightServers.nodes {
+				ulc.TrustedServers = append(ulc.TrustedServers, node.String())
+			}
+			ethConf.ULC = ulc
+		}
 		if err := rawStack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
 			return les.New(ctx, &ethConf)
 		}); err != nil {
@@ -17,8 +17,10 @@
 package params
 
 import (
+	"bytes"
 	"fmt"
 	"math/big"
+	"sort"
 
 	"github.com/ethereum/go-ethereum/common"
 )
@@ -82,16 +84,16 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), new(EthashConfig), nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, new(EthashConfig), nil, nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Ethereum core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, &CliqueConfig{Period: 0, Epoch: 30000}}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), new(EthashConfig), nil}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, new(EthashConfig), nil, nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -115,11 +117,34 @@ type ChainConfig struct {
 	EIP155Block *big.Int `json:"eip155Block,omitempty"` // EIP155 HF block
 	EIP158Block *big.Int `json:"eip158Block,omitempty"` // EIP158 HF block
 
-	ByzantiumBlock *big.Int `json:"byzantiumBlock,omitempty"` // Byzantium switch block (nil = no fork, 0 = already on byzantium)
+	ByzantiumBlock      *big.Int `json:"byzantiumBlock,omitempty"`      // Byzantium switch block (nil = no fork, 0 = already on byzantium)
+	ConstantinopleBlock *big.Int `json:"constantinopleBlock,omitempty"` // Constantinople switch block (nil = no fork, 0 = already activated)
+
+	// EIPs schedules individual protocol changes outside of the named hard forks,
+	// mapping the EIP number to its activation block. Only the EIPs listed in
+	// ActivableEIPs are supported.
+	EIPs map[int]*big.Int `json:"eips,omitempty"`
+
+	// Precompiles activates additional native contracts at the given addresses.
+	// The contracts themselves are registered with the EVM by name.
+	Precompiles map[common.Address]*PrecompileConfig `json:"precompiles,omitempty"`
 
 	// Various consensus engines
-	Ethash *EthashConfig `json:"ethash,omitempty"`
-	Clique *CliqueConfig `json:"clique,omitempty"`
+	Ethash   *EthashConfig   `json:"ethash,omitempty"`
+	Clique   *CliqueConfig   `json:"clique,omitempty"`
+	Istanbul *IstanbulConfig `json:"istanbul,omitempty"`
+}
+
+// PrecompileConfig is the activation of a native contract registered with the
+// EVM, at the address it is mapped to in the chain configuration.
+type PrecompileConfig struct {
+	Name  string   `json:"name"`  // Name the contract is registered with in the EVM
+	Block *big.Int `json:"block"` // Activation block (nil = never activated)
+}
+
+// String implements the fmt.Stringer interface.
+func (c *PrecompileConfig) String() string {
+	return fmt.Sprintf("%s@%v", c.Name, c.Block)
 }
 
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
@@ -132,8 +157,9 @@ func (c *EthashConfig) String() string {
 
 // CliqueConfig is the consensus engine configs for proof-of-authority based sealing.
 type CliqueConfig struct {
-	Period uint64 `json:"period"` // Number of seconds between blocks to enforce
-	Epoch  uint64 `json:"epoch"`  // Epoch length to reset votes and checkpoint
+	Period     uint64 `json:"period"`               // Number of seconds between blocks to enforce
+	Epoch      uint64 `json:"epoch"`                // Epoch length to reset votes and checkpoint
+	MaxSigners uint64 `json:"maxSigners,omitempty"` // Maximum number of authorized signers (0 = unlimited)
 }
 
 // String implements the stringer interface, returning the consensus engine details.
@@ -141,6 +167,19 @@ func (c *CliqueConfig) String() string {
 	return "clique"
 }
 
+// IstanbulConfig is the consensus engine configs for Istanbul byzantine fault
+// tolerant sealing.
+type IstanbulConfig struct {
+	Epoch          uint64 `json:"epoch"`          // Epoch length to reset votes and checkpoint
+	BlockPeriod    uint64 `json:"blockPeriod"`    // Minimum number of seconds between blocks to enforce
+	RequestTimeout uint64 `json:"requestTimeout"` // Milliseconds to wait for a round to complete before changing it
+}
+
+// String implements the stringer interface, returning the consensus engine details.
+func (c *IstanbulConfig) String() string {
+	return "istanbul"
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
@@ -149,10 +188,12 @@ func (c *ChainConfig) String() string {
 		engine = c.Ethash
 	case c.Clique != nil:
 		engine = c.Clique
+	case c.Istanbul != nil:
+		engine = c.Istanbul
 	default:
 		engine = "unknown"
 	}
-	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Engine: %v}",
+	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v EIPs: %v Precompiles: %v Engine: %v}",
 		c.ChainId,
 		c.HomesteadBlock,
 		c.DAOForkBlock,
@@ -161,6 +202,9 @@ func (c *ChainConfig) String() string {
 		c.EIP155Block,
 		c.EIP158Block,
 		c.ByzantiumBlock,
+		c.ConstantinopleBlock,
+		c.EIPs,
+		c.Precompiles,
 		engine,
 	)
 }
@@ -191,6 +235,92 @@ func (c *ChainConfig) IsByzantium(num *big.Int) bool {
 	return isForked(c.ByzantiumBlock, num)
 }
 
+// IsConstantinople returns whether num is either equal to the Constantinople fork block or greater.
+func (c *ChainConfig) IsConstantinople(num *big.Int) bool {
+	return isForked(c.ConstantinopleBlock, num)
+}
+
+// IsEIPActive returns whether the given EIP is active at block num, either as
+// part of a hard fork or scheduled individually in the EIPs map.
+func (c *ChainConfig) IsEIPActive(eip int, num *big.Int) bool {
+	if isForked(c.EIPs[eip], num) {
+		return true
+	}
+	if c.IsConstantinople(num) {
+		for _, fork := range ConstantinopleEIPs {
+			if eip == fork {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckEIPs verifies that all individually scheduled EIPs are supported and
+// have an activation block set.
+func (c *ChainConfig) CheckEIPs() error {
+	for _, eip := range c.scheduledEIPs() {
+		if _, ok := ActivableEIPs[eip]; !ok {
+			return fmt.Errorf("unsupported EIP-%d in activation map", eip)
+		}
+		if c.EIPs[eip] == nil {
+			return fmt.Errorf("missing activation block for EIP-%d", eip)
+		}
+	}
+	return nil
+}
+
+// scheduledEIPs returns the EIPs of the activation map in ascending order.
+func (c *ChainConfig) scheduledEIPs() []int {
+	eips := make([]int, 0, len(c.EIPs))
+	for eip := range c.EIPs {
+		eips = append(eips, eip)
+	}
+	sort.Ints(eips)
+	return eips
+}
+
+// ActivePrecompiles returns the names of the additional native contracts active
+// at block num, keyed by their addresses.
+func (c *ChainConfig) ActivePrecompiles(num *big.Int) map[common.Address]string {
+	var active map[common.Address]string
+	for addr, precompile := range c.Precompiles {
+		if precompile != nil && isForked(precompile.Block, num) {
+			if active == nil {
+				active = make(map[common.Address]string)
+			}
+			active[addr] = precompile.Name
+		}
+	}
+	return active
+}
+
+// scheduledPrecompiles returns the addresses of the additional native contracts
+// in ascending order.
+func (c *ChainConfig) scheduledPrecompiles() []common.Address {
+	addrs := make([]common.Address, 0, len(c.Precompiles))
+	for addr := range c.Precompiles {
+		addrs = append(addrs, addr)
+	}
+	sort.Sort(addressesByValue(addrs))
+	return addrs
+}
+
+// precompileBlock returns the activation block of the native contract at the
+// given address, or nil if there is none.
+func (c *ChainConfig) precompileBlock(addr common.Address) *big.Int {
+	if precompile := c.Precompiles[addr]; precompile != nil {
+		return precompile.Block
+	}
+	return nil
+}
+
+type addressesByValue []common.Address
+
+func (s addressesByValue) Len() int           { return len(s) }
+func (s addressesByValue) Less(i, j int) bool { return bytes.Compare(s[i][:], s[j][:]) < 0 }
+func (s addressesByValue) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
 // GasTable returns the gas table corresponding to the current phase (homestead or homestead reprice).
 //
 // The returned GasTable's fields shouldn't, under any circumstances, be changed.
@@ -251,9 +381,47 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.ByzantiumBlock, newcfg.ByzantiumBlock, head) {
 		return newCompatError("Byzantium fork block", c.ByzantiumBlock, newcfg.ByzantiumBlock)
 	}
+	if isForkIncompatible(c.ConstantinopleBlock, newcfg.ConstantinopleBlock, head) {
+		return newCompatError("Constantinople fork block", c.ConstantinopleBlock, newcfg.ConstantinopleBlock)
+	}
+	for _, eip := range mergeEIPs(c.scheduledEIPs(), newcfg.scheduledEIPs()) {
+		if isForkIncompatible(c.EIPs[eip], newcfg.EIPs[eip], head) {
+			return newCompatError(fmt.Sprintf("EIP-%d activation block", eip), c.EIPs[eip], newcfg.EIPs[eip])
+		}
+	}
+	addrs := append(c.scheduledPrecompiles(), newcfg.scheduledPrecompiles()...)
+	sort.Sort(addressesByValue(addrs))
+	for i, addr := range addrs {
+		if i > 0 && addrs[i-1] == addr {
+			continue
+		}
+		oldblock, newblock := c.precompileBlock(addr), newcfg.precompileBlock(addr)
+		if isForkIncompatible(oldblock, newblock, head) {
+			return newCompatError(fmt.Sprintf("precompile %x activation block", addr), oldblock, newblock)
+		}
+		if isForked(oldblock, head) && c.Precompiles[addr].Name != newcfg.Precompiles[addr].Name {
+			return newCompatError(fmt.Sprintf("precompile %x contract", addr), oldblock, newblock)
+		}
+	}
 	return nil
 }
 
+// mergeEIPs returns the sorted union of two sorted EIP lists.
+func mergeEIPs(a, b []int) []int {
+	merged := make([]int, 0, len(a)+len(b))
+	for len(a) > 0 || len(b) > 0 {
+		switch {
+		case len(b) == 0 || (len(a) > 0 && a[0] < b[0]):
+			merged, a = append(merged, a[0]), a[1:]
+		case len(a) == 0 || b[0] < a[0]:
+			merged, b = append(merged, b[0]), b[1:]
+		default:
+			merged, a, b = append(merged, a[0]), a[1:], b[1:]
+		}
+	}
+	return merged
+}
+
 // isForkIncompatible returns true if a fork scheduled at s1 cannot be rescheduled to
 // block s2 because head is already past the fork.
 func isForkIncompatible(s1, s2, head *big.Int) bool {
@@ -317,7 +485,7 @@ func (err *ConfigCompatError) Error() string {
 type Rules struct {
 	ChainId                                   *big.Int
 	IsHomestead, IsEIP150, IsEIP155, IsEIP158 bool
-	IsByzantium                               bool
+	IsByzantium, IsConstantinople             bool
 }
 
 func (c *ChainConfig) Rules(num *big.Int) Rules {
@@ -325,5 +493,5 @@ func (c *ChainConfig) Rules(num *big.Int) Rules {
 	if chainId == nil {
 		chainId = new(big.Int)
 	}
-	return Rules{ChainId: new(big.Int).Set(chainId), IsHomestead: c.IsHomestead(num), IsEIP150: c.IsEIP150(num), IsEIP155: c.IsEIP155(num), IsEIP158: c.IsEIP158(num), IsByzantium: c.IsByzantium(num)}
+	return Rules{ChainId: new(big.Int).Set(chainId), IsHomestead: c.IsHomestead(num), IsEIP150: c.IsEIP150(num), IsEIP155: c.IsEIP155(num), IsEIP158: c.IsEIP158(num), IsByzantium: c.IsByzantium(num), IsConstantinople: c.IsConstantinople(num)}
 }
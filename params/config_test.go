@@ -17,9 +17,12 @@
 package params
 
 import (
+	"encoding/json"
 	"math/big"
 	"reflect"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
 )
 
 func TestCheckCompatible(t *testing.T) {
@@ -70,6 +73,51 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     9,
 			},
 		},
+		{
+			stored:  &ChainConfig{EIPs: map[int]*big.Int{EIP1283: big.NewInt(10)}},
+			new:     &ChainConfig{EIPs: map[int]*big.Int{EIP1283: big.NewInt(20)}},
+			head:    9,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{EIPs: map[int]*big.Int{EIP145: big.NewInt(30), EIP1283: big.NewInt(10)}},
+			new:    &ChainConfig{EIPs: map[int]*big.Int{EIP145: big.NewInt(25)}},
+			head:   25,
+			wantErr: &ConfigCompatError{
+				What:         "EIP-1283 activation block",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    nil,
+				RewindTo:     9,
+			},
+		},
+		{
+			stored:  &ChainConfig{Precompiles: map[common.Address]*PrecompileConfig{common.BytesToAddress([]byte{9}): {"blake2b", big.NewInt(10)}}},
+			new:     &ChainConfig{Precompiles: map[common.Address]*PrecompileConfig{common.BytesToAddress([]byte{9}): {"ed25519", big.NewInt(20)}}},
+			head:    9,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{Precompiles: map[common.Address]*PrecompileConfig{common.BytesToAddress([]byte{9}): {"blake2b", big.NewInt(10)}}},
+			new:    &ChainConfig{Precompiles: map[common.Address]*PrecompileConfig{common.BytesToAddress([]byte{9}): {"blake2b", big.NewInt(20)}}},
+			head:   15,
+			wantErr: &ConfigCompatError{
+				What:         "precompile 0000000000000000000000000000000000000009 activation block",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(20),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{Precompiles: map[common.Address]*PrecompileConfig{common.BytesToAddress([]byte{9}): {"blake2b", big.NewInt(10)}}},
+			new:    &ChainConfig{Precompiles: map[common.Address]*PrecompileConfig{common.BytesToAddress([]byte{9}): {"ed25519", big.NewInt(10)}}},
+			head:   15,
+			wantErr: &ConfigCompatError{
+				What:         "precompile 0000000000000000000000000000000000000009 contract",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -79,3 +127,90 @@ func TestCheckCompatible(t *testing.T) {
 		}
 	}
 }
+
+func TestIsEIPActive(t *testing.T) {
+	config := &ChainConfig{
+		ConstantinopleBlock: big.NewInt(20),
+		EIPs:                map[int]*big.Int{EIP1283: big.NewInt(10)},
+	}
+	tests := []struct {
+		eip  int
+		num  int64
+		want bool
+	}{
+		{EIP1283, 9, false},
+		{EIP1283, 10, true},
+		{EIP145, 10, false},
+		{EIP145, 20, true},
+		{EIP1234, 20, true},
+		{1, 20, false},
+	}
+	for _, tt := range tests {
+		if have := config.IsEIPActive(tt.eip, big.NewInt(tt.num)); have != tt.want {
+			t.Errorf("EIP-%d at block %d: active mismatch: have %v, want %v", tt.eip, tt.num, have, tt.want)
+		}
+	}
+}
+
+func TestCheckEIPs(t *testing.T) {
+	tests := []struct {
+		eips map[int]*big.Int
+		fail bool
+	}{
+		{nil, false},
+		{map[int]*big.Int{EIP145: big.NewInt(0), EIP1234: big.NewInt(5)}, false},
+		{map[int]*big.Int{EIP145: nil}, true},
+		{map[int]*big.Int{1: big.NewInt(0)}, true},
+	}
+	for i, tt := range tests {
+		err := (&ChainConfig{EIPs: tt.eips}).CheckEIPs()
+		if (err != nil) != tt.fail {
+			t.Errorf("test %d: error mismatch: have %v, want failure %v", i, err, tt.fail)
+		}
+	}
+}
+
+func TestEIPsJSON(t *testing.T) {
+	var config ChainConfig
+	if err := json.Unmarshal([]byte(`{"chainId": 1, "eips": {"145": 5, "1283": 10}}`), &config); err != nil {
+		t.Fatalf("failed to decode config: %v", err)
+	}
+	want := map[int]*big.Int{EIP145: big.NewInt(5), EIP1283: big.NewInt(10)}
+	if !reflect.DeepEqual(config.EIPs, want) {
+		t.Errorf("activation map mismatch: have %v, want %v", config.EIPs, want)
+	}
+}
+
+func TestActivePrecompiles(t *testing.T) {
+	config := &ChainConfig{
+		Precompiles: map[common.Address]*PrecompileConfig{
+			common.BytesToAddress([]byte{9}):  {Name: "blake2b", Block: big.NewInt(5)},
+			common.BytesToAddress([]byte{10}): {Name: "ed25519", Block: big.NewInt(10)},
+			common.BytesToAddress([]byte{11}): {Name: "disabled"},
+		},
+	}
+	tests := []struct {
+		num  int64
+		want map[common.Address]string
+	}{
+		{4, nil},
+		{5, map[common.Address]string{common.BytesToAddress([]byte{9}): "blake2b"}},
+		{10, map[common.Address]string{common.BytesToAddress([]byte{9}): "blake2b", common.BytesToAddress([]byte{10}): "ed25519"}},
+	}
+	for _, tt := range tests {
+		if have := config.ActivePrecompiles(big.NewInt(tt.num)); !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("block %d: active precompiles mismatch: have %v, want %v", tt.num, have, tt.want)
+		}
+	}
+}
+
+func TestPrecompilesJSON(t *testing.T) {
+	var config ChainConfig
+	if err := json.Unmarshal([]byte(`{"chainId": 1, "precompiles": {"0x0000000000000000000000000000000000000009": {"name": "blake2b", "block": 5}}}`), &config); err != nil {
+		t.Fatalf("failed to decode config: %v", err)
+	}
+	want := map[common.Address]*PrecompileConfig{common.BytesToAddress([]byte{9}): {Name: "blake2b", Block: big.NewInt(5)}}
+	if !reflect.DeepEqual(config.Precompiles, want) {
+		t.Errorf("precompiles mismatch: have %v, want %v", config.Precompiles, want)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package params
+
+// Ethereum improvement proposals that can be activated individually through the
+// EIPs map of the chain configuration.
+const (
+	EIP145  = 145  // Bitwise shifting instructions in EVM
+	EIP152  = 152  // BLAKE2b F compression function precompile
+	EIP665  = 665  // Ed25519 signature verification precompile
+	EIP1014 = 1014 // Skinny CREATE2
+	EIP1052 = 1052 // EXTCODEHASH opcode
+	EIP1234 = 1234 // Constantinople difficulty bomb delay and block reward adjustment
+	EIP1283 = 1283 // Net gas metering for SSTORE without dirty maps
+)
+
+// ConstantinopleEIPs contains the EIPs activated by the Constantinople hard fork.
+var ConstantinopleEIPs = []int{EIP145, EIP1014, EIP1052, EIP1234, EIP1283}
+
+// ActivableEIPs contains the EIPs supported in the activation map of the chain
+// configuration, along with a short description of each.
+var ActivableEIPs = map[int]string{
+	EIP145:  "Bitwise shifting instructions in EVM",
+	EIP152:  "BLAKE2b F compression function precompile",
+	EIP665:  "Ed25519 signature verification precompile",
+	EIP1014: "Skinny CREATE2",
+	EIP1052: "EXTCODEHASH opcode",
+	EIP1234: "Constantinople difficulty bomb delay and block reward adjustment",
+	EIP1283: "Net gas metering for SSTORE without dirty maps",
+}
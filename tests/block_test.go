@@ -18,6 +18,8 @@ package tests
 
 import (
 	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
 )
 
 func TestBlockchain(t *testing.T) {
@@ -30,15 +32,16 @@ func TestBlockchain(t *testing.T) {
 	bt.skipLoad(`^bcForgedTest/bcForkUncle\.json`)
 	bt.skipLoad(`^bcMultiChainTest/(ChainAtoChainB_blockorder|CallContractFromNotBestBlock)`)
 	bt.skipLoad(`^bcTotalDifficultyTest/(lotsOfLeafs|lotsOfBranches|sideChainWithMoreTransactions)`)
-	// Constantinople is not implemented yet.
-	bt.skipLoad(`(?i)(constantinople)`)
 
 	// Still failing tests
 	bt.skipLoad(`^bcWalletTest.*_Byzantium$`)
 
 	bt.walk(t, blockTestDir, func(t *testing.T, name string, test *BlockTest) {
-		if err := bt.checkFailure(t, name, test.Run()); err != nil {
+		if err := bt.checkFailure(t, name, test.Run(vm.Config{}, false)); err != nil {
 			t.Error(err)
 		}
+		if err := bt.checkFailure(t, name, test.Run(vm.Config{}, true)); err != nil {
+			t.Errorf("in parallel mode: %v", err)
+		}
 	})
 }
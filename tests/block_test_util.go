@@ -46,6 +46,18 @@ func (t *BlockTest) UnmarshalJSON(in []byte) error {
 	return json.Unmarshal(in, &t.json)
 }
 
+// BlockError is returned by a block test failing on a particular block, either
+// because it could not be imported or because the state it resulted in (as the
+// head of the chain) doesn't match the expected post state.
+type BlockError struct {
+	Number *big.Int
+	Err    error
+}
+
+func (e BlockError) Error() string {
+	return e.Err.Error()
+}
+
 type btJSON struct {
 	Blocks    []btBlock             `json:"blocks"`
 	Genesis   btHeader              `json:"genesisBlockHeader"`
@@ -91,11 +103,22 @@ type btHeaderMarshaling struct {
 	Timestamp  *math.HexOrDecimal256
 }
 
-func (t *BlockTest) Run() error {
+// Network returns the name of the fork rules the test runs with.
+func (t *BlockTest) Network() string {
+	return t.json.Network
+}
+
+// Run imports the test blocks on top of the genesis, checking the validity of
+// each and the resulting post state, with the given EVM configuration. If parallel
+// is set, the transactions of the blocks are executed concurrently.
+func (t *BlockTest) Run(vmconfig vm.Config, parallel bool) error {
 	config, ok := Forks[t.json.Network]
 	if !ok {
 		return UnsupportedForkError{t.json.Network}
 	}
+	if err := core.CheckChainConfig(config); err != nil {
+		return err
+	}
 
 	// import pre accounts & construct test genesis block & state root
 	db, _ := ethdb.NewMemDatabase()
@@ -110,12 +133,15 @@ func (t *BlockTest) Run() error {
 		return fmt.Errorf("genesis block state root does not match test: computed=%x, test=%x", gblock.Root().Bytes()[:6], t.json.Genesis.StateRoot[:6])
 	}
 
-	chain, err := core.NewBlockChain(db, nil, config, ethash.NewShared(), vm.Config{})
+	chain, err := core.NewBlockChain(db, nil, config, ethash.NewShared(), vmconfig)
 	if err != nil {
 		return err
 	}
 	defer chain.Stop()
 
+	if parallel {
+		chain.SetProcessor(core.NewParallelStateProcessor(config, chain, chain.Engine(), 4))
+	}
 	validBlocks, err := t.insertBlocks(chain)
 	if err != nil {
 		return err
@@ -129,7 +155,7 @@ func (t *BlockTest) Run() error {
 		return err
 	}
 	if err = t.validatePostState(newDB); err != nil {
-		return fmt.Errorf("post state validation failed: %v", err)
+		return BlockError{chain.CurrentBlock().Number(), fmt.Errorf("post state validation failed: %v", err)}
 	}
 	return t.validateImportedHeaders(chain, validBlocks)
 }
@@ -181,7 +207,7 @@ func (t *BlockTest) insertBlocks(blockchain *core.BlockChain) ([]btBlock, error)
 			if b.BlockHeader == nil {
 				continue // OK - block is supposed to be invalid, continue with next block
 			} else {
-				return nil, fmt.Errorf("Block #%v insertion into chain failed: %v", blocks[i].Number(), err)
+				return nil, BlockError{blocks[i].Number(), fmt.Errorf("Block #%v insertion into chain failed: %v", blocks[i].Number(), err)}
 			}
 		}
 		if b.BlockHeader == nil {
@@ -254,13 +280,13 @@ func (t *BlockTest) validatePostState(statedb *state.StateDB) error {
 		balance2 := statedb.GetBalance(addr)
 		nonce2 := statedb.GetNonce(addr)
 		if !bytes.Equal(code2, acct.Code) {
-			return fmt.Errorf("account code mismatch for addr: %s want: %v have: %s", addr, acct.Code, hex.EncodeToString(code2))
+			return fmt.Errorf("account code mismatch for addr: %x want: %v have: %s", addr, acct.Code, hex.EncodeToString(code2))
 		}
 		if balance2.Cmp(acct.Balance) != 0 {
-			return fmt.Errorf("account balance mismatch for addr: %s, want: %d, have: %d", addr, acct.Balance, balance2)
+			return fmt.Errorf("account balance mismatch for addr: %x, want: %d, have: %d", addr, acct.Balance, balance2)
 		}
 		if nonce2 != acct.Nonce {
-			return fmt.Errorf("account nonce mismatch for addr: %s want: %d have: %d", addr, acct.Nonce, nonce2)
+			return fmt.Errorf("account nonce mismatch for addr: %x want: %d have: %d", addr, acct.Nonce, nonce2)
 		}
 	}
 	return nil
@@ -0,0 +1,52 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build gofuzz
+
+package tests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Fuzz is the entry point of the go-fuzz tool for differential EVM fuzzing. The
+// input is turned into a state test which is executed under all the fork
+// configurations that must agree on its outcome, and compared step by step
+// against the reference EVM binary set by $EVMFUZZ_REFERENCE, if any. Divergences
+// crash the fuzzer, saving the state test into the folder set by
+// $EVMFUZZ_CRASHERS, if any.
+func Fuzz(input []byte) int {
+	test := GenerateStateTest(input)
+
+	err := test.Diff()
+	if evm := os.Getenv("EVMFUZZ_REFERENCE"); err == nil && evm != "" {
+		err = test.DiffReference(evm)
+	}
+	if err != nil {
+		if dir := os.Getenv("EVMFUZZ_CRASHERS"); dir != "" {
+			name := fmt.Sprintf("evmfuzz-%x", crypto.Keccak256(input)[:8])
+			if err := test.Save(filepath.Join(dir, name+".json"), name); err != nil {
+				panic(fmt.Sprintf("failed to save crasher: %v", err))
+			}
+		}
+		panic(err)
+	}
+	return 1
+}
@@ -0,0 +1,157 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// fuzzInputs generates deterministic pseudo random fuzzer inputs.
+func fuzzInputs(n int) [][]byte {
+	rand := rand.New(rand.NewSource(1))
+
+	inputs := make([][]byte, n)
+	for i := range inputs {
+		inputs[i] = make([]byte, rand.Intn(512))
+		rand.Read(inputs[i])
+	}
+	return inputs
+}
+
+// Tests that generated state tests execute identically under equivalent fork
+// configurations.
+func TestFuzzDiff(t *testing.T) {
+	for i, input := range fuzzInputs(100) {
+		test := GenerateStateTest(input)
+		if err := test.Diff(); err != nil {
+			t.Errorf("input %d (%x): %v", i, input, err)
+		}
+	}
+}
+
+// Tests that generated state tests execute identically with a reference EVM,
+// the one set by $EVMFUZZ_REFERENCE or else the evm command built from the tree.
+func TestFuzzDiffReference(t *testing.T) {
+	evm := os.Getenv("EVMFUZZ_REFERENCE")
+	if evm == "" {
+		dir, err := ioutil.TempDir("", "evmfuzz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		evm = filepath.Join(dir, "evm")
+		gocmd := filepath.Join(runtime.GOROOT(), "bin", "go")
+		if out, err := exec.Command(gocmd, "build", "-o", evm, "github.com/ethereum/go-ethereum/cmd/evm").CombinedOutput(); err != nil {
+			t.Fatalf("failed to build evm: %v\n%s", err, out)
+		}
+	}
+	for i, input := range fuzzInputs(10) {
+		if err := GenerateStateTest(input).DiffReference(evm); err != nil {
+			t.Errorf("input %d (%x): %v", i, input, err)
+		}
+	}
+}
+
+// Tests that saved state tests can be loaded and pass with the state test runner.
+func TestFuzzSave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "evmfuzz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for i, input := range fuzzInputs(10) {
+		name := fmt.Sprintf("fuzz-%d", i)
+		path := filepath.Join(dir, name+".json")
+		if err := GenerateStateTest(input).Save(path, name); err != nil {
+			t.Fatalf("input %d: failed to save state test: %v", i, err)
+		}
+		blob, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tests map[string]StateTest
+		if err := json.Unmarshal(blob, &tests); err != nil {
+			t.Fatalf("input %d: failed to load state test: %v", i, err)
+		}
+		test, ok := tests[name]
+		if !ok {
+			t.Fatalf("input %d: state test missing", i)
+		}
+		if subtests := test.Subtests(); len(subtests) != len(Forks) {
+			t.Fatalf("input %d: subtest count mismatch: have %d, want %d", i, len(subtests), len(Forks))
+		}
+		for _, subtest := range test.Subtests() {
+			if _, err := test.Run(subtest, vm.Config{}); err != nil {
+				t.Errorf("input %d: subtest %s failed: %v", i, subtest.Fork, err)
+			}
+		}
+	}
+}
+
+// Tests that execution traces are correctly diffed against reference ones.
+func TestFuzzDiffTrace(t *testing.T) {
+	for i, input := range fuzzInputs(10) {
+		test := GenerateStateTest(input)
+		subtest := StateSubtest{"Byzantium", 0}
+
+		// Assemble a reference trace from the execution itself
+		tracer := vm.NewStructLogger(nil)
+		result, err := test.Execute(subtest, vm.Config{Debug: true, Tracer: tracer})
+		if err != nil {
+			t.Fatalf("input %d: failed to execute: %v", i, err)
+		}
+		logs := tracer.StructLogs()
+
+		reference := new(bytes.Buffer)
+		for _, log := range logs {
+			blob, _ := json.Marshal(log)
+			reference.Write(append(blob, '\n'))
+		}
+		fmt.Fprintf(reference, "{\"stateRoot\": \"%x\"}\n", result.Root)
+
+		if err := test.DiffTrace(subtest, bytes.NewReader(reference.Bytes())); err != nil {
+			t.Errorf("input %d: identical traces reported diverging: %v", i, err)
+		}
+		if len(logs) == 0 {
+			continue
+		}
+		// Tamper with the reference trace and ensure it's detected
+		logs[len(logs)/2].Gas++
+
+		reference.Reset()
+		for _, log := range logs {
+			blob, _ := json.Marshal(log)
+			reference.Write(append(blob, '\n'))
+		}
+		if err := test.DiffTrace(subtest, reference); err == nil {
+			t.Errorf("input %d: diverging traces not detected", i)
+		}
+	}
+}
@@ -0,0 +1,530 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+var (
+	// fuzzKey is the private key of the account sending the fuzzed transactions.
+	fuzzKey = common.Hex2Bytes("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+
+	fuzzSender   = common.HexToAddress("0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+	fuzzCoinbase = common.HexToAddress("0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba")
+	fuzzTarget   = common.HexToAddress("0x0000000000000000000000000000000000001000")
+	fuzzHelper   = common.HexToAddress("0x0000000000000000000000000000000000002000")
+)
+
+// fuzzTransitionBlock is the block number the composite forks of the test
+// suite switch rules at.
+const fuzzTransitionBlock = 5
+
+// fuzzEquivalences returns the groups of fork configurations a transaction
+// must behave identically under, depending on whether it is executed before or
+// after the transition block of the composite forks.
+func fuzzEquivalences(number uint64) [][]string {
+	if number < fuzzTransitionBlock {
+		return [][]string{
+			{"Frontier", "FrontierToHomesteadAt5"},
+			{"Homestead", "HomesteadToEIP150At5", "HomesteadToDaoAt5"},
+			{"EIP150"},
+			{"EIP158", "EIP158ToByzantiumAt5"},
+			{"Byzantium", "ByzantiumToConstantinopleAt5"},
+			{"Constantinople"},
+		}
+	}
+	return [][]string{
+		{"Frontier"},
+		{"Homestead", "FrontierToHomesteadAt5", "HomesteadToDaoAt5"},
+		{"EIP150", "HomesteadToEIP150At5"},
+		{"EIP158"},
+		{"Byzantium", "EIP158ToByzantiumAt5"},
+		{"Constantinople", "ByzantiumToConstantinopleAt5"},
+	}
+}
+
+// fuzzReader hands out the bytes of a fuzzer input, returning zeroes once the
+// input is exhausted.
+type fuzzReader struct {
+	data []byte
+}
+
+func (r *fuzzReader) exhausted() bool {
+	return len(r.data) == 0
+}
+
+func (r *fuzzReader) byte() byte {
+	if len(r.data) == 0 {
+		return 0
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+	return b
+}
+
+func (r *fuzzReader) bytes(n int) []byte {
+	b := make([]byte, n)
+	copy(b, r.data)
+	if n > len(r.data) {
+		n = len(r.data)
+	}
+	r.data = r.data[n:]
+	return b
+}
+
+// fuzzEnvOps are the instructions reading the execution environment without
+// taking any arguments.
+var fuzzEnvOps = []vm.OpCode{
+	vm.ADDRESS, vm.ORIGIN, vm.CALLER, vm.CALLVALUE, vm.CALLDATASIZE, vm.CODESIZE,
+	vm.GASPRICE, vm.RETURNDATASIZE, vm.COINBASE, vm.TIMESTAMP, vm.NUMBER,
+	vm.DIFFICULTY, vm.GASLIMIT, vm.PC, vm.MSIZE, vm.GAS,
+}
+
+// fuzzArithOps are the instructions operating on two stack items.
+var fuzzArithOps = []vm.OpCode{
+	vm.ADD, vm.MUL, vm.SUB, vm.DIV, vm.SDIV, vm.MOD, vm.SMOD, vm.EXP,
+	vm.SIGNEXTEND, vm.LT, vm.GT, vm.SLT, vm.SGT, vm.EQ, vm.AND, vm.OR, vm.XOR,
+	vm.BYTE, vm.SHL, vm.SHR, vm.SAR,
+}
+
+// fuzzProgram assembles EVM bytecode out of the fuzzer input. Instead of being
+// random bytes, the code is made of snippets setting up sensible arguments for
+// the instructions they exercise, so that execution gets past the first few
+// instructions: memory offsets are bounded, calls target existing contracts
+// and jumps land on valid destinations.
+func fuzzProgram(r *fuzzReader, snippets int) []byte {
+	var (
+		code   []byte
+		starts []int    // Code offsets of the snippets
+		fixups [][2]int // Jump destinations to patch: code offset and snippet index
+	)
+	push := func(value []byte) {
+		if len(value) == 0 {
+			value = []byte{0}
+		}
+		code = append(code, byte(vm.PUSH1)+byte(len(value)-1))
+		code = append(code, value...)
+	}
+	small := func(limit int) []byte {
+		return []byte{byte(int(r.byte()) % limit)}
+	}
+	address := func() []byte {
+		switch r.byte() % 4 {
+		case 0:
+			return fuzzTarget.Bytes()
+		case 1:
+			return fuzzHelper.Bytes()
+		case 2:
+			return []byte{1 + r.byte()%4} // Precompiles available in all forks
+		default:
+			return r.bytes(20)
+		}
+	}
+	for i := 0; i < snippets && !r.exhausted(); i++ {
+		starts = append(starts, len(code))
+		code = append(code, byte(vm.JUMPDEST))
+
+		switch r.byte() % 10 {
+		case 0: // Push a value of random width
+			push(r.bytes(1 + int(r.byte()%32)))
+
+		case 1: // Arithmetic, comparison or bitwise operation
+			push(r.bytes(1 + int(r.byte()%32)))
+			push(r.bytes(1 + int(r.byte()%32)))
+			code = append(code, byte(fuzzArithOps[int(r.byte())%len(fuzzArithOps)]))
+
+		case 2: // Memory access
+			switch r.byte() % 5 {
+			case 0:
+				push(r.bytes(32))
+				push(small(255))
+				code = append(code, byte(vm.MSTORE))
+			case 1:
+				push(r.bytes(1))
+				push(small(255))
+				code = append(code, byte(vm.MSTORE8))
+			case 2:
+				push(small(255))
+				code = append(code, byte(vm.MLOAD))
+			case 3:
+				push(small(64))
+				push(small(255))
+				code = append(code, byte(vm.SHA3))
+			default:
+				push(small(64))
+				push(small(64))
+				push(small(255))
+				code = append(code, byte([]vm.OpCode{vm.CALLDATACOPY, vm.CODECOPY, vm.RETURNDATACOPY}[int(r.byte())%3]))
+			}
+
+		case 3: // Storage access
+			if r.byte()%2 == 0 {
+				push(r.bytes(1 + int(r.byte()%32)))
+				push(small(4))
+				code = append(code, byte(vm.SSTORE))
+			} else {
+				push(small(4))
+				code = append(code, byte(vm.SLOAD))
+			}
+
+		case 4: // Environment access
+			switch r.byte() % 5 {
+			case 0:
+				push(address())
+				code = append(code, byte(vm.BALANCE))
+			case 1:
+				push(address())
+				code = append(code, byte(vm.EXTCODESIZE))
+			case 2:
+				push(small(8))
+				code = append(code, byte(vm.BLOCKHASH))
+			case 3:
+				push(small(64))
+				code = append(code, byte(vm.CALLDATALOAD))
+			default:
+				code = append(code, byte(fuzzEnvOps[int(r.byte())%len(fuzzEnvOps)]))
+			}
+
+		case 5: // Message call or contract creation
+			op := []vm.OpCode{vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL, vm.CREATE}[r.byte()%5]
+			if op == vm.CREATE {
+				push(small(64))
+				push(small(255))
+				push(small(3))
+			} else {
+				push(small(64))
+				push(small(255))
+				push(small(64))
+				push(small(255))
+				if op == vm.CALL || op == vm.CALLCODE {
+					push(small(3))
+				}
+				push(address())
+				push(r.bytes(3))
+			}
+			code = append(code, byte(op))
+
+		case 6: // Log emission
+			topics := int(r.byte() % 5)
+			for j := 0; j < topics; j++ {
+				push(r.bytes(1 + int(r.byte()%32)))
+			}
+			push(small(64))
+			push(small(255))
+			code = append(code, byte(vm.LOG0)+byte(topics))
+
+		case 7: // Conditional forward jump
+			push(small(2))
+			fixups = append(fixups, [2]int{len(code) + 1, i + 1 + int(r.byte()%4)})
+			push([]byte{0, 0})
+			code = append(code, byte(vm.JUMPI))
+
+		case 8: // Stack manipulation
+			op := []vm.OpCode{vm.POP, vm.DUP1, vm.DUP2, vm.SWAP1, vm.SWAP2}[r.byte()%5]
+			code = append(code, byte(op))
+
+		default: // Arbitrary instruction, valid or not
+			code = append(code, r.byte())
+		}
+	}
+	// Terminate the program, returning or reverting with some memory
+	end := len(code)
+	switch r.byte() % 3 {
+	case 0:
+		code = append(code, byte(vm.STOP))
+	case 1:
+		push(small(64))
+		push(small(64))
+		code = append(code, byte(vm.RETURN))
+	default:
+		push(small(64))
+		push(small(64))
+		code = append(code, byte(vm.REVERT))
+	}
+	for _, fixup := range fixups {
+		dest := end
+		if fixup[1] < len(starts) {
+			dest = starts[fixup[1]]
+		}
+		code[fixup[0]], code[fixup[0]+1] = byte(dest>>8), byte(dest)
+	}
+	return code
+}
+
+// GenerateStateTest assembles a state test out of a fuzzer input: a contract
+// with generated code, invoked by a transaction in a generated environment,
+// along with a helper contract it can call into. The test has a subtest for
+// every known fork, whose expected post states are left empty.
+func GenerateStateTest(input []byte) *StateTest {
+	r := &fuzzReader{data: input}
+
+	var test StateTest
+	test.json.Env = stEnv{
+		Coinbase:   fuzzCoinbase,
+		Difficulty: big.NewInt(0x20000),
+		GasLimit:   10000000,
+		Number:     1,
+		Timestamp:  1000,
+	}
+	if r.byte()%2 == 1 {
+		test.json.Env.Number = fuzzTransitionBlock + uint64(r.byte()%8)
+	}
+	test.json.Tx = stTransaction{
+		GasPrice:   big.NewInt(1),
+		To:         fuzzTarget.Hex(),
+		Data:       []string{hexutil.Encode(r.bytes(int(r.byte() % 64)))},
+		GasLimit:   []uint64{100000 + uint64(r.byte())*10000},
+		Value:      []string{fmt.Sprintf("%#x", r.byte()%4)},
+		PrivateKey: fuzzKey,
+	}
+	helper := fuzzProgram(r, 8)
+	target := fuzzProgram(r, 64)
+
+	test.json.Pre = core.GenesisAlloc{
+		fuzzSender: {Balance: big.NewInt(1000000000000000000)},
+		fuzzHelper: {Balance: big.NewInt(1), Code: helper},
+		fuzzTarget: {
+			Balance: big.NewInt(1000),
+			Code:    target,
+			Storage: map[common.Hash]common.Hash{
+				common.BigToHash(big.NewInt(1)): common.BigToHash(big.NewInt(1)),
+			},
+		},
+	}
+	test.json.Post = make(map[string][]stPostState)
+	for fork := range Forks {
+		test.json.Post[fork] = make([]stPostState, 1)
+	}
+	return &test
+}
+
+// Diff executes every subtest of the state test under all the fork
+// configurations it must behave identically under, as well as with and without
+// tracing, returning the first divergence found.
+func (t *StateTest) Diff() error {
+	for _, forks := range fuzzEquivalences(t.json.Env.Number) {
+		for index := range t.json.Post[forks[0]] {
+			base, err := t.Execute(StateSubtest{forks[0], index}, vm.Config{})
+			if err != nil {
+				return err
+			}
+			for _, fork := range forks[1:] {
+				if index >= len(t.json.Post[fork]) {
+					continue
+				}
+				result, err := t.Execute(StateSubtest{fork, index}, vm.Config{})
+				if err != nil {
+					return err
+				}
+				if err := diffStateResults(base, result); err != nil {
+					return fmt.Errorf("%s/%d and %s/%d diverge: %v", forks[0], index, fork, index, err)
+				}
+			}
+			// Tracing must not influence the outcome of the execution
+			traced, err := t.Execute(StateSubtest{forks[0], index}, vm.Config{Debug: true, Tracer: vm.NewStructLogger(nil)})
+			if err != nil {
+				return err
+			}
+			if err := diffStateResults(base, traced); err != nil {
+				return fmt.Errorf("%s/%d diverges when traced: %v", forks[0], index, err)
+			}
+		}
+	}
+	return nil
+}
+
+// diffStateResults compares the outcomes of two executions.
+func diffStateResults(a, b *StateResult) error {
+	switch {
+	case (a.Err == nil) != (b.Err == nil):
+		return fmt.Errorf("transaction validity mismatch: %v != %v", a.Err, b.Err)
+	case a.Root != b.Root:
+		return fmt.Errorf("post state root mismatch: %x != %x", a.Root, b.Root)
+	case a.Logs != b.Logs:
+		return fmt.Errorf("logs hash mismatch: %x != %x", a.Logs, b.Logs)
+	case a.GasUsed != b.GasUsed:
+		return fmt.Errorf("gas used mismatch: %d != %d", a.GasUsed, b.GasUsed)
+	case a.Failed != b.Failed:
+		return fmt.Errorf("execution failure mismatch: %v != %v", a.Failed, b.Failed)
+	case !bytes.Equal(a.Return, b.Return):
+		return fmt.Errorf("return data mismatch: %x != %x", a.Return, b.Return)
+	}
+	return nil
+}
+
+// Fill executes every subtest of the state test, setting its expected post
+// state to the outcome of the execution.
+func (t *StateTest) Fill() error {
+	for _, subtest := range t.Subtests() {
+		result, err := t.Execute(subtest, vm.Config{})
+		if err != nil {
+			return err
+		}
+		post := &t.json.Post[subtest.Fork][subtest.Index]
+		post.Root = common.UnprefixedHash(result.Root)
+		post.Logs = common.UnprefixedHash(result.Logs)
+	}
+	return nil
+}
+
+// Save fills the expected post states of the state test and writes it into a
+// JSON file in the format of the state test suite, under the given name.
+func (t *StateTest) Save(path string, name string) error {
+	if err := t.Fill(); err != nil {
+		return err
+	}
+	blob, err := json.MarshalIndent(map[string]*StateTest{name: t}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, blob, 0644)
+}
+
+// DiffTrace executes a subtest while tracing it, and compares the execution step
+// by step against a reference trace in the JSON lines format produced by
+// `evm --json statetest`, e.g. by another version of the EVM.
+func (t *StateTest) DiffTrace(subtest StateSubtest, reference io.Reader) error {
+	tracer := vm.NewStructLogger(nil)
+	result, err := t.Execute(subtest, vm.Config{Debug: true, Tracer: tracer})
+	if err != nil {
+		return err
+	}
+	logs := tracer.StructLogs()
+
+	var (
+		scanner = bufio.NewScanner(reference)
+		step    int
+	)
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(scanner.Bytes(), &fields); err != nil {
+			return fmt.Errorf("line %d: invalid trace entry: %v", line, err)
+		}
+		switch {
+		case fields["pc"] != nil:
+			var want vm.StructLog
+			if err := json.Unmarshal(scanner.Bytes(), &want); err != nil {
+				return fmt.Errorf("line %d: invalid trace step: %v", line, err)
+			}
+			if step >= len(logs) {
+				return fmt.Errorf("step %d: execution ended, reference continues with pc %d (%v)", step, want.Pc, want.Op)
+			}
+			if err := diffStructLogs(&logs[step], &want); err != nil {
+				return fmt.Errorf("step %d: %v", step, err)
+			}
+			step++
+
+		case fields["stateRoot"] != nil:
+			var root struct {
+				Root common.UnprefixedHash `json:"stateRoot"`
+			}
+			if err := json.Unmarshal(scanner.Bytes(), &root); err != nil {
+				return fmt.Errorf("line %d: invalid state root: %v", line, err)
+			}
+			if common.Hash(root.Root) != result.Root {
+				return fmt.Errorf("post state root mismatch: have %x, want %x", result.Root, root.Root)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if step < len(logs) {
+		return fmt.Errorf("step %d: reference ended, execution continues with pc %d (%v)", step, logs[step].Pc, logs[step].Op)
+	}
+	return nil
+}
+
+// DiffReference executes every subtest of the state test both locally and with
+// an independent reference EVM, invoked as `<evm> --json statetest <file>`, and
+// compares the executions step by step, returning the first divergence found.
+func (t *StateTest) DiffReference(evm string) error {
+	dir, err := ioutil.TempDir("", "evmfuzz")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	for _, subtest := range t.Subtests() {
+		// The reference runs subtests in no particular order, feed them one by one
+		single := &StateTest{json: t.json}
+		single.json.Post = map[string][]stPostState{
+			subtest.Fork: {t.json.Post[subtest.Fork][subtest.Index]},
+		}
+		path := filepath.Join(dir, "fuzz.json")
+		if err := single.Save(path, "fuzz"); err != nil {
+			return err
+		}
+		trace := new(bytes.Buffer)
+
+		cmd := exec.Command(evm, "--json", "statetest", path)
+		cmd.Stderr = trace
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s/%d: reference failed: %v", subtest.Fork, subtest.Index, err)
+		}
+		if err := t.DiffTrace(subtest, trace); err != nil {
+			return fmt.Errorf("%s/%d diverges from the reference: %v", subtest.Fork, subtest.Index, err)
+		}
+	}
+	return nil
+}
+
+// diffStructLogs compares an execution step against a reference one. Memory
+// contents are only compared if the reference contains them.
+func diffStructLogs(have, want *vm.StructLog) error {
+	switch {
+	case have.Pc != want.Pc || have.Op != want.Op:
+		return fmt.Errorf("instruction mismatch: have pc %d (%v), want pc %d (%v)", have.Pc, have.Op, want.Pc, want.Op)
+	case have.Depth != want.Depth:
+		return fmt.Errorf("depth mismatch at pc %d (%v): have %d, want %d", have.Pc, have.Op, have.Depth, want.Depth)
+	case have.Gas != want.Gas:
+		return fmt.Errorf("gas mismatch at pc %d (%v): have %d, want %d", have.Pc, have.Op, have.Gas, want.Gas)
+	case have.GasCost != want.GasCost:
+		return fmt.Errorf("gas cost mismatch at pc %d (%v): have %d, want %d", have.Pc, have.Op, have.GasCost, want.GasCost)
+	case have.MemorySize != want.MemorySize:
+		return fmt.Errorf("memory size mismatch at pc %d (%v): have %d, want %d", have.Pc, have.Op, have.MemorySize, want.MemorySize)
+	case len(want.Memory) > 0 && !bytes.Equal(have.Memory, want.Memory):
+		return fmt.Errorf("memory mismatch at pc %d (%v): have %x, want %x", have.Pc, have.Op, have.Memory, want.Memory)
+	}
+	if want.Stack != nil {
+		if len(have.Stack) != len(want.Stack) {
+			return fmt.Errorf("stack size mismatch at pc %d (%v): have %d, want %d", have.Pc, have.Op, len(have.Stack), len(want.Stack))
+		}
+		for i := range want.Stack {
+			if have.Stack[i].Cmp(want.Stack[i]) != 0 {
+				return fmt.Errorf("stack item %d mismatch at pc %d (%v): have %#x, want %#x", i, have.Pc, have.Op, have.Stack[i], want.Stack[i])
+			}
+		}
+	}
+	return nil
+}
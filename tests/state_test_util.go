@@ -53,6 +53,10 @@ func (t *StateTest) UnmarshalJSON(in []byte) error {
 	return json.Unmarshal(in, &t.json)
 }
 
+func (t *StateTest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&t.json)
+}
+
 type stJSON struct {
 	Env  stEnv                    `json:"env"`
 	Pre  core.GenesisAlloc        `json:"pre"`
@@ -68,7 +72,7 @@ type stPostState struct {
 		Data  int `json:"data"`
 		Gas   int `json:"gas"`
 		Value int `json:"value"`
-	}
+	} `json:"indexes"`
 }
 
 //go:generate gencodec -type stEnv -field-override stEnvMarshaling -out gen_stenv.go
@@ -119,12 +123,43 @@ func (t *StateTest) Subtests() []StateSubtest {
 	return sub
 }
 
+// StateResult is the outcome of executing the transaction of a state test.
+type StateResult struct {
+	State   *state.StateDB // Post state, already committed
+	Root    common.Hash    // Root hash of the post state
+	Logs    common.Hash    // Hash of the RLP encoded logs
+	Return  []byte         // Data returned by the execution
+	GasUsed uint64         // Gas used by the transaction
+	Failed  bool           // Whether the execution failed (revert, out of gas, ...)
+	Err     error          // Consensus error rejecting the transaction
+}
+
 // Run executes a specific subtest.
 func (t *StateTest) Run(subtest StateSubtest, vmconfig vm.Config) (*state.StateDB, error) {
+	result, err := t.Execute(subtest, vmconfig)
+	if err != nil {
+		return nil, err
+	}
+	post := t.json.Post[subtest.Fork][subtest.Index]
+	if result.Logs != common.Hash(post.Logs) {
+		return result.State, fmt.Errorf("post state logs hash mismatch: got %x, want %x", result.Logs, post.Logs)
+	}
+	if result.Root != common.Hash(post.Root) {
+		return result.State, fmt.Errorf("post state root mismatch: got %x, want %x", result.Root, post.Root)
+	}
+	return result.State, nil
+}
+
+// Execute runs a specific subtest without verifying its outcome against the
+// expected post state.
+func (t *StateTest) Execute(subtest StateSubtest, vmconfig vm.Config) (*StateResult, error) {
 	config, ok := Forks[subtest.Fork]
 	if !ok {
 		return nil, UnsupportedForkError{subtest.Fork}
 	}
+	if subtest.Index < 0 || subtest.Index >= len(t.json.Post[subtest.Fork]) {
+		return nil, fmt.Errorf("subtest %s/%d not found", subtest.Fork, subtest.Index)
+	}
 	block := t.genesis(config).ToBlock(nil)
 	db, _ := ethdb.NewMemDatabase()
 	statedb := MakePreState(db, t.json.Pre)
@@ -141,17 +176,14 @@ func (t *StateTest) Run(subtest StateSubtest, vmconfig vm.Config) (*state.StateD
 	gaspool := new(core.GasPool)
 	gaspool.AddGas(block.GasLimit())
 	snapshot := statedb.Snapshot()
-	if _, _, _, err := core.ApplyMessage(evm, msg, gaspool); err != nil {
+
+	result := &StateResult{State: statedb}
+	if result.Return, result.GasUsed, result.Failed, result.Err = core.ApplyMessage(evm, msg, gaspool); result.Err != nil {
 		statedb.RevertToSnapshot(snapshot)
 	}
-	if logs := rlpHash(statedb.Logs()); logs != common.Hash(post.Logs) {
-		return statedb, fmt.Errorf("post state logs hash mismatch: got %x, want %x", logs, post.Logs)
-	}
-	root, _ := statedb.Commit(config.IsEIP158(block.Number()))
-	if root != common.Hash(post.Root) {
-		return statedb, fmt.Errorf("post state root mismatch: got %x, want %x", root, post.Root)
-	}
-	return statedb, nil
+	result.Logs = rlpHash(statedb.Logs())
+	result.Root, _ = statedb.Commit(config.IsEIP158(block.Number()))
+	return result, nil
 }
 
 func (t *StateTest) gasLimit(subtest StateSubtest) uint64 {
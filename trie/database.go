@@ -23,6 +23,13 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/hashicorp/golang-lru"
+)
+
+var (
+	memcacheCleanHitMeter  = metrics.NewMeter("trie/memcache/clean/hit")
+	memcacheCleanMissMeter = metrics.NewMeter("trie/memcache/clean/miss")
 )
 
 // secureKeyPrefix is the database key prefix used to store trie node preimages.
@@ -46,6 +53,7 @@ type DatabaseReader interface {
 type Database struct {
 	diskdb ethdb.Database // Persistent storage for matured trie nodes
 
+	cleans    *lru.Cache                  // Recently used clean nodes loaded from disk, nil if disabled
 	nodes     map[common.Hash]*cachedNode // Data and references relationships of a node
 	preimages map[common.Hash][]byte      // Preimages of nodes from the secure trie
 	seckeybuf [secureKeyLength]byte       // Ephemeral buffer for calculating preimage keys
@@ -71,8 +79,20 @@ type cachedNode struct {
 // NewDatabase creates a new trie database to store ephemeral trie content before
 // its written out to disk or garbage collected.
 func NewDatabase(diskdb ethdb.Database) *Database {
+	return NewDatabaseWithCache(diskdb, 0)
+}
+
+// NewDatabaseWithCache creates a new trie database to store ephemeral trie content
+// before its written out to disk or garbage collected. It also keeps up to cache
+// clean nodes in memory, avoiding repeated disk reads of hot trie paths.
+func NewDatabaseWithCache(diskdb ethdb.Database, cache int) *Database {
+	var cleans *lru.Cache
+	if cache > 0 {
+		cleans, _ = lru.New(cache)
+	}
 	return &Database{
 		diskdb: diskdb,
+		cleans: cleans,
 		nodes: map[common.Hash]*cachedNode{
 			{}: {children: make(map[common.Hash]int)},
 		},
@@ -129,8 +149,20 @@ func (db *Database) Node(hash common.Hash) ([]byte, error) {
 	if node != nil {
 		return node.blob, nil
 	}
+	// Retrieve the node from the clean cache if it was recently loaded
+	if db.cleans != nil {
+		if blob, ok := db.cleans.Get(hash); ok {
+			memcacheCleanHitMeter.Mark(1)
+			return blob.([]byte), nil
+		}
+		memcacheCleanMissMeter.Mark(1)
+	}
 	// Content unavailable in memory, attempt to retrieve from disk
-	return db.diskdb.Get(hash[:])
+	blob, err := db.diskdb.Get(hash[:])
+	if err == nil && db.cleans != nil {
+		db.cleans.Add(hash, blob)
+	}
+	return blob, err
 }
 
 // preimage retrieves a cached trie node pre-image from memory. If it cannot be
@@ -343,6 +375,11 @@ func (db *Database) uncache(hash common.Hash) {
 	}
 	delete(db.nodes, hash)
 	db.nodesSize -= common.StorageSize(common.HashLength + len(node.blob))
+
+	// The node was just persisted, keep it around as a clean node
+	if db.cleans != nil {
+		db.cleans.Add(hash, node.blob)
+	}
 }
 
 // Size returns the current storage size of the memory cache in front of the
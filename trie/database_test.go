@@ -0,0 +1,62 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// Tests that nodes persisted to or loaded from disk are retained in the clean
+// cache, serving subsequent reads from memory.
+func TestDatabaseCleanCache(t *testing.T) {
+	for _, cache := range []int{0, 1024} {
+		diskdb, _ := ethdb.NewMemDatabase()
+		triedb := NewDatabaseWithCache(diskdb, cache)
+
+		trie, _ := New(common.Hash{}, triedb)
+		for i := byte(0); i < 16; i++ {
+			trie.Update([]byte{i}, bytes.Repeat([]byte{i}, 32))
+		}
+		root, _ := trie.Commit(nil)
+		if err := triedb.Commit(root, false); err != nil {
+			t.Fatalf("cache %d: failed to commit trie: %v", cache, err)
+		}
+		// Drop the persisted nodes, only cached ones are still available
+		for _, key := range diskdb.Keys() {
+			diskdb.Delete(key)
+		}
+		trie, err := New(root, triedb)
+		if cache == 0 {
+			if err == nil {
+				t.Errorf("cache %d: trie opened without persisted nodes", cache)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("cache %d: failed to open trie: %v", cache, err)
+		}
+		for i := byte(0); i < 16; i++ {
+			if value, err := trie.TryGet([]byte{i}); err != nil || !bytes.Equal(value, bytes.Repeat([]byte{i}, 32)) {
+				t.Errorf("cache %d: item %d: value mismatch: have %x, %v", cache, i, value, err)
+			}
+		}
+	}
+}